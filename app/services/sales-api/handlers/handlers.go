@@ -7,10 +7,13 @@ import (
 	"net/http"
 	"net/http/pprof"
 	"os"
+	"time"
 
 	"github.com/andrewyang17/service/app/services/sales-api/handlers/debug/checkgrp"
 	v1 "github.com/andrewyang17/service/app/services/sales-api/handlers/v1"
 	"github.com/andrewyang17/service/business/sys/auth"
+	"github.com/andrewyang17/service/business/sys/mail"
+	"github.com/andrewyang17/service/business/sys/password"
 	"github.com/andrewyang17/service/business/web/v1/mid"
 	"github.com/andrewyang17/service/foundation/web"
 	"github.com/jmoiron/sqlx"
@@ -31,10 +34,16 @@ func WithCORS(origin string) func(opts *Options) {
 
 // APIMuxConfig contains all the mandatory systems required by handlers.
 type APIMuxConfig struct {
-	Shutdown chan os.Signal
-	Log      *zap.SugaredLogger
-	Auth     *auth.Auth
-	DB       *sqlx.DB
+	Shutdown            chan os.Signal
+	Log                 *zap.SugaredLogger
+	Auth                *auth.Auth
+	DB                  *sqlx.DB
+	Mailer              mail.Mailer
+	Hasher              password.Hasher
+	ReservationTTL      time.Duration
+	RequireVerification bool
+	RegisterLimit       int
+	RegisterPeriod      time.Duration
 }
 
 // APIMux constructs a http.Handler with all application routes defined.
@@ -62,9 +71,15 @@ func APIMux(cfg APIMuxConfig, options ...func(opts *Options)) http.Handler {
 	}
 
 	v1.Routes(app, v1.Config{
-		Log:  cfg.Log,
-		Auth: cfg.Auth,
-		DB:   cfg.DB,
+		Log:                 cfg.Log,
+		Auth:                cfg.Auth,
+		DB:                  cfg.DB,
+		Mailer:              cfg.Mailer,
+		Hasher:              cfg.Hasher,
+		ReservationTTL:      cfg.ReservationTTL,
+		RequireVerification: cfg.RequireVerification,
+		RegisterLimit:       cfg.RegisterLimit,
+		RegisterPeriod:      cfg.RegisterPeriod,
 	})
 
 	return app
@@ -0,0 +1,150 @@
+// Package apikeygrp maintains the group of handlers for API keys.
+package apikeygrp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/andrewyang17/service/business/core/apikey"
+	"github.com/andrewyang17/service/business/sys/auth"
+	v1Web "github.com/andrewyang17/service/business/web/v1"
+	"github.com/andrewyang17/service/foundation/web"
+)
+
+// Handlers manages the set of API key endpoints.
+type Handlers struct {
+	APIKey apikey.Core
+}
+
+// Query returns a list of API keys. Users allowed to manage API keys see
+// every key of the organization, other users only see their own.
+func (h Handlers) Query(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	claims, err := auth.GetClaims(ctx)
+	if err != nil {
+		return v1Web.NewRequestError(auth.ErrForbidden, http.StatusForbidden)
+	}
+
+	var keys []apikey.Key
+	switch {
+	case claims.HasPermission(auth.PermAPIKeysManage):
+		keys, err = h.APIKey.Query(ctx)
+	default:
+		keys, err = h.APIKey.QueryByUserID(ctx, claims.Subject)
+	}
+	if err != nil {
+		return fmt.Errorf("unable to query for api keys: %w", err)
+	}
+
+	return web.Response(ctx, w, http.StatusOK, keys)
+}
+
+// QueryByID returns an API key by its ID.
+func (h Handlers) QueryByID(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	keyID := web.Param(r, "id")
+
+	key, err := h.checkOwner(ctx, keyID)
+	if err != nil {
+		return err
+	}
+
+	return web.Response(ctx, w, http.StatusOK, key)
+}
+
+// Create issues a new API key to the authenticated user, limited to the roles
+// of the token the request was made with and expiring when it does if not
+// earlier. The response is the only time the key itself is shown.
+func (h Handlers) Create(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	v, err := web.GetValues(ctx)
+	if err != nil {
+		return web.NewShutdownError("web value missing from context")
+	}
+
+	claims, err := auth.GetClaims(ctx)
+	if err != nil {
+		return v1Web.NewRequestError(auth.ErrForbidden, http.StatusForbidden)
+	}
+
+	var nk apikey.NewKey
+	if err := web.Decode(r, &nk); err != nil {
+		return fmt.Errorf("unable to decode payload: %w", err)
+	}
+
+	key, secret, err := h.APIKey.Create(ctx, nk, claims, v.Now)
+	if err != nil {
+		switch {
+		case errors.Is(err, apikey.ErrUserNotFound):
+			return v1Web.NewRequestError(err, http.StatusNotFound)
+		case errors.Is(err, apikey.ErrFromAPIKey):
+			return v1Web.NewRequestError(err, http.StatusForbidden)
+		default:
+			return fmt.Errorf("creating new api key, nk[%+v]: %w", nk, err)
+		}
+	}
+
+	resp := struct {
+		apikey.Key
+		Secret string `json:"secret"`
+	}{
+		Key:    key,
+		Secret: secret,
+	}
+
+	return web.Response(ctx, w, http.StatusCreated, resp)
+}
+
+// Revoke stops an API key from being used again.
+func (h Handlers) Revoke(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	v, err := web.GetValues(ctx)
+	if err != nil {
+		return web.NewShutdownError("web value missing from context")
+	}
+
+	keyID := web.Param(r, "id")
+
+	if _, err := h.checkOwner(ctx, keyID); err != nil {
+		return err
+	}
+
+	if err := h.APIKey.Revoke(ctx, keyID, v.Now); err != nil {
+		return keyError(keyID, err)
+	}
+
+	return web.Response(ctx, w, http.StatusNoContent, nil)
+}
+
+// =============================================================================
+
+// checkOwner returns the key when it was issued to the authenticated user or
+// the user is allowed to manage every key.
+func (h Handlers) checkOwner(ctx context.Context, keyID string) (apikey.Key, error) {
+	claims, err := auth.GetClaims(ctx)
+	if err != nil {
+		return apikey.Key{}, v1Web.NewRequestError(auth.ErrForbidden, http.StatusForbidden)
+	}
+
+	key, err := h.APIKey.QueryByID(ctx, keyID)
+	if err != nil {
+		return apikey.Key{}, keyError(keyID, err)
+	}
+
+	// If you lack the permission and are looking at a key you don't own.
+	if !claims.HasPermission(auth.PermAPIKeysManage) && key.UserID != claims.Subject {
+		return apikey.Key{}, v1Web.NewRequestError(auth.ErrForbidden, http.StatusForbidden)
+	}
+
+	return key, nil
+}
+
+// keyError maps errors from looking up an API key to web errors.
+func keyError(keyID string, err error) error {
+	switch {
+	case errors.Is(err, apikey.ErrInvalidID):
+		return v1Web.NewRequestError(err, http.StatusBadRequest)
+	case errors.Is(err, apikey.ErrNotFound):
+		return v1Web.NewRequestError(err, http.StatusNotFound)
+	default:
+		return fmt.Errorf("ID[%s]: %w", keyID, err)
+	}
+}
@@ -0,0 +1,197 @@
+// Package categorygrp maintains the group of handlers for the product
+// taxonomy.
+package categorygrp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/andrewyang17/service/business/core/category"
+	"github.com/andrewyang17/service/business/core/product"
+	v1Web "github.com/andrewyang17/service/business/web/v1"
+	"github.com/andrewyang17/service/foundation/web"
+)
+
+// Handlers manages the set of category endpoints.
+type Handlers struct {
+	Category category.Core
+	Product  product.Core
+}
+
+// Create adds a new category to the taxonomy.
+func (h Handlers) Create(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	v, err := web.GetValues(ctx)
+	if err != nil {
+		return web.NewShutdownError("web value missing from context")
+	}
+
+	var nc category.NewCategory
+	if err := web.Decode(r, &nc); err != nil {
+		return fmt.Errorf("unable to decode payload: %w", err)
+	}
+
+	cat, err := h.Category.Create(ctx, nc, v.Now)
+	if err != nil {
+		switch {
+		case errors.Is(err, category.ErrParentNotFound):
+			return v1Web.NewRequestError(err, http.StatusBadRequest)
+		default:
+			return fmt.Errorf("creating new category, nc[%+v]: %w", nc, err)
+		}
+	}
+
+	return web.Response(ctx, w, http.StatusCreated, cat)
+}
+
+// Update renames a category or moves it within the taxonomy.
+func (h Handlers) Update(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	v, err := web.GetValues(ctx)
+	if err != nil {
+		return web.NewShutdownError("web value missing from context")
+	}
+
+	var uc category.UpdateCategory
+	if err := web.Decode(r, &uc); err != nil {
+		return fmt.Errorf("unable to decode payload: %w", err)
+	}
+
+	categoryID := web.Param(r, "id")
+
+	if err := h.Category.Update(ctx, categoryID, uc, v.Now); err != nil {
+		switch {
+		case errors.Is(err, category.ErrInvalidID), errors.Is(err, category.ErrParentNotFound):
+			return v1Web.NewRequestError(err, http.StatusBadRequest)
+		case errors.Is(err, category.ErrNotFound):
+			return v1Web.NewRequestError(err, http.StatusNotFound)
+		case errors.Is(err, category.ErrCycle):
+			return v1Web.NewRequestError(err, http.StatusConflict)
+		default:
+			return fmt.Errorf("ID[%s] Category[%+v]: %w", categoryID, &uc, err)
+		}
+	}
+
+	return web.Response(ctx, w, http.StatusNoContent, nil)
+}
+
+// Delete removes a category from the taxonomy.
+func (h Handlers) Delete(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	categoryID := web.Param(r, "id")
+
+	if err := h.Category.Delete(ctx, categoryID); err != nil {
+		switch {
+		case errors.Is(err, category.ErrInvalidID):
+			return v1Web.NewRequestError(err, http.StatusBadRequest)
+		case errors.Is(err, category.ErrHasChildren):
+			return v1Web.NewRequestError(err, http.StatusConflict)
+		default:
+			return fmt.Errorf("ID[%s]: %w", categoryID, err)
+		}
+	}
+
+	return web.Response(ctx, w, http.StatusNoContent, nil)
+}
+
+// Query returns every category in the taxonomy.
+func (h Handlers) Query(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	cats, err := h.Category.Query(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to query for categories: %w", err)
+	}
+
+	return web.Response(ctx, w, http.StatusOK, cats)
+}
+
+// QueryByID returns a category by its ID.
+func (h Handlers) QueryByID(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	categoryID := web.Param(r, "id")
+
+	cat, err := h.Category.QueryByID(ctx, categoryID)
+	if err != nil {
+		return categoryError(categoryID, err)
+	}
+
+	return web.Response(ctx, w, http.StatusOK, cat)
+}
+
+// QueryChildren returns the categories directly beneath a category.
+func (h Handlers) QueryChildren(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	categoryID := web.Param(r, "id")
+
+	if _, err := h.Category.QueryByID(ctx, categoryID); err != nil {
+		return categoryError(categoryID, err)
+	}
+
+	cats, err := h.Category.QueryChildren(ctx, categoryID)
+	if err != nil {
+		return fmt.Errorf("ID[%s]: %w", categoryID, err)
+	}
+
+	return web.Response(ctx, w, http.StatusOK, cats)
+}
+
+// QueryAncestors returns the path from a category up to the top of the
+// taxonomy, nearest first.
+func (h Handlers) QueryAncestors(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	categoryID := web.Param(r, "id")
+
+	if _, err := h.Category.QueryByID(ctx, categoryID); err != nil {
+		return categoryError(categoryID, err)
+	}
+
+	cats, err := h.Category.QueryAncestors(ctx, categoryID)
+	if err != nil {
+		return fmt.Errorf("ID[%s]: %w", categoryID, err)
+	}
+
+	return web.Response(ctx, w, http.StatusOK, cats)
+}
+
+// QueryProducts returns the products in a category or any category beneath
+// it, with paging.
+func (h Handlers) QueryProducts(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	categoryID := web.Param(r, "id")
+
+	page := web.Param(r, "page")
+	pageNumber, err := strconv.Atoi(page)
+	if err != nil {
+		return v1Web.NewRequestError(fmt.Errorf("invalid page format [%s]", page), http.StatusBadRequest)
+	}
+
+	rows := web.Param(r, "rows")
+	rowsPerPage, err := strconv.Atoi(rows)
+	if err != nil {
+		return v1Web.NewRequestError(fmt.Errorf("invalid rows format [%s]", rows), http.StatusBadRequest)
+	}
+
+	if _, err := h.Category.QueryByID(ctx, categoryID); err != nil {
+		return categoryError(categoryID, err)
+	}
+
+	filter := product.QueryFilter{
+		CategoryID: &categoryID,
+	}
+
+	products, err := h.Product.Query(ctx, filter, pageNumber, rowsPerPage)
+	if err != nil {
+		return fmt.Errorf("unable to query for products: %w", err)
+	}
+
+	return web.Response(ctx, w, http.StatusOK, products)
+}
+
+// =============================================================================
+
+// categoryError maps errors from looking up a category to web errors.
+func categoryError(categoryID string, err error) error {
+	switch {
+	case errors.Is(err, category.ErrInvalidID):
+		return v1Web.NewRequestError(err, http.StatusBadRequest)
+	case errors.Is(err, category.ErrNotFound):
+		return v1Web.NewRequestError(err, http.StatusNotFound)
+	default:
+		return fmt.Errorf("ID[%s]: %w", categoryID, err)
+	}
+}
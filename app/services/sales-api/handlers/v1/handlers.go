@@ -2,36 +2,206 @@ package v1
 
 import (
 	"net/http"
+	"time"
 
+	"github.com/andrewyang17/service/app/services/sales-api/handlers/v1/apikeygrp"
+	"github.com/andrewyang17/service/app/services/sales-api/handlers/v1/categorygrp"
+	"github.com/andrewyang17/service/app/services/sales-api/handlers/v1/ordergrp"
+	"github.com/andrewyang17/service/app/services/sales-api/handlers/v1/orggrp"
+	"github.com/andrewyang17/service/app/services/sales-api/handlers/v1/productgrp"
+	"github.com/andrewyang17/service/app/services/sales-api/handlers/v1/reportgrp"
+	"github.com/andrewyang17/service/app/services/sales-api/handlers/v1/rolegrp"
+	"github.com/andrewyang17/service/app/services/sales-api/handlers/v1/salegrp"
+	"github.com/andrewyang17/service/app/services/sales-api/handlers/v1/taggrp"
 	"github.com/andrewyang17/service/app/services/sales-api/handlers/v1/usergrp"
+	"github.com/andrewyang17/service/business/core/apikey"
+	"github.com/andrewyang17/service/business/core/category"
+	"github.com/andrewyang17/service/business/core/order"
+	"github.com/andrewyang17/service/business/core/organization"
+	"github.com/andrewyang17/service/business/core/product"
+	"github.com/andrewyang17/service/business/core/report"
+	"github.com/andrewyang17/service/business/core/role"
+	"github.com/andrewyang17/service/business/core/sale"
+	"github.com/andrewyang17/service/business/core/session"
+	"github.com/andrewyang17/service/business/core/tag"
 	"github.com/andrewyang17/service/business/core/user"
 	"github.com/andrewyang17/service/business/sys/auth"
+	"github.com/andrewyang17/service/business/sys/mail"
+	"github.com/andrewyang17/service/business/sys/password"
 	"github.com/andrewyang17/service/business/web/v1/mid"
+	"github.com/andrewyang17/service/foundation/limiter"
 	"github.com/andrewyang17/service/foundation/web"
 	"github.com/jmoiron/sqlx"
 	"go.uber.org/zap"
 )
 
+// Set of defaults for registration used when none are configured.
+const (
+	defaultRegisterLimit  = 10
+	defaultRegisterPeriod = time.Hour
+)
+
 type Config struct {
-	Log  *zap.SugaredLogger
-	Auth *auth.Auth
-	DB   *sqlx.DB
+	Log                 *zap.SugaredLogger
+	Auth                *auth.Auth
+	DB                  *sqlx.DB
+	Mailer              mail.Mailer
+	Hasher              password.Hasher
+	ReservationTTL      time.Duration
+	RequireVerification bool
+	RegisterLimit       int
+	RegisterPeriod      time.Duration
 }
 
 func Routes(app *web.App, cfg Config) {
 	const version = "v1"
-	authen := mid.Authenticate(cfg.Auth)
-	admin := mid.Authorize(auth.RoleAdmin)
+	akgh := apikeygrp.Handlers{
+		APIKey: apikey.NewCore(cfg.Log, cfg.DB),
+	}
+
+	authen := mid.Authenticate(cfg.Auth, akgh.APIKey)
+	verified := mid.RequireVerified()
+	token := mid.RequireToken()
+	perm := mid.RequirePermission
 
 	// Register user management and authentication endpoints.
 	ugh := usergrp.Handlers{
-		User: user.NewCore(cfg.Log, cfg.DB),
-		Auth: cfg.Auth,
+		User:                user.NewCore(cfg.Log, cfg.DB, cfg.Mailer, cfg.Hasher),
+		Session:             session.NewCore(cfg.Log, cfg.DB),
+		Auth:                cfg.Auth,
+		RequireVerification: cfg.RequireVerification,
+	}
+
+	registerLimit, registerPeriod := cfg.RegisterLimit, cfg.RegisterPeriod
+	if registerLimit <= 0 || registerPeriod <= 0 {
+		registerLimit, registerPeriod = defaultRegisterLimit, defaultRegisterPeriod
 	}
+	register := mid.RateLimit(limiter.New(registerLimit, registerPeriod))
+
 	app.Handle(http.MethodGet, version, "/users/token", ugh.Token)
-	app.Handle(http.MethodGet, version, "/users/:page/:rows", ugh.Query, authen, admin)
+	app.Handle(http.MethodPost, version, "/users/token/refresh", ugh.RefreshToken)
+	app.Handle(http.MethodPost, version, "/users/logout", ugh.Logout, authen)
+	app.Handle(http.MethodPost, version, "/users/register", ugh.Register, register)
+	app.Handle(http.MethodPost, version, "/users/password/forgot", ugh.ForgotPassword)
+	app.Handle(http.MethodPost, version, "/users/password/reset", ugh.ResetPassword)
+	app.Handle(http.MethodPost, version, "/users/verify", ugh.VerifyEmail)
+	app.Handle(http.MethodPost, version, "/users/verify/resend", ugh.ResendVerification, authen)
+	app.Handle(http.MethodGet, version, "/users", ugh.QueryCursor, authen, perm(auth.PermUsersRead))
+	app.Handle(http.MethodGet, version, "/users/:page/:rows", ugh.Query, authen, perm(auth.PermUsersRead))
 	app.Handle(http.MethodGet, version, "/users/:id", ugh.QueryByID, authen)
-	app.Handle(http.MethodPost, version, "/users", ugh.Create, authen, admin)
-	app.Handle(http.MethodPut, version, "/users/:id", ugh.Update, authen, admin)
-	app.Handle(http.MethodDelete, version, "/users/:id", ugh.Delete, authen, admin)
+	app.Handle(http.MethodPost, version, "/users", ugh.Create, authen, perm(auth.PermUsersWrite))
+	app.Handle(http.MethodPut, version, "/users/:id", ugh.Update, authen, perm(auth.PermUsersWrite))
+	app.Handle(http.MethodPatch, version, "/users/:id", ugh.Patch, authen, perm(auth.PermUsersWrite))
+	app.Handle(http.MethodDelete, version, "/users/:id", ugh.Delete, authen, perm(auth.PermUsersWrite))
+	app.Handle(http.MethodPost, version, "/users/:id/restore", ugh.Restore, authen, perm(auth.PermUsersWrite))
+	app.Handle(http.MethodPost, version, "/users/:id/unlock", ugh.Unlock, authen, perm(auth.PermUsersWrite))
+	app.Handle(http.MethodDelete, version, "/users/:id/sessions", ugh.RevokeSessions, authen)
+
+	// Register role management endpoints.
+	rlgh := rolegrp.Handlers{
+		Role: role.NewCore(cfg.Log, cfg.DB),
+	}
+	app.Handle(http.MethodGet, version, "/roles", rlgh.Query, authen, perm(auth.PermRolesManage))
+	app.Handle(http.MethodGet, version, "/roles/:name", rlgh.QueryByName, authen, perm(auth.PermRolesManage))
+	app.Handle(http.MethodPost, version, "/roles", rlgh.Create, authen, perm(auth.PermRolesManage))
+	app.Handle(http.MethodPut, version, "/roles/:name", rlgh.Update, authen, perm(auth.PermRolesManage))
+	app.Handle(http.MethodDelete, version, "/roles/:name", rlgh.Delete, authen, perm(auth.PermRolesManage))
+	app.Handle(http.MethodGet, version, "/permissions", rlgh.QueryPermissions, authen, perm(auth.PermRolesManage))
+
+	// Register organization endpoints.
+	orgh := orggrp.Handlers{
+		Organization: organization.NewCore(cfg.Log, cfg.DB),
+	}
+	app.Handle(http.MethodGet, version, "/orgs", orgh.Query, authen)
+	app.Handle(http.MethodGet, version, "/orgs/:id", orgh.QueryByID, authen)
+	app.Handle(http.MethodPost, version, "/orgs", orgh.Create, authen, perm(auth.PermOrgsManage))
+	app.Handle(http.MethodPut, version, "/orgs/:id", orgh.Update, authen, perm(auth.PermOrgsManage))
+	app.Handle(http.MethodGet, version, "/orgs/:id/members", orgh.QueryMembers, authen, perm(auth.PermOrgsManage))
+	app.Handle(http.MethodPost, version, "/orgs/:id/members", orgh.AddMember, authen, perm(auth.PermOrgsManage))
+	app.Handle(http.MethodDelete, version, "/orgs/:id/members/:user_id", orgh.RemoveMember, authen, perm(auth.PermOrgsManage))
+
+	// Register API key endpoints. Keys can't be managed with an API key, so a
+	// leaked key can't be used to make more.
+	app.Handle(http.MethodGet, version, "/apikeys", akgh.Query, authen, token)
+	app.Handle(http.MethodGet, version, "/apikeys/:id", akgh.QueryByID, authen, token)
+	app.Handle(http.MethodPost, version, "/apikeys", akgh.Create, authen, token)
+	app.Handle(http.MethodDelete, version, "/apikeys/:id", akgh.Revoke, authen, token)
+
+	// Register product management endpoints.
+	pgh := productgrp.Handlers{
+		Product:        product.NewCore(cfg.Log, cfg.DB),
+		ReservationTTL: cfg.ReservationTTL,
+	}
+	app.Handle(http.MethodGet, version, "/products/:page/:rows", pgh.Query, authen)
+	app.Handle(http.MethodGet, version, "/products/search", pgh.Search, authen)
+	app.Handle(http.MethodGet, version, "/products/:id", pgh.QueryByID, authen)
+	app.Handle(http.MethodGet, version, "/users/:id/products", pgh.QueryByUserID, authen)
+	app.Handle(http.MethodPost, version, "/products", pgh.Create, authen, verified)
+	app.Handle(http.MethodPut, version, "/products/:id", pgh.Update, authen)
+	app.Handle(http.MethodDelete, version, "/products/:id", pgh.Delete, authen)
+	app.Handle(http.MethodPost, version, "/products/:id/reservations", pgh.Reserve, authen, verified)
+	app.Handle(http.MethodGet, version, "/products/:id/variants", pgh.QueryVariants, authen)
+	app.Handle(http.MethodPost, version, "/products/:id/variants", pgh.CreateVariant, authen, verified)
+	app.Handle(http.MethodGet, version, "/variants/:id", pgh.QueryVariantByID, authen)
+	app.Handle(http.MethodPut, version, "/variants/:id", pgh.UpdateVariant, authen)
+	app.Handle(http.MethodDelete, version, "/variants/:id", pgh.DeleteVariant, authen)
+	app.Handle(http.MethodGet, version, "/reservations/:id", pgh.QueryReservationByID, authen)
+	app.Handle(http.MethodPost, version, "/reservations/:id/confirm", pgh.ConfirmReservation, authen)
+	app.Handle(http.MethodPost, version, "/reservations/:id/release", pgh.ReleaseReservation, authen)
+
+	// Register taxonomy endpoints.
+	cgh := categorygrp.Handlers{
+		Category: category.NewCore(cfg.Log, cfg.DB),
+		Product:  pgh.Product,
+	}
+	app.Handle(http.MethodGet, version, "/categories", cgh.Query, authen)
+	app.Handle(http.MethodGet, version, "/categories/:id", cgh.QueryByID, authen)
+	app.Handle(http.MethodGet, version, "/categories/:id/children", cgh.QueryChildren, authen)
+	app.Handle(http.MethodGet, version, "/categories/:id/ancestors", cgh.QueryAncestors, authen)
+	app.Handle(http.MethodGet, version, "/categories/:id/products/:page/:rows", cgh.QueryProducts, authen)
+	app.Handle(http.MethodPost, version, "/categories", cgh.Create, authen, perm(auth.PermCategoriesWrite))
+	app.Handle(http.MethodPut, version, "/categories/:id", cgh.Update, authen, perm(auth.PermCategoriesWrite))
+	app.Handle(http.MethodDelete, version, "/categories/:id", cgh.Delete, authen, perm(auth.PermCategoriesWrite))
+
+	tgh := taggrp.Handlers{
+		Tag:     tag.NewCore(cfg.Log, cfg.DB),
+		Product: pgh.Product,
+	}
+	app.Handle(http.MethodGet, version, "/tags/:page/:rows", tgh.Query, authen)
+	app.Handle(http.MethodDelete, version, "/tags/:id", tgh.Delete, authen, perm(auth.PermTagsDelete))
+	app.Handle(http.MethodGet, version, "/products/:id/tags", tgh.QueryByProductID, authen)
+	app.Handle(http.MethodPost, version, "/products/:id/tags", tgh.TagProduct, authen)
+	app.Handle(http.MethodDelete, version, "/products/:id/tags/:tag_id", tgh.UntagProduct, authen)
+
+	// Register sale endpoints.
+	sgh := salegrp.Handlers{
+		Sale: sale.NewCore(cfg.Log, cfg.DB),
+	}
+	app.Handle(http.MethodGet, version, "/sales", sgh.QueryCursor, authen)
+	app.Handle(http.MethodGet, version, "/sales/:page/:rows", sgh.Query, authen)
+	app.Handle(http.MethodGet, version, "/sales/:id", sgh.QueryByID, authen)
+	app.Handle(http.MethodPost, version, "/sales", sgh.Create, authen, verified)
+	app.Handle(http.MethodGet, version, "/sales/:id/refunds", sgh.QueryRefunds, authen)
+	app.Handle(http.MethodPost, version, "/sales/:id/refunds", sgh.Refund, authen, perm(auth.PermSalesRefund))
+
+	// Register order endpoints.
+	ogh := ordergrp.Handlers{
+		Order: order.NewCore(cfg.Log, cfg.DB),
+	}
+	app.Handle(http.MethodGet, version, "/orders", ogh.QueryCursor, authen)
+	app.Handle(http.MethodGet, version, "/orders/:page/:rows", ogh.Query, authen)
+	app.Handle(http.MethodGet, version, "/orders/:id", ogh.QueryByID, authen)
+	app.Handle(http.MethodPost, version, "/orders", ogh.Create, authen, verified)
+	app.Handle(http.MethodPost, version, "/orders/:id/cancel", ogh.Cancel, authen)
+
+	// Register reporting endpoints.
+	rgh := reportgrp.Handlers{
+		Report: report.NewCore(cfg.Log, cfg.DB),
+	}
+	app.Handle(http.MethodGet, version, "/reports/products", rgh.ByProduct, authen, perm(auth.PermReportsRead))
+	app.Handle(http.MethodGet, version, "/reports/variants", rgh.ByVariant, authen, perm(auth.PermReportsRead))
+	app.Handle(http.MethodGet, version, "/reports/sellers", rgh.BySeller, authen, perm(auth.PermReportsRead))
+	app.Handle(http.MethodGet, version, "/reports/periods/:period", rgh.ByPeriod, authen, perm(auth.PermReportsRead))
+	app.Handle(http.MethodGet, version, "/reports/rates", rgh.QueryRates, authen, perm(auth.PermReportsRead))
+	app.Handle(http.MethodPut, version, "/reports/rates", rgh.SaveRate, authen, perm(auth.PermRatesWrite))
 }
@@ -0,0 +1,202 @@
+// Package ordergrp maintains the group of handlers for order access.
+package ordergrp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/andrewyang17/service/business/core/order"
+	"github.com/andrewyang17/service/business/sys/auth"
+	v1Web "github.com/andrewyang17/service/business/web/v1"
+	"github.com/andrewyang17/service/foundation/web"
+)
+
+// defaultQueryRows is the page size used by QueryCursor when the client does
+// not provide one.
+const defaultQueryRows = 20
+
+// Handlers manages the set of order endpoints.
+type Handlers struct {
+	Order order.Core
+}
+
+// Create places an order for the authenticated user.
+func (h Handlers) Create(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	v, err := web.GetValues(ctx)
+	if err != nil {
+		return web.NewShutdownError("web value missing from context")
+	}
+
+	claims, err := auth.GetClaims(ctx)
+	if err != nil {
+		return v1Web.NewRequestError(auth.ErrForbidden, http.StatusForbidden)
+	}
+
+	var no order.NewOrder
+	if err := web.Decode(r, &no); err != nil {
+		return fmt.Errorf("unable to decode payload: %w", err)
+	}
+
+	// The order is always placed for the user making the request.
+	no.UserID = claims.Subject
+
+	ord, err := h.Order.Create(ctx, no, v.Now)
+	if err != nil {
+		switch {
+		case errors.Is(err, order.ErrProductNotFound), errors.Is(err, order.ErrVariantNotFound):
+			return v1Web.NewRequestError(err, http.StatusNotFound)
+		case errors.Is(err, order.ErrInsufficientStock):
+			return v1Web.NewRequestError(err, http.StatusConflict)
+		case errors.Is(err, order.ErrMixedCurrency):
+			return v1Web.NewRequestError(err, http.StatusBadRequest)
+		default:
+			return fmt.Errorf("creating new order, no[%+v]: %w", no, err)
+		}
+	}
+
+	return web.Response(ctx, w, http.StatusCreated, ord)
+}
+
+// Cancel cancels a placed order and returns its stock.
+func (h Handlers) Cancel(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	v, err := web.GetValues(ctx)
+	if err != nil {
+		return web.NewShutdownError("web value missing from context")
+	}
+
+	claims, err := auth.GetClaims(ctx)
+	if err != nil {
+		return v1Web.NewRequestError(auth.ErrForbidden, http.StatusForbidden)
+	}
+
+	orderID := web.Param(r, "id")
+
+	ord, err := h.Order.QueryByID(ctx, orderID)
+	if err != nil {
+		switch {
+		case errors.Is(err, order.ErrInvalidID):
+			return v1Web.NewRequestError(err, http.StatusBadRequest)
+		case errors.Is(err, order.ErrNotFound):
+			return v1Web.NewRequestError(err, http.StatusNotFound)
+		default:
+			return fmt.Errorf("querying order[%s]: %w", orderID, err)
+		}
+	}
+
+	// If you lack the permission and are looking to cancel someone else's order.
+	if !claims.HasPermission(auth.PermOrdersWrite) && ord.UserID != claims.Subject {
+		return v1Web.NewRequestError(auth.ErrForbidden, http.StatusForbidden)
+	}
+
+	if err := h.Order.Cancel(ctx, orderID, v.Now); err != nil {
+		switch {
+		case errors.Is(err, order.ErrNotFound):
+			return v1Web.NewRequestError(err, http.StatusNotFound)
+		case errors.Is(err, order.ErrNotCancellable):
+			return v1Web.NewRequestError(err, http.StatusConflict)
+		default:
+			return fmt.Errorf("ID[%s]: %w", orderID, err)
+		}
+	}
+
+	return web.Response(ctx, w, http.StatusNoContent, nil)
+}
+
+// Query returns a list of orders with paging. Users allowed to read every
+// order see them all, other users only see their own.
+func (h Handlers) Query(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	claims, err := auth.GetClaims(ctx)
+	if err != nil {
+		return v1Web.NewRequestError(auth.ErrForbidden, http.StatusForbidden)
+	}
+
+	page := web.Param(r, "page")
+	pageNumber, err := strconv.Atoi(page)
+	if err != nil {
+		return v1Web.NewRequestError(fmt.Errorf("invalid page format [%s]", page), http.StatusBadRequest)
+	}
+
+	rows := web.Param(r, "rows")
+	rowsPerPage, err := strconv.Atoi(rows)
+	if err != nil {
+		return v1Web.NewRequestError(fmt.Errorf("invalid rows format [%s]", rows), http.StatusBadRequest)
+	}
+
+	var orders []order.Order
+	switch {
+	case claims.HasPermission(auth.PermOrdersRead):
+		orders, err = h.Order.Query(ctx, pageNumber, rowsPerPage)
+	default:
+		orders, err = h.Order.QueryByUserID(ctx, claims.Subject, pageNumber, rowsPerPage)
+	}
+	if err != nil {
+		return fmt.Errorf("unable to query for orders: %w", err)
+	}
+
+	return web.Response(ctx, w, http.StatusOK, orders)
+}
+
+// QueryCursor returns a page of orders, newest first, along with the cursors
+// for the pages either side of it. The cursor parameter takes a next or prev
+// cursor from an earlier response. Users allowed to read every order see
+// them all, other users only see their own.
+func (h Handlers) QueryCursor(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	claims, err := auth.GetClaims(ctx)
+	if err != nil {
+		return v1Web.NewRequestError(auth.ErrForbidden, http.StatusForbidden)
+	}
+
+	qs := r.URL.Query()
+
+	rowsPerPage := defaultQueryRows
+	if rows := qs.Get("rows"); rows != "" {
+		rowsPerPage, err = strconv.Atoi(rows)
+		if err != nil || rowsPerPage < 1 {
+			return v1Web.NewRequestError(fmt.Errorf("invalid rows format [%s]", rows), http.StatusBadRequest)
+		}
+	}
+
+	var userID string
+	if !claims.HasPermission(auth.PermOrdersRead) {
+		userID = claims.Subject
+	}
+
+	page, err := h.Order.QueryCursor(ctx, userID, qs.Get("cursor"), rowsPerPage)
+	if err != nil {
+		return fmt.Errorf("unable to query for orders: %w", err)
+	}
+
+	return web.Response(ctx, w, http.StatusOK, page)
+}
+
+// QueryByID returns an order by its ID.
+func (h Handlers) QueryByID(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	claims, err := auth.GetClaims(ctx)
+	if err != nil {
+		return v1Web.NewRequestError(auth.ErrForbidden, http.StatusForbidden)
+	}
+
+	orderID := web.Param(r, "id")
+
+	ord, err := h.Order.QueryByID(ctx, orderID)
+	if err != nil {
+		switch {
+		case errors.Is(err, order.ErrInvalidID):
+			return v1Web.NewRequestError(err, http.StatusBadRequest)
+		case errors.Is(err, order.ErrNotFound):
+			return v1Web.NewRequestError(err, http.StatusNotFound)
+		default:
+			return fmt.Errorf("ID[%s]: %w", orderID, err)
+		}
+	}
+
+	// If you lack the permission and are looking to retrieve someone else's order.
+	if !claims.HasPermission(auth.PermOrdersRead) && ord.UserID != claims.Subject {
+		return v1Web.NewRequestError(auth.ErrForbidden, http.StatusForbidden)
+	}
+
+	return web.Response(ctx, w, http.StatusOK, ord)
+}
@@ -0,0 +1,209 @@
+// Package orggrp maintains the group of handlers for organizations and their
+// members.
+package orggrp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/andrewyang17/service/business/core/organization"
+	"github.com/andrewyang17/service/business/sys/auth"
+	v1Web "github.com/andrewyang17/service/business/web/v1"
+	"github.com/andrewyang17/service/foundation/web"
+)
+
+// Handlers manages the set of organization endpoints.
+type Handlers struct {
+	Organization organization.Core
+}
+
+// Query returns a list of organizations. Users allowed to manage
+// organizations see them all, other users only see the ones they belong to.
+func (h Handlers) Query(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	claims, err := auth.GetClaims(ctx)
+	if err != nil {
+		return v1Web.NewRequestError(auth.ErrForbidden, http.StatusForbidden)
+	}
+
+	var orgs []organization.Organization
+	switch {
+	case claims.HasPermission(auth.PermOrgsManage):
+		orgs, err = h.Organization.Query(ctx)
+	default:
+		orgs, err = h.Organization.QueryByUserID(ctx, claims.Subject)
+	}
+	if err != nil {
+		return fmt.Errorf("unable to query for organizations: %w", err)
+	}
+
+	return web.Response(ctx, w, http.StatusOK, orgs)
+}
+
+// QueryByID returns an organization by its ID.
+func (h Handlers) QueryByID(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	claims, err := auth.GetClaims(ctx)
+	if err != nil {
+		return v1Web.NewRequestError(auth.ErrForbidden, http.StatusForbidden)
+	}
+
+	orgID := web.Param(r, "id")
+
+	// If you lack the permission and are looking to retrieve an organization
+	// you don't belong to.
+	if !claims.HasPermission(auth.PermOrgsManage) {
+		member, err := h.Organization.IsMember(ctx, orgID, claims.Subject)
+		if err != nil {
+			return fmt.Errorf("ID[%s]: %w", orgID, err)
+		}
+		if !member {
+			return v1Web.NewRequestError(auth.ErrForbidden, http.StatusForbidden)
+		}
+	}
+
+	org, err := h.Organization.QueryByID(ctx, orgID)
+	if err != nil {
+		switch {
+		case errors.Is(err, organization.ErrInvalidID):
+			return v1Web.NewRequestError(err, http.StatusBadRequest)
+		case errors.Is(err, organization.ErrNotFound):
+			return v1Web.NewRequestError(err, http.StatusNotFound)
+		default:
+			return fmt.Errorf("ID[%s]: %w", orgID, err)
+		}
+	}
+
+	return web.Response(ctx, w, http.StatusOK, org)
+}
+
+// Create adds a new organization with the authenticated user as its first
+// member.
+func (h Handlers) Create(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	v, err := web.GetValues(ctx)
+	if err != nil {
+		return web.NewShutdownError("web value missing from context")
+	}
+
+	claims, err := auth.GetClaims(ctx)
+	if err != nil {
+		return v1Web.NewRequestError(auth.ErrForbidden, http.StatusForbidden)
+	}
+
+	var no organization.NewOrganization
+	if err := web.Decode(r, &no); err != nil {
+		return fmt.Errorf("unable to decode payload: %w", err)
+	}
+
+	org, err := h.Organization.Create(ctx, no, claims.Subject, v.Now)
+	if err != nil {
+		return fmt.Errorf("creating new organization, no[%+v]: %w", no, err)
+	}
+
+	return web.Response(ctx, w, http.StatusCreated, org)
+}
+
+// Update updates an organization in the system.
+func (h Handlers) Update(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	v, err := web.GetValues(ctx)
+	if err != nil {
+		return web.NewShutdownError("web value missing from context")
+	}
+
+	var uo organization.UpdateOrganization
+	if err := web.Decode(r, &uo); err != nil {
+		return fmt.Errorf("unable to decode payload: %w", err)
+	}
+
+	orgID := web.Param(r, "id")
+
+	if err := h.Organization.Update(ctx, orgID, uo, v.Now); err != nil {
+		switch {
+		case errors.Is(err, organization.ErrInvalidID):
+			return v1Web.NewRequestError(err, http.StatusBadRequest)
+		case errors.Is(err, organization.ErrNotFound):
+			return v1Web.NewRequestError(err, http.StatusNotFound)
+		default:
+			return fmt.Errorf("ID[%s] organization[%+v]: %w", orgID, &uo, err)
+		}
+	}
+
+	return web.Response(ctx, w, http.StatusNoContent, nil)
+}
+
+// QueryMembers returns the users that belong to an organization.
+func (h Handlers) QueryMembers(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	orgID := web.Param(r, "id")
+
+	mbrs, err := h.Organization.QueryMembers(ctx, orgID)
+	if err != nil {
+		switch {
+		case errors.Is(err, organization.ErrInvalidID):
+			return v1Web.NewRequestError(err, http.StatusBadRequest)
+		case errors.Is(err, organization.ErrNotFound):
+			return v1Web.NewRequestError(err, http.StatusNotFound)
+		default:
+			return fmt.Errorf("ID[%s]: %w", orgID, err)
+		}
+	}
+
+	return web.Response(ctx, w, http.StatusOK, mbrs)
+}
+
+// AddMember adds a user to an organization. Only users allowed to manage
+// roles can choose the roles of the new member.
+func (h Handlers) AddMember(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	v, err := web.GetValues(ctx)
+	if err != nil {
+		return web.NewShutdownError("web value missing from context")
+	}
+
+	claims, err := auth.GetClaims(ctx)
+	if err != nil {
+		return v1Web.NewRequestError(auth.ErrForbidden, http.StatusForbidden)
+	}
+
+	var nm organization.NewMember
+	if err := web.Decode(r, &nm); err != nil {
+		return fmt.Errorf("unable to decode payload: %w", err)
+	}
+
+	if len(nm.Roles) > 0 && !claims.HasPermission(auth.PermRolesManage) {
+		return v1Web.NewRequestError(auth.ErrForbidden, http.StatusForbidden)
+	}
+
+	orgID := web.Param(r, "id")
+
+	mbr, err := h.Organization.AddMember(ctx, orgID, nm, v.Now)
+	if err != nil {
+		switch {
+		case errors.Is(err, organization.ErrInvalidID):
+			return v1Web.NewRequestError(err, http.StatusBadRequest)
+		case errors.Is(err, organization.ErrNotFound), errors.Is(err, organization.ErrUserNotFound):
+			return v1Web.NewRequestError(err, http.StatusNotFound)
+		case errors.Is(err, organization.ErrAlreadyMember):
+			return v1Web.NewRequestError(err, http.StatusConflict)
+		default:
+			return fmt.Errorf("ID[%s] member[%+v]: %w", orgID, nm, err)
+		}
+	}
+
+	return web.Response(ctx, w, http.StatusCreated, mbr)
+}
+
+// RemoveMember removes a user from an organization.
+func (h Handlers) RemoveMember(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	orgID := web.Param(r, "id")
+	userID := web.Param(r, "user_id")
+
+	if err := h.Organization.RemoveMember(ctx, orgID, userID); err != nil {
+		switch {
+		case errors.Is(err, organization.ErrInvalidID):
+			return v1Web.NewRequestError(err, http.StatusBadRequest)
+		default:
+			return fmt.Errorf("ID[%s] user[%s]: %w", orgID, userID, err)
+		}
+	}
+
+	return web.Response(ctx, w, http.StatusNoContent, nil)
+}
@@ -0,0 +1,599 @@
+// Package productgrp maintains the group of handlers for product access.
+package productgrp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/andrewyang17/service/business/core/product"
+	"github.com/andrewyang17/service/business/sys/auth"
+	v1Web "github.com/andrewyang17/service/business/web/v1"
+	"github.com/andrewyang17/service/foundation/web"
+)
+
+// defaultSearchRows is the page size used by Search when the client does not
+// provide one.
+const defaultSearchRows = 20
+
+// Handlers manages the set of product endpoints.
+type Handlers struct {
+	Product        product.Core
+	ReservationTTL time.Duration
+}
+
+// Create adds a new product to the system.
+func (h Handlers) Create(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	v, err := web.GetValues(ctx)
+	if err != nil {
+		return web.NewShutdownError("web value missing from context")
+	}
+
+	claims, err := auth.GetClaims(ctx)
+	if err != nil {
+		return v1Web.NewRequestError(auth.ErrForbidden, http.StatusForbidden)
+	}
+
+	var np product.NewProduct
+	if err := web.Decode(r, &np); err != nil {
+		return fmt.Errorf("unable to decode payload: %w", err)
+	}
+
+	// The product is always owned by the user creating it.
+	np.UserID = claims.Subject
+
+	prd, err := h.Product.Create(ctx, np, v.Now)
+	if err != nil {
+		switch {
+		case errors.Is(err, product.ErrCategoryNotFound):
+			return v1Web.NewRequestError(err, http.StatusBadRequest)
+		default:
+			return fmt.Errorf("creating new product, np[%+v]: %w", np, err)
+		}
+	}
+
+	return web.Response(ctx, w, http.StatusCreated, prd)
+}
+
+// Update updates a product in the system.
+func (h Handlers) Update(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	v, err := web.GetValues(ctx)
+	if err != nil {
+		return web.NewShutdownError("web value missing from context")
+	}
+
+	claims, err := auth.GetClaims(ctx)
+	if err != nil {
+		return v1Web.NewRequestError(auth.ErrForbidden, http.StatusForbidden)
+	}
+
+	var upd product.UpdateProduct
+	if err := web.Decode(r, &upd); err != nil {
+		return fmt.Errorf("unable to decode payload: %w", err)
+	}
+
+	productID := web.Param(r, "id")
+
+	prd, err := h.Product.QueryByID(ctx, productID)
+	if err != nil {
+		switch {
+		case errors.Is(err, product.ErrInvalidID):
+			return v1Web.NewRequestError(err, http.StatusBadRequest)
+		case errors.Is(err, product.ErrNotFound):
+			return v1Web.NewRequestError(err, http.StatusNotFound)
+		default:
+			return fmt.Errorf("querying product[%s]: %w", productID, err)
+		}
+	}
+
+	// If you lack the permission and are looking to update a product you don't own.
+	if !claims.HasPermission(auth.PermProductsWrite) && prd.UserID != claims.Subject {
+		return v1Web.NewRequestError(auth.ErrForbidden, http.StatusForbidden)
+	}
+
+	if err := h.Product.Update(ctx, productID, upd, v.Now); err != nil {
+		switch {
+		case errors.Is(err, product.ErrInvalidID), errors.Is(err, product.ErrCategoryNotFound):
+			return v1Web.NewRequestError(err, http.StatusBadRequest)
+		case errors.Is(err, product.ErrNotFound):
+			return v1Web.NewRequestError(err, http.StatusNotFound)
+		case errors.Is(err, product.ErrVariantCurrency):
+			return v1Web.NewRequestError(err, http.StatusConflict)
+		default:
+			return fmt.Errorf("ID[%s] User[%+v]: %w", productID, &upd, err)
+		}
+	}
+
+	return web.Response(ctx, w, http.StatusNoContent, nil)
+}
+
+// Delete removes a product from the system.
+func (h Handlers) Delete(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	claims, err := auth.GetClaims(ctx)
+	if err != nil {
+		return v1Web.NewRequestError(auth.ErrForbidden, http.StatusForbidden)
+	}
+
+	productID := web.Param(r, "id")
+
+	prd, err := h.Product.QueryByID(ctx, productID)
+	if err != nil {
+		switch {
+		case errors.Is(err, product.ErrInvalidID):
+			return v1Web.NewRequestError(err, http.StatusBadRequest)
+		case errors.Is(err, product.ErrNotFound):
+
+			// Don't send StatusNotFound here since the call to Delete
+			// below won't if this product is not found. We only know
+			// this because we are doing the Query for the UserID.
+			return web.Response(ctx, w, http.StatusNoContent, nil)
+		default:
+			return fmt.Errorf("querying product[%s]: %w", productID, err)
+		}
+	}
+
+	// If you lack the permission and are looking to delete a product you don't own.
+	if !claims.HasPermission(auth.PermProductsWrite) && prd.UserID != claims.Subject {
+		return v1Web.NewRequestError(auth.ErrForbidden, http.StatusForbidden)
+	}
+
+	if err := h.Product.Delete(ctx, productID); err != nil {
+		switch {
+		case errors.Is(err, product.ErrInvalidID):
+			return v1Web.NewRequestError(err, http.StatusBadRequest)
+		default:
+			return fmt.Errorf("ID[%s]: %w", productID, err)
+		}
+	}
+
+	return web.Response(ctx, w, http.StatusNoContent, nil)
+}
+
+// Query returns a list of products with paging.
+func (h Handlers) Query(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	page := web.Param(r, "page")
+	pageNumber, err := strconv.Atoi(page)
+	if err != nil {
+		return v1Web.NewRequestError(fmt.Errorf("invalid page format [%s]", page), http.StatusBadRequest)
+	}
+
+	rows := web.Param(r, "rows")
+	rowsPerPage, err := strconv.Atoi(rows)
+	if err != nil {
+		return v1Web.NewRequestError(fmt.Errorf("invalid rows format [%s]", rows), http.StatusBadRequest)
+	}
+
+	products, err := h.Product.Query(ctx, product.QueryFilter{}, pageNumber, rowsPerPage)
+	if err != nil {
+		return fmt.Errorf("unable to query for products: %w", err)
+	}
+
+	return web.Response(ctx, w, http.StatusOK, products)
+}
+
+// Search returns a list of products matching the search text and filters in
+// the query string, ranked by relevance, with paging.
+func (h Handlers) Search(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	qs := r.URL.Query()
+
+	pageNumber := 1
+	if page := qs.Get("page"); page != "" {
+		var err error
+		pageNumber, err = strconv.Atoi(page)
+		if err != nil {
+			return v1Web.NewRequestError(fmt.Errorf("invalid page format [%s]", page), http.StatusBadRequest)
+		}
+	}
+
+	rowsPerPage := defaultSearchRows
+	if rows := qs.Get("rows"); rows != "" {
+		var err error
+		rowsPerPage, err = strconv.Atoi(rows)
+		if err != nil {
+			return v1Web.NewRequestError(fmt.Errorf("invalid rows format [%s]", rows), http.StatusBadRequest)
+		}
+	}
+
+	var filter product.QueryFilter
+
+	if q := qs.Get("q"); q != "" {
+		filter.Text = &q
+	}
+
+	if s := qs.Get("min_price"); s != "" {
+		minPrice, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return v1Web.NewRequestError(fmt.Errorf("invalid min_price format [%s]", s), http.StatusBadRequest)
+		}
+		filter.MinCost = &minPrice
+	}
+
+	if s := qs.Get("max_price"); s != "" {
+		maxPrice, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return v1Web.NewRequestError(fmt.Errorf("invalid max_price format [%s]", s), http.StatusBadRequest)
+		}
+		filter.MaxCost = &maxPrice
+	}
+
+	if s := qs.Get("currency"); s != "" {
+		currency := strings.ToUpper(s)
+		filter.Currency = &currency
+	}
+
+	if s := qs.Get("user_id"); s != "" {
+		filter.UserID = &s
+	}
+
+	if s := qs.Get("in_stock"); s != "" {
+		inStock, err := strconv.ParseBool(s)
+		if err != nil {
+			return v1Web.NewRequestError(fmt.Errorf("invalid in_stock format [%s]", s), http.StatusBadRequest)
+		}
+		filter.InStock = &inStock
+	}
+
+	if s := qs.Get("category_id"); s != "" {
+		filter.CategoryID = &s
+	}
+
+	if s := qs.Get("tag"); s != "" {
+		filter.Tag = &s
+	}
+
+	products, err := h.Product.Query(ctx, filter, pageNumber, rowsPerPage)
+	if err != nil {
+		return fmt.Errorf("unable to search for products: %w", err)
+	}
+
+	return web.Response(ctx, w, http.StatusOK, products)
+}
+
+// QueryByID returns a product by its ID.
+func (h Handlers) QueryByID(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	productID := web.Param(r, "id")
+
+	prd, err := h.Product.QueryByID(ctx, productID)
+	if err != nil {
+		switch {
+		case errors.Is(err, product.ErrInvalidID):
+			return v1Web.NewRequestError(err, http.StatusBadRequest)
+		case errors.Is(err, product.ErrNotFound):
+			return v1Web.NewRequestError(err, http.StatusNotFound)
+		default:
+			return fmt.Errorf("ID[%s]: %w", productID, err)
+		}
+	}
+
+	return web.Response(ctx, w, http.StatusOK, prd)
+}
+
+// QueryByUserID returns the products owned by the specified user.
+func (h Handlers) QueryByUserID(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	userID := web.Param(r, "id")
+
+	products, err := h.Product.QueryByUserID(ctx, userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, product.ErrInvalidID):
+			return v1Web.NewRequestError(err, http.StatusBadRequest)
+		default:
+			return fmt.Errorf("userID[%s]: %w", userID, err)
+		}
+	}
+
+	return web.Response(ctx, w, http.StatusOK, products)
+}
+
+// Reserve holds stock of a product for the authenticated user.
+func (h Handlers) Reserve(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	v, err := web.GetValues(ctx)
+	if err != nil {
+		return web.NewShutdownError("web value missing from context")
+	}
+
+	claims, err := auth.GetClaims(ctx)
+	if err != nil {
+		return v1Web.NewRequestError(auth.ErrForbidden, http.StatusForbidden)
+	}
+
+	var nr product.NewReservation
+	if err := web.Decode(r, &nr); err != nil {
+		return fmt.Errorf("unable to decode payload: %w", err)
+	}
+
+	nr.ProductID = web.Param(r, "id")
+	nr.UserID = claims.Subject
+
+	res, err := h.Product.Reserve(ctx, nr, h.ReservationTTL, v.Now)
+	if err != nil {
+		switch {
+		case errors.Is(err, product.ErrInvalidID):
+			return v1Web.NewRequestError(err, http.StatusBadRequest)
+		case errors.Is(err, product.ErrNotFound), errors.Is(err, product.ErrVariantNotFound):
+			return v1Web.NewRequestError(err, http.StatusNotFound)
+		case errors.Is(err, product.ErrInsufficientStock):
+			return v1Web.NewRequestError(err, http.StatusConflict)
+		default:
+			return fmt.Errorf("reserving product, nr[%+v]: %w", nr, err)
+		}
+	}
+
+	return web.Response(ctx, w, http.StatusCreated, res)
+}
+
+// QueryReservationByID returns a reservation by its ID.
+func (h Handlers) QueryReservationByID(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	res, err := h.ownedReservation(ctx, web.Param(r, "id"))
+	if err != nil {
+		return err
+	}
+
+	return web.Response(ctx, w, http.StatusOK, res)
+}
+
+// ConfirmReservation converts a pending reservation into a sale.
+func (h Handlers) ConfirmReservation(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	v, err := web.GetValues(ctx)
+	if err != nil {
+		return web.NewShutdownError("web value missing from context")
+	}
+
+	res, err := h.ownedReservation(ctx, web.Param(r, "id"))
+	if err != nil {
+		return err
+	}
+
+	res, err = h.Product.Confirm(ctx, res.ID, v.Now)
+	if err != nil {
+		switch {
+		case errors.Is(err, product.ErrReservationNotFound):
+			return v1Web.NewRequestError(err, http.StatusNotFound)
+		case errors.Is(err, product.ErrReservationNotPending), errors.Is(err, product.ErrReservationExpired):
+			return v1Web.NewRequestError(err, http.StatusConflict)
+		default:
+			return fmt.Errorf("ID[%s]: %w", res.ID, err)
+		}
+	}
+
+	return web.Response(ctx, w, http.StatusOK, res)
+}
+
+// ReleaseReservation gives up a pending reservation and returns its stock.
+func (h Handlers) ReleaseReservation(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	v, err := web.GetValues(ctx)
+	if err != nil {
+		return web.NewShutdownError("web value missing from context")
+	}
+
+	res, err := h.ownedReservation(ctx, web.Param(r, "id"))
+	if err != nil {
+		return err
+	}
+
+	if err := h.Product.Release(ctx, res.ID, v.Now); err != nil {
+		switch {
+		case errors.Is(err, product.ErrReservationNotFound):
+			return v1Web.NewRequestError(err, http.StatusNotFound)
+		case errors.Is(err, product.ErrReservationNotPending):
+			return v1Web.NewRequestError(err, http.StatusConflict)
+		default:
+			return fmt.Errorf("ID[%s]: %w", res.ID, err)
+		}
+	}
+
+	return web.Response(ctx, w, http.StatusNoContent, nil)
+}
+
+// ownedReservation retrieves the specified reservation, making sure the
+// authenticated user is allowed to act on it.
+func (h Handlers) ownedReservation(ctx context.Context, reservationID string) (product.Reservation, error) {
+	claims, err := auth.GetClaims(ctx)
+	if err != nil {
+		return product.Reservation{}, v1Web.NewRequestError(auth.ErrForbidden, http.StatusForbidden)
+	}
+
+	res, err := h.Product.QueryReservationByID(ctx, reservationID)
+	if err != nil {
+		switch {
+		case errors.Is(err, product.ErrInvalidID):
+			return product.Reservation{}, v1Web.NewRequestError(err, http.StatusBadRequest)
+		case errors.Is(err, product.ErrReservationNotFound):
+			return product.Reservation{}, v1Web.NewRequestError(err, http.StatusNotFound)
+		default:
+			return product.Reservation{}, fmt.Errorf("ID[%s]: %w", reservationID, err)
+		}
+	}
+
+	// If you lack the permission and are looking to use someone else's reservation.
+	if !claims.HasPermission(auth.PermProductsWrite) && res.UserID != claims.Subject {
+		return product.Reservation{}, v1Web.NewRequestError(auth.ErrForbidden, http.StatusForbidden)
+	}
+
+	return res, nil
+}
+
+// CreateVariant adds a new variant to a product.
+func (h Handlers) CreateVariant(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	v, err := web.GetValues(ctx)
+	if err != nil {
+		return web.NewShutdownError("web value missing from context")
+	}
+
+	var nv product.NewVariant
+	if err := web.Decode(r, &nv); err != nil {
+		return fmt.Errorf("unable to decode payload: %w", err)
+	}
+
+	prd, err := h.ownedProduct(ctx, web.Param(r, "id"))
+	if err != nil {
+		return err
+	}
+
+	vrt, err := h.Product.CreateVariant(ctx, prd.ID, nv, v.Now)
+	if err != nil {
+		switch {
+		case errors.Is(err, product.ErrNotFound):
+			return v1Web.NewRequestError(err, http.StatusNotFound)
+		case errors.Is(err, product.ErrVariantCurrency):
+			return v1Web.NewRequestError(err, http.StatusBadRequest)
+		case errors.Is(err, product.ErrDuplicateSKU):
+			return v1Web.NewRequestError(err, http.StatusConflict)
+		default:
+			return fmt.Errorf("creating new variant, nv[%+v]: %w", nv, err)
+		}
+	}
+
+	return web.Response(ctx, w, http.StatusCreated, vrt)
+}
+
+// UpdateVariant updates a variant of a product.
+func (h Handlers) UpdateVariant(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	v, err := web.GetValues(ctx)
+	if err != nil {
+		return web.NewShutdownError("web value missing from context")
+	}
+
+	var upd product.UpdateVariant
+	if err := web.Decode(r, &upd); err != nil {
+		return fmt.Errorf("unable to decode payload: %w", err)
+	}
+
+	vrt, err := h.ownedVariant(ctx, web.Param(r, "id"))
+	if err != nil {
+		return err
+	}
+
+	if err := h.Product.UpdateVariant(ctx, vrt.ID, upd, v.Now); err != nil {
+		switch {
+		case errors.Is(err, product.ErrVariantNotFound):
+			return v1Web.NewRequestError(err, http.StatusNotFound)
+		case errors.Is(err, product.ErrVariantCurrency):
+			return v1Web.NewRequestError(err, http.StatusBadRequest)
+		case errors.Is(err, product.ErrDuplicateSKU):
+			return v1Web.NewRequestError(err, http.StatusConflict)
+		default:
+			return fmt.Errorf("ID[%s] Variant[%+v]: %w", vrt.ID, &upd, err)
+		}
+	}
+
+	return web.Response(ctx, w, http.StatusNoContent, nil)
+}
+
+// DeleteVariant removes a variant from a product.
+func (h Handlers) DeleteVariant(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	variantID := web.Param(r, "id")
+
+	vrt, err := h.Product.QueryVariantByID(ctx, variantID)
+	if err != nil {
+		switch {
+		case errors.Is(err, product.ErrVariantNotFound):
+
+			// Don't send StatusNotFound here since the call to Delete
+			// below won't if this variant is not found.
+			return web.Response(ctx, w, http.StatusNoContent, nil)
+		default:
+			return variantError(variantID, err)
+		}
+	}
+
+	if _, err := h.ownedProduct(ctx, vrt.ProductID); err != nil {
+		return err
+	}
+
+	if err := h.Product.DeleteVariant(ctx, vrt.ID); err != nil {
+		return fmt.Errorf("ID[%s]: %w", vrt.ID, err)
+	}
+
+	return web.Response(ctx, w, http.StatusNoContent, nil)
+}
+
+// QueryVariants returns the variants of a product.
+func (h Handlers) QueryVariants(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	productID := web.Param(r, "id")
+
+	vrts, err := h.Product.QueryVariants(ctx, productID)
+	if err != nil {
+		switch {
+		case errors.Is(err, product.ErrInvalidID):
+			return v1Web.NewRequestError(err, http.StatusBadRequest)
+		case errors.Is(err, product.ErrNotFound):
+			return v1Web.NewRequestError(err, http.StatusNotFound)
+		default:
+			return fmt.Errorf("ID[%s]: %w", productID, err)
+		}
+	}
+
+	return web.Response(ctx, w, http.StatusOK, vrts)
+}
+
+// QueryVariantByID returns a variant by its ID.
+func (h Handlers) QueryVariantByID(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	variantID := web.Param(r, "id")
+
+	vrt, err := h.Product.QueryVariantByID(ctx, variantID)
+	if err != nil {
+		return variantError(variantID, err)
+	}
+
+	return web.Response(ctx, w, http.StatusOK, vrt)
+}
+
+// ownedProduct retrieves the specified product, making sure the authenticated
+// user is allowed to change it.
+func (h Handlers) ownedProduct(ctx context.Context, productID string) (product.Product, error) {
+	claims, err := auth.GetClaims(ctx)
+	if err != nil {
+		return product.Product{}, v1Web.NewRequestError(auth.ErrForbidden, http.StatusForbidden)
+	}
+
+	prd, err := h.Product.QueryByID(ctx, productID)
+	if err != nil {
+		switch {
+		case errors.Is(err, product.ErrInvalidID):
+			return product.Product{}, v1Web.NewRequestError(err, http.StatusBadRequest)
+		case errors.Is(err, product.ErrNotFound):
+			return product.Product{}, v1Web.NewRequestError(err, http.StatusNotFound)
+		default:
+			return product.Product{}, fmt.Errorf("querying product[%s]: %w", productID, err)
+		}
+	}
+
+	// If you lack the permission and are looking to change a product you don't own.
+	if !claims.HasPermission(auth.PermProductsWrite) && prd.UserID != claims.Subject {
+		return product.Product{}, v1Web.NewRequestError(auth.ErrForbidden, http.StatusForbidden)
+	}
+
+	return prd, nil
+}
+
+// ownedVariant retrieves the specified variant, making sure the authenticated
+// user is allowed to change the product it belongs to.
+func (h Handlers) ownedVariant(ctx context.Context, variantID string) (product.Variant, error) {
+	vrt, err := h.Product.QueryVariantByID(ctx, variantID)
+	if err != nil {
+		return product.Variant{}, variantError(variantID, err)
+	}
+
+	if _, err := h.ownedProduct(ctx, vrt.ProductID); err != nil {
+		return product.Variant{}, err
+	}
+
+	return vrt, nil
+}
+
+// variantError maps the errors returned when looking up a variant.
+func variantError(variantID string, err error) error {
+	switch {
+	case errors.Is(err, product.ErrInvalidID):
+		return v1Web.NewRequestError(err, http.StatusBadRequest)
+	case errors.Is(err, product.ErrVariantNotFound):
+		return v1Web.NewRequestError(err, http.StatusNotFound)
+	default:
+		return fmt.Errorf("ID[%s]: %w", variantID, err)
+	}
+}
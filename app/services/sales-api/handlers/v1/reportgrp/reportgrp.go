@@ -0,0 +1,175 @@
+// Package reportgrp maintains the group of handlers for sales reporting.
+package reportgrp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/andrewyang17/service/business/core/report"
+	"github.com/andrewyang17/service/business/sys/money"
+	v1Web "github.com/andrewyang17/service/business/web/v1"
+	"github.com/andrewyang17/service/foundation/web"
+)
+
+// dateFormat is the layout used for the start_date and end_date parameters.
+const dateFormat = "2006-01-02"
+
+// defaultDays is the size of the date range used when the client does not
+// provide one.
+const defaultDays = 30
+
+// Handlers manages the set of report endpoints.
+type Handlers struct {
+	Report report.Core
+}
+
+// ByProduct returns units sold and revenue grouped by product.
+func (h Handlers) ByProduct(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	start, end, err := dateRange(ctx, r)
+	if err != nil {
+		return err
+	}
+
+	sums, err := h.Report.ByProduct(ctx, currency(r), start, end)
+	if err != nil {
+		return reportError(err)
+	}
+
+	return web.Response(ctx, w, http.StatusOK, sums)
+}
+
+// ByVariant returns units sold and revenue grouped by product variant.
+func (h Handlers) ByVariant(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	start, end, err := dateRange(ctx, r)
+	if err != nil {
+		return err
+	}
+
+	sums, err := h.Report.ByVariant(ctx, currency(r), start, end)
+	if err != nil {
+		return reportError(err)
+	}
+
+	return web.Response(ctx, w, http.StatusOK, sums)
+}
+
+// BySeller returns units sold and revenue grouped by seller.
+func (h Handlers) BySeller(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	start, end, err := dateRange(ctx, r)
+	if err != nil {
+		return err
+	}
+
+	sums, err := h.Report.BySeller(ctx, currency(r), start, end)
+	if err != nil {
+		return reportError(err)
+	}
+
+	return web.Response(ctx, w, http.StatusOK, sums)
+}
+
+// ByPeriod returns units sold and revenue grouped by day, week or month.
+func (h Handlers) ByPeriod(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	start, end, err := dateRange(ctx, r)
+	if err != nil {
+		return err
+	}
+
+	period := web.Param(r, "period")
+
+	sums, err := h.Report.ByPeriod(ctx, period, currency(r), start, end)
+	if err != nil {
+		return reportError(err)
+	}
+
+	return web.Response(ctx, w, http.StatusOK, sums)
+}
+
+// QueryRates returns the exchange rates used to convert report revenue.
+func (h Handlers) QueryRates(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	rates, err := h.Report.QueryRates(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to query rates: %w", err)
+	}
+
+	return web.Response(ctx, w, http.StatusOK, rates)
+}
+
+// SaveRate adds or replaces the rate for converting between two currencies.
+func (h Handlers) SaveRate(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	v, err := web.GetValues(ctx)
+	if err != nil {
+		return web.NewShutdownError("web value missing from context")
+	}
+
+	var nr report.NewExchangeRate
+	if err := web.Decode(r, &nr); err != nil {
+		return fmt.Errorf("unable to decode payload: %w", err)
+	}
+
+	rate, err := h.Report.SaveRate(ctx, nr, v.Now)
+	if err != nil {
+		return fmt.Errorf("saving rate[%+v]: %w", nr, err)
+	}
+
+	return web.Response(ctx, w, http.StatusOK, rate)
+}
+
+// =============================================================================
+
+// currency reads the optional currency query parameter that reports convert
+// revenue into, which defaults to US dollars.
+func currency(r *http.Request) string {
+	if s := r.URL.Query().Get("currency"); s != "" {
+		return strings.ToUpper(s)
+	}
+	return money.USD
+}
+
+// dateRange reads the optional start_date and end_date query parameters. Both
+// dates are inclusive and are converted into a [start, end) range in UTC. When
+// they are not provided the range covers the last defaultDays days.
+func dateRange(ctx context.Context, r *http.Request) (time.Time, time.Time, error) {
+	v, err := web.GetValues(ctx)
+	if err != nil {
+		return time.Time{}, time.Time{}, web.NewShutdownError("web value missing from context")
+	}
+
+	today := v.Now.UTC().Truncate(24 * time.Hour)
+
+	end := today.AddDate(0, 0, 1)
+	if s := r.URL.Query().Get("end_date"); s != "" {
+		t, err := time.Parse(dateFormat, s)
+		if err != nil {
+			return time.Time{}, time.Time{}, v1Web.NewRequestError(fmt.Errorf("invalid end_date format [%s]", s), http.StatusBadRequest)
+		}
+		end = t.AddDate(0, 0, 1)
+	}
+
+	start := end.AddDate(0, 0, -defaultDays)
+	if s := r.URL.Query().Get("start_date"); s != "" {
+		t, err := time.Parse(dateFormat, s)
+		if err != nil {
+			return time.Time{}, time.Time{}, v1Web.NewRequestError(fmt.Errorf("invalid start_date format [%s]", s), http.StatusBadRequest)
+		}
+		start = t
+	}
+
+	return start, end, nil
+}
+
+// reportError maps errors from the report core to web errors.
+func reportError(err error) error {
+	switch {
+	case errors.Is(err, report.ErrInvalidPeriod), errors.Is(err, report.ErrInvalidRange), errors.Is(err, report.ErrInvalidCurrency):
+		return v1Web.NewRequestError(err, http.StatusBadRequest)
+	case errors.Is(err, report.ErrMissingRate):
+		return v1Web.NewRequestError(err, http.StatusConflict)
+	default:
+		return fmt.Errorf("unable to query report: %w", err)
+	}
+}
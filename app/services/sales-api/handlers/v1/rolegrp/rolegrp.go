@@ -0,0 +1,126 @@
+// Package rolegrp maintains the group of handlers for roles and permissions.
+package rolegrp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/andrewyang17/service/business/core/role"
+	v1Web "github.com/andrewyang17/service/business/web/v1"
+	"github.com/andrewyang17/service/foundation/web"
+)
+
+// Handlers manages the set of role endpoints.
+type Handlers struct {
+	Role role.Core
+}
+
+// Query returns every role with the permissions it grants.
+func (h Handlers) Query(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	roles, err := h.Role.Query(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to query for roles: %w", err)
+	}
+
+	return web.Response(ctx, w, http.StatusOK, roles)
+}
+
+// QueryByName returns the specified role.
+func (h Handlers) QueryByName(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	name := web.Param(r, "name")
+
+	rol, err := h.Role.QueryByName(ctx, name)
+	if err != nil {
+		switch {
+		case errors.Is(err, role.ErrNotFound):
+			return v1Web.NewRequestError(err, http.StatusNotFound)
+		default:
+			return fmt.Errorf("name[%s]: %w", name, err)
+		}
+	}
+
+	return web.Response(ctx, w, http.StatusOK, rol)
+}
+
+// Create defines a new role.
+func (h Handlers) Create(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	v, err := web.GetValues(ctx)
+	if err != nil {
+		return web.NewShutdownError("web value missing from context")
+	}
+
+	var nr role.NewRole
+	if err := web.Decode(r, &nr); err != nil {
+		return fmt.Errorf("unable to decode payload: %w", err)
+	}
+
+	rol, err := h.Role.Create(ctx, nr, v.Now)
+	if err != nil {
+		switch {
+		case errors.Is(err, role.ErrDuplicateName):
+			return v1Web.NewRequestError(err, http.StatusConflict)
+		default:
+			return fmt.Errorf("role[%+v]: %w", &nr, err)
+		}
+	}
+
+	return web.Response(ctx, w, http.StatusCreated, rol)
+}
+
+// Update changes the description and permissions of a role.
+func (h Handlers) Update(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	v, err := web.GetValues(ctx)
+	if err != nil {
+		return web.NewShutdownError("web value missing from context")
+	}
+
+	var ur role.UpdateRole
+	if err := web.Decode(r, &ur); err != nil {
+		return fmt.Errorf("unable to decode payload: %w", err)
+	}
+
+	name := web.Param(r, "name")
+
+	if err := h.Role.Update(ctx, name, ur, v.Now); err != nil {
+		switch {
+		case errors.Is(err, role.ErrNotFound):
+			return v1Web.NewRequestError(err, http.StatusNotFound)
+		case errors.Is(err, role.ErrBuiltIn):
+			return v1Web.NewRequestError(err, http.StatusConflict)
+		default:
+			return fmt.Errorf("name[%s] role[%+v]: %w", name, &ur, err)
+		}
+	}
+
+	return web.Response(ctx, w, http.StatusNoContent, nil)
+}
+
+// Delete removes a role that no user holds.
+func (h Handlers) Delete(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	name := web.Param(r, "name")
+
+	if err := h.Role.Delete(ctx, name); err != nil {
+		switch {
+		case errors.Is(err, role.ErrNotFound):
+			return v1Web.NewRequestError(err, http.StatusNotFound)
+		case errors.Is(err, role.ErrBuiltIn), errors.Is(err, role.ErrInUse):
+			return v1Web.NewRequestError(err, http.StatusConflict)
+		default:
+			return fmt.Errorf("name[%s]: %w", name, err)
+		}
+	}
+
+	return web.Response(ctx, w, http.StatusNoContent, nil)
+}
+
+// QueryPermissions returns every permission a role can grant.
+func (h Handlers) QueryPermissions(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	perms, err := h.Role.QueryPermissions(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to query for permissions: %w", err)
+	}
+
+	return web.Response(ctx, w, http.StatusOK, perms)
+}
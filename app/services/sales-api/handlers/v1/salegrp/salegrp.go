@@ -0,0 +1,230 @@
+// Package salegrp maintains the group of handlers for sale access.
+package salegrp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/andrewyang17/service/business/core/sale"
+	"github.com/andrewyang17/service/business/sys/auth"
+	v1Web "github.com/andrewyang17/service/business/web/v1"
+	"github.com/andrewyang17/service/foundation/web"
+)
+
+// defaultQueryRows is the page size used by QueryCursor when the client does
+// not provide one.
+const defaultQueryRows = 20
+
+// Handlers manages the set of sale endpoints.
+type Handlers struct {
+	Sale sale.Core
+}
+
+// Create records a purchase for the authenticated user.
+func (h Handlers) Create(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	v, err := web.GetValues(ctx)
+	if err != nil {
+		return web.NewShutdownError("web value missing from context")
+	}
+
+	claims, err := auth.GetClaims(ctx)
+	if err != nil {
+		return v1Web.NewRequestError(auth.ErrForbidden, http.StatusForbidden)
+	}
+
+	var ns sale.NewSale
+	if err := web.Decode(r, &ns); err != nil {
+		return fmt.Errorf("unable to decode payload: %w", err)
+	}
+
+	// The sale is always recorded against the user making the purchase.
+	ns.UserID = claims.Subject
+
+	sle, err := h.Sale.Create(ctx, ns, v.Now)
+	if err != nil {
+		switch {
+		case errors.Is(err, sale.ErrInvalidID):
+			return v1Web.NewRequestError(err, http.StatusBadRequest)
+		case errors.Is(err, sale.ErrProductNotFound), errors.Is(err, sale.ErrVariantNotFound):
+			return v1Web.NewRequestError(err, http.StatusNotFound)
+		case errors.Is(err, sale.ErrInsufficientStock):
+			return v1Web.NewRequestError(err, http.StatusConflict)
+		default:
+			return fmt.Errorf("creating new sale, ns[%+v]: %w", ns, err)
+		}
+	}
+
+	return web.Response(ctx, w, http.StatusCreated, sle)
+}
+
+// Query returns a list of sales with paging. Users allowed to read every sale
+// see them all, other users only see their own purchases.
+func (h Handlers) Query(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	claims, err := auth.GetClaims(ctx)
+	if err != nil {
+		return v1Web.NewRequestError(auth.ErrForbidden, http.StatusForbidden)
+	}
+
+	page := web.Param(r, "page")
+	pageNumber, err := strconv.Atoi(page)
+	if err != nil {
+		return v1Web.NewRequestError(fmt.Errorf("invalid page format [%s]", page), http.StatusBadRequest)
+	}
+
+	rows := web.Param(r, "rows")
+	rowsPerPage, err := strconv.Atoi(rows)
+	if err != nil {
+		return v1Web.NewRequestError(fmt.Errorf("invalid rows format [%s]", rows), http.StatusBadRequest)
+	}
+
+	var sales []sale.Sale
+	switch {
+	case claims.HasPermission(auth.PermSalesRead):
+		sales, err = h.Sale.Query(ctx, pageNumber, rowsPerPage)
+	default:
+		sales, err = h.Sale.QueryByUserID(ctx, claims.Subject, pageNumber, rowsPerPage)
+	}
+	if err != nil {
+		return fmt.Errorf("unable to query for sales: %w", err)
+	}
+
+	return web.Response(ctx, w, http.StatusOK, sales)
+}
+
+// QueryCursor returns a page of sales, newest first, along with the cursors
+// for the pages either side of it. The cursor parameter takes a next or prev
+// cursor from an earlier response. Users allowed to read sales see them all,
+// other users only see their own purchases.
+func (h Handlers) QueryCursor(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	claims, err := auth.GetClaims(ctx)
+	if err != nil {
+		return v1Web.NewRequestError(auth.ErrForbidden, http.StatusForbidden)
+	}
+
+	qs := r.URL.Query()
+
+	rowsPerPage := defaultQueryRows
+	if rows := qs.Get("rows"); rows != "" {
+		rowsPerPage, err = strconv.Atoi(rows)
+		if err != nil || rowsPerPage < 1 {
+			return v1Web.NewRequestError(fmt.Errorf("invalid rows format [%s]", rows), http.StatusBadRequest)
+		}
+	}
+
+	var userID string
+	if !claims.HasPermission(auth.PermSalesRead) {
+		userID = claims.Subject
+	}
+
+	page, err := h.Sale.QueryCursor(ctx, userID, qs.Get("cursor"), rowsPerPage)
+	if err != nil {
+		return fmt.Errorf("unable to query for sales: %w", err)
+	}
+
+	return web.Response(ctx, w, http.StatusOK, page)
+}
+
+// QueryByID returns a sale by its ID.
+func (h Handlers) QueryByID(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	claims, err := auth.GetClaims(ctx)
+	if err != nil {
+		return v1Web.NewRequestError(auth.ErrForbidden, http.StatusForbidden)
+	}
+
+	saleID := web.Param(r, "id")
+
+	sle, err := h.Sale.QueryByID(ctx, saleID)
+	if err != nil {
+		switch {
+		case errors.Is(err, sale.ErrInvalidID):
+			return v1Web.NewRequestError(err, http.StatusBadRequest)
+		case errors.Is(err, sale.ErrNotFound):
+			return v1Web.NewRequestError(err, http.StatusNotFound)
+		default:
+			return fmt.Errorf("ID[%s]: %w", saleID, err)
+		}
+	}
+
+	// If you lack the permission and are looking to retrieve someone else's sale.
+	if !claims.HasPermission(auth.PermSalesRead) && sle.UserID != claims.Subject {
+		return v1Web.NewRequestError(auth.ErrForbidden, http.StatusForbidden)
+	}
+
+	return web.Response(ctx, w, http.StatusOK, sle)
+}
+
+// Refund records a full or partial refund against a sale.
+func (h Handlers) Refund(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	v, err := web.GetValues(ctx)
+	if err != nil {
+		return web.NewShutdownError("web value missing from context")
+	}
+
+	claims, err := auth.GetClaims(ctx)
+	if err != nil {
+		return v1Web.NewRequestError(auth.ErrForbidden, http.StatusForbidden)
+	}
+
+	var nr sale.NewRefund
+	if err := web.Decode(r, &nr); err != nil {
+		return fmt.Errorf("unable to decode payload: %w", err)
+	}
+
+	// The refund is always recorded against the user issuing it.
+	nr.UserID = claims.Subject
+
+	saleID := web.Param(r, "id")
+
+	ref, err := h.Sale.Refund(ctx, saleID, nr, v.Now)
+	if err != nil {
+		switch {
+		case errors.Is(err, sale.ErrInvalidID):
+			return v1Web.NewRequestError(err, http.StatusBadRequest)
+		case errors.Is(err, sale.ErrNotFound), errors.Is(err, sale.ErrProductNotFound), errors.Is(err, sale.ErrVariantNotFound):
+			return v1Web.NewRequestError(err, http.StatusNotFound)
+		case errors.Is(err, sale.ErrRefundExceedsPaid), errors.Is(err, sale.ErrRefundExceedsQuantity):
+			return v1Web.NewRequestError(err, http.StatusConflict)
+		default:
+			return fmt.Errorf("refunding sale[%s], nr[%+v]: %w", saleID, nr, err)
+		}
+	}
+
+	return web.Response(ctx, w, http.StatusCreated, ref)
+}
+
+// QueryRefunds returns the refunds recorded against a sale.
+func (h Handlers) QueryRefunds(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	claims, err := auth.GetClaims(ctx)
+	if err != nil {
+		return v1Web.NewRequestError(auth.ErrForbidden, http.StatusForbidden)
+	}
+
+	saleID := web.Param(r, "id")
+
+	sle, err := h.Sale.QueryByID(ctx, saleID)
+	if err != nil {
+		switch {
+		case errors.Is(err, sale.ErrInvalidID):
+			return v1Web.NewRequestError(err, http.StatusBadRequest)
+		case errors.Is(err, sale.ErrNotFound):
+			return v1Web.NewRequestError(err, http.StatusNotFound)
+		default:
+			return fmt.Errorf("ID[%s]: %w", saleID, err)
+		}
+	}
+
+	// If you lack the permission and are looking to retrieve someone else's refunds.
+	if !claims.HasPermission(auth.PermSalesRead) && sle.UserID != claims.Subject {
+		return v1Web.NewRequestError(auth.ErrForbidden, http.StatusForbidden)
+	}
+
+	refs, err := h.Sale.QueryRefunds(ctx, saleID)
+	if err != nil {
+		return fmt.Errorf("ID[%s]: %w", saleID, err)
+	}
+
+	return web.Response(ctx, w, http.StatusOK, refs)
+}
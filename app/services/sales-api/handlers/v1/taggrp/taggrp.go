@@ -0,0 +1,163 @@
+// Package taggrp maintains the group of handlers for product tags.
+package taggrp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/andrewyang17/service/business/core/product"
+	"github.com/andrewyang17/service/business/core/tag"
+	"github.com/andrewyang17/service/business/sys/auth"
+	v1Web "github.com/andrewyang17/service/business/web/v1"
+	"github.com/andrewyang17/service/foundation/web"
+)
+
+// Handlers manages the set of tag endpoints.
+type Handlers struct {
+	Tag     tag.Core
+	Product product.Core
+}
+
+// Query returns a list of tags with paging.
+func (h Handlers) Query(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	page := web.Param(r, "page")
+	pageNumber, err := strconv.Atoi(page)
+	if err != nil {
+		return v1Web.NewRequestError(fmt.Errorf("invalid page format [%s]", page), http.StatusBadRequest)
+	}
+
+	rows := web.Param(r, "rows")
+	rowsPerPage, err := strconv.Atoi(rows)
+	if err != nil {
+		return v1Web.NewRequestError(fmt.Errorf("invalid rows format [%s]", rows), http.StatusBadRequest)
+	}
+
+	tags, err := h.Tag.Query(ctx, pageNumber, rowsPerPage)
+	if err != nil {
+		return fmt.Errorf("unable to query for tags: %w", err)
+	}
+
+	return web.Response(ctx, w, http.StatusOK, tags)
+}
+
+// Delete removes a tag from the system and from every product.
+func (h Handlers) Delete(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	tagID := web.Param(r, "id")
+
+	if err := h.Tag.Delete(ctx, tagID); err != nil {
+		switch {
+		case errors.Is(err, tag.ErrInvalidID):
+			return v1Web.NewRequestError(err, http.StatusBadRequest)
+		default:
+			return fmt.Errorf("ID[%s]: %w", tagID, err)
+		}
+	}
+
+	return web.Response(ctx, w, http.StatusNoContent, nil)
+}
+
+// QueryByProductID returns the tags attached to a product.
+func (h Handlers) QueryByProductID(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	productID := web.Param(r, "id")
+
+	if _, err := h.Product.QueryByID(ctx, productID); err != nil {
+		return productError(productID, err)
+	}
+
+	tags, err := h.Tag.QueryByProductID(ctx, productID)
+	if err != nil {
+		return fmt.Errorf("ID[%s]: %w", productID, err)
+	}
+
+	return web.Response(ctx, w, http.StatusOK, tags)
+}
+
+// TagProduct attaches a tag to a product the user owns.
+func (h Handlers) TagProduct(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	v, err := web.GetValues(ctx)
+	if err != nil {
+		return web.NewShutdownError("web value missing from context")
+	}
+
+	var nt tag.NewTag
+	if err := web.Decode(r, &nt); err != nil {
+		return fmt.Errorf("unable to decode payload: %w", err)
+	}
+
+	productID := web.Param(r, "id")
+
+	if err := h.checkOwner(ctx, productID); err != nil {
+		return err
+	}
+
+	tg, err := h.Tag.TagProduct(ctx, productID, nt, v.Now)
+	if err != nil {
+		switch {
+		case errors.Is(err, tag.ErrProductNotFound):
+			return v1Web.NewRequestError(err, http.StatusNotFound)
+		default:
+			return fmt.Errorf("tagging product[%s], nt[%+v]: %w", productID, nt, err)
+		}
+	}
+
+	return web.Response(ctx, w, http.StatusCreated, tg)
+}
+
+// UntagProduct detaches a tag from a product the user owns.
+func (h Handlers) UntagProduct(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	productID := web.Param(r, "id")
+	tagID := web.Param(r, "tag_id")
+
+	if err := h.checkOwner(ctx, productID); err != nil {
+		return err
+	}
+
+	if err := h.Tag.UntagProduct(ctx, productID, tagID); err != nil {
+		switch {
+		case errors.Is(err, tag.ErrInvalidID):
+			return v1Web.NewRequestError(err, http.StatusBadRequest)
+		default:
+			return fmt.Errorf("product[%s] tag[%s]: %w", productID, tagID, err)
+		}
+	}
+
+	return web.Response(ctx, w, http.StatusNoContent, nil)
+}
+
+// =============================================================================
+
+// checkOwner only lets the owner of a product and users allowed to change
+// any product change its tags.
+func (h Handlers) checkOwner(ctx context.Context, productID string) error {
+	claims, err := auth.GetClaims(ctx)
+	if err != nil {
+		return v1Web.NewRequestError(auth.ErrForbidden, http.StatusForbidden)
+	}
+
+	prd, err := h.Product.QueryByID(ctx, productID)
+	if err != nil {
+		return productError(productID, err)
+	}
+
+	// If you lack the permission and are looking to tag a product you don't own.
+	if !claims.HasPermission(auth.PermProductsWrite) && prd.UserID != claims.Subject {
+		return v1Web.NewRequestError(auth.ErrForbidden, http.StatusForbidden)
+	}
+
+	return nil
+}
+
+// productError maps errors from looking up a product to web errors.
+func productError(productID string, err error) error {
+	switch {
+	case errors.Is(err, product.ErrInvalidID):
+		return v1Web.NewRequestError(err, http.StatusBadRequest)
+	case errors.Is(err, product.ErrNotFound):
+		return v1Web.NewRequestError(err, http.StatusNotFound)
+	default:
+		return fmt.Errorf("querying product[%s]: %w", productID, err)
+	}
+}
@@ -7,16 +7,29 @@ import (
 	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
+	"github.com/andrewyang17/service/business/core/session"
 	"github.com/andrewyang17/service/business/core/user"
+	"github.com/andrewyang17/service/business/data/order"
 	"github.com/andrewyang17/service/business/sys/auth"
 	v1Web "github.com/andrewyang17/service/business/web/v1"
 	"github.com/andrewyang17/service/foundation/web"
 )
 
+// defaultQueryRows is the page size used by QueryCursor when the client does
+// not provide one.
+const defaultQueryRows = 20
+
+// dateFormat is the layout of the created dates used to filter users.
+const dateFormat = "2006-01-02"
+
 type Handlers struct {
-	User user.Core
-	Auth *auth.Auth
+	User                user.Core
+	Session             session.Core
+	Auth                *auth.Auth
+	RequireVerification bool
 }
 
 // Create adds a new user to the system.
@@ -39,7 +52,35 @@ func (h Handlers) Create(ctx context.Context, w http.ResponseWriter, r *http.Req
 	return web.Response(ctx, w, http.StatusCreated, usr)
 }
 
-// Update updates a user in the system.
+// Register signs up a new customer. Any roles in the request are ignored and
+// the user is only given the USER role.
+func (h Handlers) Register(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	v, err := web.GetValues(ctx)
+	if err != nil {
+		return web.NewShutdownError("web value missing from context")
+	}
+
+	var nr user.NewRegistration
+	if err := web.Decode(r, &nr); err != nil {
+		return fmt.Errorf("unable to decode payload: %w", err)
+	}
+
+	usr, err := h.User.Register(ctx, nr, v.Now)
+	if err != nil {
+		switch {
+		case errors.Is(err, user.ErrDuplicateEmail):
+			return v1Web.NewRequestError(err, http.StatusConflict)
+		default:
+			return fmt.Errorf("registering: %w", err)
+		}
+	}
+
+	return web.Response(ctx, w, http.StatusCreated, usr)
+}
+
+// Update updates a user in the system. When an If-Match header with the ETag
+// from an earlier read is given, the user is only updated if no one changed
+// it since. Only users themselves can change their email and password.
 func (h Handlers) Update(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
 	v, err := web.GetValues(ctx)
 	if err != nil {
@@ -58,17 +99,48 @@ func (h Handlers) Update(ctx context.Context, w http.ResponseWriter, r *http.Req
 
 	userID := web.Param(r, "id")
 
-	// If you are not an admin and looking to retrieve someone other than yourself.
-	if !claims.Authorized(auth.RoleAdmin) && claims.Subject != userID {
+	// If you lack the permission and are looking to retrieve someone other than yourself.
+	if !claims.HasPermission(auth.PermUsersWrite) && claims.Subject != userID {
 		return v1Web.NewRequestError(auth.ErrForbidden, http.StatusForbidden)
 	}
 
-	if err := h.User.Update(ctx, userID, upd, v.Now); err != nil {
+	version, err := parseIfMatch(r)
+	if err != nil {
+		return v1Web.NewRequestError(err, http.StatusPreconditionFailed)
+	}
+
+	if upd.Roles != nil || upd.Email != nil || upd.Password != nil {
+		usr, err := h.User.QueryByID(ctx, userID)
+		if err != nil {
+			switch {
+			case errors.Is(err, user.ErrInvalidID):
+				return v1Web.NewRequestError(err, http.StatusBadRequest)
+			case errors.Is(err, user.ErrNotFound):
+				return v1Web.NewRequestError(err, http.StatusNotFound)
+			default:
+				return fmt.Errorf("ID[%s]: %w", userID, err)
+			}
+		}
+
+		if err := checkCredentialChange(claims, usr, upd); err != nil {
+			return err
+		}
+
+		if err := checkRoleChange(claims, usr.Roles, upd.Roles); err != nil {
+			return err
+		}
+	}
+
+	if err := h.User.Update(ctx, userID, upd, version, v.Now); err != nil {
 		switch {
 		case errors.Is(err, user.ErrInvalidID):
 			return v1Web.NewRequestError(err, http.StatusBadRequest)
 		case errors.Is(err, user.ErrNotFound):
 			return v1Web.NewRequestError(err, http.StatusNotFound)
+		case errors.Is(err, user.ErrVersionConflict):
+			return v1Web.NewRequestError(err, http.StatusPreconditionFailed)
+		case errors.Is(err, user.ErrDuplicateEmail):
+			return v1Web.NewRequestError(err, http.StatusConflict)
 		default:
 			return fmt.Errorf("ID[%s] User[%+v]: %w", userID, &upd, err)
 		}
@@ -77,8 +149,110 @@ func (h Handlers) Update(ctx context.Context, w http.ResponseWriter, r *http.Req
 	return web.Response(ctx, w, http.StatusNoContent, nil)
 }
 
-// Delete removes a user from the system.
+// patchUser is the document a patch to a user is applied to. The passwords
+// are not part of the user but a patch may add them to change the password.
+type patchUser struct {
+	Name            string   `json:"name"`
+	Email           string   `json:"email"`
+	Roles           []string `json:"roles"`
+	Password        *string  `json:"password,omitempty"`
+	PasswordConfirm *string  `json:"password_confirm,omitempty"`
+}
+
+// Patch applies a JSON Merge Patch or JSON Patch document to a user. The
+// patched user is validated and saved like a full update, so removing the
+// roles or setting them to null clears them. The user is only saved if no one
+// changed it since it was read, or since the ETag given in If-Match.
+func (h Handlers) Patch(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	v, err := web.GetValues(ctx)
+	if err != nil {
+		return web.NewShutdownError("web value missing from context")
+	}
+
+	claims, err := auth.GetClaims(ctx)
+	if err != nil {
+		return v1Web.NewRequestError(auth.ErrForbidden, http.StatusForbidden)
+	}
+
+	userID := web.Param(r, "id")
+
+	// If you lack the permission and are looking to change someone other than yourself.
+	if !claims.HasPermission(auth.PermUsersWrite) && claims.Subject != userID {
+		return v1Web.NewRequestError(auth.ErrForbidden, http.StatusForbidden)
+	}
+
+	version, err := parseIfMatch(r)
+	if err != nil {
+		return v1Web.NewRequestError(err, http.StatusPreconditionFailed)
+	}
+
+	usr, err := h.User.QueryByID(ctx, userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, user.ErrInvalidID):
+			return v1Web.NewRequestError(err, http.StatusBadRequest)
+		case errors.Is(err, user.ErrNotFound):
+			return v1Web.NewRequestError(err, http.StatusNotFound)
+		default:
+			return fmt.Errorf("ID[%s]: %w", userID, err)
+		}
+	}
+
+	if version != 0 && version != usr.Version {
+		return v1Web.NewRequestError(user.ErrVersionConflict, http.StatusPreconditionFailed)
+	}
+
+	current := patchUser{
+		Name:  usr.Name,
+		Email: usr.Email,
+		Roles: usr.Roles,
+	}
+
+	var upd user.UpdateUser
+	if err := v1Web.DecodePatch(r, current, &upd); err != nil {
+		return err
+	}
+
+	if upd.Name == nil || upd.Email == nil {
+		return v1Web.NewRequestError(errors.New("name and email can not be removed"), http.StatusBadRequest)
+	}
+	if upd.Roles == nil {
+		upd.Roles = []string{}
+	}
+
+	if err := checkCredentialChange(claims, usr, upd); err != nil {
+		return err
+	}
+
+	if err := checkRoleChange(claims, usr.Roles, upd.Roles); err != nil {
+		return err
+	}
+
+	if err := h.User.Update(ctx, userID, upd, usr.Version, v.Now); err != nil {
+		switch {
+		case errors.Is(err, user.ErrNotFound):
+			return v1Web.NewRequestError(err, http.StatusNotFound)
+		case errors.Is(err, user.ErrVersionConflict):
+			return v1Web.NewRequestError(err, http.StatusPreconditionFailed)
+		case errors.Is(err, user.ErrDuplicateEmail):
+			return v1Web.NewRequestError(err, http.StatusConflict)
+		default:
+			return fmt.Errorf("ID[%s] User[%+v]: %w", userID, &upd, err)
+		}
+	}
+
+	return web.Response(ctx, w, http.StatusNoContent, nil)
+}
+
+// Delete removes a user from the organization. A user who belongs to no other
+// organization is only marked as deleted and can be restored until it is
+// purged.
 func (h Handlers) Delete(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	v, err := web.GetValues(ctx)
+	if err != nil {
+		return web.NewShutdownError("web value missing from context")
+	}
+
 	claims, err := auth.GetClaims(ctx)
 	if err != nil {
 		return v1Web.NewRequestError(auth.ErrForbidden, http.StatusForbidden)
@@ -86,12 +260,54 @@ func (h Handlers) Delete(ctx context.Context, w http.ResponseWriter, r *http.Req
 
 	userID := web.Param(r, "id")
 
-	// If you are not an admin and looking to delete someone other than yourself.
-	if !claims.Authorized(auth.RoleAdmin) && claims.Subject != userID {
+	// If you lack the permission and are looking to delete someone other than yourself.
+	if !claims.HasPermission(auth.PermUsersWrite) && claims.Subject != userID {
 		return v1Web.NewRequestError(auth.ErrForbidden, http.StatusForbidden)
 	}
 
-	if err := h.User.Delete(ctx, userID); err != nil {
+	if err := h.User.Delete(ctx, userID, v.Now); err != nil {
+		switch {
+		case errors.Is(err, user.ErrInvalidID):
+			return v1Web.NewRequestError(err, http.StatusBadRequest)
+		case errors.Is(err, user.ErrNotFound):
+			return v1Web.NewRequestError(err, http.StatusNotFound)
+		default:
+			return fmt.Errorf("ID[%s]: %w", userID, err)
+		}
+	}
+
+	return web.Response(ctx, w, http.StatusNoContent, nil)
+}
+
+// Restore brings back a deleted user.
+func (h Handlers) Restore(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	v, err := web.GetValues(ctx)
+	if err != nil {
+		return web.NewShutdownError("web value missing from context")
+	}
+
+	userID := web.Param(r, "id")
+
+	if err := h.User.Restore(ctx, userID, v.Now); err != nil {
+		switch {
+		case errors.Is(err, user.ErrInvalidID):
+			return v1Web.NewRequestError(err, http.StatusBadRequest)
+		case errors.Is(err, user.ErrNotFound):
+			return v1Web.NewRequestError(err, http.StatusNotFound)
+		default:
+			return fmt.Errorf("ID[%s]: %w", userID, err)
+		}
+	}
+
+	return web.Response(ctx, w, http.StatusNoContent, nil)
+}
+
+// Unlock lets a user who was locked out after too many failed logins
+// authenticate again.
+func (h Handlers) Unlock(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	userID := web.Param(r, "id")
+
+	if err := h.User.Unlock(ctx, userID); err != nil {
 		switch {
 		case errors.Is(err, user.ErrInvalidID):
 			return v1Web.NewRequestError(err, http.StatusBadRequest)
@@ -105,7 +321,9 @@ func (h Handlers) Delete(ctx context.Context, w http.ResponseWriter, r *http.Req
 	return web.Response(ctx, w, http.StatusNoContent, nil)
 }
 
-// Query returns a list of users with paging.
+// Query returns a list of users with paging. Users can be filtered by name,
+// email, role and created date and ordered with order_by, for example
+// order_by=email,desc. Deleted users are only listed with deleted=true.
 func (h Handlers) Query(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
 	page := web.Param(r, "page")
 	pageNumber, err := strconv.Atoi(page)
@@ -119,7 +337,17 @@ func (h Handlers) Query(ctx context.Context, w http.ResponseWriter, r *http.Requ
 		return v1Web.NewRequestError(fmt.Errorf("invalid rows format [%s]", rows), http.StatusBadRequest)
 	}
 
-	users, err := h.User.Query(ctx, pageNumber, rowsPerPage)
+	filter, err := parseFilter(r)
+	if err != nil {
+		return err
+	}
+
+	orderBy, err := order.Parse(r.URL.Query().Get("order_by"), user.DefaultOrderBy)
+	if err != nil {
+		return err
+	}
+
+	users, err := h.User.Query(ctx, filter, orderBy, pageNumber, rowsPerPage)
 	if err != nil {
 		return fmt.Errorf("unable to query for users: %w", err)
 	}
@@ -127,7 +355,42 @@ func (h Handlers) Query(ctx context.Context, w http.ResponseWriter, r *http.Requ
 	return web.Response(ctx, w, http.StatusOK, users)
 }
 
-// QueryByID returns a user by its ID.
+// QueryCursor returns a page of users along with the cursors for the pages
+// either side of it. The filter and order_by work as they do for Query. The
+// cursor parameter takes a next or prev cursor from an earlier response and
+// carries the order with it.
+func (h Handlers) QueryCursor(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	qs := r.URL.Query()
+
+	rowsPerPage := defaultQueryRows
+	if rows := qs.Get("rows"); rows != "" {
+		var err error
+		rowsPerPage, err = strconv.Atoi(rows)
+		if err != nil || rowsPerPage < 1 {
+			return v1Web.NewRequestError(fmt.Errorf("invalid rows format [%s]", rows), http.StatusBadRequest)
+		}
+	}
+
+	filter, err := parseFilter(r)
+	if err != nil {
+		return err
+	}
+
+	orderBy, err := order.Parse(qs.Get("order_by"), user.DefaultOrderBy)
+	if err != nil {
+		return err
+	}
+
+	page, err := h.User.QueryCursor(ctx, filter, orderBy, qs.Get("cursor"), rowsPerPage)
+	if err != nil {
+		return fmt.Errorf("unable to query for users: %w", err)
+	}
+
+	return web.Response(ctx, w, http.StatusOK, page)
+}
+
+// QueryByID returns a user by its ID. The ETag header carries the version of
+// the user for use in an If-Match header when updating it.
 func (h Handlers) QueryByID(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
 	claims, err := auth.GetClaims(ctx)
 	if err != nil {
@@ -136,8 +399,8 @@ func (h Handlers) QueryByID(ctx context.Context, w http.ResponseWriter, r *http.
 
 	userID := web.Param(r, "id")
 
-	// If you are not an admin and looking to retrieve someone other than yourself.
-	if !claims.Authorized(auth.RoleAdmin) && claims.Subject != userID {
+	// If you lack the permission and are looking to retrieve someone other than yourself.
+	if !claims.HasPermission(auth.PermUsersRead) && claims.Subject != userID {
 		return v1Web.NewRequestError(auth.ErrForbidden, http.StatusForbidden)
 	}
 
@@ -153,10 +416,14 @@ func (h Handlers) QueryByID(ctx context.Context, w http.ResponseWriter, r *http.
 		}
 	}
 
+	w.Header().Set("ETag", etag(usr.Version))
+
 	return web.Response(ctx, w, http.StatusOK, usr)
 }
 
-// Token provides an API token for the authenticated user.
+// Token provides an API token for the authenticated user along with a
+// refresh token for getting the next one. The org_id query parameter selects
+// the organization the token acts for.
 func (h Handlers) Token(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
 	v, err := web.GetValues(ctx)
 	if err != nil {
@@ -169,26 +436,346 @@ func (h Handlers) Token(ctx context.Context, w http.ResponseWriter, r *http.Requ
 		return v1Web.NewRequestError(err, http.StatusUnauthorized)
 	}
 
-	claims, err := h.User.Authenticate(ctx, v.Now, email, pass)
+	orgID := r.URL.Query().Get("org_id")
+
+	claims, err := h.User.Authenticate(ctx, v.Now, email, pass, orgID)
 	if err != nil {
 		switch {
 		case errors.Is(err, user.ErrNotFound):
 			return v1Web.NewRequestError(err, http.StatusNotFound)
 		case errors.Is(err, user.ErrAuthenticationFailure):
 			return v1Web.NewRequestError(err, http.StatusUnauthorized)
+		case errors.Is(err, user.ErrAccountLocked):
+			return v1Web.NewRequestError(err, http.StatusTooManyRequests)
+		case errors.Is(err, user.ErrNotMember):
+			return v1Web.NewRequestError(err, http.StatusForbidden)
 		default:
 			return fmt.Errorf("authenticating: %w", err)
 		}
 	}
 
-	var tkn struct {
+	if h.RequireVerification && !claims.Verified {
+		err := errors.New("email must be verified before logging in")
+		return v1Web.NewRequestError(err, http.StatusForbidden)
+	}
+
+	claims, refresh, err := h.Session.Create(ctx, claims, v.Now)
+	if err != nil {
+		return fmt.Errorf("starting session: %w", err)
+	}
+
+	return h.respondToken(ctx, w, claims, refresh)
+}
+
+// RefreshToken redeems a refresh token for a new access token and a new
+// refresh token. Using a refresh token twice revokes its session.
+func (h Handlers) RefreshToken(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	v, err := web.GetValues(ctx)
+	if err != nil {
+		return web.NewShutdownError("web value missing from context")
+	}
+
+	var rf session.Refresh
+	if err := web.Decode(r, &rf); err != nil {
+		return fmt.Errorf("unable to decode payload: %w", err)
+	}
+
+	claims, refresh, err := h.Session.Refresh(ctx, rf, v.Now)
+	if err != nil {
+		switch {
+		case errors.Is(err, session.ErrInvalidToken):
+			return v1Web.NewRequestError(err, http.StatusUnauthorized)
+		case errors.Is(err, session.ErrTokenReused):
+			return v1Web.NewRequestError(err, http.StatusUnauthorized)
+		default:
+			return fmt.Errorf("refreshing token: %w", err)
+		}
+	}
+
+	return h.respondToken(ctx, w, claims, refresh)
+}
+
+// Logout revokes the token of the authenticated user along with its session.
+func (h Handlers) Logout(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	v, err := web.GetValues(ctx)
+	if err != nil {
+		return web.NewShutdownError("web value missing from context")
+	}
+
+	claims, err := auth.GetClaims(ctx)
+	if err != nil {
+		return v1Web.NewRequestError(auth.ErrForbidden, http.StatusForbidden)
+	}
+
+	if err := h.Session.Logout(ctx, claims, v.Now); err != nil {
+		return fmt.Errorf("logging out: %w", err)
+	}
+
+	return web.Response(ctx, w, http.StatusNoContent, nil)
+}
+
+// RevokeSessions revokes every session of a user, logging them out
+// everywhere.
+func (h Handlers) RevokeSessions(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	v, err := web.GetValues(ctx)
+	if err != nil {
+		return web.NewShutdownError("web value missing from context")
+	}
+
+	claims, err := auth.GetClaims(ctx)
+	if err != nil {
+		return v1Web.NewRequestError(auth.ErrForbidden, http.StatusForbidden)
+	}
+
+	userID := web.Param(r, "id")
+
+	// If you lack the permission and are looking to log out another user.
+	if !claims.HasPermission(auth.PermUsersWrite) && claims.Subject != userID {
+		return v1Web.NewRequestError(auth.ErrForbidden, http.StatusForbidden)
+	}
+
+	if err := h.Session.RevokeByUserID(ctx, userID, v.Now); err != nil {
+		switch {
+		case errors.Is(err, session.ErrInvalidID):
+			return v1Web.NewRequestError(err, http.StatusBadRequest)
+		default:
+			return fmt.Errorf("ID[%s]: %w", userID, err)
+		}
+	}
+
+	return web.Response(ctx, w, http.StatusNoContent, nil)
+}
+
+// ForgotPassword sends a password reset token to the user with the specified
+// email. It always accepts the request so callers can't learn which emails
+// belong to users.
+func (h Handlers) ForgotPassword(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	v, err := web.GetValues(ctx)
+	if err != nil {
+		return web.NewShutdownError("web value missing from context")
+	}
+
+	var fp struct {
+		Email string `json:"email"`
+	}
+	if err := web.Decode(r, &fp); err != nil {
+		return fmt.Errorf("unable to decode payload: %w", err)
+	}
+
+	if err := h.User.RequestPasswordReset(ctx, fp.Email, v.Now); err != nil {
+		return fmt.Errorf("requesting password reset: %w", err)
+	}
+
+	return web.Response(ctx, w, http.StatusAccepted, nil)
+}
+
+// ResetPassword sets a new password for a user with a password reset token.
+func (h Handlers) ResetPassword(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	v, err := web.GetValues(ctx)
+	if err != nil {
+		return web.NewShutdownError("web value missing from context")
+	}
+
+	var pr user.PasswordReset
+	if err := web.Decode(r, &pr); err != nil {
+		return fmt.Errorf("unable to decode payload: %w", err)
+	}
+
+	if err := h.User.ResetPassword(ctx, pr, v.Now); err != nil {
+		switch {
+		case errors.Is(err, user.ErrInvalidToken):
+			return v1Web.NewRequestError(err, http.StatusBadRequest)
+		default:
+			return fmt.Errorf("resetting password: %w", err)
+		}
+	}
+
+	return web.Response(ctx, w, http.StatusNoContent, nil)
+}
+
+// VerifyEmail marks a user's email as verified with a verification token.
+func (h Handlers) VerifyEmail(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	v, err := web.GetValues(ctx)
+	if err != nil {
+		return web.NewShutdownError("web value missing from context")
+	}
+
+	var ve struct {
 		Token string `json:"token"`
 	}
+	if err := web.Decode(r, &ve); err != nil {
+		return fmt.Errorf("unable to decode payload: %w", err)
+	}
+
+	if err := h.User.VerifyEmail(ctx, ve.Token, v.Now); err != nil {
+		switch {
+		case errors.Is(err, user.ErrInvalidToken):
+			return v1Web.NewRequestError(err, http.StatusBadRequest)
+		default:
+			return fmt.Errorf("verifying email: %w", err)
+		}
+	}
+
+	return web.Response(ctx, w, http.StatusNoContent, nil)
+}
+
+// ResendVerification mails the authenticated user a new token to verify
+// their email with.
+func (h Handlers) ResendVerification(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	v, err := web.GetValues(ctx)
+	if err != nil {
+		return web.NewShutdownError("web value missing from context")
+	}
+
+	claims, err := auth.GetClaims(ctx)
+	if err != nil {
+		return v1Web.NewRequestError(auth.ErrForbidden, http.StatusForbidden)
+	}
+
+	if err := h.User.ResendVerification(ctx, claims.Subject, v.Now); err != nil {
+		switch {
+		case errors.Is(err, user.ErrNotFound):
+			return v1Web.NewRequestError(err, http.StatusNotFound)
+		case errors.Is(err, user.ErrAlreadyVerified):
+			return v1Web.NewRequestError(err, http.StatusConflict)
+		case errors.Is(err, user.ErrTokenLimit):
+			return v1Web.NewRequestError(err, http.StatusTooManyRequests)
+		default:
+			return fmt.Errorf("resending verification: %w", err)
+		}
+	}
+
+	return web.Response(ctx, w, http.StatusAccepted, nil)
+}
+
+// checkCredentialChange only lets users change their own email and password.
+// A user can belong to many organizations, so being allowed to change the
+// users of one must not be enough to take over their account in the others.
+func checkCredentialChange(claims auth.Claims, usr user.User, upd user.UpdateUser) error {
+	if claims.Subject == usr.ID {
+		return nil
+	}
+
+	if upd.Password != nil || (upd.Email != nil && *upd.Email != usr.Email) {
+		return v1Web.NewRequestError(auth.ErrForbidden, http.StatusForbidden)
+	}
+
+	return nil
+}
+
+// checkRoleChange only lets users allowed to manage roles change the roles of
+// a user. Being allowed to change any user is not enough, or anyone who can
+// edit users could grant roles to themselves.
+func checkRoleChange(claims auth.Claims, current []string, roles []string) error {
+	if roles == nil || claims.HasPermission(auth.PermRolesManage) {
+		return nil
+	}
+
+	if len(current) != len(roles) {
+		return v1Web.NewRequestError(auth.ErrForbidden, http.StatusForbidden)
+	}
+
+	held := make(map[string]bool, len(current))
+	for _, role := range current {
+		held[role] = true
+	}
+	for _, role := range roles {
+		if !held[role] {
+			return v1Web.NewRequestError(auth.ErrForbidden, http.StatusForbidden)
+		}
+	}
 
-	tkn.Token, err = h.Auth.GenerateToken(claims)
+	return nil
+}
+
+// parseFilter reads the user filter from the query string. Dates are given
+// as days and the end date is inclusive.
+func parseFilter(r *http.Request) (user.QueryFilter, error) {
+	qs := r.URL.Query()
+
+	var filter user.QueryFilter
+
+	if s := qs.Get("deleted"); s != "" {
+		deleted, err := strconv.ParseBool(s)
+		if err != nil {
+			return user.QueryFilter{}, v1Web.NewRequestError(fmt.Errorf("invalid deleted format [%s]", s), http.StatusBadRequest)
+		}
+		filter.Deleted = deleted
+	}
+
+	if s := qs.Get("name"); s != "" {
+		filter.Name = &s
+	}
+
+	if s := qs.Get("email"); s != "" {
+		filter.Email = &s
+	}
+
+	if s := qs.Get("role"); s != "" {
+		filter.Role = &s
+	}
+
+	if s := qs.Get("start_created_date"); s != "" {
+		t, err := time.Parse(dateFormat, s)
+		if err != nil {
+			return user.QueryFilter{}, v1Web.NewRequestError(fmt.Errorf("invalid start_created_date format [%s]", s), http.StatusBadRequest)
+		}
+		filter.StartCreatedDate = &t
+	}
+
+	if s := qs.Get("end_created_date"); s != "" {
+		t, err := time.Parse(dateFormat, s)
+		if err != nil {
+			return user.QueryFilter{}, v1Web.NewRequestError(fmt.Errorf("invalid end_created_date format [%s]", s), http.StatusBadRequest)
+		}
+		t = t.AddDate(0, 0, 1)
+		filter.EndCreatedDate = &t
+	}
+
+	return filter, nil
+}
+
+// etag returns the ETag for a version of a user.
+func etag(version int) string {
+	return strconv.Quote(strconv.Itoa(version))
+}
+
+// parseIfMatch returns the version of the user named by the If-Match header.
+// It returns zero when the header is missing or matches any version.
+func parseIfMatch(r *http.Request) (int, error) {
+	value := strings.TrimSpace(r.Header.Get("If-Match"))
+	if value == "" || value == "*" {
+		return 0, nil
+	}
+
+	s, err := strconv.Unquote(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid If-Match format [%s]", value)
+	}
+
+	version, err := strconv.Atoi(s)
+	if err != nil || version < 1 {
+		return 0, fmt.Errorf("invalid If-Match format [%s]", value)
+	}
+
+	return version, nil
+}
+
+// respondToken signs the claims and responds with the access token along
+// with the refresh token that can be used to get the next one.
+func (h Handlers) respondToken(ctx context.Context, w http.ResponseWriter, claims auth.Claims, refresh string) error {
+	token, err := h.Auth.GenerateToken(claims)
 	if err != nil {
 		return fmt.Errorf("generating token: %w", err)
 	}
 
+	tkn := struct {
+		Token        string `json:"token"`
+		RefreshToken string `json:"refresh_token"`
+	}{
+		Token:        token,
+		RefreshToken: refresh,
+	}
+
 	return web.Response(ctx, w, http.StatusOK, tkn)
-}
\ No newline at end of file
+}
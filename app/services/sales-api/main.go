@@ -13,8 +13,14 @@ import (
 	"time"
 
 	"github.com/andrewyang17/service/app/services/sales-api/handlers"
+	"github.com/andrewyang17/service/business/core/product"
+	"github.com/andrewyang17/service/business/core/session"
+	"github.com/andrewyang17/service/business/core/user"
 	"github.com/andrewyang17/service/business/sys/auth"
 	"github.com/andrewyang17/service/business/sys/database"
+	"github.com/andrewyang17/service/business/sys/mail"
+	"github.com/andrewyang17/service/business/sys/password"
+	"github.com/andrewyang17/service/business/sys/validate"
 	"github.com/andrewyang17/service/foundation/keystore"
 	"github.com/andrewyang17/service/foundation/logger"
 	"github.com/ardanlabs/conf/v2"
@@ -87,6 +93,34 @@ func run(log *zap.SugaredLogger) error {
 			MaxOpenConns int    `conf:"default:0"`
 			DisableTLS   bool   `conf:"default:true"`
 		}
+		Reservation struct {
+			TTL           time.Duration `conf:"default:15m"`
+			SweepInterval time.Duration `conf:"default:1m"`
+		}
+		User struct {
+			PurgeRetention      time.Duration `conf:"default:720h"`
+			PurgeInterval       time.Duration `conf:"default:1h"`
+			RequireVerification bool          `conf:"default:false"`
+			RegisterLimit       int           `conf:"default:10"`
+			RegisterPeriod      time.Duration `conf:"default:1h"`
+		}
+		Password struct {
+			Algorithm     string `conf:"default:bcrypt"`
+			BcryptCost    int    `conf:"default:10"`
+			Argon2Time    uint32 `conf:"default:1"`
+			Argon2Memory  uint32 `conf:"default:65536"`
+			Argon2Threads uint8  `conf:"default:4"`
+			MinLength     int    `conf:"default:8"`
+			RequireUpper  bool   `conf:"default:true"`
+			RequireLower  bool   `conf:"default:true"`
+			RequireDigit  bool   `conf:"default:true"`
+			RequireSymbol bool   `conf:"default:false"`
+			RejectCommon  bool   `conf:"default:true"`
+		}
+		Mail struct {
+			Mailer string `conf:"default:log"`
+			Dir    string `conf:"default:/tmp/sales-mail"`
+		}
 		Zipkin struct {
 			ReporterURI string  `conf:"default:http://localhost:9411/api/v2/spans"`
 			ServiceName string  `conf:"default:sales-api"`
@@ -123,21 +157,6 @@ func run(log *zap.SugaredLogger) error {
 
 	expvar.NewString("build").Set(build)
 
-	// =========================================================================
-	// Initialize authentication support
-
-	log.Infow("startup", "status", "initializing authentication support")
-
-	ks, err := keystore.NewFS(os.DirFS(cfg.Auth.KeyFolder))
-	if err != nil {
-		return fmt.Errorf("reading keys: %w", err)
-	}
-
-	auth, err := auth.New(cfg.Auth.ActiveKID, ks)
-	if err != nil {
-		return fmt.Errorf("constructing auth: %w", err)
-	}
-
 	// =========================================================================
 	// Database Support
 
@@ -160,6 +179,76 @@ func run(log *zap.SugaredLogger) error {
 		db.Close()
 	}()
 
+	// =========================================================================
+	// Initialize authentication support
+
+	log.Infow("startup", "status", "initializing authentication support")
+
+	ks, err := keystore.NewFS(os.DirFS(cfg.Auth.KeyFolder))
+	if err != nil {
+		return fmt.Errorf("reading keys: %w", err)
+	}
+
+	// Tokens are checked against the sessions in the database, so logged out
+	// and compromised sessions can't be used.
+	auth, err := auth.New(cfg.Auth.ActiveKID, ks, session.NewCore(log, db))
+	if err != nil {
+		return fmt.Errorf("constructing auth: %w", err)
+	}
+
+	// =========================================================================
+	// Password Hashing Support
+
+	log.Infow("startup", "status", "initializing password hashing support", "algorithm", cfg.Password.Algorithm)
+
+	var hasher password.Hasher
+	switch cfg.Password.Algorithm {
+	case "bcrypt":
+		hasher, err = password.NewBcrypt(cfg.Password.BcryptCost)
+		if err != nil {
+			return fmt.Errorf("constructing bcrypt hasher: %w", err)
+		}
+	case "argon2id":
+		params := password.DefaultArgon2Params
+		params.Time = cfg.Password.Argon2Time
+		params.Memory = cfg.Password.Argon2Memory
+		params.Threads = cfg.Password.Argon2Threads
+
+		hasher, err = password.NewArgon2id(params)
+		if err != nil {
+			return fmt.Errorf("constructing argon2id hasher: %w", err)
+		}
+	default:
+		return fmt.Errorf("unknown password algorithm %q", cfg.Password.Algorithm)
+	}
+
+	validate.SetPasswordPolicy(validate.PasswordPolicy{
+		MinLength:     cfg.Password.MinLength,
+		RequireUpper:  cfg.Password.RequireUpper,
+		RequireLower:  cfg.Password.RequireLower,
+		RequireDigit:  cfg.Password.RequireDigit,
+		RequireSymbol: cfg.Password.RequireSymbol,
+		RejectCommon:  cfg.Password.RejectCommon,
+	})
+
+	// =========================================================================
+	// Mail Support
+
+	log.Infow("startup", "status", "initializing mail support", "mailer", cfg.Mail.Mailer)
+
+	var mailer mail.Mailer
+	switch cfg.Mail.Mailer {
+	case "log":
+		mailer = mail.NewLogMailer(log)
+	case "file":
+		mailer, err = mail.NewFileMailer(cfg.Mail.Dir)
+		if err != nil {
+			return fmt.Errorf("constructing file mailer: %w", err)
+		}
+	default:
+		return fmt.Errorf("unknown mailer %q", cfg.Mail.Mailer)
+	}
+
 	// =========================================================================
 	// Start Tracing Support
 	log.Infow("startup", "status", "initializing OT/Zipkin tracing support")
@@ -174,6 +263,28 @@ func run(log *zap.SugaredLogger) error {
 	}
 	defer traceProvider.Shutdown(context.Background())
 
+	// =========================================================================
+	// Start Reservation Sweeper
+
+	log.Infow("startup", "status", "starting reservation sweeper", "interval", cfg.Reservation.SweepInterval)
+
+	stopSweeper := startReservationSweeper(log, product.NewCore(log, db), cfg.Reservation.SweepInterval)
+	defer func() {
+		log.Infow("shutdown", "status", "stopping reservation sweeper")
+		stopSweeper()
+	}()
+
+	// =========================================================================
+	// Start User Purger
+
+	log.Infow("startup", "status", "starting user purger", "interval", cfg.User.PurgeInterval, "retention", cfg.User.PurgeRetention)
+
+	stopPurger := startUserPurger(log, user.NewCore(log, db, mailer, hasher), cfg.User.PurgeInterval, cfg.User.PurgeRetention)
+	defer func() {
+		log.Infow("shutdown", "status", "stopping user purger")
+		stopPurger()
+	}()
+
 	// =========================================================================
 	// Start Debug Service
 
@@ -200,10 +311,16 @@ func run(log *zap.SugaredLogger) error {
 
 	// Construct the mux for the API calls.
 	apiMux := handlers.APIMux(handlers.APIMuxConfig{
-		Shutdown: shutdown,
-		Log:      log,
-		Auth:     auth,
-		DB:       db,
+		Shutdown:            shutdown,
+		Log:                 log,
+		Auth:                auth,
+		DB:                  db,
+		Mailer:              mailer,
+		Hasher:              hasher,
+		ReservationTTL:      cfg.Reservation.TTL,
+		RequireVerification: cfg.User.RequireVerification,
+		RegisterLimit:       cfg.User.RegisterLimit,
+		RegisterPeriod:      cfg.User.RegisterPeriod,
 	})
 
 	api := http.Server{
@@ -276,3 +393,77 @@ func startTracing(serviceName string, reporterURI string, probability float64) (
 	return traceProvider, nil
 
 }
+
+// startReservationSweeper releases the stock held by expired reservations on
+// the specified interval. The returned function stops the sweeper and waits
+// for any sweep in progress to finish.
+func startReservationSweeper(log *zap.SugaredLogger, core product.Core, interval time.Duration) func() {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+
+			case <-ticker.C:
+				expired, err := core.ExpireReservations(ctx, time.Now())
+				if err != nil {
+					log.Errorw("reservation sweeper", "ERROR", err)
+					continue
+				}
+				if expired > 0 {
+					log.Infow("reservation sweeper", "expired", expired)
+				}
+			}
+		}
+	}()
+
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
+// startUserPurger removes users that were deleted longer ago than the
+// retention period on the specified interval. The returned function stops
+// the purger and waits for any purge in progress to finish.
+func startUserPurger(log *zap.SugaredLogger, core user.Core, interval time.Duration, retention time.Duration) func() {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+
+			case <-ticker.C:
+				purged, err := core.Purge(ctx, time.Now().UTC().Add(-retention))
+				if err != nil {
+					log.Errorw("user purger", "ERROR", err)
+					continue
+				}
+				if purged > 0 {
+					log.Infow("user purger", "purged", purged)
+				}
+			}
+		}
+	}()
+
+	return func() {
+		cancel()
+		<-done
+	}
+}
@@ -0,0 +1,419 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/andrewyang17/service/app/services/sales-api/handlers"
+	"github.com/andrewyang17/service/business/core/product"
+	"github.com/andrewyang17/service/business/data/dbtest"
+	"github.com/andrewyang17/service/business/sys/mail"
+	"github.com/andrewyang17/service/business/sys/money"
+	"github.com/google/go-cmp/cmp"
+)
+
+// ProductTests holds methods for each product subtest. This type allows
+// passing dependencies for tests while still providing a convenient syntax
+// when subtests are registered.
+type ProductTests struct {
+	app        http.Handler
+	userToken  string
+	adminToken string
+}
+
+// TestProducts is the entry point for testing product management functions.
+func TestProducts(t *testing.T) {
+	t.Parallel()
+
+	test := dbtest.NewIntegration(t, c, "inttestproducts")
+	t.Cleanup(test.Teardown)
+
+	shutdown := make(chan os.Signal, 1)
+	tests := ProductTests{
+		app: handlers.APIMux(handlers.APIMuxConfig{
+			Shutdown: shutdown,
+			Log:      test.Log,
+			Auth:     test.Auth,
+			DB:       test.DB,
+			Mailer:   mail.NewLogMailer(test.Log),
+			Hasher:   test.Hasher,
+		}),
+		userToken:  test.Token("user@example.com", "gophers"),
+		adminToken: test.Token("admin@example.com", "gophers"),
+	}
+
+	t.Run("postProduct400", tests.postProduct400)
+	t.Run("postProduct401", tests.postProduct401)
+	t.Run("getProduct404", tests.getProduct404)
+	t.Run("getProduct400", tests.getProduct400)
+	t.Run("deleteProductNotFound", tests.deleteProductNotFound)
+	t.Run("putProduct404", tests.putProduct404)
+	t.Run("crudProducts", tests.crudProduct)
+}
+
+// postProduct400 validates a product can't be created with the endpoint
+// unless a valid product document is submitted.
+func (pt *ProductTests) postProduct400(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPost, "/v1/products", strings.NewReader(`{}`))
+	w := httptest.NewRecorder()
+
+	r.Header.Set("Authorization", "Bearer "+pt.adminToken)
+	pt.app.ServeHTTP(w, r)
+
+	t.Log("Given the need to validate a new product can't be created with an invalid document.")
+	{
+		testID := 0
+		t.Logf("\tTest %d:\tWhen using an incomplete product value.", testID)
+		{
+			if w.Code != http.StatusBadRequest {
+				t.Fatalf("\t%s\tTest %d:\tShould receive a status code of 400 for the response : %v", dbtest.Failed, testID, w.Code)
+			}
+			t.Logf("\t%s\tTest %d:\tShould receive a status code of 400 for the response.", dbtest.Success, testID)
+		}
+	}
+}
+
+// postProduct401 validates a product can't be created with the endpoint
+// unless the user is authenticated.
+func (pt *ProductTests) postProduct401(t *testing.T) {
+	np := product.NewProduct{
+		Name:     "Comic Books",
+		Cost:     money.New(25, money.USD),
+		Quantity: 60,
+	}
+
+	body, err := json.Marshal(&np)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := httptest.NewRequest(http.MethodPost, "/v1/products", bytes.NewBuffer(body))
+	w := httptest.NewRecorder()
+
+	// Not setting an authorization header.
+	pt.app.ServeHTTP(w, r)
+
+	t.Log("Given the need to validate a new product can't be created without an authenticated user.")
+	{
+		testID := 0
+		t.Logf("\tTest %d:\tWhen using a valid product value with no token.", testID)
+		{
+			if w.Code != http.StatusUnauthorized {
+				t.Fatalf("\t%s\tTest %d:\tShould receive a status code of 401 for the response : %v", dbtest.Failed, testID, w.Code)
+			}
+			t.Logf("\t%s\tTest %d:\tShould receive a status code of 401 for the response.", dbtest.Success, testID)
+		}
+	}
+}
+
+// getProduct400 validates a product request for a malformed id.
+func (pt *ProductTests) getProduct400(t *testing.T) {
+	id := "12345"
+
+	r := httptest.NewRequest(http.MethodGet, "/v1/products/"+id, nil)
+	w := httptest.NewRecorder()
+
+	r.Header.Set("Authorization", "Bearer "+pt.userToken)
+	pt.app.ServeHTTP(w, r)
+
+	t.Log("Given the need to validate getting a product with a malformed id.")
+	{
+		testID := 0
+		t.Logf("\tTest %d:\tWhen using the new product %s.", testID, id)
+		{
+			if w.Code != http.StatusBadRequest {
+				t.Fatalf("\t%s\tTest %d:\tShould receive a status code of 400 for the response : %v", dbtest.Failed, testID, w.Code)
+			}
+			t.Logf("\t%s\tTest %d:\tShould receive a status code of 400 for the response.", dbtest.Success, testID)
+
+			got := w.Body.String()
+			exp := `{"error":"ID is not in its proper form"}`
+			if got != exp {
+				t.Logf("\t\tTest %d:\tGot : %v", testID, got)
+				t.Logf("\t\tTest %d:\tExp: %v", testID, exp)
+				t.Fatalf("\t%s\tTest %d:\tShould get the expected result.", dbtest.Failed, testID)
+			}
+			t.Logf("\t%s\tTest %d:\tShould get the expected result.", dbtest.Success, testID)
+		}
+	}
+}
+
+// getProduct404 validates a product request for a product that does not
+// exist with the endpoint.
+func (pt *ProductTests) getProduct404(t *testing.T) {
+	id := "a224a8d6-3f9e-4b11-9900-e81a25d80702"
+
+	r := httptest.NewRequest(http.MethodGet, "/v1/products/"+id, nil)
+	w := httptest.NewRecorder()
+
+	r.Header.Set("Authorization", "Bearer "+pt.userToken)
+	pt.app.ServeHTTP(w, r)
+
+	t.Log("Given the need to validate getting a product with an unknown id.")
+	{
+		testID := 0
+		t.Logf("\tTest %d:\tWhen using the new product %s.", testID, id)
+		{
+			if w.Code != http.StatusNotFound {
+				t.Fatalf("\t%s\tTest %d:\tShould receive a status code of 404 for the response : %v", dbtest.Failed, testID, w.Code)
+			}
+			t.Logf("\t%s\tTest %d:\tShould receive a status code of 404 for the response.", dbtest.Success, testID)
+
+			got := w.Body.String()
+			exp := "not found"
+			if !strings.Contains(got, exp) {
+				t.Logf("\t\tTest %d:\tGot : %v", testID, got)
+				t.Logf("\t\tTest %d:\tExp: %v", testID, exp)
+				t.Fatalf("\t%s\tTest %d:\tShould get the expected result.", dbtest.Failed, testID)
+			}
+			t.Logf("\t%s\tTest %d:\tShould get the expected result.", dbtest.Success, testID)
+		}
+	}
+}
+
+// deleteProductNotFound validates deleting a product that does not exist is
+// not a failure.
+func (pt *ProductTests) deleteProductNotFound(t *testing.T) {
+	id := "112262f1-1a77-4374-9f22-39e575aa6348"
+
+	r := httptest.NewRequest(http.MethodDelete, "/v1/products/"+id, nil)
+	w := httptest.NewRecorder()
+
+	r.Header.Set("Authorization", "Bearer "+pt.adminToken)
+	pt.app.ServeHTTP(w, r)
+
+	t.Log("Given the need to validate deleting a product that does not exist.")
+	{
+		testID := 0
+		t.Logf("\tTest %d:\tWhen using the new product %s.", testID, id)
+		{
+			if w.Code != http.StatusNoContent {
+				t.Fatalf("\t%s\tTest %d:\tShould receive a status code of 204 for the response : %v", dbtest.Failed, testID, w.Code)
+			}
+			t.Logf("\t%s\tTest %d:\tShould receive a status code of 204 for the response.", dbtest.Success, testID)
+		}
+	}
+}
+
+// putProduct404 validates updating a product that does not exist.
+func (pt *ProductTests) putProduct404(t *testing.T) {
+	id := "9b468f90-1cf1-4377-b3fa-68b450d632a0"
+
+	r := httptest.NewRequest(http.MethodPut, "/v1/products/"+id, strings.NewReader(`{"name": "Nonexistent"}`))
+	w := httptest.NewRecorder()
+
+	r.Header.Set("Authorization", "Bearer "+pt.adminToken)
+	pt.app.ServeHTTP(w, r)
+
+	t.Log("Given the need to validate updating a product that does not exist.")
+	{
+		testID := 0
+		t.Logf("\tTest %d:\tWhen using the new product %s.", testID, id)
+		{
+			if w.Code != http.StatusNotFound {
+				t.Fatalf("\t%s\tTest %d:\tShould receive a status code of 404 for the response : %v", dbtest.Failed, testID, w.Code)
+			}
+			t.Logf("\t%s\tTest %d:\tShould receive a status code of 404 for the response.", dbtest.Success, testID)
+		}
+	}
+}
+
+// crudProduct performs a complete test of CRUD against the api.
+func (pt *ProductTests) crudProduct(t *testing.T) {
+	p := pt.postProduct201(t)
+	defer pt.deleteProduct204(t, p.ID)
+
+	pt.getProduct200(t, p.ID)
+	pt.putProduct204(t, p.ID)
+	pt.putProduct403(t, p.ID)
+}
+
+// postProduct201 validates a product can be created with the endpoint.
+func (pt *ProductTests) postProduct201(t *testing.T) product.Product {
+	np := product.NewProduct{
+		Name:     "Comic Books",
+		Cost:     money.New(25, money.USD),
+		Quantity: 60,
+	}
+
+	body, err := json.Marshal(&np)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := httptest.NewRequest(http.MethodPost, "/v1/products", bytes.NewBuffer(body))
+	w := httptest.NewRecorder()
+
+	r.Header.Set("Authorization", "Bearer "+pt.adminToken)
+	pt.app.ServeHTTP(w, r)
+
+	// This needs to be returned for other tests.
+	var got product.Product
+
+	t.Log("Given the need to create a new product with the products endpoint.")
+	{
+		testID := 0
+		t.Logf("\tTest %d:\tWhen using the declared product value.", testID)
+		{
+			if w.Code != http.StatusCreated {
+				t.Fatalf("\t%s\tTest %d:\tShould receive a status code of 201 for the response : %v", dbtest.Failed, testID, w.Code)
+			}
+			t.Logf("\t%s\tTest %d:\tShould receive a status code of 201 for the response.", dbtest.Success, testID)
+
+			if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to unmarshal the response : %v", dbtest.Failed, testID, err)
+			}
+
+			// Define what we wanted to receive. We will just trust the generated
+			// fields like ID and Dates so we copy p.
+			exp := got
+			exp.Name = "Comic Books"
+			exp.Cost = money.New(25, money.USD)
+			exp.Quantity = 60
+
+			if diff := cmp.Diff(got, exp); diff != "" {
+				t.Fatalf("\t%s\tTest %d:\tShould get the expected result. Diff:\n%s", dbtest.Failed, testID, diff)
+			}
+			t.Logf("\t%s\tTest %d:\tShould get the expected result.", dbtest.Success, testID)
+		}
+	}
+
+	return got
+}
+
+// deleteProduct204 validates deleting a product that does exist.
+func (pt *ProductTests) deleteProduct204(t *testing.T, id string) {
+	r := httptest.NewRequest(http.MethodDelete, "/v1/products/"+id, nil)
+	w := httptest.NewRecorder()
+
+	r.Header.Set("Authorization", "Bearer "+pt.adminToken)
+	pt.app.ServeHTTP(w, r)
+
+	t.Log("Given the need to validate deleting a product that does exist.")
+	{
+		testID := 0
+		t.Logf("\tTest %d:\tWhen using the new product %s.", testID, id)
+		{
+			if w.Code != http.StatusNoContent {
+				t.Fatalf("\t%s\tTest %d:\tShould receive a status code of 204 for the response : %v", dbtest.Failed, testID, w.Code)
+			}
+			t.Logf("\t%s\tTest %d:\tShould receive a status code of 204 for the response.", dbtest.Success, testID)
+		}
+	}
+}
+
+// getProduct200 validates a product request for an existing id.
+func (pt *ProductTests) getProduct200(t *testing.T, id string) {
+	r := httptest.NewRequest(http.MethodGet, "/v1/products/"+id, nil)
+	w := httptest.NewRecorder()
+
+	r.Header.Set("Authorization", "Bearer "+pt.adminToken)
+	pt.app.ServeHTTP(w, r)
+
+	t.Log("Given the need to validate getting a product that exists.")
+	{
+		testID := 0
+		t.Logf("\tTest %d:\tWhen using the new product %s.", testID, id)
+		{
+			if w.Code != http.StatusOK {
+				t.Fatalf("\t%s\tTest %d:\tShould receive a status code of 200 for the response : %v", dbtest.Failed, testID, w.Code)
+			}
+			t.Logf("\t%s\tTest %d:\tShould receive a status code of 200 for the response.", dbtest.Success, testID)
+
+			var got product.Product
+			if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to unmarshal the response : %v", dbtest.Failed, testID, err)
+			}
+
+			// Define what we wanted to receive. We will just trust the generated
+			// fields like Dates so we copy p.
+			exp := got
+			exp.ID = id
+			exp.Name = "Comic Books"
+			exp.Cost = money.New(25, money.USD)
+			exp.Quantity = 60
+
+			if diff := cmp.Diff(got, exp); diff != "" {
+				t.Fatalf("\t%s\tTest %d:\tShould get the expected result. Diff:\n%s", dbtest.Failed, testID, diff)
+			}
+			t.Logf("\t%s\tTest %d:\tShould get the expected result.", dbtest.Success, testID)
+		}
+	}
+}
+
+// putProduct204 validates updating a product that does exist.
+func (pt *ProductTests) putProduct204(t *testing.T, id string) {
+	body := `{"name": "Graphic Novels", "cost": {"amount": 100, "currency": "usd"}}`
+
+	r := httptest.NewRequest(http.MethodPut, "/v1/products/"+id, strings.NewReader(body))
+	w := httptest.NewRecorder()
+
+	r.Header.Set("Authorization", "Bearer "+pt.adminToken)
+	pt.app.ServeHTTP(w, r)
+
+	t.Log("Given the need to update a product with the products endpoint.")
+	{
+		testID := 0
+		t.Logf("\tTest %d:\tWhen using the modified product value.", testID)
+		{
+			if w.Code != http.StatusNoContent {
+				t.Fatalf("\t%s\tTest %d:\tShould receive a status code of 204 for the response : %v", dbtest.Failed, testID, w.Code)
+			}
+			t.Logf("\t%s\tTest %d:\tShould receive a status code of 204 for the response.", dbtest.Success, testID)
+
+			r = httptest.NewRequest(http.MethodGet, "/v1/products/"+id, nil)
+			w = httptest.NewRecorder()
+
+			r.Header.Set("Authorization", "Bearer "+pt.adminToken)
+			pt.app.ServeHTTP(w, r)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("\t%s\tTest %d:\tShould receive a status code of 200 for the retrieve : %v", dbtest.Failed, testID, w.Code)
+			}
+			t.Logf("\t%s\tTest %d:\tShould receive a status code of 200 for the retrieve.", dbtest.Success, testID)
+
+			var ru product.Product
+			if err := json.NewDecoder(w.Body).Decode(&ru); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to unmarshal the response : %v", dbtest.Failed, testID, err)
+			}
+
+			if ru.Name != "Graphic Novels" {
+				t.Fatalf("\t%s\tTest %d:\tShould see an updated Name : got %q want %q", dbtest.Failed, testID, ru.Name, "Graphic Novels")
+			}
+			t.Logf("\t%s\tTest %d:\tShould see an updated Name.", dbtest.Success, testID)
+
+			if ru.Quantity != 60 {
+				t.Fatalf("\t%s\tTest %d:\tShould not affect other fields like Quantity : got %d want %d", dbtest.Failed, testID, ru.Quantity, 60)
+			}
+			t.Logf("\t%s\tTest %d:\tShould not affect other fields like Quantity.", dbtest.Success, testID)
+		}
+	}
+}
+
+// putProduct403 validates that a user can't modify a product they don't own.
+func (pt *ProductTests) putProduct403(t *testing.T, id string) {
+	body := `{"name": "Graphic Novels"}`
+
+	r := httptest.NewRequest(http.MethodPut, "/v1/products/"+id, strings.NewReader(body))
+	w := httptest.NewRecorder()
+
+	r.Header.Set("Authorization", "Bearer "+pt.userToken)
+	pt.app.ServeHTTP(w, r)
+
+	t.Log("Given the need to validate users can't modify products they don't own.")
+	{
+		testID := 0
+		t.Logf("\tTest %d:\tWhen a non-owner, non-admin user makes a request.", testID)
+		{
+			if w.Code != http.StatusForbidden {
+				t.Fatalf("\t%s\tTest %d:\tShould receive a status code of 403 for the response : %v", dbtest.Failed, testID, w.Code)
+			}
+			t.Logf("\t%s\tTest %d:\tShould receive a status code of 403 for the response.", dbtest.Success, testID)
+		}
+	}
+}
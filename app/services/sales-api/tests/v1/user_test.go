@@ -2,17 +2,23 @@ package tests
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/andrewyang17/service/app/services/sales-api/handlers"
+	"github.com/andrewyang17/service/business/core/organization"
+	"github.com/andrewyang17/service/business/core/role"
 	"github.com/andrewyang17/service/business/core/user"
 	"github.com/andrewyang17/service/business/data/dbtest"
 	"github.com/andrewyang17/service/business/sys/auth"
+	"github.com/andrewyang17/service/business/sys/mail"
+	"github.com/andrewyang17/service/business/sys/tenant"
 	"github.com/andrewyang17/service/business/sys/validate"
 	v1Web "github.com/andrewyang17/service/business/web/v1"
 	"github.com/google/go-cmp/cmp"
@@ -23,9 +29,10 @@ import (
 // dependencies for tests while still providing a convenient syntax when
 // subtests are registered.
 type UserTests struct {
-	app        http.Handler
-	userToken  string
-	adminToken string
+	app            http.Handler
+	userToken      string
+	adminToken     string
+	userAdminToken string
 }
 
 // TestUsers is the entry point for testing user management functions.
@@ -42,14 +49,18 @@ func TestUsers(t *testing.T) {
 			Log:      test.Log,
 			Auth:     test.Auth,
 			DB:       test.DB,
+			Mailer:   mail.NewLogMailer(test.Log),
+			Hasher:   test.Hasher,
 		}),
-		userToken:  test.Token("user@example.com", "gophers"),
-		adminToken: test.Token("admin@example.com", "gophers"),
+		userToken:      test.Token("user@example.com", "gophers"),
+		adminToken:     test.Token("admin@example.com", "gophers"),
+		userAdminToken: userAdminToken(t, test),
 	}
 
 	t.Run("getToken404", tests.getToken404)
 	t.Run("getToken200", tests.getToken200)
 	t.Run("postUser400", tests.postUser400)
+	t.Run("register", tests.register)
 	t.Run("postUser401", tests.postUser401)
 	t.Run("postUser403", tests.postUser403)
 	t.Run("getUser400", tests.getUser400)
@@ -57,6 +68,37 @@ func TestUsers(t *testing.T) {
 	t.Run("getUser404", tests.getUser404)
 	t.Run("deleteUserNotFound", tests.deleteUserNotFound)
 	t.Run("crudUsers", tests.crudUser)
+	t.Run("patchUserRoles403", tests.patchUserRoles403)
+	t.Run("putUserRoles403", tests.putUserRoles403)
+}
+
+// userAdminToken creates a user whose role may change any user but not manage
+// roles and generates a token for them.
+func userAdminToken(t *testing.T, test *dbtest.Test) string {
+	ctx := tenant.Set(context.Background(), organization.DefaultID)
+	now := time.Now()
+
+	nr := role.NewRole{
+		Name:        "USER_ADMIN",
+		Description: "Changes users without managing roles",
+		Permissions: []string{auth.PermUsersRead, auth.PermUsersWrite},
+	}
+	if _, err := role.NewCore(test.Log, test.DB).Create(ctx, nr, now); err != nil {
+		t.Fatalf("creating role: %s", err)
+	}
+
+	nu := user.NewUser{
+		Name:            "Uma Admin",
+		Email:           "useradmin@example.com",
+		Roles:           []string{nr.Name},
+		Password:        "gophers",
+		PasswordConfirm: "gophers",
+	}
+	if _, err := user.NewCore(test.Log, test.DB, mail.NewLogMailer(test.Log), test.Hasher).Create(ctx, nu, now); err != nil {
+		t.Fatalf("creating user: %s", err)
+	}
+
+	return test.Token(nu.Email, nu.Password)
 }
 
 // getToken401 ensures an unknown user can't generate a token.
@@ -112,6 +154,67 @@ func (ut *UserTests) getToken200(t *testing.T) {
 
 // postUser400 validates a user can't be created with the endpoint
 // unless a valid user document is submitted.
+// register validates a customer can sign up without a token, only ever gets
+// the USER role and can't reuse an email.
+func (ut *UserTests) register(t *testing.T) {
+	doc := struct {
+		user.NewRegistration
+		Roles []string `json:"roles"`
+	}{
+		NewRegistration: user.NewRegistration{
+			Name:            "Jacob Walker",
+			Email:           "jacob@example.com",
+			Password:        "Gophers2022!",
+			PasswordConfirm: "Gophers2022!",
+		},
+		Roles: []string{auth.RoleAdmin},
+	}
+
+	body, err := json.Marshal(&doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Log("Given the need to let customers sign up.")
+	{
+		testID := 0
+		t.Logf("\tTest %d:\tWhen asking for the ADMIN role.", testID)
+		{
+			r := httptest.NewRequest(http.MethodPost, "/v1/users/register", bytes.NewBuffer(body))
+			w := httptest.NewRecorder()
+			ut.app.ServeHTTP(w, r)
+
+			if w.Code != http.StatusCreated {
+				t.Fatalf("\t%s\tTest %d:\tShould receive a status code of 201 for the response : %v", dbtest.Failed, testID, w.Code)
+			}
+			t.Logf("\t%s\tTest %d:\tShould receive a status code of 201 for the response.", dbtest.Success, testID)
+
+			var got user.User
+			if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to unmarshal the response : %v", dbtest.Failed, testID, err)
+			}
+
+			if diff := cmp.Diff(got.Roles, []string{auth.RoleUser}); diff != "" {
+				t.Fatalf("\t%s\tTest %d:\tShould only get the USER role. Diff:\n%s", dbtest.Failed, testID, diff)
+			}
+			t.Logf("\t%s\tTest %d:\tShould only get the USER role.", dbtest.Success, testID)
+		}
+
+		testID++
+		t.Logf("\tTest %d:\tWhen the email is already in use.", testID)
+		{
+			r := httptest.NewRequest(http.MethodPost, "/v1/users/register", bytes.NewBuffer(body))
+			w := httptest.NewRecorder()
+			ut.app.ServeHTTP(w, r)
+
+			if w.Code != http.StatusConflict {
+				t.Fatalf("\t%s\tTest %d:\tShould receive a status code of 409 for the response : %v", dbtest.Failed, testID, w.Code)
+			}
+			t.Logf("\t%s\tTest %d:\tShould receive a status code of 409 for the response.", dbtest.Success, testID)
+		}
+	}
+}
+
 func (ut *UserTests) postUser400(t *testing.T) {
 	body, err := json.Marshal(&user.NewUser{})
 	if err != nil {
@@ -360,6 +463,8 @@ func (ut *UserTests) crudUser(t *testing.T) {
 
 	ut.getUser200(t, nu.ID)
 	ut.putUser204(t, nu.ID)
+	ut.putUser412(t, nu.ID)
+	ut.patchUser(t, nu.ID)
 	ut.putUser403(t, nu.ID)
 }
 
@@ -369,8 +474,8 @@ func (ut *UserTests) postUser201(t *testing.T) user.User {
 		Name:            "Bill Kennedy",
 		Email:           "bill@ardanlabs.com",
 		Roles:           []string{auth.RoleAdmin},
-		Password:        "gophers",
-		PasswordConfirm: "gophers",
+		Password:        "Gophers2022",
+		PasswordConfirm: "Gophers2022",
 	}
 
 	body, err := json.Marshal(&nu)
@@ -479,6 +584,50 @@ func (ut *UserTests) getUser200(t *testing.T, id string) {
 }
 
 // putUser204 validates updating a user that does exist.
+// putUser412 validates an update made with a stale ETag is rejected.
+func (ut *UserTests) putUser412(t *testing.T, id string) {
+	r := httptest.NewRequest(http.MethodGet, "/v1/users/"+id, nil)
+	w := httptest.NewRecorder()
+
+	r.Header.Set("Authorization", "Bearer "+ut.adminToken)
+	ut.app.ServeHTTP(w, r)
+
+	etag := w.Header().Get("ETag")
+
+	put := func() int {
+		r := httptest.NewRequest(http.MethodPut, "/v1/users/"+id, strings.NewReader(`{"name": "Jacob Walker"}`))
+		w := httptest.NewRecorder()
+
+		r.Header.Set("Authorization", "Bearer "+ut.adminToken)
+		r.Header.Set("If-Match", etag)
+		ut.app.ServeHTTP(w, r)
+
+		return w.Code
+	}
+
+	t.Log("Given the need to keep concurrent updates from overwriting each other.")
+	{
+		testID := 0
+		t.Logf("\tTest %d:\tWhen updating with the ETag %s.", testID, etag)
+		{
+			if etag == "" {
+				t.Fatalf("\t%s\tTest %d:\tShould receive an ETag for the user.", dbtest.Failed, testID)
+			}
+			t.Logf("\t%s\tTest %d:\tShould receive an ETag for the user.", dbtest.Success, testID)
+
+			if code := put(); code != http.StatusNoContent {
+				t.Fatalf("\t%s\tTest %d:\tShould receive a status code of 204 for the current ETag : %v", dbtest.Failed, testID, code)
+			}
+			t.Logf("\t%s\tTest %d:\tShould receive a status code of 204 for the current ETag.", dbtest.Success, testID)
+
+			if code := put(); code != http.StatusPreconditionFailed {
+				t.Fatalf("\t%s\tTest %d:\tShould receive a status code of 412 for a stale ETag : %v", dbtest.Failed, testID, code)
+			}
+			t.Logf("\t%s\tTest %d:\tShould receive a status code of 412 for a stale ETag.", dbtest.Success, testID)
+		}
+	}
+}
+
 func (ut *UserTests) putUser204(t *testing.T, id string) {
 	body := `{"name": "Jacob Walker"}`
 
@@ -527,6 +676,190 @@ func (ut *UserTests) putUser204(t *testing.T, id string) {
 	}
 }
 
+// patchUser validates a user can be changed with merge patch and JSON Patch
+// documents.
+func (ut *UserTests) patchUser(t *testing.T, id string) {
+	patch := func(contentType string, body string) int {
+		r := httptest.NewRequest(http.MethodPatch, "/v1/users/"+id, strings.NewReader(body))
+		w := httptest.NewRecorder()
+
+		r.Header.Set("Authorization", "Bearer "+ut.adminToken)
+		r.Header.Set("Content-Type", contentType)
+		ut.app.ServeHTTP(w, r)
+
+		return w.Code
+	}
+
+	get := func() user.User {
+		r := httptest.NewRequest(http.MethodGet, "/v1/users/"+id, nil)
+		w := httptest.NewRecorder()
+
+		r.Header.Set("Authorization", "Bearer "+ut.adminToken)
+		ut.app.ServeHTTP(w, r)
+
+		var usr user.User
+		if err := json.NewDecoder(w.Body).Decode(&usr); err != nil {
+			t.Fatalf("\t%s\tShould be able to unmarshal the response : %v", dbtest.Failed, err)
+		}
+		return usr
+	}
+
+	t.Log("Given the need to partially update a user with a patch.")
+	{
+		testID := 0
+		t.Logf("\tTest %d:\tWhen using a merge patch.", testID)
+		{
+			if code := patch("application/merge-patch+json", `{"name": "Jack Walker", "roles": null}`); code != http.StatusNoContent {
+				t.Fatalf("\t%s\tTest %d:\tShould receive a status code of 204 for the response : %v", dbtest.Failed, testID, code)
+			}
+			t.Logf("\t%s\tTest %d:\tShould receive a status code of 204 for the response.", dbtest.Success, testID)
+
+			usr := get()
+			if usr.Name != "Jack Walker" || len(usr.Roles) != 0 {
+				t.Fatalf("\t%s\tTest %d:\tShould see the new name and no roles : got %q %v", dbtest.Failed, testID, usr.Name, usr.Roles)
+			}
+			t.Logf("\t%s\tTest %d:\tShould see the new name and no roles.", dbtest.Success, testID)
+		}
+
+		testID++
+		t.Logf("\tTest %d:\tWhen using a JSON Patch.", testID)
+		{
+			body := `[{"op": "test", "path": "/name", "value": "Jack Walker"}, {"op": "add", "path": "/roles/-", "value": "USER"}]`
+			if code := patch("application/json-patch+json", body); code != http.StatusNoContent {
+				t.Fatalf("\t%s\tTest %d:\tShould receive a status code of 204 for the response : %v", dbtest.Failed, testID, code)
+			}
+			t.Logf("\t%s\tTest %d:\tShould receive a status code of 204 for the response.", dbtest.Success, testID)
+
+			usr := get()
+			if len(usr.Roles) != 1 || usr.Roles[0] != "USER" {
+				t.Fatalf("\t%s\tTest %d:\tShould see the added role : got %v", dbtest.Failed, testID, usr.Roles)
+			}
+			t.Logf("\t%s\tTest %d:\tShould see the added role.", dbtest.Success, testID)
+		}
+
+		testID++
+		t.Logf("\tTest %d:\tWhen using patches that can not be applied.", testID)
+		{
+			if code := patch("application/json-patch+json", `[{"op": "test", "path": "/name", "value": "Bill"}]`); code != http.StatusConflict {
+				t.Fatalf("\t%s\tTest %d:\tShould receive a status code of 409 for a failed test : %v", dbtest.Failed, testID, code)
+			}
+			t.Logf("\t%s\tTest %d:\tShould receive a status code of 409 for a failed test.", dbtest.Success, testID)
+
+			if code := patch("application/merge-patch+json", `{"roles": ["NOPE"]}`); code != http.StatusBadRequest {
+				t.Fatalf("\t%s\tTest %d:\tShould receive a status code of 400 for an invalid user : %v", dbtest.Failed, testID, code)
+			}
+			t.Logf("\t%s\tTest %d:\tShould receive a status code of 400 for an invalid user.", dbtest.Success, testID)
+
+			if code := patch("application/merge-patch+json", `{"email": "jack@example.com"}`); code != http.StatusForbidden {
+				t.Fatalf("\t%s\tTest %d:\tShould receive a status code of 403 for the email of another user : %v", dbtest.Failed, testID, code)
+			}
+			t.Logf("\t%s\tTest %d:\tShould receive a status code of 403 for the email of another user.", dbtest.Success, testID)
+
+			if code := patch("application/json", `{"name": "Bill"}`); code != http.StatusUnsupportedMediaType {
+				t.Fatalf("\t%s\tTest %d:\tShould receive a status code of 415 for a plain JSON body : %v", dbtest.Failed, testID, code)
+			}
+			t.Logf("\t%s\tTest %d:\tShould receive a status code of 415 for a plain JSON body.", dbtest.Success, testID)
+		}
+	}
+}
+
+// patchUserRoles403 validates that only users allowed to manage roles can
+// change roles with a patch, even if they may change any user.
+func (ut *UserTests) patchUserRoles403(t *testing.T) {
+	const userID = "45b5fbd3-755f-4379-8f07-a58d4a30fa2f"
+
+	body := `{"roles": ["ADMIN", "USER"]}`
+
+	patch := func(token string) int {
+		r := httptest.NewRequest(http.MethodPatch, "/v1/users/"+userID, strings.NewReader(body))
+		w := httptest.NewRecorder()
+
+		r.Header.Set("Authorization", "Bearer "+token)
+		r.Header.Set("Content-Type", "application/merge-patch+json")
+		ut.app.ServeHTTP(w, r)
+
+		return w.Code
+	}
+
+	t.Log("Given the need to keep users from changing roles.")
+	{
+		testID := 0
+		t.Logf("\tTest %d:\tWhen a non-admin user patches their roles.", testID)
+		{
+			if code := patch(ut.userToken); code != http.StatusForbidden {
+				t.Fatalf("\t%s\tTest %d:\tShould receive a status code of 403 for the response : %v", dbtest.Failed, testID, code)
+			}
+			t.Logf("\t%s\tTest %d:\tShould receive a status code of 403 for the response.", dbtest.Success, testID)
+		}
+
+		testID = 1
+		t.Logf("\tTest %d:\tWhen a user who may change users but not manage roles patches roles.", testID)
+		{
+			if code := patch(ut.userAdminToken); code != http.StatusForbidden {
+				t.Fatalf("\t%s\tTest %d:\tShould receive a status code of 403 for the response : %v", dbtest.Failed, testID, code)
+			}
+			t.Logf("\t%s\tTest %d:\tShould receive a status code of 403 for the response.", dbtest.Success, testID)
+
+			r := httptest.NewRequest(http.MethodGet, "/v1/users/"+userID, nil)
+			w := httptest.NewRecorder()
+
+			r.Header.Set("Authorization", "Bearer "+ut.adminToken)
+			ut.app.ServeHTTP(w, r)
+
+			var usr user.User
+			if err := json.NewDecoder(w.Body).Decode(&usr); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to unmarshal the response : %v", dbtest.Failed, testID, err)
+			}
+
+			if len(usr.Roles) != 1 || usr.Roles[0] != "USER" {
+				t.Fatalf("\t%s\tTest %d:\tShould keep the roles of the user : got %v", dbtest.Failed, testID, usr.Roles)
+			}
+			t.Logf("\t%s\tTest %d:\tShould keep the roles of the user.", dbtest.Success, testID)
+		}
+	}
+}
+
+// putUserRoles403 validates that only users allowed to manage roles can
+// change roles with an update, even if they may change any user.
+func (ut *UserTests) putUserRoles403(t *testing.T) {
+	const userID = "45b5fbd3-755f-4379-8f07-a58d4a30fa2f"
+
+	body := `{"roles": ["ADMIN"]}`
+
+	t.Log("Given the need to keep users from changing roles.")
+	{
+		testID := 0
+		t.Logf("\tTest %d:\tWhen a non-admin user updates their roles.", testID)
+		{
+			r := httptest.NewRequest(http.MethodPut, "/v1/users/"+userID, strings.NewReader(body))
+			w := httptest.NewRecorder()
+
+			r.Header.Set("Authorization", "Bearer "+ut.userToken)
+			ut.app.ServeHTTP(w, r)
+
+			if w.Code != http.StatusForbidden {
+				t.Fatalf("\t%s\tTest %d:\tShould receive a status code of 403 for the response : %v", dbtest.Failed, testID, w.Code)
+			}
+			t.Logf("\t%s\tTest %d:\tShould receive a status code of 403 for the response.", dbtest.Success, testID)
+		}
+
+		testID = 1
+		t.Logf("\tTest %d:\tWhen a user who may change users but not manage roles updates roles.", testID)
+		{
+			r := httptest.NewRequest(http.MethodPut, "/v1/users/"+userID, strings.NewReader(body))
+			w := httptest.NewRecorder()
+
+			r.Header.Set("Authorization", "Bearer "+ut.userAdminToken)
+			ut.app.ServeHTTP(w, r)
+
+			if w.Code != http.StatusForbidden {
+				t.Fatalf("\t%s\tTest %d:\tShould receive a status code of 403 for the response : %v", dbtest.Failed, testID, w.Code)
+			}
+			t.Logf("\t%s\tTest %d:\tShould receive a status code of 403 for the response.", dbtest.Success, testID)
+		}
+	}
+}
+
 // putUser403 validates that a user can't modify users unless they are an admin.
 func (ut *UserTests) putUser403(t *testing.T, id string) {
 	body := `{"name": "Anna Walker"}`
@@ -5,13 +5,22 @@ import (
 	"encoding/pem"
 	"errors"
 	"fmt"
-	"github.com/golang-jwt/jwt/v4"
 	"io"
 	"os"
 	"time"
+
+	"github.com/andrewyang17/service/business/sys/auth"
+	"github.com/andrewyang17/service/business/sys/validate"
+	"github.com/golang-jwt/jwt/v4"
 )
 
-func GenToken() error {
+// GenToken generates an admin token with every permission for acting in the
+// specified organization.
+func GenToken(orgID string) error {
+	if err := validate.CheckID(orgID); err != nil {
+		return fmt.Errorf("org id must be a UUID: %w", err)
+	}
+
 	file, err := os.Open("zarf/keys/0ddfa338-de77-4c23-acf6-2368202fc5a1.pem")
 	if err != nil {
 		return err
@@ -31,7 +40,9 @@ func GenToken() error {
 
 	claims := struct {
 		jwt.RegisteredClaims
-		Roles []string
+		Roles       []string
+		Permissions []string
+		OrgID       string `json:"org_id"`
 	}{
 		RegisteredClaims: jwt.RegisteredClaims{
 			Issuer:    "service project",
@@ -40,6 +51,13 @@ func GenToken() error {
 			IssuedAt:  jwt.NewNumericDate(time.Now().UTC()),
 		},
 		Roles: []string{"ADMIN"},
+		Permissions: []string{
+			auth.PermUsersRead, auth.PermUsersWrite, auth.PermRolesManage, auth.PermProductsWrite,
+			auth.PermCategoriesWrite, auth.PermTagsDelete, auth.PermSalesRead, auth.PermSalesRefund,
+			auth.PermOrdersRead, auth.PermOrdersWrite, auth.PermReportsRead, auth.PermRatesWrite,
+			auth.PermOrgsManage, auth.PermAPIKeysManage,
+		},
+		OrgID: orgID,
 	}
 
 	method := jwt.GetSigningMethod("RS256")
@@ -0,0 +1,324 @@
+// Package apikey provides the core business API for the long-lived keys
+// machine clients authenticate with in place of a user's password.
+package apikey
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/andrewyang17/service/business/core/apikey/db"
+	roleDB "github.com/andrewyang17/service/business/core/role/db"
+	userDB "github.com/andrewyang17/service/business/core/user/db"
+	"github.com/andrewyang17/service/business/sys/auth"
+	"github.com/andrewyang17/service/business/sys/database"
+	"github.com/andrewyang17/service/business/sys/tenant"
+	"github.com/andrewyang17/service/business/sys/validate"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrNotFound     = errors.New("api key not found")
+	ErrInvalidID    = errors.New("ID is not in its proper form")
+	ErrInvalidKey   = auth.ErrInvalidKey
+	ErrUserNotFound = errors.New("user not found")
+	ErrFromAPIKey   = errors.New("api keys can not be used to manage api keys")
+)
+
+// Set of values that shape the keys handed out to clients.
+const (
+	keyPrefix    = "sk_"
+	prefixLength = len(keyPrefix) + 8
+)
+
+// lastUsedInterval is how stale the last used date of a key may get before
+// it is recorded again, so not every request writes to the database.
+const lastUsedInterval = time.Minute
+
+// Core manages the set of APIs for API key access.
+type Core struct {
+	log       *zap.SugaredLogger
+	store     db.Store
+	userStore userDB.Store
+	roleStore roleDB.Store
+}
+
+// NewCore constructs a core for API key api access.
+func NewCore(log *zap.SugaredLogger, sqlxDB *sqlx.DB) Core {
+	return Core{
+		log:       log,
+		store:     db.NewStore(log, sqlxDB),
+		userStore: userDB.NewStore(log, sqlxDB),
+		roleStore: roleDB.NewStore(log, sqlxDB),
+	}
+}
+
+// Create issues a new key to the user of the claims for the organization in
+// the context. The key can only be given roles the user holds there and the
+// claims carry, so a key can never act for more than the credentials that
+// issued it, and it expires when the claims do if not earlier. Claims derived
+// from another key can't create keys. Only a hash of the key is stored, so
+// the key returned here is the only copy of it.
+func (c Core) Create(ctx context.Context, nk NewKey, claims auth.Claims, now time.Time) (Key, string, error) {
+	orgID, err := tenant.Get(ctx)
+	if err != nil {
+		return Key{}, "", err
+	}
+
+	if claims.FromAPIKey() {
+		return Key{}, "", ErrFromAPIKey
+	}
+
+	if err := validate.Check(nk); err != nil {
+		return Key{}, "", fmt.Errorf("validating data: %w", err)
+	}
+
+	if nk.DateExpires != nil && !nk.DateExpires.After(now) {
+		return Key{}, "", validate.FieldErrors{
+			{Field: "date_expires", Error: "must be in the future"},
+		}
+	}
+
+	var expires *time.Time
+	if nk.DateExpires != nil {
+		t := nk.DateExpires.UTC()
+		expires = &t
+	}
+
+	if claims.ExpiresAt != nil {
+		limit := claims.ExpiresAt.Time.UTC()
+		switch {
+		case expires == nil:
+			expires = &limit
+		case expires.After(limit):
+			return Key{}, "", validate.FieldErrors{
+				{Field: "date_expires", Error: "must not be after the credentials creating the key expire"},
+			}
+		}
+	}
+
+	userID := claims.Subject
+
+	dbUsr, err := c.userStore.QueryMemberByID(ctx, orgID, userID)
+	if err != nil {
+		if errors.Is(err, database.ErrDBNotFound) {
+			return Key{}, "", ErrUserNotFound
+		}
+		return Key{}, "", fmt.Errorf("query user: %w", err)
+	}
+
+	for _, role := range nk.Roles {
+		if !contains(dbUsr.Roles, role) || !contains(claims.Roles, role) {
+			return Key{}, "", validate.FieldErrors{
+				{Field: "roles", Error: fmt.Sprintf("role %q is not held by the user", role)},
+			}
+		}
+	}
+
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return Key{}, "", fmt.Errorf("generating key: %w", err)
+	}
+	secret := keyPrefix + base64.RawURLEncoding.EncodeToString(b)
+
+	dbKey := db.Key{
+		ID:          validate.GenerateID(),
+		OrgID:       orgID,
+		UserID:      userID,
+		Name:        nk.Name,
+		Prefix:      secret[:prefixLength],
+		KeyHash:     hashKey(secret),
+		Roles:       nk.Roles,
+		DateExpires: expires,
+		DateCreated: now,
+	}
+
+	if err := c.store.Create(ctx, dbKey); err != nil {
+		return Key{}, "", fmt.Errorf("create: %w", err)
+	}
+
+	return toKey(dbKey), secret, nil
+}
+
+// Revoke stops the key identified by a given ID from being used again.
+// Revoking a key twice is not an error.
+func (c Core) Revoke(ctx context.Context, keyID string, now time.Time) error {
+	orgID, err := tenant.Get(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := validate.CheckID(keyID); err != nil {
+		return ErrInvalidID
+	}
+
+	if _, err := c.store.QueryByID(ctx, orgID, keyID); err != nil {
+		if errors.Is(err, database.ErrDBNotFound) {
+			return ErrNotFound
+		}
+		return fmt.Errorf("query: %w", err)
+	}
+
+	if err := c.store.Revoke(ctx, orgID, keyID, now); err != nil {
+		return fmt.Errorf("revoke: %w", err)
+	}
+
+	return nil
+}
+
+// Query gets the keys of the organization in the context.
+func (c Core) Query(ctx context.Context) ([]Key, error) {
+	orgID, err := tenant.Get(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	dbKeys, err := c.store.Query(ctx, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("query: %w", err)
+	}
+
+	return toKeySlice(dbKeys), nil
+}
+
+// QueryByUserID gets the keys issued to a user for the organization in the
+// context.
+func (c Core) QueryByUserID(ctx context.Context, userID string) ([]Key, error) {
+	orgID, err := tenant.Get(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := validate.CheckID(userID); err != nil {
+		return nil, ErrInvalidID
+	}
+
+	dbKeys, err := c.store.QueryByUserID(ctx, orgID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("query: %w", err)
+	}
+
+	return toKeySlice(dbKeys), nil
+}
+
+// QueryByID finds the key identified by a given ID.
+func (c Core) QueryByID(ctx context.Context, keyID string) (Key, error) {
+	orgID, err := tenant.Get(ctx)
+	if err != nil {
+		return Key{}, err
+	}
+
+	if err := validate.CheckID(keyID); err != nil {
+		return Key{}, ErrInvalidID
+	}
+
+	dbKey, err := c.store.QueryByID(ctx, orgID, keyID)
+	if err != nil {
+		if errors.Is(err, database.ErrDBNotFound) {
+			return Key{}, ErrNotFound
+		}
+		return Key{}, fmt.Errorf("query: %w", err)
+	}
+
+	return toKey(dbKey), nil
+}
+
+// Authenticate finds the key and returns the claims it acts with. The key
+// keeps only the roles its user still holds in the organization and stops
+// working once the user is deleted, locked out or removed from the
+// organization. ErrInvalidKey is returned whenever the key can't be used.
+func (c Core) Authenticate(ctx context.Context, now time.Time, secret string) (auth.Claims, error) {
+	if !strings.HasPrefix(secret, keyPrefix) {
+		return auth.Claims{}, ErrInvalidKey
+	}
+
+	dbKey, err := c.store.QueryByHash(ctx, hashKey(secret))
+	if err != nil {
+		if errors.Is(err, database.ErrDBNotFound) {
+			return auth.Claims{}, ErrInvalidKey
+		}
+		return auth.Claims{}, fmt.Errorf("query: %w", err)
+	}
+
+	if dbKey.DateRevoked != nil || (dbKey.DateExpires != nil && !now.Before(*dbKey.DateExpires)) {
+		return auth.Claims{}, ErrInvalidKey
+	}
+
+	// Users removed from the organization are not found as members.
+	dbUsr, err := c.userStore.QueryMemberByID(ctx, dbKey.OrgID, dbKey.UserID)
+	if err != nil {
+		if errors.Is(err, database.ErrDBNotFound) {
+			return auth.Claims{}, ErrInvalidKey
+		}
+		return auth.Claims{}, fmt.Errorf("query user: %w", err)
+	}
+
+	if dbUsr.DateLockedUntil != nil && now.Before(*dbUsr.DateLockedUntil) {
+		return auth.Claims{}, ErrInvalidKey
+	}
+
+	roles := make([]string, 0, len(dbKey.Roles))
+	for _, role := range dbKey.Roles {
+		if contains(dbUsr.Roles, role) {
+			roles = append(roles, role)
+		}
+	}
+
+	perms, err := c.roleStore.QueryPermissions(ctx, roles)
+	if err != nil {
+		return auth.Claims{}, fmt.Errorf("query permissions: %w", err)
+	}
+
+	// The key is already authenticated, so a failure to record its use is
+	// only logged.
+	if dbKey.DateLastUsed == nil || now.Sub(*dbKey.DateLastUsed) >= lastUsedInterval {
+		if err := c.store.UpdateLastUsed(ctx, dbKey.ID, now); err != nil {
+			c.log.Errorw("authenticate", "status", "update last used", "keyID", dbKey.ID, "ERROR", err)
+		}
+	}
+
+	claims := auth.Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:   "service project",
+			Subject:  dbUsr.ID,
+			IssuedAt: jwt.NewNumericDate(now),
+		},
+		Roles:       roles,
+		Permissions: perms,
+		OrgID:       dbKey.OrgID,
+		APIKeyID:    dbKey.ID,
+		Verified:    dbUsr.DateVerified != nil,
+	}
+	if dbKey.DateExpires != nil {
+		claims.ExpiresAt = jwt.NewNumericDate(*dbKey.DateExpires)
+	}
+
+	return claims, nil
+}
+
+// =============================================================================
+
+// hashKey returns the hash of a key that is stored in its place. Keys are
+// long and random, so a fast hash is enough.
+func hashKey(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// contains reports whether the role is in the list.
+func contains(roles []string, role string) bool {
+	for _, r := range roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
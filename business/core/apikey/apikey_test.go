@@ -0,0 +1,167 @@
+package apikey_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/andrewyang17/service/business/core/apikey"
+	"github.com/andrewyang17/service/business/core/organization"
+	"github.com/andrewyang17/service/business/data/dbtest"
+	"github.com/andrewyang17/service/business/sys/auth"
+	"github.com/andrewyang17/service/business/sys/tenant"
+	"github.com/andrewyang17/service/business/sys/validate"
+	"github.com/andrewyang17/service/foundation/docker"
+	"github.com/golang-jwt/jwt/v4"
+)
+
+var c *docker.Container
+
+func TestMain(m *testing.M) {
+	var err error
+	c, err = dbtest.StartDB()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer dbtest.StopDB(c)
+
+	m.Run()
+}
+
+func TestAPIKey(t *testing.T) {
+	log, db, teardown := dbtest.NewUnit(t, c, "testapikey")
+	t.Cleanup(teardown)
+
+	core := apikey.NewCore(log, db)
+
+	const (
+		adminID = "5cf37266-3473-4006-984f-9325122678b7"
+		userID  = "45b5fbd3-755f-4379-8f07-a58d4a30fa2f"
+	)
+
+	t.Log("Given the need to work with API keys.")
+	{
+		testID := 0
+		t.Logf("\tTest %d:\tWhen handling a single API key.", testID)
+		{
+			ctx := tenant.Set(context.Background(), organization.DefaultID)
+			now := time.Date(2022, time.January, 8, 17, 56, 0, 0, time.UTC)
+			expires := now.Add(24 * time.Hour)
+
+			adminClaims := auth.Claims{
+				RegisteredClaims: jwt.RegisteredClaims{Subject: adminID},
+				Roles:            []string{auth.RoleAdmin, auth.RoleUser},
+			}
+			userClaims := auth.Claims{
+				RegisteredClaims: jwt.RegisteredClaims{Subject: userID},
+				Roles:            []string{auth.RoleUser},
+			}
+
+			nk := apikey.NewKey{
+				Name:        "Inventory Sync",
+				Roles:       []string{auth.RoleUser},
+				DateExpires: &expires,
+			}
+
+			key, secret, err := core.Create(ctx, nk, adminClaims, now)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to create key : %s.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould be able to create key.", dbtest.Success, testID)
+
+			if key.KeyHash == secret || key.Prefix == "" || secret[:len(key.Prefix)] != key.Prefix {
+				t.Fatalf("\t%s\tTest %d:\tShould only keep a hash and prefix of the key.", dbtest.Failed, testID)
+			}
+			t.Logf("\t%s\tTest %d:\tShould only keep a hash and prefix of the key.", dbtest.Success, testID)
+
+			claims, err := core.Authenticate(context.Background(), now.Add(time.Hour), secret)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to authenticate with key : %s.", dbtest.Failed, testID, err)
+			}
+
+			if claims.Subject != adminID || claims.OrgID != organization.DefaultID {
+				t.Fatalf("\t%s\tTest %d:\tShould act for the user and organization of the key : %+v.", dbtest.Failed, testID, claims)
+			}
+
+			if len(claims.Roles) != 1 || claims.Roles[0] != auth.RoleUser || claims.HasPermission(auth.PermUsersWrite) {
+				t.Fatalf("\t%s\tTest %d:\tShould be limited to the roles of the key : %+v.", dbtest.Failed, testID, claims)
+			}
+
+			if !claims.FromAPIKey() || claims.ExpiresAt == nil || !claims.ExpiresAt.Time.Equal(expires) {
+				t.Fatalf("\t%s\tTest %d:\tShould mark the claims as coming from the key and expire with it : %+v.", dbtest.Failed, testID, claims)
+			}
+			t.Logf("\t%s\tTest %d:\tShould be able to authenticate with key.", dbtest.Success, testID)
+
+			if _, _, err := core.Create(ctx, nk, claims, now); !errors.Is(err, apikey.ErrFromAPIKey) {
+				t.Fatalf("\t%s\tTest %d:\tShould NOT be able to create a key with another key : %v.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould NOT be able to create a key with another key.", dbtest.Success, testID)
+
+			tokenClaims := adminClaims
+			tokenClaims.ExpiresAt = jwt.NewNumericDate(now.Add(time.Hour))
+
+			var fieldErrors validate.FieldErrors
+			if _, _, err := core.Create(ctx, nk, tokenClaims, now); !errors.As(err, &fieldErrors) {
+				t.Fatalf("\t%s\tTest %d:\tShould NOT be able to create a key that outlives the token : %v.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould NOT be able to create a key that outlives the token.", dbtest.Success, testID)
+
+			short, _, err := core.Create(ctx, apikey.NewKey{Name: "Short Lived", Roles: []string{auth.RoleUser}}, tokenClaims, now)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to create key without an expiry : %s.", dbtest.Failed, testID, err)
+			}
+
+			if short.DateExpires == nil || !short.DateExpires.Equal(tokenClaims.ExpiresAt.Time) {
+				t.Fatalf("\t%s\tTest %d:\tShould expire the key with the token : %v.", dbtest.Failed, testID, short.DateExpires)
+			}
+			t.Logf("\t%s\tTest %d:\tShould expire the key with the token.", dbtest.Success, testID)
+
+			escalate := apikey.NewKey{
+				Name:  "Escalation",
+				Roles: []string{auth.RoleAdmin},
+			}
+
+			saved, err := core.QueryByID(ctx, key.ID)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to retrieve key by ID : %s.", dbtest.Failed, testID, err)
+			}
+
+			if saved.DateLastUsed == nil || !saved.DateLastUsed.Equal(now.Add(time.Hour)) {
+				t.Fatalf("\t%s\tTest %d:\tShould record when the key was last used : %v.", dbtest.Failed, testID, saved.DateLastUsed)
+			}
+			t.Logf("\t%s\tTest %d:\tShould record when the key was last used.", dbtest.Success, testID)
+
+			if _, err := core.Authenticate(context.Background(), expires, secret); !errors.Is(err, apikey.ErrInvalidKey) {
+				t.Fatalf("\t%s\tTest %d:\tShould NOT be able to authenticate with an expired key : %v.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould NOT be able to authenticate with an expired key.", dbtest.Success, testID)
+
+			if err := core.Revoke(ctx, key.ID, now.Add(2*time.Hour)); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to revoke key : %s.", dbtest.Failed, testID, err)
+			}
+
+			if _, err := core.Authenticate(context.Background(), now.Add(3*time.Hour), secret); !errors.Is(err, apikey.ErrInvalidKey) {
+				t.Fatalf("\t%s\tTest %d:\tShould NOT be able to authenticate with a revoked key : %v.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould NOT be able to authenticate with a revoked key.", dbtest.Success, testID)
+
+			if _, _, err := core.Create(ctx, escalate, userClaims, now); !errors.As(err, &fieldErrors) {
+				t.Fatalf("\t%s\tTest %d:\tShould NOT be able to give a key roles the user lacks : %v.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould NOT be able to give a key roles the user lacks.", dbtest.Success, testID)
+
+			keys, err := core.QueryByUserID(ctx, userID)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to query keys by user : %s.", dbtest.Failed, testID, err)
+			}
+
+			if len(keys) != 0 {
+				t.Fatalf("\t%s\tTest %d:\tShould only list the keys of the user : %+v.", dbtest.Failed, testID, keys)
+			}
+			t.Logf("\t%s\tTest %d:\tShould only list the keys of the user.", dbtest.Success, testID)
+		}
+	}
+}
@@ -0,0 +1,222 @@
+// Package db contains API key related CRUD functionality.
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/andrewyang17/service/business/sys/database"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+type Store struct {
+	log          *zap.SugaredLogger
+	tr           database.Transactor
+	db           sqlx.ExtContext
+	isWithinTran bool
+}
+
+func NewStore(log *zap.SugaredLogger, db *sqlx.DB) Store {
+	return Store{
+		log: log,
+		tr:  db,
+		db:  db,
+	}
+}
+
+// WithinTran runs passed function and do commit/rollback at the end.
+func (s Store) WithinTran(ctx context.Context, fn func(extContext sqlx.ExtContext) error) error {
+	if s.isWithinTran {
+		return fn(s.db)
+	}
+	return database.WithinTran(ctx, s.log, s.tr, fn)
+}
+
+// Tran return new Store with transaction in it.
+func (s Store) Tran(tx sqlx.ExtContext) Store {
+	return Store{
+		log:          s.log,
+		tr:           s.tr,
+		db:           tx,
+		isWithinTran: true,
+	}
+}
+
+// Create adds a Key to the database.
+func (s Store) Create(ctx context.Context, key Key) error {
+	const q = `
+	INSERT INTO api_keys
+		(key_id, org_id, user_id, name, prefix, key_hash, roles, date_expires, date_revoked, date_last_used, date_created)
+	VALUES
+		(:key_id, :org_id, :user_id, :name, :prefix, :key_hash, :roles, :date_expires, :date_revoked, :date_last_used, :date_created)`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, key); err != nil {
+		return fmt.Errorf("inserting key: %w", err)
+	}
+
+	return nil
+}
+
+// Revoke marks the key identified by a given ID in an organization as
+// revoked. A key that is already revoked keeps its original date.
+func (s Store) Revoke(ctx context.Context, orgID string, keyID string, now time.Time) error {
+	data := struct {
+		OrgID       string    `db:"org_id"`
+		KeyID       string    `db:"key_id"`
+		DateRevoked time.Time `db:"date_revoked"`
+	}{
+		OrgID:       orgID,
+		KeyID:       keyID,
+		DateRevoked: now,
+	}
+
+	const q = `
+	UPDATE
+		api_keys
+	SET
+		"date_revoked" = :date_revoked
+	WHERE
+		org_id = :org_id AND
+		key_id = :key_id AND
+		date_revoked IS NULL`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, data); err != nil {
+		return fmt.Errorf("revoking keyID[%q]: %w", keyID, err)
+	}
+
+	return nil
+}
+
+// UpdateLastUsed records when the specified key was last used.
+func (s Store) UpdateLastUsed(ctx context.Context, keyID string, now time.Time) error {
+	data := struct {
+		KeyID        string    `db:"key_id"`
+		DateLastUsed time.Time `db:"date_last_used"`
+	}{
+		KeyID:        keyID,
+		DateLastUsed: now,
+	}
+
+	const q = `
+	UPDATE
+		api_keys
+	SET
+		"date_last_used" = :date_last_used
+	WHERE
+		key_id = :key_id`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, data); err != nil {
+		return fmt.Errorf("updating last used keyID[%q]: %w", keyID, err)
+	}
+
+	return nil
+}
+
+// Query gets the Keys of an organization from the database.
+func (s Store) Query(ctx context.Context, orgID string) ([]Key, error) {
+	data := struct {
+		OrgID string `db:"org_id"`
+	}{
+		OrgID: orgID,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		api_keys
+	WHERE
+		org_id = :org_id
+	ORDER BY
+		date_created, key_id`
+
+	var keys []Key
+	if err := database.NamedQuerySlice(ctx, s.log, s.db, q, data, &keys); err != nil {
+		return nil, fmt.Errorf("selecting keys: %w", err)
+	}
+
+	return keys, nil
+}
+
+// QueryByUserID gets the Keys of an organization issued to a given user ID.
+func (s Store) QueryByUserID(ctx context.Context, orgID string, userID string) ([]Key, error) {
+	data := struct {
+		OrgID  string `db:"org_id"`
+		UserID string `db:"user_id"`
+	}{
+		OrgID:  orgID,
+		UserID: userID,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		api_keys
+	WHERE
+		org_id = :org_id AND
+		user_id = :user_id
+	ORDER BY
+		date_created, key_id`
+
+	var keys []Key
+	if err := database.NamedQuerySlice(ctx, s.log, s.db, q, data, &keys); err != nil {
+		return nil, fmt.Errorf("selecting keys userID[%q]: %w", userID, err)
+	}
+
+	return keys, nil
+}
+
+// QueryByID finds the key identified by a given ID in an organization.
+func (s Store) QueryByID(ctx context.Context, orgID string, keyID string) (Key, error) {
+	data := struct {
+		OrgID string `db:"org_id"`
+		KeyID string `db:"key_id"`
+	}{
+		OrgID: orgID,
+		KeyID: keyID,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		api_keys
+	WHERE
+		org_id = :org_id AND
+		key_id = :key_id`
+
+	var key Key
+	if err := database.NamedQueryStruct(ctx, s.log, s.db, q, data, &key); err != nil {
+		return Key{}, fmt.Errorf("selecting keyID[%q]: %w", keyID, err)
+	}
+
+	return key, nil
+}
+
+// QueryByHash finds the key with the specified hash. The key decides which
+// organization it acts for, so the lookup spans every organization.
+func (s Store) QueryByHash(ctx context.Context, keyHash string) (Key, error) {
+	data := struct {
+		KeyHash string `db:"key_hash"`
+	}{
+		KeyHash: keyHash,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		api_keys
+	WHERE
+		key_hash = :key_hash`
+
+	var key Key
+	if err := database.NamedQueryStruct(ctx, s.log, s.db, q, data, &key); err != nil {
+		return Key{}, fmt.Errorf("selecting key: %w", err)
+	}
+
+	return key, nil
+}
@@ -0,0 +1,23 @@
+package db
+
+import (
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Key represents the structure we need for moving data
+// between the app and the database.
+type Key struct {
+	ID           string         `db:"key_id"`
+	OrgID        string         `db:"org_id"`
+	UserID       string         `db:"user_id"`
+	Name         string         `db:"name"`
+	Prefix       string         `db:"prefix"`
+	KeyHash      string         `db:"key_hash"`
+	Roles        pq.StringArray `db:"roles"`
+	DateExpires  *time.Time     `db:"date_expires"`
+	DateRevoked  *time.Time     `db:"date_revoked"`
+	DateLastUsed *time.Time     `db:"date_last_used"`
+	DateCreated  time.Time      `db:"date_created"`
+}
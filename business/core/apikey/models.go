@@ -0,0 +1,49 @@
+package apikey
+
+import (
+	"time"
+	"unsafe"
+
+	"github.com/andrewyang17/service/business/core/apikey/db"
+)
+
+// Key lets a machine client act for the user it was issued to within one
+// organization, limited to the roles it was given. The key itself is only
+// shown when it is created, Prefix is kept so it can be recognized later.
+type Key struct {
+	ID           string     `json:"id"`
+	OrgID        string     `json:"org_id"`
+	UserID       string     `json:"user_id"`
+	Name         string     `json:"name"`
+	Prefix       string     `json:"prefix"`
+	KeyHash      string     `json:"-"`
+	Roles        []string   `json:"roles"`
+	DateExpires  *time.Time `json:"date_expires"`
+	DateRevoked  *time.Time `json:"date_revoked"`
+	DateLastUsed *time.Time `json:"date_last_used"`
+	DateCreated  time.Time  `json:"date_created"`
+}
+
+// NewKey contains information needed to create a new Key. A key without
+// DateExpires expires with the credentials that create it, or never if they
+// don't expire.
+type NewKey struct {
+	Name        string     `json:"name" validate:"required,max=100"`
+	Roles       []string   `json:"roles" validate:"required,min=1"`
+	DateExpires *time.Time `json:"date_expires"`
+}
+
+// =============================================================================
+
+func toKey(dbKey db.Key) Key {
+	pk := (*Key)(unsafe.Pointer(&dbKey))
+	return *pk
+}
+
+func toKeySlice(dbKeys []db.Key) []Key {
+	keys := make([]Key, len(dbKeys))
+	for i, dbKey := range dbKeys {
+		keys[i] = toKey(dbKey)
+	}
+	return keys
+}
@@ -0,0 +1,264 @@
+// Package category provides the core business API for the product taxonomy.
+package category
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/andrewyang17/service/business/core/category/db"
+	"github.com/andrewyang17/service/business/sys/database"
+	"github.com/andrewyang17/service/business/sys/tenant"
+	"github.com/andrewyang17/service/business/sys/validate"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrNotFound       = errors.New("category not found")
+	ErrInvalidID      = errors.New("ID is not in its proper form")
+	ErrParentNotFound = errors.New("parent category not found")
+	ErrCycle          = errors.New("category can not be moved beneath itself")
+	ErrHasChildren    = errors.New("category still has child categories")
+)
+
+// Core manages the set of APIs for category access.
+type Core struct {
+	store db.Store
+}
+
+// NewCore constructs a core for category api access.
+func NewCore(log *zap.SugaredLogger, sqlxDB *sqlx.DB) Core {
+	return Core{
+		store: db.NewStore(log, sqlxDB),
+	}
+}
+
+// Create adds a Category to the database for the organization the context
+// acts for. It returns the created Category with fields like ID and
+// DateCreated populated.
+func (c Core) Create(ctx context.Context, nc NewCategory, now time.Time) (Category, error) {
+	if err := validate.Check(nc); err != nil {
+		return Category{}, fmt.Errorf("validating data: %w", err)
+	}
+
+	orgID, err := tenant.Get(ctx)
+	if err != nil {
+		return Category{}, err
+	}
+
+	dbCat := db.Category{
+		ID:          validate.GenerateID(),
+		OrgID:       orgID,
+		Name:        nc.Name,
+		DateCreated: now,
+		DateUpdated: now,
+	}
+
+	if nc.ParentID != "" {
+		if _, err := c.store.QueryByID(ctx, orgID, nc.ParentID); err != nil {
+			if errors.Is(err, database.ErrDBNotFound) {
+				return Category{}, ErrParentNotFound
+			}
+			return Category{}, fmt.Errorf("query parent: %w", err)
+		}
+		dbCat.ParentID = &nc.ParentID
+	}
+
+	if err := c.store.Create(ctx, dbCat); err != nil {
+		return Category{}, fmt.Errorf("create: %w", err)
+	}
+
+	return toCategory(dbCat), nil
+}
+
+// Update modifies data about a Category. Moving a category moves its whole
+// subtree with it, so a category can't be moved beneath one of its own
+// descendants.
+func (c Core) Update(ctx context.Context, categoryID string, uc UpdateCategory, now time.Time) error {
+	if err := validate.CheckID(categoryID); err != nil {
+		return ErrInvalidID
+	}
+
+	if err := validate.Check(uc); err != nil {
+		return fmt.Errorf("validating data: %w", err)
+	}
+
+	if uc.ParentID != nil && *uc.ParentID != "" {
+		if err := validate.CheckID(*uc.ParentID); err != nil {
+			return ErrInvalidID
+		}
+	}
+
+	orgID, err := tenant.Get(ctx)
+	if err != nil {
+		return err
+	}
+
+	tran := func(tx sqlx.ExtContext) error {
+		store := c.store.Tran(tx)
+
+		dbCat, err := store.QueryByID(ctx, orgID, categoryID)
+		if err != nil {
+			if errors.Is(err, database.ErrDBNotFound) {
+				return ErrNotFound
+			}
+			return fmt.Errorf("query: %w", err)
+		}
+
+		if uc.Name != nil {
+			dbCat.Name = *uc.Name
+		}
+
+		if uc.ParentID != nil {
+			switch parentID := *uc.ParentID; parentID {
+			case "":
+				dbCat.ParentID = nil
+
+			case categoryID:
+				return ErrCycle
+
+			default:
+				if _, err := store.QueryByID(ctx, orgID, parentID); err != nil {
+					if errors.Is(err, database.ErrDBNotFound) {
+						return ErrParentNotFound
+					}
+					return fmt.Errorf("query parent: %w", err)
+				}
+
+				ancestors, err := store.QueryAncestors(ctx, orgID, parentID)
+				if err != nil {
+					return fmt.Errorf("query ancestors: %w", err)
+				}
+				for _, ancestor := range ancestors {
+					if ancestor.ID == categoryID {
+						return ErrCycle
+					}
+				}
+
+				dbCat.ParentID = &parentID
+			}
+		}
+
+		dbCat.DateUpdated = now
+
+		if err := store.Update(ctx, dbCat); err != nil {
+			return fmt.Errorf("update: %w", err)
+		}
+
+		return nil
+	}
+
+	if err := c.store.WithinTran(ctx, tran); err != nil {
+		return fmt.Errorf("tran: %w", err)
+	}
+
+	return nil
+}
+
+// Delete removes the category identified by a given ID. Products in the
+// category are left without one. A category that still has children can't be
+// deleted.
+func (c Core) Delete(ctx context.Context, categoryID string) error {
+	if err := validate.CheckID(categoryID); err != nil {
+		return ErrInvalidID
+	}
+
+	orgID, err := tenant.Get(ctx)
+	if err != nil {
+		return err
+	}
+
+	children, err := c.store.QueryChildren(ctx, orgID, categoryID)
+	if err != nil {
+		return fmt.Errorf("query children: %w", err)
+	}
+
+	if len(children) > 0 {
+		return ErrHasChildren
+	}
+
+	if err := c.store.Delete(ctx, orgID, categoryID); err != nil {
+		return fmt.Errorf("delete: %w", err)
+	}
+
+	return nil
+}
+
+// Query gets all Categories of the organization the context acts for from
+// the database.
+func (c Core) Query(ctx context.Context) ([]Category, error) {
+	orgID, err := tenant.Get(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	dbCats, err := c.store.Query(ctx, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("query: %w", err)
+	}
+
+	return toCategorySlice(dbCats), nil
+}
+
+// QueryByID finds the category identified by a given ID.
+func (c Core) QueryByID(ctx context.Context, categoryID string) (Category, error) {
+	if err := validate.CheckID(categoryID); err != nil {
+		return Category{}, ErrInvalidID
+	}
+
+	orgID, err := tenant.Get(ctx)
+	if err != nil {
+		return Category{}, err
+	}
+
+	dbCat, err := c.store.QueryByID(ctx, orgID, categoryID)
+	if err != nil {
+		if errors.Is(err, database.ErrDBNotFound) {
+			return Category{}, ErrNotFound
+		}
+		return Category{}, fmt.Errorf("query: %w", err)
+	}
+
+	return toCategory(dbCat), nil
+}
+
+// QueryChildren gets the categories directly beneath the specified category.
+func (c Core) QueryChildren(ctx context.Context, categoryID string) ([]Category, error) {
+	if err := validate.CheckID(categoryID); err != nil {
+		return nil, ErrInvalidID
+	}
+
+	orgID, err := tenant.Get(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	dbCats, err := c.store.QueryChildren(ctx, orgID, categoryID)
+	if err != nil {
+		return nil, fmt.Errorf("query: %w", err)
+	}
+
+	return toCategorySlice(dbCats), nil
+}
+
+// QueryAncestors gets the path from the specified category up to the top of
+// the taxonomy, nearest first, for building breadcrumbs.
+func (c Core) QueryAncestors(ctx context.Context, categoryID string) ([]Category, error) {
+	if err := validate.CheckID(categoryID); err != nil {
+		return nil, ErrInvalidID
+	}
+
+	orgID, err := tenant.Get(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	dbCats, err := c.store.QueryAncestors(ctx, orgID, categoryID)
+	if err != nil {
+		return nil, fmt.Errorf("query: %w", err)
+	}
+
+	return toCategorySlice(dbCats), nil
+}
@@ -0,0 +1,172 @@
+package category_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/andrewyang17/service/business/core/category"
+	"github.com/andrewyang17/service/business/core/organization"
+	"github.com/andrewyang17/service/business/core/product"
+	"github.com/andrewyang17/service/business/data/dbtest"
+	"github.com/andrewyang17/service/business/sys/money"
+	"github.com/andrewyang17/service/business/sys/tenant"
+	"github.com/andrewyang17/service/foundation/docker"
+)
+
+var c *docker.Container
+
+func TestMain(m *testing.M) {
+	var err error
+	c, err = dbtest.StartDB()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer dbtest.StopDB(c)
+
+	m.Run()
+}
+
+func TestCategory(t *testing.T) {
+	log, db, teardown := dbtest.NewUnit(t, c, "testcategory")
+	t.Cleanup(teardown)
+
+	core := category.NewCore(log, db)
+	prdCore := product.NewCore(log, db)
+
+	t.Log("Given the need to work with a nested Category tree.")
+	{
+		testID := 0
+		t.Logf("\tTest %d:\tWhen handling a category with a child.", testID)
+		{
+			ctx := tenant.Set(context.Background(), organization.DefaultID)
+			now := time.Date(2022, time.January, 8, 17, 56, 0, 0, time.UTC)
+
+			toys, err := core.Create(ctx, category.NewCategory{Name: "Toys"}, now)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to create a top level category : %s.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould be able to create a top level category.", dbtest.Success, testID)
+
+			puzzles, err := core.Create(ctx, category.NewCategory{ParentID: toys.ID, Name: "Puzzles"}, now)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to create a child category : %s.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould be able to create a child category.", dbtest.Success, testID)
+
+			np := product.NewProduct{
+				Name:       "Jigsaw",
+				Cost:       money.New(1500, money.USD),
+				Quantity:   5,
+				UserID:     "5cf37266-3473-4006-984f-9325122678b7",
+				CategoryID: puzzles.ID,
+			}
+			if _, err := prdCore.Create(ctx, np, now); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to create a product in the child category : %s.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould be able to create a product in the child category.", dbtest.Success, testID)
+
+			products, err := prdCore.Query(ctx, product.QueryFilter{CategoryID: &toys.ID}, 1, 10)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to query products by category : %s.", dbtest.Failed, testID, err)
+			}
+
+			if len(products) != 1 || products[0].CategoryID != puzzles.ID {
+				t.Fatalf("\t%s\tTest %d:\tShould find products in the whole subtree : %+v.", dbtest.Failed, testID, products)
+			}
+			t.Logf("\t%s\tTest %d:\tShould find products in the whole subtree.", dbtest.Success, testID)
+
+			ancestors, err := core.QueryAncestors(ctx, puzzles.ID)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to query ancestors : %s.", dbtest.Failed, testID, err)
+			}
+
+			if len(ancestors) != 1 || ancestors[0].ID != toys.ID {
+				t.Fatalf("\t%s\tTest %d:\tShould get the parent as the only ancestor : %+v.", dbtest.Failed, testID, ancestors)
+			}
+			t.Logf("\t%s\tTest %d:\tShould get the parent as the only ancestor.", dbtest.Success, testID)
+
+			if err := core.Update(ctx, toys.ID, category.UpdateCategory{ParentID: &puzzles.ID}, now); !errors.Is(err, category.ErrCycle) {
+				t.Fatalf("\t%s\tTest %d:\tShould NOT be able to move a category beneath its child : %v.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould NOT be able to move a category beneath its child.", dbtest.Success, testID)
+
+			if err := core.Delete(ctx, toys.ID); !errors.Is(err, category.ErrHasChildren) {
+				t.Fatalf("\t%s\tTest %d:\tShould NOT be able to delete a category with children : %v.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould NOT be able to delete a category with children.", dbtest.Success, testID)
+
+			if err := core.Delete(ctx, puzzles.ID); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to delete a leaf category : %s.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould be able to delete a leaf category.", dbtest.Success, testID)
+		}
+	}
+}
+
+func TestOrganizationCategory(t *testing.T) {
+	log, db, teardown := dbtest.NewUnit(t, c, "testorgcategory")
+	t.Cleanup(teardown)
+
+	core := category.NewCore(log, db)
+	orgCore := organization.NewCore(log, db)
+
+	t.Log("Given the need to keep the categories of organizations apart.")
+	{
+		testID := 0
+		t.Logf("\tTest %d:\tWhen handling a category of another organization.", testID)
+		{
+			ctx := tenant.Set(context.Background(), organization.DefaultID)
+			now := time.Date(2022, time.January, 8, 17, 56, 0, 0, time.UTC)
+
+			org, err := orgCore.Create(ctx, organization.NewOrganization{Name: "Other"}, "5cf37266-3473-4006-984f-9325122678b7", now)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to create organization : %s.", dbtest.Failed, testID, err)
+			}
+			otherCtx := tenant.Set(context.Background(), org.ID)
+
+			toys, err := core.Create(ctx, category.NewCategory{Name: "Toys"}, now)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to create category : %s.", dbtest.Failed, testID, err)
+			}
+
+			cats, err := core.Query(otherCtx)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to query categories : %s.", dbtest.Failed, testID, err)
+			}
+
+			if len(cats) != 0 {
+				t.Fatalf("\t%s\tTest %d:\tShould NOT list the category : %+v.", dbtest.Failed, testID, cats)
+			}
+			t.Logf("\t%s\tTest %d:\tShould NOT list the category.", dbtest.Success, testID)
+
+			name := "Taken Over"
+			if err := core.Update(otherCtx, toys.ID, category.UpdateCategory{Name: &name}, now); !errors.Is(err, category.ErrNotFound) {
+				t.Fatalf("\t%s\tTest %d:\tShould NOT be able to update the category : %v.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould NOT be able to update the category.", dbtest.Success, testID)
+
+			if _, err := core.Create(otherCtx, category.NewCategory{ParentID: toys.ID, Name: "Puzzles"}, now); !errors.Is(err, category.ErrParentNotFound) {
+				t.Fatalf("\t%s\tTest %d:\tShould NOT be able to add beneath the category : %v.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould NOT be able to add beneath the category.", dbtest.Success, testID)
+
+			if err := core.Delete(otherCtx, toys.ID); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to call delete : %s.", dbtest.Failed, testID, err)
+			}
+
+			saved, err := core.QueryByID(ctx, toys.ID)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould NOT be able to delete the category : %s.", dbtest.Failed, testID, err)
+			}
+
+			if saved.Name != "Toys" {
+				t.Fatalf("\t%s\tTest %d:\tShould NOT be able to update the category : %s.", dbtest.Failed, testID, saved.Name)
+			}
+			t.Logf("\t%s\tTest %d:\tShould NOT be able to delete the category.", dbtest.Success, testID)
+		}
+	}
+}
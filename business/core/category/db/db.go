@@ -0,0 +1,228 @@
+// Package db contains category related CRUD functionality.
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/andrewyang17/service/business/sys/database"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+type Store struct {
+	log          *zap.SugaredLogger
+	tr           database.Transactor
+	db           sqlx.ExtContext
+	isWithinTran bool
+}
+
+func NewStore(log *zap.SugaredLogger, db *sqlx.DB) Store {
+	return Store{
+		log: log,
+		tr:  db,
+		db:  db,
+	}
+}
+
+// WithinTran runs passed function and do commit/rollback at the end.
+func (s Store) WithinTran(ctx context.Context, fn func(extContext sqlx.ExtContext) error) error {
+	if s.isWithinTran {
+		return fn(s.db)
+	}
+	return database.WithinTran(ctx, s.log, s.tr, fn)
+}
+
+// Tran return new Store with transaction in it.
+func (s Store) Tran(tx sqlx.ExtContext) Store {
+	return Store{
+		log:          s.log,
+		tr:           s.tr,
+		db:           tx,
+		isWithinTran: true,
+	}
+}
+
+// Create adds a Category to the database for the organization it belongs to.
+func (s Store) Create(ctx context.Context, cat Category) error {
+	const q = `
+	INSERT INTO categories
+		(category_id, org_id, parent_id, name, date_created, date_updated)
+	VALUES
+		(:category_id, :org_id, :parent_id, :name, :date_created, :date_updated)`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, cat); err != nil {
+		return fmt.Errorf("inserting category: %w", err)
+	}
+
+	return nil
+}
+
+// Update modifies data about a Category of the organization it belongs to.
+func (s Store) Update(ctx context.Context, cat Category) error {
+	const q = `
+	UPDATE
+		categories
+	SET
+		"parent_id" = :parent_id,
+		"name" = :name,
+		"date_updated" = :date_updated
+	WHERE
+		org_id = :org_id AND category_id = :category_id`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, cat); err != nil {
+		return fmt.Errorf("updating categoryID[%q]: %w", cat.ID, err)
+	}
+
+	return nil
+}
+
+// Delete removes the category identified by a given ID in an organization.
+func (s Store) Delete(ctx context.Context, orgID string, categoryID string) error {
+	data := struct {
+		OrgID      string `db:"org_id"`
+		CategoryID string `db:"category_id"`
+	}{
+		OrgID:      orgID,
+		CategoryID: categoryID,
+	}
+
+	const q = `
+	DELETE FROM
+		categories
+	WHERE
+		org_id = :org_id AND category_id = :category_id`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, data); err != nil {
+		return fmt.Errorf("deleting categoryID[%q]: %w", categoryID, err)
+	}
+
+	return nil
+}
+
+// Query gets all Categories of an organization from the database. Categories
+// are small enough in number for clients to build the whole tree from a
+// single response.
+func (s Store) Query(ctx context.Context, orgID string) ([]Category, error) {
+	data := struct {
+		OrgID string `db:"org_id"`
+	}{
+		OrgID: orgID,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		categories
+	WHERE
+		org_id = :org_id
+	ORDER BY
+		name, category_id`
+
+	var cats []Category
+	if err := database.NamedQuerySlice(ctx, s.log, s.db, q, data, &cats); err != nil {
+		return nil, fmt.Errorf("selecting categories: %w", err)
+	}
+
+	return cats, nil
+}
+
+// QueryByID finds the category identified by a given ID in an organization.
+func (s Store) QueryByID(ctx context.Context, orgID string, categoryID string) (Category, error) {
+	data := struct {
+		OrgID      string `db:"org_id"`
+		CategoryID string `db:"category_id"`
+	}{
+		OrgID:      orgID,
+		CategoryID: categoryID,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		categories
+	WHERE
+		org_id = :org_id AND category_id = :category_id`
+
+	var cat Category
+	if err := database.NamedQueryStruct(ctx, s.log, s.db, q, data, &cat); err != nil {
+		return Category{}, fmt.Errorf("selecting categoryID[%q]: %w", categoryID, err)
+	}
+
+	return cat, nil
+}
+
+// QueryChildren gets the categories directly beneath the specified category
+// of an organization.
+func (s Store) QueryChildren(ctx context.Context, orgID string, categoryID string) ([]Category, error) {
+	data := struct {
+		OrgID      string `db:"org_id"`
+		CategoryID string `db:"category_id"`
+	}{
+		OrgID:      orgID,
+		CategoryID: categoryID,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		categories
+	WHERE
+		org_id = :org_id AND parent_id = :category_id
+	ORDER BY
+		name, category_id`
+
+	var cats []Category
+	if err := database.NamedQuerySlice(ctx, s.log, s.db, q, data, &cats); err != nil {
+		return nil, fmt.Errorf("selecting children categoryID[%q]: %w", categoryID, err)
+	}
+
+	return cats, nil
+}
+
+// QueryAncestors gets every category above the specified category of an
+// organization, nearest first, by following parent links up to the root.
+func (s Store) QueryAncestors(ctx context.Context, orgID string, categoryID string) ([]Category, error) {
+	data := struct {
+		OrgID      string `db:"org_id"`
+		CategoryID string `db:"category_id"`
+	}{
+		OrgID:      orgID,
+		CategoryID: categoryID,
+	}
+
+	const q = `
+	WITH RECURSIVE ancestors AS (
+		SELECT
+			c.*, 1 AS depth
+		FROM
+			categories AS c
+		JOIN
+			categories AS child ON child.parent_id = c.category_id
+		WHERE
+			child.org_id = :org_id AND child.category_id = :category_id
+		UNION ALL
+		SELECT
+			c.*, a.depth + 1
+		FROM
+			categories AS c
+		JOIN
+			ancestors AS a ON a.parent_id = c.category_id
+	)
+	SELECT
+		category_id, org_id, parent_id, name, date_created, date_updated
+	FROM
+		ancestors
+	ORDER BY
+		depth`
+
+	var cats []Category
+	if err := database.NamedQuerySlice(ctx, s.log, s.db, q, data, &cats); err != nil {
+		return nil, fmt.Errorf("selecting ancestors categoryID[%q]: %w", categoryID, err)
+	}
+
+	return cats, nil
+}
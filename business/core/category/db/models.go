@@ -0,0 +1,14 @@
+package db
+
+import "time"
+
+// Category represents the structure we need for moving data
+// between the app and the database.
+type Category struct {
+	ID          string    `db:"category_id"`
+	OrgID       string    `db:"org_id"`
+	ParentID    *string   `db:"parent_id"`
+	Name        string    `db:"name"`
+	DateCreated time.Time `db:"date_created"`
+	DateUpdated time.Time `db:"date_updated"`
+}
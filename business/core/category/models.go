@@ -0,0 +1,58 @@
+package category
+
+import (
+	"time"
+
+	"github.com/andrewyang17/service/business/core/category/db"
+)
+
+// Category represents a node in the product taxonomy. Top level categories
+// have no parent.
+type Category struct {
+	ID          string    `json:"id"`
+	ParentID    string    `json:"parent_id,omitempty"`
+	Name        string    `json:"name"`
+	DateCreated time.Time `json:"date_created"`
+	DateUpdated time.Time `json:"date_updated"`
+}
+
+// NewCategory is what we require from clients when adding a Category. Leave
+// ParentID empty to add a top level category.
+type NewCategory struct {
+	ParentID string `json:"parent_id" validate:"omitempty,uuid"`
+	Name     string `json:"name" validate:"required,max=100"`
+}
+
+// UpdateCategory defines what information may be provided to modify an
+// existing Category. All fields are optional so clients can send just the
+// fields they want changed. Setting ParentID to an empty string moves the
+// category to the top level.
+type UpdateCategory struct {
+	ParentID *string `json:"parent_id"`
+	Name     *string `json:"name" validate:"omitempty,max=100"`
+}
+
+// =============================================================================
+
+func toCategory(dbCat db.Category) Category {
+	var parentID string
+	if dbCat.ParentID != nil {
+		parentID = *dbCat.ParentID
+	}
+
+	return Category{
+		ID:          dbCat.ID,
+		ParentID:    parentID,
+		Name:        dbCat.Name,
+		DateCreated: dbCat.DateCreated,
+		DateUpdated: dbCat.DateUpdated,
+	}
+}
+
+func toCategorySlice(dbCats []db.Category) []Category {
+	cats := make([]Category, len(dbCats))
+	for i, dbCat := range dbCats {
+		cats[i] = toCategory(dbCat)
+	}
+	return cats
+}
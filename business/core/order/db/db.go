@@ -0,0 +1,305 @@
+// Package db contains order related CRUD functionality.
+package db
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/andrewyang17/service/business/data/order"
+	"github.com/andrewyang17/service/business/sys/database"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+)
+
+type Store struct {
+	log          *zap.SugaredLogger
+	tr           database.Transactor
+	db           sqlx.ExtContext
+	isWithinTran bool
+}
+
+func NewStore(log *zap.SugaredLogger, db *sqlx.DB) Store {
+	return Store{
+		log: log,
+		tr:  db,
+		db:  db,
+	}
+}
+
+// WithinTran runs passed function and do commit/rollback at the end.
+func (s Store) WithinTran(ctx context.Context, fn func(extContext sqlx.ExtContext) error) error {
+	if s.isWithinTran {
+		return fn(s.db)
+	}
+	return database.WithinTran(ctx, s.log, s.tr, fn)
+}
+
+// Tran return new Store with transaction in it.
+func (s Store) Tran(tx sqlx.ExtContext) Store {
+	return Store{
+		log:          s.log,
+		tr:           s.tr,
+		db:           tx,
+		isWithinTran: true,
+	}
+}
+
+// Create inserts a new order header into the database.
+func (s Store) Create(ctx context.Context, ord Order) error {
+	const q = `
+	INSERT INTO orders
+		(order_id, org_id, user_id, status, total, currency, date_created, date_updated)
+	VALUES
+		(:order_id, :org_id, :user_id, :status, :total, :currency, :date_created, :date_updated)`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, ord); err != nil {
+		return fmt.Errorf("inserting order: %w", err)
+	}
+
+	return nil
+}
+
+// CreateItem inserts a new order line into the database.
+func (s Store) CreateItem(ctx context.Context, item OrderItem) error {
+	const q = `
+	INSERT INTO order_items
+		(order_item_id, order_id, product_id, variant_id, quantity, unit_price, total)
+	VALUES
+		(:order_item_id, :order_id, :product_id, :variant_id, :quantity, :unit_price, :total)`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, item); err != nil {
+		return fmt.Errorf("inserting order item: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateStatus changes the status of the specified order.
+func (s Store) UpdateStatus(ctx context.Context, orderID string, status string, now time.Time) error {
+	data := struct {
+		OrderID     string    `db:"order_id"`
+		Status      string    `db:"status"`
+		DateUpdated time.Time `db:"date_updated"`
+	}{
+		OrderID:     orderID,
+		Status:      status,
+		DateUpdated: now,
+	}
+
+	const q = `
+	UPDATE
+		orders
+	SET
+		"status" = :status,
+		"date_updated" = :date_updated
+	WHERE
+		order_id = :order_id`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, data); err != nil {
+		return fmt.Errorf("updating status orderID[%q]: %w", orderID, err)
+	}
+
+	return nil
+}
+
+// Query retrieves a list of the existing orders of an organization from the
+// database.
+func (s Store) Query(ctx context.Context, orgID string, pageNumber int, rowsPerPage int) ([]Order, error) {
+	data := struct {
+		OrgID       string `db:"org_id"`
+		Offset      int    `db:"offset"`
+		RowsPerPage int    `db:"rows_per_page"`
+	}{
+		OrgID:       orgID,
+		Offset:      (pageNumber - 1) * rowsPerPage,
+		RowsPerPage: rowsPerPage,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		orders
+	WHERE
+		org_id = :org_id
+	ORDER BY
+		date_created DESC, order_id
+	OFFSET :offset ROWS FETCH NEXT :rows_per_page ROWS ONLY`
+
+	var ords []Order
+	if err := database.NamedQuerySlice(ctx, s.log, s.db, q, data, &ords); err != nil {
+		return nil, fmt.Errorf("selecting orders: %w", err)
+	}
+
+	return ords, nil
+}
+
+// QueryByUserID retrieves a list of the orders of an organization placed by
+// the specified user.
+func (s Store) QueryByUserID(ctx context.Context, orgID string, userID string, pageNumber int, rowsPerPage int) ([]Order, error) {
+	data := struct {
+		OrgID       string `db:"org_id"`
+		UserID      string `db:"user_id"`
+		Offset      int    `db:"offset"`
+		RowsPerPage int    `db:"rows_per_page"`
+	}{
+		OrgID:       orgID,
+		UserID:      userID,
+		Offset:      (pageNumber - 1) * rowsPerPage,
+		RowsPerPage: rowsPerPage,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		orders
+	WHERE
+		org_id = :org_id AND user_id = :user_id
+	ORDER BY
+		date_created DESC, order_id
+	OFFSET :offset ROWS FETCH NEXT :rows_per_page ROWS ONLY`
+
+	var ords []Order
+	if err := database.NamedQuerySlice(ctx, s.log, s.db, q, data, &ords); err != nil {
+		return nil, fmt.Errorf("selecting orders userID[%q]: %w", userID, err)
+	}
+
+	return ords, nil
+}
+
+// QueryKeyset retrieves up to limit orders of an organization, newest first,
+// that come after the keyset position. Only the orders placed by userID are
+// returned unless it is empty. When the keyset is Backward the orders before
+// the position are returned, nearest first. A nil keyset starts at the newest
+// order.
+func (s Store) QueryKeyset(ctx context.Context, orgID string, userID string, keyset *Keyset, limit int) ([]Order, error) {
+	data := map[string]interface{}{
+		"org_id": orgID,
+		"limit":  limit,
+	}
+
+	wc := []string{"org_id = :org_id"}
+	if userID != "" {
+		data["user_id"] = userID
+		wc = append(wc, "user_id = :user_id")
+	}
+
+	direction := order.DESC
+	if keyset != nil {
+		op := "<"
+		if keyset.Backward {
+			op = ">"
+			direction = order.ASC
+		}
+
+		data["keyset_date_created"] = keyset.DateCreated
+		data["keyset_order_id"] = keyset.OrderID
+		wc = append(wc, "(date_created, order_id) "+op+" (:keyset_date_created, :keyset_order_id)")
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		orders`
+
+	buf := bytes.NewBufferString(q)
+	buf.WriteString(" WHERE ")
+	buf.WriteString(strings.Join(wc, " AND "))
+	buf.WriteString(" ORDER BY date_created " + direction + ", order_id " + direction)
+	buf.WriteString(" FETCH FIRST :limit ROWS ONLY")
+
+	var ords []Order
+	if err := database.NamedQuerySlice(ctx, s.log, s.db, buf.String(), data, &ords); err != nil {
+		return nil, fmt.Errorf("selecting orders: %w", err)
+	}
+
+	return ords, nil
+}
+
+// QueryByID gets the specified order header of an organization from the
+// database.
+func (s Store) QueryByID(ctx context.Context, orgID string, orderID string) (Order, error) {
+	data := struct {
+		OrgID   string `db:"org_id"`
+		OrderID string `db:"order_id"`
+	}{
+		OrgID:   orgID,
+		OrderID: orderID,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		orders
+	WHERE
+		org_id = :org_id AND order_id = :order_id`
+
+	var ord Order
+	if err := database.NamedQueryStruct(ctx, s.log, s.db, q, data, &ord); err != nil {
+		return Order{}, fmt.Errorf("selecting orderID[%q]: %w", orderID, err)
+	}
+
+	return ord, nil
+}
+
+// QueryByIDForUpdate gets the specified order header of an organization and
+// locks the row until the surrounding transaction completes. It must be called
+// from a Store returned by Tran.
+func (s Store) QueryByIDForUpdate(ctx context.Context, orgID string, orderID string) (Order, error) {
+	data := struct {
+		OrgID   string `db:"org_id"`
+		OrderID string `db:"order_id"`
+	}{
+		OrgID:   orgID,
+		OrderID: orderID,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		orders
+	WHERE
+		org_id = :org_id AND order_id = :order_id
+	FOR UPDATE`
+
+	var ord Order
+	if err := database.NamedQueryStruct(ctx, s.log, s.db, q, data, &ord); err != nil {
+		return Order{}, fmt.Errorf("selecting orderID[%q] for update: %w", orderID, err)
+	}
+
+	return ord, nil
+}
+
+// QueryItems retrieves the lines for the specified set of orders.
+func (s Store) QueryItems(ctx context.Context, orderIDs []string) ([]OrderItem, error) {
+	data := struct {
+		OrderIDs pq.StringArray `db:"order_ids"`
+	}{
+		OrderIDs: orderIDs,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		order_items
+	WHERE
+		order_id = ANY(CAST(:order_ids AS UUID[]))
+	ORDER BY
+		order_id, order_item_id`
+
+	var items []OrderItem
+	if err := database.NamedQuerySlice(ctx, s.log, s.db, q, data, &items); err != nil {
+		return nil, fmt.Errorf("selecting order items: %w", err)
+	}
+
+	return items, nil
+}
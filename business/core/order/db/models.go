@@ -0,0 +1,36 @@
+package db
+
+import "time"
+
+// Order represents the structure we need for moving data
+// between the app and the database.
+type Order struct {
+	ID          string    `db:"order_id"`
+	OrgID       string    `db:"org_id"`
+	UserID      string    `db:"user_id"`
+	Status      string    `db:"status"`
+	Total       int64     `db:"total"`
+	Currency    string    `db:"currency"`
+	DateCreated time.Time `db:"date_created"`
+	DateUpdated time.Time `db:"date_updated"`
+}
+
+// Keyset is the position in the list of orders, newest first, to continue a
+// query from. When Backward is set the orders before the position are
+// returned instead of those after it.
+type Keyset struct {
+	DateCreated time.Time
+	OrderID     string
+	Backward    bool
+}
+
+// OrderItem represents a single line of an order.
+type OrderItem struct {
+	ID        string  `db:"order_item_id"`
+	OrderID   string  `db:"order_id"`
+	ProductID string  `db:"product_id"`
+	VariantID *string `db:"variant_id"`
+	Quantity  int     `db:"quantity"`
+	UnitPrice int64   `db:"unit_price"`
+	Total     int64   `db:"total"`
+}
@@ -0,0 +1,103 @@
+package order
+
+import (
+	"time"
+
+	"github.com/andrewyang17/service/business/core/order/db"
+	"github.com/andrewyang17/service/business/sys/money"
+)
+
+// Set of statuses an order can be in.
+const (
+	StatusPlaced    = "PLACED"
+	StatusCancelled = "CANCELLED"
+)
+
+// Order represents a checkout basket made up of one or more lines.
+type Order struct {
+	ID          string      `json:"id"`
+	UserID      string      `json:"user_id"`
+	Status      string      `json:"status"`
+	Total       money.Money `json:"total"`
+	Items       []OrderItem `json:"items"`
+	DateCreated time.Time   `json:"date_created"`
+	DateUpdated time.Time   `json:"date_updated"`
+}
+
+// OrderItem represents a single product line of an order. The unit price is
+// captured from the product, or its variant, at the time the order was placed.
+type OrderItem struct {
+	ID        string      `json:"id"`
+	ProductID string      `json:"product_id"`
+	VariantID string      `json:"variant_id,omitempty"`
+	Quantity  int         `json:"quantity"`
+	UnitPrice money.Money `json:"unit_price"`
+	Total     money.Money `json:"total"`
+}
+
+// NewOrder is what we require from clients when placing an Order.
+type NewOrder struct {
+	UserID string         `json:"user_id" validate:"required"`
+	Items  []NewOrderItem `json:"items" validate:"required,min=1,dive"`
+}
+
+// NewOrderItem is what we require from clients for each line of an Order.
+// When a VariantID is provided the line is for that variant of the product.
+type NewOrderItem struct {
+	ProductID string `json:"product_id" validate:"required,uuid"`
+	VariantID string `json:"variant_id" validate:"omitempty,uuid"`
+	Quantity  int    `json:"quantity" validate:"gte=1"`
+}
+
+// Page is a page of orders retrieved with a cursor. Next and Prev are opaque
+// tokens for the pages after and before this one, and are empty when there
+// is no such page.
+type Page struct {
+	Items []Order `json:"items"`
+	Next  string  `json:"next,omitempty"`
+	Prev  string  `json:"prev,omitempty"`
+}
+
+// =============================================================================
+
+func toOrder(dbOrd db.Order, dbItems []db.OrderItem) Order {
+	items := make([]OrderItem, 0, len(dbItems))
+	for _, dbItem := range dbItems {
+		var variantID string
+		if dbItem.VariantID != nil {
+			variantID = *dbItem.VariantID
+		}
+
+		items = append(items, OrderItem{
+			ID:        dbItem.ID,
+			ProductID: dbItem.ProductID,
+			VariantID: variantID,
+			Quantity:  dbItem.Quantity,
+			UnitPrice: money.New(dbItem.UnitPrice, dbOrd.Currency),
+			Total:     money.New(dbItem.Total, dbOrd.Currency),
+		})
+	}
+
+	return Order{
+		ID:          dbOrd.ID,
+		UserID:      dbOrd.UserID,
+		Status:      dbOrd.Status,
+		Total:       money.New(dbOrd.Total, dbOrd.Currency),
+		Items:       items,
+		DateCreated: dbOrd.DateCreated,
+		DateUpdated: dbOrd.DateUpdated,
+	}
+}
+
+func toOrderSlice(dbOrds []db.Order, dbItems []db.OrderItem) []Order {
+	byOrder := make(map[string][]db.OrderItem)
+	for _, dbItem := range dbItems {
+		byOrder[dbItem.OrderID] = append(byOrder[dbItem.OrderID], dbItem)
+	}
+
+	ords := make([]Order, len(dbOrds))
+	for i, dbOrd := range dbOrds {
+		ords[i] = toOrder(dbOrd, byOrder[dbOrd.ID])
+	}
+	return ords
+}
@@ -0,0 +1,482 @@
+// Package order provides the core business API for multi-line orders.
+package order
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/andrewyang17/service/business/core/order/db"
+	productDB "github.com/andrewyang17/service/business/core/product/db"
+	"github.com/andrewyang17/service/business/data/cursor"
+	orderby "github.com/andrewyang17/service/business/data/order"
+	"github.com/andrewyang17/service/business/sys/database"
+	"github.com/andrewyang17/service/business/sys/tenant"
+	"github.com/andrewyang17/service/business/sys/validate"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrNotFound          = errors.New("order not found")
+	ErrInvalidID         = errors.New("ID is not in its proper form")
+	ErrProductNotFound   = errors.New("product not found")
+	ErrVariantNotFound   = errors.New("variant not found")
+	ErrInsufficientStock = errors.New("not enough product in stock")
+	ErrNotCancellable    = errors.New("order can no longer be cancelled")
+	ErrMixedCurrency     = errors.New("every product in an order must be priced in the same currency")
+)
+
+// Core manages the set of APIs for order access.
+type Core struct {
+	store        db.Store
+	productStore productDB.Store
+}
+
+// NewCore constructs a core for order api access.
+func NewCore(log *zap.SugaredLogger, sqlxDB *sqlx.DB) Core {
+	return Core{
+		store:        db.NewStore(log, sqlxDB),
+		productStore: productDB.NewStore(log, sqlxDB),
+	}
+}
+
+// Create places a new order. Every product and variant in the order is locked,
+// checked and decremented inside a single transaction, so either every line is
+// fulfilled or nothing changes. Products and then variants are locked in a
+// stable order to keep concurrent orders from deadlocking each other. The
+// order is charged in the currency its products are priced in, which must be
+// the same for every line.
+func (c Core) Create(ctx context.Context, no NewOrder, now time.Time) (Order, error) {
+	if err := validate.Check(no); err != nil {
+		return Order{}, fmt.Errorf("validating data: %w", err)
+	}
+
+	// The same product or variant may appear on more than one line, so stock
+	// has to be checked against the combined quantity. Every product is
+	// locked, even when only its variants are ordered, since the order is
+	// priced in the currency of the product.
+	want := make(map[string]int)
+	wantVariants := make(map[string]int)
+	for _, item := range no.Items {
+		switch item.VariantID {
+		case "":
+			want[item.ProductID] += item.Quantity
+		default:
+			if _, exists := want[item.ProductID]; !exists {
+				want[item.ProductID] = 0
+			}
+			wantVariants[item.VariantID] += item.Quantity
+		}
+	}
+
+	productIDs := make([]string, 0, len(want))
+	for productID := range want {
+		productIDs = append(productIDs, productID)
+	}
+	sort.Strings(productIDs)
+
+	variantIDs := make([]string, 0, len(wantVariants))
+	for variantID := range wantVariants {
+		variantIDs = append(variantIDs, variantID)
+	}
+	sort.Strings(variantIDs)
+
+	orgID, err := tenant.Get(ctx)
+	if err != nil {
+		return Order{}, err
+	}
+
+	dbOrd := db.Order{
+		ID:          validate.GenerateID(),
+		OrgID:       orgID,
+		UserID:      no.UserID,
+		Status:      StatusPlaced,
+		DateCreated: now,
+		DateUpdated: now,
+	}
+	dbItems := make([]db.OrderItem, len(no.Items))
+
+	tran := func(tx sqlx.ExtContext) error {
+		products := c.productStore.Tran(tx)
+
+		prices := make(map[string]int64)
+		for _, productID := range productIDs {
+			dbPrd, err := products.QueryByIDForUpdate(ctx, orgID, productID)
+			if err != nil {
+				if errors.Is(err, database.ErrDBNotFound) {
+					return fmt.Errorf("%w: productID[%s]", ErrProductNotFound, productID)
+				}
+				return fmt.Errorf("lock product: %w", err)
+			}
+
+			switch {
+			case dbOrd.Currency == "":
+				dbOrd.Currency = dbPrd.Currency
+			case dbOrd.Currency != dbPrd.Currency:
+				return fmt.Errorf("%w: productID[%s]", ErrMixedCurrency, productID)
+			}
+
+			if dbPrd.Quantity < want[productID] {
+				return fmt.Errorf("%w: productID[%s]", ErrInsufficientStock, productID)
+			}
+
+			if want[productID] > 0 {
+				if err := products.UpdateQuantity(ctx, productID, dbPrd.Quantity-want[productID], now); err != nil {
+					return fmt.Errorf("update quantity: %w", err)
+				}
+			}
+
+			prices[productID] = dbPrd.Cost
+		}
+
+		variantPrices := make(map[string]int64)
+		variantProducts := make(map[string]string)
+		for _, variantID := range variantIDs {
+			dbVrt, err := products.QueryVariantByIDForUpdate(ctx, orgID, variantID)
+			if err != nil {
+				if errors.Is(err, database.ErrDBNotFound) {
+					return fmt.Errorf("%w: variantID[%s]", ErrVariantNotFound, variantID)
+				}
+				return fmt.Errorf("lock variant: %w", err)
+			}
+
+			if dbVrt.Quantity < wantVariants[variantID] {
+				return fmt.Errorf("%w: variantID[%s]", ErrInsufficientStock, variantID)
+			}
+
+			if err := products.UpdateVariantQuantity(ctx, variantID, dbVrt.Quantity-wantVariants[variantID], now); err != nil {
+				return fmt.Errorf("update variant quantity: %w", err)
+			}
+
+			variantPrices[variantID] = prices[dbVrt.ProductID]
+			if dbVrt.Cost != nil {
+				variantPrices[variantID] = *dbVrt.Cost
+			}
+			variantProducts[variantID] = dbVrt.ProductID
+		}
+
+		for i, item := range no.Items {
+			dbItems[i] = db.OrderItem{
+				ID:        validate.GenerateID(),
+				OrderID:   dbOrd.ID,
+				ProductID: item.ProductID,
+				Quantity:  item.Quantity,
+				UnitPrice: prices[item.ProductID],
+			}
+
+			if item.VariantID != "" {
+				if variantProducts[item.VariantID] != item.ProductID {
+					return fmt.Errorf("%w: variantID[%s]", ErrVariantNotFound, item.VariantID)
+				}
+
+				variantID := item.VariantID
+				dbItems[i].VariantID = &variantID
+				dbItems[i].UnitPrice = variantPrices[variantID]
+			}
+
+			dbItems[i].Total = dbItems[i].UnitPrice * int64(item.Quantity)
+			dbOrd.Total += dbItems[i].Total
+		}
+
+		orders := c.store.Tran(tx)
+
+		if err := orders.Create(ctx, dbOrd); err != nil {
+			return fmt.Errorf("create: %w", err)
+		}
+
+		for _, dbItem := range dbItems {
+			if err := orders.CreateItem(ctx, dbItem); err != nil {
+				return fmt.Errorf("create item: %w", err)
+			}
+		}
+
+		return nil
+	}
+
+	if err := c.store.WithinTran(ctx, tran); err != nil {
+		return Order{}, fmt.Errorf("tran: %w", err)
+	}
+
+	return toOrder(dbOrd, dbItems), nil
+}
+
+// Cancel marks a placed order as cancelled and returns its stock to the
+// products and variants it was taken from.
+func (c Core) Cancel(ctx context.Context, orderID string, now time.Time) error {
+	if err := validate.CheckID(orderID); err != nil {
+		return ErrInvalidID
+	}
+
+	orgID, err := tenant.Get(ctx)
+	if err != nil {
+		return err
+	}
+
+	tran := func(tx sqlx.ExtContext) error {
+		orders := c.store.Tran(tx)
+
+		dbOrd, err := orders.QueryByIDForUpdate(ctx, orgID, orderID)
+		if err != nil {
+			if errors.Is(err, database.ErrDBNotFound) {
+				return ErrNotFound
+			}
+			return fmt.Errorf("lock order: %w", err)
+		}
+
+		if dbOrd.Status != StatusPlaced {
+			return ErrNotCancellable
+		}
+
+		dbItems, err := orders.QueryItems(ctx, []string{orderID})
+		if err != nil {
+			return fmt.Errorf("query items: %w", err)
+		}
+
+		restock := make(map[string]int)
+		restockVariants := make(map[string]int)
+		for _, dbItem := range dbItems {
+			switch dbItem.VariantID {
+			case nil:
+				restock[dbItem.ProductID] += dbItem.Quantity
+			default:
+				restockVariants[*dbItem.VariantID] += dbItem.Quantity
+			}
+		}
+
+		productIDs := make([]string, 0, len(restock))
+		for productID := range restock {
+			productIDs = append(productIDs, productID)
+		}
+		sort.Strings(productIDs)
+
+		products := c.productStore.Tran(tx)
+		for _, productID := range productIDs {
+			dbPrd, err := products.QueryByIDForUpdate(ctx, orgID, productID)
+			if err != nil {
+				if errors.Is(err, database.ErrDBNotFound) {
+					continue
+				}
+				return fmt.Errorf("lock product: %w", err)
+			}
+
+			if err := products.UpdateQuantity(ctx, productID, dbPrd.Quantity+restock[productID], now); err != nil {
+				return fmt.Errorf("update quantity: %w", err)
+			}
+		}
+
+		variantIDs := make([]string, 0, len(restockVariants))
+		for variantID := range restockVariants {
+			variantIDs = append(variantIDs, variantID)
+		}
+		sort.Strings(variantIDs)
+
+		for _, variantID := range variantIDs {
+			dbVrt, err := products.QueryVariantByIDForUpdate(ctx, orgID, variantID)
+			if err != nil {
+				if errors.Is(err, database.ErrDBNotFound) {
+					continue
+				}
+				return fmt.Errorf("lock variant: %w", err)
+			}
+
+			if err := products.UpdateVariantQuantity(ctx, variantID, dbVrt.Quantity+restockVariants[variantID], now); err != nil {
+				return fmt.Errorf("update variant quantity: %w", err)
+			}
+		}
+
+		if err := orders.UpdateStatus(ctx, orderID, StatusCancelled, now); err != nil {
+			return fmt.Errorf("update status: %w", err)
+		}
+
+		return nil
+	}
+
+	if err := c.store.WithinTran(ctx, tran); err != nil {
+		return fmt.Errorf("tran: %w", err)
+	}
+
+	return nil
+}
+
+// Query retrieves a list of the existing orders of the organization the
+// context acts for from the database.
+func (c Core) Query(ctx context.Context, pageNumber int, rowsPerPage int) ([]Order, error) {
+	orgID, err := tenant.Get(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	dbOrds, err := c.store.Query(ctx, orgID, pageNumber, rowsPerPage)
+	if err != nil {
+		return nil, fmt.Errorf("query: %w", err)
+	}
+
+	return c.withItems(ctx, dbOrds)
+}
+
+// QueryByUserID retrieves a list of orders placed by the specified user.
+func (c Core) QueryByUserID(ctx context.Context, userID string, pageNumber int, rowsPerPage int) ([]Order, error) {
+	if err := validate.CheckID(userID); err != nil {
+		return nil, ErrInvalidID
+	}
+
+	orgID, err := tenant.Get(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	dbOrds, err := c.store.QueryByUserID(ctx, orgID, userID, pageNumber, rowsPerPage)
+	if err != nil {
+		return nil, fmt.Errorf("query: %w", err)
+	}
+
+	return c.withItems(ctx, dbOrds)
+}
+
+// QueryCursor retrieves a page of orders, including their lines, newest
+// first, starting from the position marked by the cursor token. An empty
+// token starts at the newest order. Only the orders placed by userID are
+// returned unless it is empty.
+func (c Core) QueryCursor(ctx context.Context, userID string, token string, rows int) (Page, error) {
+	if userID != "" {
+		if err := validate.CheckID(userID); err != nil {
+			return Page{}, ErrInvalidID
+		}
+	}
+
+	keyset, err := decodeKeyset(token)
+	if err != nil {
+		return Page{}, err
+	}
+
+	orgID, err := tenant.Get(ctx)
+	if err != nil {
+		return Page{}, err
+	}
+
+	// One extra order is asked for to find out if there is another page.
+	dbOrds, err := c.store.QueryKeyset(ctx, orgID, userID, keyset, rows+1)
+	if err != nil {
+		return Page{}, fmt.Errorf("query: %w", err)
+	}
+
+	more := len(dbOrds) > rows
+	if more {
+		dbOrds = dbOrds[:rows]
+	}
+
+	backward := keyset != nil && keyset.Backward
+	if backward {
+		for i, j := 0, len(dbOrds)-1; i < j; i, j = i+1, j-1 {
+			dbOrds[i], dbOrds[j] = dbOrds[j], dbOrds[i]
+		}
+	}
+
+	items, err := c.withItems(ctx, dbOrds)
+	if err != nil {
+		return Page{}, err
+	}
+
+	page := Page{
+		Items: items,
+	}
+
+	if len(dbOrds) == 0 {
+		return page, nil
+	}
+
+	first, last := dbOrds[0], dbOrds[len(dbOrds)-1]
+
+	// Moving backward means there are always orders after this page, and
+	// moving forward from a cursor means there are always orders before it.
+	if more || backward {
+		page.Next = cursor.New(cursorOrder, last.DateCreated.Format(time.RFC3339Nano), last.ID, false).Encode()
+	}
+	if (more && backward) || (keyset != nil && !backward) {
+		page.Prev = cursor.New(cursorOrder, first.DateCreated.Format(time.RFC3339Nano), first.ID, true).Encode()
+	}
+
+	return page, nil
+}
+
+// QueryByID gets the specified order, including its lines, from the database.
+func (c Core) QueryByID(ctx context.Context, orderID string) (Order, error) {
+	if err := validate.CheckID(orderID); err != nil {
+		return Order{}, ErrInvalidID
+	}
+
+	orgID, err := tenant.Get(ctx)
+	if err != nil {
+		return Order{}, err
+	}
+
+	dbOrd, err := c.store.QueryByID(ctx, orgID, orderID)
+	if err != nil {
+		if errors.Is(err, database.ErrDBNotFound) {
+			return Order{}, ErrNotFound
+		}
+		return Order{}, fmt.Errorf("query: %w", err)
+	}
+
+	dbItems, err := c.store.QueryItems(ctx, []string{orderID})
+	if err != nil {
+		return Order{}, fmt.Errorf("query items: %w", err)
+	}
+
+	return toOrder(dbOrd, dbItems), nil
+}
+
+// =============================================================================
+
+// cursorOrder is the order orders are paged through with a cursor.
+var cursorOrder = orderby.NewBy("date_created", orderby.DESC)
+
+// decodeKeyset turns a cursor token into the position in the list of orders
+// to continue from. An empty token starts at the newest order.
+func decodeKeyset(token string) (*db.Keyset, error) {
+	if token == "" {
+		return nil, nil
+	}
+
+	cur, err := cursor.Decode(token)
+	if err != nil {
+		return nil, err
+	}
+
+	dateCreated, err := time.Parse(time.RFC3339Nano, cur.Value)
+	if err != nil || cur.OrderBy() != cursorOrder {
+		return nil, validate.FieldErrors{
+			{Field: "cursor", Error: "cursor is not valid"},
+		}
+	}
+
+	keyset := db.Keyset{
+		DateCreated: dateCreated,
+		OrderID:     cur.ID,
+		Backward:    cur.Backward,
+	}
+
+	return &keyset, nil
+}
+
+// withItems loads the lines for a page of orders with a single query.
+func (c Core) withItems(ctx context.Context, dbOrds []db.Order) ([]Order, error) {
+	if len(dbOrds) == 0 {
+		return []Order{}, nil
+	}
+
+	orderIDs := make([]string, len(dbOrds))
+	for i, dbOrd := range dbOrds {
+		orderIDs[i] = dbOrd.ID
+	}
+
+	dbItems, err := c.store.QueryItems(ctx, orderIDs)
+	if err != nil {
+		return nil, fmt.Errorf("query items: %w", err)
+	}
+
+	return toOrderSlice(dbOrds, dbItems), nil
+}
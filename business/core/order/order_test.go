@@ -0,0 +1,132 @@
+package order_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/andrewyang17/service/business/core/order"
+	"github.com/andrewyang17/service/business/core/organization"
+	"github.com/andrewyang17/service/business/core/product"
+	"github.com/andrewyang17/service/business/data/dbtest"
+	"github.com/andrewyang17/service/business/sys/money"
+	"github.com/andrewyang17/service/business/sys/tenant"
+	"github.com/andrewyang17/service/foundation/docker"
+)
+
+var c *docker.Container
+
+func TestMain(m *testing.M) {
+	var err error
+	c, err = dbtest.StartDB()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer dbtest.StopDB(c)
+
+	m.Run()
+}
+
+const (
+	adminID = "5cf37266-3473-4006-984f-9325122678b7"
+	userID  = "45b5fbd3-755f-4379-8f07-a58d4a30fa2f"
+)
+
+func TestOrder(t *testing.T) {
+	log, db, teardown := dbtest.NewUnit(t, c, "testorder")
+	t.Cleanup(teardown)
+
+	prdCore := product.NewCore(log, db)
+	core := order.NewCore(log, db)
+
+	t.Log("Given the need to work with Order records.")
+	{
+		testID := 0
+		t.Logf("\tTest %d:\tWhen handling a multi-line Order.", testID)
+		{
+			ctx := tenant.Set(context.Background(), organization.DefaultID)
+			now := time.Date(2022, time.January, 8, 17, 56, 0, 0, time.UTC)
+
+			prd1, err := prdCore.Create(ctx, product.NewProduct{Name: "Pens", Cost: money.New(2, money.USD), Quantity: 10, UserID: adminID}, now)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to create a product : %s.", dbtest.Failed, testID, err)
+			}
+
+			prd2, err := prdCore.Create(ctx, product.NewProduct{Name: "Paper", Cost: money.New(5, money.USD), Quantity: 3, UserID: adminID}, now)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to create a product : %s.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould be able to create products.", dbtest.Success, testID)
+
+			no := order.NewOrder{
+				UserID: userID,
+				Items: []order.NewOrderItem{
+					{ProductID: prd1.ID, Quantity: 4},
+					{ProductID: prd2.ID, Quantity: 2},
+				},
+			}
+
+			ord, err := core.Create(ctx, no, now)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to place an order : %s.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould be able to place an order.", dbtest.Success, testID)
+
+			if ord.Total != money.New(18, money.USD) || len(ord.Items) != 2 || ord.Status != order.StatusPlaced {
+				t.Fatalf("\t%s\tTest %d:\tShould get the expected order totals : %+v.", dbtest.Failed, testID, ord)
+			}
+			t.Logf("\t%s\tTest %d:\tShould get the expected order totals.", dbtest.Success, testID)
+
+			// The second line can't be filled, so nothing may be taken from the
+			// first product either.
+			no.Items[1].Quantity = 2
+			if _, err := core.Create(ctx, no, now); !errors.Is(err, order.ErrInsufficientStock) {
+				t.Fatalf("\t%s\tTest %d:\tShould NOT be able to oversell a line : %v.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould NOT be able to oversell a line.", dbtest.Success, testID)
+
+			saved, err := prdCore.QueryByID(ctx, prd1.ID)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to retrieve product : %s.", dbtest.Failed, testID, err)
+			}
+
+			if saved.Quantity != 6 {
+				t.Fatalf("\t%s\tTest %d:\tShould leave stock untouched by the failed order : got %d want %d.", dbtest.Failed, testID, saved.Quantity, 6)
+			}
+			t.Logf("\t%s\tTest %d:\tShould leave stock untouched by the failed order.", dbtest.Success, testID)
+
+			if err := core.Cancel(ctx, ord.ID, now); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to cancel the order : %s.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould be able to cancel the order.", dbtest.Success, testID)
+
+			saved, err = prdCore.QueryByID(ctx, prd1.ID)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to retrieve product : %s.", dbtest.Failed, testID, err)
+			}
+
+			if saved.Quantity != 10 {
+				t.Fatalf("\t%s\tTest %d:\tShould restock cancelled lines : got %d want %d.", dbtest.Failed, testID, saved.Quantity, 10)
+			}
+			t.Logf("\t%s\tTest %d:\tShould restock cancelled lines.", dbtest.Success, testID)
+
+			if err := core.Cancel(ctx, ord.ID, now); !errors.Is(err, order.ErrNotCancellable) {
+				t.Fatalf("\t%s\tTest %d:\tShould NOT be able to cancel the order twice : %v.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould NOT be able to cancel the order twice.", dbtest.Success, testID)
+
+			orders, err := core.QueryByUserID(ctx, userID, 1, 10)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to retrieve orders by user : %s.", dbtest.Failed, testID, err)
+			}
+
+			if len(orders) != 1 || len(orders[0].Items) != 2 || orders[0].Status != order.StatusCancelled {
+				t.Fatalf("\t%s\tTest %d:\tShould get back the cancelled order with its lines : %+v.", dbtest.Failed, testID, orders)
+			}
+			t.Logf("\t%s\tTest %d:\tShould get back the cancelled order with its lines.", dbtest.Success, testID)
+		}
+	}
+}
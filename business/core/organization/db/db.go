@@ -0,0 +1,241 @@
+// Package db contains organization related CRUD functionality.
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/andrewyang17/service/business/sys/database"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+)
+
+type Store struct {
+	log          *zap.SugaredLogger
+	tr           database.Transactor
+	db           sqlx.ExtContext
+	isWithinTran bool
+}
+
+func NewStore(log *zap.SugaredLogger, db *sqlx.DB) Store {
+	return Store{
+		log: log,
+		tr:  db,
+		db:  db,
+	}
+}
+
+// WithinTran runs passed function and do commit/rollback at the end.
+func (s Store) WithinTran(ctx context.Context, fn func(extContext sqlx.ExtContext) error) error {
+	if s.isWithinTran {
+		return fn(s.db)
+	}
+	return database.WithinTran(ctx, s.log, s.tr, fn)
+}
+
+// Tran return new Store with transaction in it.
+func (s Store) Tran(tx sqlx.ExtContext) Store {
+	return Store{
+		log:          s.log,
+		tr:           s.tr,
+		db:           tx,
+		isWithinTran: true,
+	}
+}
+
+// Create adds an Organization to the database.
+func (s Store) Create(ctx context.Context, org Organization) error {
+	const q = `
+	INSERT INTO organizations
+		(org_id, name, date_created, date_updated)
+	VALUES
+		(:org_id, :name, :date_created, :date_updated)`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, org); err != nil {
+		return fmt.Errorf("inserting organization: %w", err)
+	}
+
+	return nil
+}
+
+// Update replaces the name of an Organization in the database.
+func (s Store) Update(ctx context.Context, org Organization) error {
+	const q = `
+	UPDATE
+		organizations
+	SET
+		"name" = :name,
+		"date_updated" = :date_updated
+	WHERE
+		org_id = :org_id`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, org); err != nil {
+		return fmt.Errorf("updating orgID[%q]: %w", org.ID, err)
+	}
+
+	return nil
+}
+
+// Query gets all Organizations from the database.
+func (s Store) Query(ctx context.Context) ([]Organization, error) {
+	const q = `
+	SELECT
+		*
+	FROM
+		organizations
+	ORDER BY
+		name, org_id`
+
+	var orgs []Organization
+	if err := database.NamedQuerySlice(ctx, s.log, s.db, q, struct{}{}, &orgs); err != nil {
+		return nil, fmt.Errorf("selecting organizations: %w", err)
+	}
+
+	return orgs, nil
+}
+
+// QueryByID finds the organization identified by a given ID.
+func (s Store) QueryByID(ctx context.Context, orgID string) (Organization, error) {
+	data := struct {
+		OrgID string `db:"org_id"`
+	}{
+		OrgID: orgID,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		organizations
+	WHERE
+		org_id = :org_id`
+
+	var org Organization
+	if err := database.NamedQueryStruct(ctx, s.log, s.db, q, data, &org); err != nil {
+		return Organization{}, fmt.Errorf("selecting orgID[%q]: %w", orgID, err)
+	}
+
+	return org, nil
+}
+
+// QueryByUserID finds the organizations a given user belongs to, in the
+// order the user joined them.
+func (s Store) QueryByUserID(ctx context.Context, userID string) ([]Organization, error) {
+	data := struct {
+		UserID string `db:"user_id"`
+	}{
+		UserID: userID,
+	}
+
+	const q = `
+	SELECT
+		o.*
+	FROM
+		organizations AS o
+	JOIN
+		user_orgs AS uo ON uo.org_id = o.org_id
+	WHERE
+		uo.user_id = :user_id
+	ORDER BY
+		uo.date_created, o.org_id`
+
+	var orgs []Organization
+	if err := database.NamedQuerySlice(ctx, s.log, s.db, q, data, &orgs); err != nil {
+		return nil, fmt.Errorf("selecting organizations userID[%q]: %w", userID, err)
+	}
+
+	return orgs, nil
+}
+
+// AddMember adds a user to an organization. Adding a user that already
+// belongs to the organization returns database.ErrDBDuplicatedEntry.
+func (s Store) AddMember(ctx context.Context, mbr Member) error {
+	const q = `
+	INSERT INTO user_orgs
+		(user_id, org_id, roles, date_created)
+	VALUES
+		(:user_id, :org_id, :roles, :date_created)`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, mbr); err != nil {
+		return fmt.Errorf("inserting member userID[%q] orgID[%q]: %w", mbr.UserID, mbr.OrgID, err)
+	}
+
+	return nil
+}
+
+// UpdateRoles replaces the roles a user holds in an organization.
+func (s Store) UpdateRoles(ctx context.Context, orgID string, userID string, roles []string) error {
+	data := struct {
+		OrgID  string         `db:"org_id"`
+		UserID string         `db:"user_id"`
+		Roles  pq.StringArray `db:"roles"`
+	}{
+		OrgID:  orgID,
+		UserID: userID,
+		Roles:  roles,
+	}
+
+	const q = `
+	UPDATE
+		user_orgs
+	SET
+		"roles" = :roles
+	WHERE
+		org_id = :org_id AND user_id = :user_id`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, data); err != nil {
+		return fmt.Errorf("updating roles userID[%q] orgID[%q]: %w", userID, orgID, err)
+	}
+
+	return nil
+}
+
+// RemoveMember removes a user from an organization.
+func (s Store) RemoveMember(ctx context.Context, orgID string, userID string) error {
+	data := struct {
+		OrgID  string `db:"org_id"`
+		UserID string `db:"user_id"`
+	}{
+		OrgID:  orgID,
+		UserID: userID,
+	}
+
+	const q = `
+	DELETE FROM
+		user_orgs
+	WHERE
+		org_id = :org_id AND user_id = :user_id`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, data); err != nil {
+		return fmt.Errorf("deleting member userID[%q] orgID[%q]: %w", userID, orgID, err)
+	}
+
+	return nil
+}
+
+// QueryMembers gets the users that belong to an organization.
+func (s Store) QueryMembers(ctx context.Context, orgID string) ([]Member, error) {
+	data := struct {
+		OrgID string `db:"org_id"`
+	}{
+		OrgID: orgID,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		user_orgs
+	WHERE
+		org_id = :org_id
+	ORDER BY
+		date_created, user_id`
+
+	var mbrs []Member
+	if err := database.NamedQuerySlice(ctx, s.log, s.db, q, data, &mbrs); err != nil {
+		return nil, fmt.Errorf("selecting members orgID[%q]: %w", orgID, err)
+	}
+
+	return mbrs, nil
+}
@@ -0,0 +1,25 @@
+package db
+
+import (
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Organization represents the structure we need for moving data
+// between the app and the database.
+type Organization struct {
+	ID          string    `db:"org_id"`
+	Name        string    `db:"name"`
+	DateCreated time.Time `db:"date_created"`
+	DateUpdated time.Time `db:"date_updated"`
+}
+
+// Member represents the structure we need for moving data
+// between the app and the database.
+type Member struct {
+	UserID      string         `db:"user_id"`
+	OrgID       string         `db:"org_id"`
+	Roles       pq.StringArray `db:"roles"`
+	DateCreated time.Time      `db:"date_created"`
+}
@@ -0,0 +1,73 @@
+package organization
+
+import (
+	"time"
+	"unsafe"
+
+	"github.com/andrewyang17/service/business/core/organization/db"
+)
+
+// Organization is a tenant of the service, such as one storefront. Products,
+// sales and orders belong to exactly one organization.
+type Organization struct {
+	ID          string    `json:"id"`
+	Name        string    `json:"name"`
+	DateCreated time.Time `json:"date_created"`
+	DateUpdated time.Time `json:"date_updated"`
+}
+
+// NewOrganization contains information needed to create a new Organization.
+type NewOrganization struct {
+	Name string `json:"name" validate:"required,max=100"`
+}
+
+// UpdateOrganization defines what information may be provided to modify an
+// existing Organization. All fields are optional so clients can send just the
+// fields they want changed.
+type UpdateOrganization struct {
+	Name *string `json:"name" validate:"omitempty,max=100"`
+}
+
+// Member records that a user belongs to an organization and the roles they
+// hold in it.
+type Member struct {
+	UserID      string    `json:"user_id"`
+	OrgID       string    `json:"org_id"`
+	Roles       []string  `json:"roles"`
+	DateCreated time.Time `json:"date_created"`
+}
+
+// NewMember is what we require from clients when adding a user to an
+// organization. Members are only given the USER role when no roles are sent.
+type NewMember struct {
+	UserID string   `json:"user_id" validate:"required,uuid"`
+	Roles  []string `json:"roles"`
+}
+
+// =============================================================================
+
+func toOrganization(dbOrg db.Organization) Organization {
+	po := (*Organization)(unsafe.Pointer(&dbOrg))
+	return *po
+}
+
+func toOrganizationSlice(dbOrgs []db.Organization) []Organization {
+	orgs := make([]Organization, len(dbOrgs))
+	for i, dbOrg := range dbOrgs {
+		orgs[i] = toOrganization(dbOrg)
+	}
+	return orgs
+}
+
+func toMember(dbMbr db.Member) Member {
+	pm := (*Member)(unsafe.Pointer(&dbMbr))
+	return *pm
+}
+
+func toMemberSlice(dbMbrs []db.Member) []Member {
+	mbrs := make([]Member, len(dbMbrs))
+	for i, dbMbr := range dbMbrs {
+		mbrs[i] = toMember(dbMbr)
+	}
+	return mbrs
+}
@@ -0,0 +1,302 @@
+// Package organization provides the core business API for the organizations
+// that own products, sales and orders and the users that belong to them.
+package organization
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/andrewyang17/service/business/core/organization/db"
+	roleDB "github.com/andrewyang17/service/business/core/role/db"
+	userDB "github.com/andrewyang17/service/business/core/user/db"
+	"github.com/andrewyang17/service/business/sys/auth"
+	"github.com/andrewyang17/service/business/sys/database"
+	"github.com/andrewyang17/service/business/sys/validate"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+// DefaultID identifies the organization that owned everything before
+// organizations were introduced. Users that register without naming an
+// organization join it.
+const DefaultID = "7c5e6f32-0a1d-4b8e-9f2c-3d4a5b6c7d8e"
+
+var (
+	ErrNotFound      = errors.New("organization not found")
+	ErrInvalidID     = errors.New("ID is not in its proper form")
+	ErrUserNotFound  = errors.New("user not found")
+	ErrAlreadyMember = errors.New("user is already a member of the organization")
+)
+
+// Core manages the set of APIs for organization access.
+type Core struct {
+	store     db.Store
+	userStore userDB.Store
+	roleStore roleDB.Store
+}
+
+// NewCore constructs a core for organization api access.
+func NewCore(log *zap.SugaredLogger, sqlxDB *sqlx.DB) Core {
+	return Core{
+		store:     db.NewStore(log, sqlxDB),
+		userStore: userDB.NewStore(log, sqlxDB),
+		roleStore: roleDB.NewStore(log, sqlxDB),
+	}
+}
+
+// Create defines a new organization with the specified user as its first
+// member and administrator.
+func (c Core) Create(ctx context.Context, no NewOrganization, userID string, now time.Time) (Organization, error) {
+	if err := validate.Check(no); err != nil {
+		return Organization{}, fmt.Errorf("validating data: %w", err)
+	}
+
+	dbOrg := db.Organization{
+		ID:          validate.GenerateID(),
+		Name:        no.Name,
+		DateCreated: now,
+		DateUpdated: now,
+	}
+
+	tran := func(tx sqlx.ExtContext) error {
+		store := c.store.Tran(tx)
+
+		if err := store.Create(ctx, dbOrg); err != nil {
+			return fmt.Errorf("create: %w", err)
+		}
+
+		dbMbr := db.Member{
+			UserID:      userID,
+			OrgID:       dbOrg.ID,
+			Roles:       []string{auth.RoleAdmin, auth.RoleUser},
+			DateCreated: now,
+		}
+
+		if err := store.AddMember(ctx, dbMbr); err != nil {
+			return fmt.Errorf("add member: %w", err)
+		}
+
+		return nil
+	}
+
+	if err := c.store.WithinTran(ctx, tran); err != nil {
+		return Organization{}, fmt.Errorf("tran: %w", err)
+	}
+
+	return toOrganization(dbOrg), nil
+}
+
+// Update replaces the name of an organization.
+func (c Core) Update(ctx context.Context, orgID string, uo UpdateOrganization, now time.Time) error {
+	if err := validate.CheckID(orgID); err != nil {
+		return ErrInvalidID
+	}
+
+	if err := validate.Check(uo); err != nil {
+		return fmt.Errorf("validating data: %w", err)
+	}
+
+	dbOrg, err := c.store.QueryByID(ctx, orgID)
+	if err != nil {
+		if errors.Is(err, database.ErrDBNotFound) {
+			return ErrNotFound
+		}
+		return fmt.Errorf("query: %w", err)
+	}
+
+	if uo.Name != nil {
+		dbOrg.Name = *uo.Name
+	}
+	dbOrg.DateUpdated = now
+
+	if err := c.store.Update(ctx, dbOrg); err != nil {
+		return fmt.Errorf("update: %w", err)
+	}
+
+	return nil
+}
+
+// Query gets all Organizations from the database.
+func (c Core) Query(ctx context.Context) ([]Organization, error) {
+	dbOrgs, err := c.store.Query(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("query: %w", err)
+	}
+
+	return toOrganizationSlice(dbOrgs), nil
+}
+
+// QueryByID finds the organization identified by a given ID.
+func (c Core) QueryByID(ctx context.Context, orgID string) (Organization, error) {
+	if err := validate.CheckID(orgID); err != nil {
+		return Organization{}, ErrInvalidID
+	}
+
+	dbOrg, err := c.store.QueryByID(ctx, orgID)
+	if err != nil {
+		if errors.Is(err, database.ErrDBNotFound) {
+			return Organization{}, ErrNotFound
+		}
+		return Organization{}, fmt.Errorf("query: %w", err)
+	}
+
+	return toOrganization(dbOrg), nil
+}
+
+// QueryByUserID gets the organizations a user belongs to.
+func (c Core) QueryByUserID(ctx context.Context, userID string) ([]Organization, error) {
+	if err := validate.CheckID(userID); err != nil {
+		return nil, ErrInvalidID
+	}
+
+	dbOrgs, err := c.store.QueryByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("query: %w", err)
+	}
+
+	return toOrganizationSlice(dbOrgs), nil
+}
+
+// IsMember reports whether a user belongs to an organization.
+func (c Core) IsMember(ctx context.Context, orgID string, userID string) (bool, error) {
+	orgs, err := c.QueryByUserID(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+
+	for _, org := range orgs {
+		if org.ID == orgID {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// AddMember adds a user to an organization with the roles they hold in it,
+// or just the USER role when none are given. ErrAlreadyMember is returned
+// when the user belongs to the organization already.
+func (c Core) AddMember(ctx context.Context, orgID string, nm NewMember, now time.Time) (Member, error) {
+	if err := validate.CheckID(orgID); err != nil {
+		return Member{}, ErrInvalidID
+	}
+
+	if err := validate.Check(nm); err != nil {
+		return Member{}, fmt.Errorf("validating data: %w", err)
+	}
+
+	roles := nm.Roles
+	if len(roles) == 0 {
+		roles = []string{auth.RoleUser}
+	}
+
+	if err := c.checkRoles(ctx, roles); err != nil {
+		return Member{}, err
+	}
+
+	dbMbr := db.Member{
+		UserID:      nm.UserID,
+		OrgID:       orgID,
+		Roles:       roles,
+		DateCreated: now,
+	}
+
+	tran := func(tx sqlx.ExtContext) error {
+		store := c.store.Tran(tx)
+
+		if _, err := store.QueryByID(ctx, orgID); err != nil {
+			if errors.Is(err, database.ErrDBNotFound) {
+				return ErrNotFound
+			}
+			return fmt.Errorf("query: %w", err)
+		}
+
+		if _, err := c.userStore.Tran(tx).QueryByID(ctx, nm.UserID); err != nil {
+			if errors.Is(err, database.ErrDBNotFound) {
+				return ErrUserNotFound
+			}
+			return fmt.Errorf("query user: %w", err)
+		}
+
+		if err := store.AddMember(ctx, dbMbr); err != nil {
+			if errors.Is(err, database.ErrDBDuplicatedEntry) {
+				return ErrAlreadyMember
+			}
+			return fmt.Errorf("add member: %w", err)
+		}
+
+		return nil
+	}
+
+	if err := c.store.WithinTran(ctx, tran); err != nil {
+		return Member{}, fmt.Errorf("tran: %w", err)
+	}
+
+	return toMember(dbMbr), nil
+}
+
+// RemoveMember removes a user from an organization. Tokens the user already
+// holds for the organization stay valid until they expire.
+func (c Core) RemoveMember(ctx context.Context, orgID string, userID string) error {
+	if err := validate.CheckID(orgID); err != nil {
+		return ErrInvalidID
+	}
+
+	if err := validate.CheckID(userID); err != nil {
+		return ErrInvalidID
+	}
+
+	if err := c.store.RemoveMember(ctx, orgID, userID); err != nil {
+		return fmt.Errorf("remove member: %w", err)
+	}
+
+	return nil
+}
+
+// QueryMembers gets the users that belong to an organization.
+func (c Core) QueryMembers(ctx context.Context, orgID string) ([]Member, error) {
+	if err := validate.CheckID(orgID); err != nil {
+		return nil, ErrInvalidID
+	}
+
+	if _, err := c.store.QueryByID(ctx, orgID); err != nil {
+		if errors.Is(err, database.ErrDBNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("query: %w", err)
+	}
+
+	dbMbrs, err := c.store.QueryMembers(ctx, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("query members: %w", err)
+	}
+
+	return toMemberSlice(dbMbrs), nil
+}
+
+// =============================================================================
+
+// checkRoles makes sure every role in the list is defined.
+func (c Core) checkRoles(ctx context.Context, roles []string) error {
+	names, err := c.roleStore.QueryNames(ctx, roles)
+	if err != nil {
+		return fmt.Errorf("query roles: %w", err)
+	}
+
+	defined := make(map[string]bool, len(names))
+	for _, name := range names {
+		defined[name] = true
+	}
+
+	for _, role := range roles {
+		if !defined[role] {
+			return validate.FieldErrors{
+				{Field: "roles", Error: fmt.Sprintf("role %q is not defined", role)},
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,169 @@
+package organization_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/andrewyang17/service/business/core/organization"
+	"github.com/andrewyang17/service/business/core/product"
+	"github.com/andrewyang17/service/business/core/sale"
+	"github.com/andrewyang17/service/business/core/user"
+	"github.com/andrewyang17/service/business/data/dbtest"
+	"github.com/andrewyang17/service/business/sys/auth"
+	"github.com/andrewyang17/service/business/sys/mail"
+	"github.com/andrewyang17/service/business/sys/money"
+	"github.com/andrewyang17/service/business/sys/tenant"
+	"github.com/andrewyang17/service/foundation/docker"
+)
+
+var c *docker.Container
+
+func TestMain(m *testing.M) {
+	var err error
+	c, err = dbtest.StartDB()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer dbtest.StopDB(c)
+
+	m.Run()
+}
+
+func TestOrganization(t *testing.T) {
+	log, db, teardown := dbtest.NewUnit(t, c, "testorg")
+	t.Cleanup(teardown)
+
+	core := organization.NewCore(log, db)
+	usrCore := user.NewCore(log, db, mail.NewLogMailer(log), dbtest.NewHasher(t))
+	prdCore := product.NewCore(log, db)
+	sleCore := sale.NewCore(log, db)
+
+	const (
+		adminID   = "5cf37266-3473-4006-984f-9325122678b7"
+		userID    = "45b5fbd3-755f-4379-8f07-a58d4a30fa2f"
+		productID = "a2b0639f-2cc6-44b8-b97b-15d69dbb511e"
+		saleID    = "98b6d4b8-f04b-4c79-8c2e-a0aef46854b7"
+	)
+
+	t.Log("Given the need to keep the data of organizations apart.")
+	{
+		testID := 0
+		t.Logf("\tTest %d:\tWhen handling a second storefront.", testID)
+		{
+			ctx := tenant.Set(context.Background(), organization.DefaultID)
+			now := time.Date(2022, time.January, 8, 17, 56, 0, 0, time.UTC)
+
+			org, err := core.Create(ctx, organization.NewOrganization{Name: "Second Store"}, adminID, now)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to create organization : %s.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould be able to create organization.", dbtest.Success, testID)
+
+			orgs, err := core.QueryByUserID(ctx, adminID)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to query organizations by user : %s.", dbtest.Failed, testID, err)
+			}
+
+			if len(orgs) != 2 || orgs[0].ID != organization.DefaultID || orgs[1].ID != org.ID {
+				t.Fatalf("\t%s\tTest %d:\tShould make the creator a member : %+v.", dbtest.Failed, testID, orgs)
+			}
+			t.Logf("\t%s\tTest %d:\tShould make the creator a member.", dbtest.Success, testID)
+
+			nm := organization.NewMember{UserID: userID, Roles: []string{auth.RoleAdmin, auth.RoleUser}}
+			if _, err := core.AddMember(ctx, org.ID, nm, now); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to add a member : %s.", dbtest.Failed, testID, err)
+			}
+
+			if _, err := core.AddMember(ctx, org.ID, organization.NewMember{UserID: userID}, now); !errors.Is(err, organization.ErrAlreadyMember) {
+				t.Fatalf("\t%s\tTest %d:\tShould NOT be able to add a member twice : %v.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould NOT be able to add a member twice.", dbtest.Success, testID)
+
+			claims, err := usrCore.Authenticate(ctx, now, "user@example.com", "gophers", org.ID)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to authenticate for the organization : %s.", dbtest.Failed, testID, err)
+			}
+
+			if claims.OrgID != org.ID {
+				t.Fatalf("\t%s\tTest %d:\tShould get the organization in the claims : %q.", dbtest.Failed, testID, claims.OrgID)
+			}
+			t.Logf("\t%s\tTest %d:\tShould get the organization in the claims.", dbtest.Success, testID)
+
+			if !claims.Authorized(auth.RoleAdmin) {
+				t.Fatalf("\t%s\tTest %d:\tShould get the roles held in the organization : %v.", dbtest.Failed, testID, claims.Roles)
+			}
+			t.Logf("\t%s\tTest %d:\tShould get the roles held in the organization.", dbtest.Success, testID)
+
+			claims, err = usrCore.Authenticate(ctx, now, "user@example.com", "gophers", organization.DefaultID)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to authenticate for the default organization : %s.", dbtest.Failed, testID, err)
+			}
+
+			if claims.Authorized(auth.RoleAdmin) {
+				t.Fatalf("\t%s\tTest %d:\tShould NOT get the roles held in another organization : %v.", dbtest.Failed, testID, claims.Roles)
+			}
+			t.Logf("\t%s\tTest %d:\tShould NOT get the roles held in another organization.", dbtest.Success, testID)
+
+			orgCtx := tenant.Set(context.Background(), org.ID)
+
+			if _, err := prdCore.QueryByID(orgCtx, productID); !errors.Is(err, product.ErrNotFound) {
+				t.Fatalf("\t%s\tTest %d:\tShould NOT see the products of another organization : %v.", dbtest.Failed, testID, err)
+			}
+
+			prds, err := prdCore.Query(orgCtx, product.QueryFilter{}, 1, 10)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to query products : %s.", dbtest.Failed, testID, err)
+			}
+
+			if len(prds) != 0 {
+				t.Fatalf("\t%s\tTest %d:\tShould NOT list the products of another organization : %+v.", dbtest.Failed, testID, prds)
+			}
+			t.Logf("\t%s\tTest %d:\tShould NOT see the products of another organization.", dbtest.Success, testID)
+
+			ns := sale.NewSale{ProductID: productID, Quantity: 1, UserID: userID}
+			if _, err := sleCore.Create(orgCtx, ns, now); !errors.Is(err, sale.ErrProductNotFound) {
+				t.Fatalf("\t%s\tTest %d:\tShould NOT be able to buy the products of another organization : %v.", dbtest.Failed, testID, err)
+			}
+
+			if _, err := sleCore.QueryByID(orgCtx, saleID); !errors.Is(err, sale.ErrNotFound) {
+				t.Fatalf("\t%s\tTest %d:\tShould NOT see the sales of another organization : %v.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould NOT see the sales of another organization.", dbtest.Success, testID)
+
+			np := product.NewProduct{
+				Name:     "Posters",
+				Cost:     money.New(15, money.USD),
+				Quantity: 10,
+				UserID:   userID,
+			}
+
+			prd, err := prdCore.Create(orgCtx, np, now)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to create a product : %s.", dbtest.Failed, testID, err)
+			}
+
+			if _, err := prdCore.QueryByID(ctx, prd.ID); !errors.Is(err, product.ErrNotFound) {
+				t.Fatalf("\t%s\tTest %d:\tShould keep new products in their organization : %v.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould keep new products in their organization.", dbtest.Success, testID)
+
+			if _, err := prdCore.QueryByID(context.Background(), prd.ID); !errors.Is(err, tenant.ErrMissing) {
+				t.Fatalf("\t%s\tTest %d:\tShould NOT query products without an organization : %v.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould NOT query products without an organization.", dbtest.Success, testID)
+
+			if err := core.RemoveMember(ctx, org.ID, userID); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to remove a member : %s.", dbtest.Failed, testID, err)
+			}
+
+			if _, err := usrCore.Authenticate(ctx, now, "user@example.com", "gophers", org.ID); !errors.Is(err, user.ErrNotMember) {
+				t.Fatalf("\t%s\tTest %d:\tShould NOT be able to authenticate for an organization after leaving : %v.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould NOT be able to authenticate for an organization after leaving.", dbtest.Success, testID)
+		}
+	}
+}
@@ -0,0 +1,251 @@
+// Package db contains product related CRUD functionality.
+package db
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/andrewyang17/service/business/sys/database"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+type Store struct {
+	log          *zap.SugaredLogger
+	tr           database.Transactor
+	db           sqlx.ExtContext
+	isWithinTran bool
+}
+
+func NewStore(log *zap.SugaredLogger, db *sqlx.DB) Store {
+	return Store{
+		log: log,
+		tr:  db,
+		db:  db,
+	}
+}
+
+// WithinTran runs passed function and do commit/rollback at the end.
+func (s Store) WithinTran(ctx context.Context, fn func(extContext sqlx.ExtContext) error) error {
+	if s.isWithinTran {
+		return fn(s.db)
+	}
+	return database.WithinTran(ctx, s.log, s.tr, fn)
+}
+
+// Tran return new Store with transaction in it.
+func (s Store) Tran(tx sqlx.ExtContext) Store {
+	return Store{
+		log:          s.log,
+		tr:           s.tr,
+		db:           tx,
+		isWithinTran: true,
+	}
+}
+
+// Create adds a Product to the database.
+func (s Store) Create(ctx context.Context, prd Product) error {
+	const q = `
+	INSERT INTO products
+		(product_id, org_id, user_id, category_id, name, description, cost, currency, quantity, date_created, date_updated)
+	VALUES
+		(:product_id, :org_id, :user_id, :category_id, :name, :description, :cost, :currency, :quantity, :date_created, :date_updated)`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, prd); err != nil {
+		return fmt.Errorf("inserting product: %w", err)
+	}
+
+	return nil
+}
+
+// Update modifies data about a Product.
+func (s Store) Update(ctx context.Context, prd Product) error {
+	const q = `
+	UPDATE
+		products
+	SET
+		"category_id" = :category_id,
+		"name" = :name,
+		"description" = :description,
+		"cost" = :cost,
+		"currency" = :currency,
+		"quantity" = :quantity,
+		"date_updated" = :date_updated
+	WHERE
+		product_id = :product_id`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, prd); err != nil {
+		return fmt.Errorf("updating productID[%q]: %w", prd.ID, err)
+	}
+
+	return nil
+}
+
+// Delete removes the product identified by a given ID from an organization.
+func (s Store) Delete(ctx context.Context, orgID string, productID string) error {
+	data := struct {
+		OrgID     string `db:"org_id"`
+		ProductID string `db:"product_id"`
+	}{
+		OrgID:     orgID,
+		ProductID: productID,
+	}
+
+	const q = `
+	DELETE FROM
+		products
+	WHERE
+		org_id = :org_id AND product_id = :product_id`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, data); err != nil {
+		return fmt.Errorf("deleting productID[%q]: %w", productID, err)
+	}
+
+	return nil
+}
+
+// Query gets the Products of an organization that match the filter from the
+// database. When the filter contains search text the products are ordered by
+// how well they match.
+func (s Store) Query(ctx context.Context, orgID string, filter QueryFilter, pageNumber int, rowsPerPage int) ([]Product, error) {
+	data := map[string]interface{}{
+		"offset":        (pageNumber - 1) * rowsPerPage,
+		"rows_per_page": rowsPerPage,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		products`
+
+	buf := bytes.NewBufferString(q)
+	search := applyFilter(orgID, filter, data, buf)
+
+	if search {
+		buf.WriteString(" ORDER BY ts_rank(" + searchDocument + ", to_tsquery('english', :query)) DESC, product_id")
+	} else {
+		buf.WriteString(" ORDER BY product_id")
+	}
+	buf.WriteString(" OFFSET :offset ROWS FETCH NEXT :rows_per_page ROWS ONLY")
+
+	var prds []Product
+	if err := database.NamedQuerySlice(ctx, s.log, s.db, buf.String(), data, &prds); err != nil {
+		return nil, fmt.Errorf("selecting products: %w", err)
+	}
+
+	return prds, nil
+}
+
+// QueryByID finds the product identified by a given ID in an organization.
+func (s Store) QueryByID(ctx context.Context, orgID string, productID string) (Product, error) {
+	data := struct {
+		OrgID     string `db:"org_id"`
+		ProductID string `db:"product_id"`
+	}{
+		OrgID:     orgID,
+		ProductID: productID,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		products
+	WHERE
+		org_id = :org_id AND product_id = :product_id`
+
+	var prd Product
+	if err := database.NamedQueryStruct(ctx, s.log, s.db, q, data, &prd); err != nil {
+		return Product{}, fmt.Errorf("selecting productID[%q]: %w", productID, err)
+	}
+
+	return prd, nil
+}
+
+// QueryByIDForUpdate finds the product identified by a given ID in an
+// organization and locks the row until the surrounding transaction completes.
+// It must be called from a Store returned by Tran.
+func (s Store) QueryByIDForUpdate(ctx context.Context, orgID string, productID string) (Product, error) {
+	data := struct {
+		OrgID     string `db:"org_id"`
+		ProductID string `db:"product_id"`
+	}{
+		OrgID:     orgID,
+		ProductID: productID,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		products
+	WHERE
+		org_id = :org_id AND product_id = :product_id
+	FOR UPDATE`
+
+	var prd Product
+	if err := database.NamedQueryStruct(ctx, s.log, s.db, q, data, &prd); err != nil {
+		return Product{}, fmt.Errorf("selecting productID[%q] for update: %w", productID, err)
+	}
+
+	return prd, nil
+}
+
+// UpdateQuantity sets the quantity on hand for the specified product.
+func (s Store) UpdateQuantity(ctx context.Context, productID string, quantity int, now time.Time) error {
+	data := struct {
+		ProductID   string    `db:"product_id"`
+		Quantity    int       `db:"quantity"`
+		DateUpdated time.Time `db:"date_updated"`
+	}{
+		ProductID:   productID,
+		Quantity:    quantity,
+		DateUpdated: now,
+	}
+
+	const q = `
+	UPDATE
+		products
+	SET
+		"quantity" = :quantity,
+		"date_updated" = :date_updated
+	WHERE
+		product_id = :product_id`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, data); err != nil {
+		return fmt.Errorf("updating quantity productID[%q]: %w", productID, err)
+	}
+
+	return nil
+}
+
+// QueryByUserID finds the products of an organization owned by a given user ID.
+func (s Store) QueryByUserID(ctx context.Context, orgID string, userID string) ([]Product, error) {
+	data := struct {
+		OrgID  string `db:"org_id"`
+		UserID string `db:"user_id"`
+	}{
+		OrgID:  orgID,
+		UserID: userID,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		products
+	WHERE
+		org_id = :org_id AND user_id = :user_id
+	ORDER BY
+		product_id`
+
+	var prds []Product
+	if err := database.NamedQuerySlice(ctx, s.log, s.db, q, data, &prds); err != nil {
+		return nil, fmt.Errorf("selecting products userID[%q]: %w", userID, err)
+	}
+
+	return prds, nil
+}
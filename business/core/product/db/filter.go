@@ -0,0 +1,95 @@
+package db
+
+import (
+	"bytes"
+	"strings"
+	"unicode"
+)
+
+// searchDocument is the text search document for a product. It must match
+// the expression used by the products_search_idx index for the index to be
+// used.
+const searchDocument = "to_tsvector('english', name || ' ' || description)"
+
+// applyFilter adds a WHERE clause for the organization and the filter to the
+// query in buf and the parameters it uses to data. It reports whether the
+// filter searches text.
+func applyFilter(orgID string, filter QueryFilter, data map[string]interface{}, buf *bytes.Buffer) bool {
+	data["org_id"] = orgID
+	wc := []string{"org_id = :org_id"}
+	var search bool
+
+	if filter.Text != nil {
+		if query := searchQuery(*filter.Text); query != "" {
+			data["query"] = query
+			wc = append(wc, searchDocument+" @@ to_tsquery('english', :query)")
+			search = true
+		}
+	}
+
+	if filter.MinCost != nil {
+		data["min_cost"] = *filter.MinCost
+		wc = append(wc, "cost >= :min_cost")
+	}
+
+	if filter.MaxCost != nil {
+		data["max_cost"] = *filter.MaxCost
+		wc = append(wc, "cost <= :max_cost")
+	}
+
+	if filter.Currency != nil {
+		data["currency"] = *filter.Currency
+		wc = append(wc, "currency = :currency")
+	}
+
+	if filter.UserID != nil {
+		data["user_id"] = *filter.UserID
+		wc = append(wc, "user_id = :user_id")
+	}
+
+	if filter.InStock != nil {
+		if *filter.InStock {
+			wc = append(wc, "quantity > 0")
+		} else {
+			wc = append(wc, "quantity = 0")
+		}
+	}
+
+	if filter.CategoryID != nil {
+		data["category_id"] = *filter.CategoryID
+		wc = append(wc, `category_id IN (
+		WITH RECURSIVE subtree AS (
+			SELECT category_id FROM categories WHERE category_id = :category_id
+			UNION ALL
+			SELECT c.category_id FROM categories AS c JOIN subtree AS s ON c.parent_id = s.category_id
+		)
+		SELECT category_id FROM subtree)`)
+	}
+
+	if filter.Tag != nil {
+		data["tag"] = *filter.Tag
+		wc = append(wc, `product_id IN (
+		SELECT pt.product_id FROM product_tags AS pt JOIN tags AS t ON t.tag_id = pt.tag_id WHERE t.name = :tag)`)
+	}
+
+	buf.WriteString(" WHERE ")
+	buf.WriteString(strings.Join(wc, " AND "))
+
+	return search
+}
+
+// searchQuery turns free text from a user into a tsquery that matches
+// products containing every word, treating each word as a prefix so partial
+// input still finds results. Anything other than letters and digits is
+// dropped so the text can't alter the meaning of the query.
+func searchQuery(text string) string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	for i, word := range words {
+		words[i] = word + ":*"
+	}
+
+	return strings.Join(words, " & ")
+}
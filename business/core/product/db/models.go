@@ -0,0 +1,62 @@
+package db
+
+import "time"
+
+// Product represents the structure we need for moving data
+// between the app and the database.
+type Product struct {
+	ID          string    `db:"product_id"`
+	OrgID       string    `db:"org_id"`
+	Name        string    `db:"name"`
+	Description string    `db:"description"`
+	Cost        int64     `db:"cost"`
+	Currency    string    `db:"currency"`
+	Quantity    int       `db:"quantity"`
+	UserID      string    `db:"user_id"`
+	CategoryID  *string   `db:"category_id"`
+	DateCreated time.Time `db:"date_created"`
+	DateUpdated time.Time `db:"date_updated"`
+}
+
+// QueryFilter holds the optional criteria used to narrow down a query for
+// products. Nil fields are not applied.
+type QueryFilter struct {
+	Text       *string
+	MinCost    *int64
+	MaxCost    *int64
+	Currency   *string
+	UserID     *string
+	InStock    *bool
+	CategoryID *string
+	Tag        *string
+}
+
+// Reservation represents the structure we need for moving data
+// between the app and the database.
+type Reservation struct {
+	ID          string    `db:"reservation_id"`
+	OrgID       string    `db:"org_id"`
+	ProductID   string    `db:"product_id"`
+	VariantID   *string   `db:"variant_id"`
+	UserID      string    `db:"user_id"`
+	SaleID      *string   `db:"sale_id"`
+	Quantity    int       `db:"quantity"`
+	Status      string    `db:"status"`
+	DateExpires time.Time `db:"date_expires"`
+	DateCreated time.Time `db:"date_created"`
+	DateUpdated time.Time `db:"date_updated"`
+}
+
+// Variant represents the structure we need for moving data
+// between the app and the database.
+type Variant struct {
+	ID          string    `db:"variant_id"`
+	OrgID       string    `db:"org_id"`
+	ProductID   string    `db:"product_id"`
+	SKU         string    `db:"sku"`
+	Name        string    `db:"name"`
+	Cost        *int64    `db:"cost"`
+	Quantity    int       `db:"quantity"`
+	DateCreated time.Time `db:"date_created"`
+	DateUpdated time.Time `db:"date_updated"`
+}
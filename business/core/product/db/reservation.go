@@ -0,0 +1,134 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/andrewyang17/service/business/sys/database"
+)
+
+// CreateReservation inserts a new reservation into the database.
+func (s Store) CreateReservation(ctx context.Context, res Reservation) error {
+	const q = `
+	INSERT INTO reservations
+		(reservation_id, org_id, product_id, variant_id, user_id, sale_id, quantity, status, date_expires, date_created, date_updated)
+	VALUES
+		(:reservation_id, :org_id, :product_id, :variant_id, :user_id, :sale_id, :quantity, :status, :date_expires, :date_created, :date_updated)`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, res); err != nil {
+		return fmt.Errorf("inserting reservation: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateReservation modifies the status and sale of a reservation.
+func (s Store) UpdateReservation(ctx context.Context, res Reservation) error {
+	const q = `
+	UPDATE
+		reservations
+	SET
+		"sale_id" = :sale_id,
+		"status" = :status,
+		"date_updated" = :date_updated
+	WHERE
+		reservation_id = :reservation_id`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, res); err != nil {
+		return fmt.Errorf("updating reservationID[%q]: %w", res.ID, err)
+	}
+
+	return nil
+}
+
+// QueryReservationByID gets the specified reservation of an organization
+// from the database.
+func (s Store) QueryReservationByID(ctx context.Context, orgID string, reservationID string) (Reservation, error) {
+	data := struct {
+		OrgID         string `db:"org_id"`
+		ReservationID string `db:"reservation_id"`
+	}{
+		OrgID:         orgID,
+		ReservationID: reservationID,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		reservations
+	WHERE
+		org_id = :org_id AND reservation_id = :reservation_id`
+
+	var res Reservation
+	if err := database.NamedQueryStruct(ctx, s.log, s.db, q, data, &res); err != nil {
+		return Reservation{}, fmt.Errorf("selecting reservationID[%q]: %w", reservationID, err)
+	}
+
+	return res, nil
+}
+
+// QueryReservationByIDForUpdate gets the specified reservation of an
+// organization and locks the row until the surrounding transaction completes.
+// It must be called from a Store returned by Tran.
+func (s Store) QueryReservationByIDForUpdate(ctx context.Context, orgID string, reservationID string) (Reservation, error) {
+	data := struct {
+		OrgID         string `db:"org_id"`
+		ReservationID string `db:"reservation_id"`
+	}{
+		OrgID:         orgID,
+		ReservationID: reservationID,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		reservations
+	WHERE
+		org_id = :org_id AND reservation_id = :reservation_id
+	FOR UPDATE`
+
+	var res Reservation
+	if err := database.NamedQueryStruct(ctx, s.log, s.db, q, data, &res); err != nil {
+		return Reservation{}, fmt.Errorf("selecting reservationID[%q] for update: %w", reservationID, err)
+	}
+
+	return res, nil
+}
+
+// QueryExpiredReservationsForUpdate locks and returns up to limit reservations
+// in the specified status that expired before now, whatever organization they
+// belong to. Rows already locked by another transaction are skipped so
+// several sweepers can run at once.
+func (s Store) QueryExpiredReservationsForUpdate(ctx context.Context, status string, now time.Time, limit int) ([]Reservation, error) {
+	data := struct {
+		Status string    `db:"status"`
+		Now    time.Time `db:"now"`
+		Limit  int       `db:"limit"`
+	}{
+		Status: status,
+		Now:    now,
+		Limit:  limit,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		reservations
+	WHERE
+		status = :status AND date_expires <= :now
+	ORDER BY
+		date_expires
+	LIMIT :limit
+	FOR UPDATE SKIP LOCKED`
+
+	var ress []Reservation
+	if err := database.NamedQuerySlice(ctx, s.log, s.db, q, data, &ress); err != nil {
+		return nil, fmt.Errorf("selecting expired reservations: %w", err)
+	}
+
+	return ress, nil
+}
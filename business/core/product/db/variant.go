@@ -0,0 +1,208 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/andrewyang17/service/business/sys/database"
+)
+
+// CreateVariant adds a Variant to the database.
+func (s Store) CreateVariant(ctx context.Context, vrt Variant) error {
+	const q = `
+	INSERT INTO product_variants
+		(variant_id, org_id, product_id, sku, name, cost, quantity, date_created, date_updated)
+	VALUES
+		(:variant_id, :org_id, :product_id, :sku, :name, :cost, :quantity, :date_created, :date_updated)`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, vrt); err != nil {
+		return fmt.Errorf("inserting variant: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateVariant modifies data about a Variant.
+func (s Store) UpdateVariant(ctx context.Context, vrt Variant) error {
+	const q = `
+	UPDATE
+		product_variants
+	SET
+		"sku" = :sku,
+		"name" = :name,
+		"cost" = :cost,
+		"quantity" = :quantity,
+		"date_updated" = :date_updated
+	WHERE
+		variant_id = :variant_id`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, vrt); err != nil {
+		return fmt.Errorf("updating variantID[%q]: %w", vrt.ID, err)
+	}
+
+	return nil
+}
+
+// DeleteVariant removes the variant identified by a given ID from an
+// organization.
+func (s Store) DeleteVariant(ctx context.Context, orgID string, variantID string) error {
+	data := struct {
+		OrgID     string `db:"org_id"`
+		VariantID string `db:"variant_id"`
+	}{
+		OrgID:     orgID,
+		VariantID: variantID,
+	}
+
+	const q = `
+	DELETE FROM
+		product_variants
+	WHERE
+		org_id = :org_id AND variant_id = :variant_id`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, data); err != nil {
+		return fmt.Errorf("deleting variantID[%q]: %w", variantID, err)
+	}
+
+	return nil
+}
+
+// QueryVariants gets the variants of the specified product in an
+// organization.
+func (s Store) QueryVariants(ctx context.Context, orgID string, productID string) ([]Variant, error) {
+	data := struct {
+		OrgID     string `db:"org_id"`
+		ProductID string `db:"product_id"`
+	}{
+		OrgID:     orgID,
+		ProductID: productID,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		product_variants
+	WHERE
+		org_id = :org_id AND product_id = :product_id
+	ORDER BY
+		sku`
+
+	var vrts []Variant
+	if err := database.NamedQuerySlice(ctx, s.log, s.db, q, data, &vrts); err != nil {
+		return nil, fmt.Errorf("selecting variants productID[%q]: %w", productID, err)
+	}
+
+	return vrts, nil
+}
+
+// QueryVariantByID finds the variant identified by a given ID in an
+// organization.
+func (s Store) QueryVariantByID(ctx context.Context, orgID string, variantID string) (Variant, error) {
+	data := struct {
+		OrgID     string `db:"org_id"`
+		VariantID string `db:"variant_id"`
+	}{
+		OrgID:     orgID,
+		VariantID: variantID,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		product_variants
+	WHERE
+		org_id = :org_id AND variant_id = :variant_id`
+
+	var vrt Variant
+	if err := database.NamedQueryStruct(ctx, s.log, s.db, q, data, &vrt); err != nil {
+		return Variant{}, fmt.Errorf("selecting variantID[%q]: %w", variantID, err)
+	}
+
+	return vrt, nil
+}
+
+// QueryVariantBySKU finds the variant with the given SKU in an organization.
+func (s Store) QueryVariantBySKU(ctx context.Context, orgID string, sku string) (Variant, error) {
+	data := struct {
+		OrgID string `db:"org_id"`
+		SKU   string `db:"sku"`
+	}{
+		OrgID: orgID,
+		SKU:   sku,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		product_variants
+	WHERE
+		org_id = :org_id AND sku = :sku`
+
+	var vrt Variant
+	if err := database.NamedQueryStruct(ctx, s.log, s.db, q, data, &vrt); err != nil {
+		return Variant{}, fmt.Errorf("selecting variant sku[%q]: %w", sku, err)
+	}
+
+	return vrt, nil
+}
+
+// QueryVariantByIDForUpdate finds the variant identified by a given ID in an
+// organization and locks the row until the surrounding transaction completes.
+// It must be called from a Store returned by Tran.
+func (s Store) QueryVariantByIDForUpdate(ctx context.Context, orgID string, variantID string) (Variant, error) {
+	data := struct {
+		OrgID     string `db:"org_id"`
+		VariantID string `db:"variant_id"`
+	}{
+		OrgID:     orgID,
+		VariantID: variantID,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		product_variants
+	WHERE
+		org_id = :org_id AND variant_id = :variant_id
+	FOR UPDATE`
+
+	var vrt Variant
+	if err := database.NamedQueryStruct(ctx, s.log, s.db, q, data, &vrt); err != nil {
+		return Variant{}, fmt.Errorf("selecting variantID[%q] for update: %w", variantID, err)
+	}
+
+	return vrt, nil
+}
+
+// UpdateVariantQuantity sets the quantity on hand for the specified variant.
+func (s Store) UpdateVariantQuantity(ctx context.Context, variantID string, quantity int, now time.Time) error {
+	data := struct {
+		VariantID   string    `db:"variant_id"`
+		Quantity    int       `db:"quantity"`
+		DateUpdated time.Time `db:"date_updated"`
+	}{
+		VariantID:   variantID,
+		Quantity:    quantity,
+		DateUpdated: now,
+	}
+
+	const q = `
+	UPDATE
+		product_variants
+	SET
+		"quantity" = :quantity,
+		"date_updated" = :date_updated
+	WHERE
+		variant_id = :variant_id`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, data); err != nil {
+		return fmt.Errorf("updating quantity variantID[%q]: %w", variantID, err)
+	}
+
+	return nil
+}
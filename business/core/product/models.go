@@ -0,0 +1,213 @@
+package product
+
+import (
+	"time"
+
+	"github.com/andrewyang17/service/business/core/product/db"
+	"github.com/andrewyang17/service/business/sys/money"
+)
+
+// Product represents an individual product.
+type Product struct {
+	ID          string      `json:"id"`
+	Name        string      `json:"name"`
+	Description string      `json:"description"`
+	Cost        money.Money `json:"cost"`
+	Quantity    int         `json:"quantity"`
+	UserID      string      `json:"user_id"`
+	CategoryID  string      `json:"category_id,omitempty"`
+	DateCreated time.Time   `json:"date_created"`
+	DateUpdated time.Time   `json:"date_updated"`
+}
+
+// NewProduct is what we require from clients when adding a Product.
+type NewProduct struct {
+	Name        string      `json:"name" validate:"required"`
+	Description string      `json:"description"`
+	Cost        money.Money `json:"cost"`
+	Quantity    int         `json:"quantity" validate:"gte=1"`
+	UserID      string      `json:"user_id" validate:"required"`
+	CategoryID  string      `json:"category_id" validate:"omitempty,uuid"`
+}
+
+// UpdateProduct defines what information may be provided to modify an
+// existing Product. All fields are optional so clients can send just the
+// fields they want changed. It uses pointer fields so we can differentiate
+// between a field that was not provided and a field that was provided as
+// explicitly blank. Normally we do not want to use pointers to basic types but
+// we make exceptions around marshalling/unmarshalling. Setting CategoryID to an
+// empty string removes the product from its category.
+type UpdateProduct struct {
+	Name        *string      `json:"name"`
+	Description *string      `json:"description"`
+	Cost        *money.Money `json:"cost"`
+	Quantity    *int         `json:"quantity" validate:"omitempty,gte=0"`
+	CategoryID  *string      `json:"category_id"`
+}
+
+// QueryFilter holds the available fields a query for products can be
+// filtered on. Nil fields are not applied. A price range is only meaningful
+// within a single currency, so MinCost and MaxCost require Currency.
+// CategoryID matches products in the category or any category beneath it.
+type QueryFilter struct {
+	Text       *string `json:"q" validate:"omitempty,max=200"`
+	MinCost    *int64  `json:"min_price" validate:"omitempty,gte=0"`
+	MaxCost    *int64  `json:"max_price" validate:"omitempty,gte=0"`
+	Currency   *string `json:"currency" validate:"omitempty,currency"`
+	UserID     *string `json:"user_id" validate:"omitempty,uuid"`
+	InStock    *bool   `json:"in_stock"`
+	CategoryID *string `json:"category_id" validate:"omitempty,uuid"`
+	Tag        *string `json:"tag" validate:"omitempty,max=50"`
+}
+
+// =============================================================================
+
+func toProduct(dbPrd db.Product) Product {
+	var categoryID string
+	if dbPrd.CategoryID != nil {
+		categoryID = *dbPrd.CategoryID
+	}
+
+	return Product{
+		ID:          dbPrd.ID,
+		Name:        dbPrd.Name,
+		Description: dbPrd.Description,
+		Cost:        money.New(dbPrd.Cost, dbPrd.Currency),
+		Quantity:    dbPrd.Quantity,
+		UserID:      dbPrd.UserID,
+		CategoryID:  categoryID,
+		DateCreated: dbPrd.DateCreated,
+		DateUpdated: dbPrd.DateUpdated,
+	}
+}
+
+func toProductSlice(dbPrds []db.Product) []Product {
+	prds := make([]Product, len(dbPrds))
+	for i, dbPrd := range dbPrds {
+		prds[i] = toProduct(dbPrd)
+	}
+	return prds
+}
+
+// =============================================================================
+
+// Variant represents a saleable version of a product, such as a particular
+// size or colour. A variant has its own SKU and stock count and may override
+// the price of its product. Cost is always the price the variant sells for.
+type Variant struct {
+	ID          string      `json:"id"`
+	ProductID   string      `json:"product_id"`
+	SKU         string      `json:"sku"`
+	Name        string      `json:"name"`
+	Cost        money.Money `json:"cost"`
+	Quantity    int         `json:"quantity"`
+	DateCreated time.Time   `json:"date_created"`
+	DateUpdated time.Time   `json:"date_updated"`
+}
+
+// NewVariant is what we require from clients when adding a Variant. When Cost
+// is not provided the variant sells at the price of its product. A price
+// override must be in the same currency as the product.
+type NewVariant struct {
+	SKU      string       `json:"sku" validate:"required,max=64"`
+	Name     string       `json:"name" validate:"required"`
+	Cost     *money.Money `json:"cost"`
+	Quantity int          `json:"quantity" validate:"gte=0"`
+}
+
+// UpdateVariant defines what information may be provided to modify an
+// existing Variant. All fields are optional so clients can send just the
+// fields they want changed. Setting ClearCost removes the price override so
+// the variant sells at the price of its product again.
+type UpdateVariant struct {
+	SKU       *string      `json:"sku" validate:"omitempty,max=64"`
+	Name      *string      `json:"name"`
+	Cost      *money.Money `json:"cost"`
+	ClearCost bool         `json:"clear_cost"`
+	Quantity  *int         `json:"quantity" validate:"omitempty,gte=0"`
+}
+
+func toVariant(dbVrt db.Variant, dbPrd db.Product) Variant {
+	cost := dbPrd.Cost
+	if dbVrt.Cost != nil {
+		cost = *dbVrt.Cost
+	}
+
+	return Variant{
+		ID:          dbVrt.ID,
+		ProductID:   dbVrt.ProductID,
+		SKU:         dbVrt.SKU,
+		Name:        dbVrt.Name,
+		Cost:        money.New(cost, dbPrd.Currency),
+		Quantity:    dbVrt.Quantity,
+		DateCreated: dbVrt.DateCreated,
+		DateUpdated: dbVrt.DateUpdated,
+	}
+}
+
+func toVariantSlice(dbVrts []db.Variant, dbPrd db.Product) []Variant {
+	vrts := make([]Variant, len(dbVrts))
+	for i, dbVrt := range dbVrts {
+		vrts[i] = toVariant(dbVrt, dbPrd)
+	}
+	return vrts
+}
+
+// =============================================================================
+
+// Set of statuses a reservation can be in.
+const (
+	ReservationPending   = "PENDING"
+	ReservationConfirmed = "CONFIRMED"
+	ReservationReleased  = "RELEASED"
+	ReservationExpired   = "EXPIRED"
+)
+
+// Reservation represents stock held for a user for a limited time. While a
+// reservation is pending its quantity is not available to other buyers.
+type Reservation struct {
+	ID          string    `json:"id"`
+	ProductID   string    `json:"product_id"`
+	VariantID   string    `json:"variant_id,omitempty"`
+	UserID      string    `json:"user_id"`
+	SaleID      string    `json:"sale_id,omitempty"`
+	Quantity    int       `json:"quantity"`
+	Status      string    `json:"status"`
+	DateExpires time.Time `json:"date_expires"`
+	DateCreated time.Time `json:"date_created"`
+	DateUpdated time.Time `json:"date_updated"`
+}
+
+// NewReservation is what we require from clients when holding stock. When a
+// VariantID is provided the stock is held against that variant of the product.
+type NewReservation struct {
+	ProductID string `json:"-" validate:"required"`
+	VariantID string `json:"variant_id" validate:"omitempty,uuid"`
+	UserID    string `json:"-" validate:"required"`
+	Quantity  int    `json:"quantity" validate:"gte=1"`
+}
+
+func toReservation(dbRes db.Reservation) Reservation {
+	var saleID string
+	if dbRes.SaleID != nil {
+		saleID = *dbRes.SaleID
+	}
+
+	var variantID string
+	if dbRes.VariantID != nil {
+		variantID = *dbRes.VariantID
+	}
+
+	return Reservation{
+		ID:          dbRes.ID,
+		ProductID:   dbRes.ProductID,
+		VariantID:   variantID,
+		UserID:      dbRes.UserID,
+		SaleID:      saleID,
+		Quantity:    dbRes.Quantity,
+		Status:      dbRes.Status,
+		DateExpires: dbRes.DateExpires,
+		DateCreated: dbRes.DateCreated,
+		DateUpdated: dbRes.DateUpdated,
+	}
+}
@@ -0,0 +1,265 @@
+// Package product provides an example of a core business API.
+package product
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	categoryDB "github.com/andrewyang17/service/business/core/category/db"
+	"github.com/andrewyang17/service/business/core/product/db"
+	saleDB "github.com/andrewyang17/service/business/core/sale/db"
+	"github.com/andrewyang17/service/business/sys/database"
+	"github.com/andrewyang17/service/business/sys/tenant"
+	"github.com/andrewyang17/service/business/sys/validate"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrNotFound          = errors.New("product not found")
+	ErrInvalidID         = errors.New("ID is not in its proper form")
+	ErrInsufficientStock = errors.New("not enough product in stock")
+	ErrCategoryNotFound  = errors.New("category not found")
+)
+
+// Core manages the set of APIs for product access.
+type Core struct {
+	store         db.Store
+	saleStore     saleDB.Store
+	categoryStore categoryDB.Store
+}
+
+// NewCore constructs a core for product api access.
+func NewCore(log *zap.SugaredLogger, sqlxDB *sqlx.DB) Core {
+	return Core{
+		store:         db.NewStore(log, sqlxDB),
+		saleStore:     saleDB.NewStore(log, sqlxDB),
+		categoryStore: categoryDB.NewStore(log, sqlxDB),
+	}
+}
+
+// Create adds a Product to the database for the organization the context
+// acts for. It returns the created Product with fields like ID and
+// DateCreated populated.
+func (c Core) Create(ctx context.Context, np NewProduct, now time.Time) (Product, error) {
+	if err := validate.Check(np); err != nil {
+		return Product{}, fmt.Errorf("validating data: %w", err)
+	}
+
+	orgID, err := tenant.Get(ctx)
+	if err != nil {
+		return Product{}, err
+	}
+
+	dbPrd := db.Product{
+		ID:          validate.GenerateID(),
+		OrgID:       orgID,
+		Name:        np.Name,
+		Description: np.Description,
+		Cost:        np.Cost.Amount,
+		Currency:    np.Cost.Currency,
+		Quantity:    np.Quantity,
+		UserID:      np.UserID,
+		DateCreated: now,
+		DateUpdated: now,
+	}
+
+	if np.CategoryID != "" {
+		if err := c.checkCategory(ctx, orgID, np.CategoryID); err != nil {
+			return Product{}, err
+		}
+		dbPrd.CategoryID = &np.CategoryID
+	}
+
+	if err := c.store.Create(ctx, dbPrd); err != nil {
+		return Product{}, fmt.Errorf("create: %w", err)
+	}
+
+	return toProduct(dbPrd), nil
+}
+
+// Update modifies data about a Product. It will error if the specified ID is
+// invalid or does not reference an existing Product.
+func (c Core) Update(ctx context.Context, productID string, up UpdateProduct, now time.Time) error {
+	if err := validate.CheckID(productID); err != nil {
+		return ErrInvalidID
+	}
+
+	if err := validate.Check(up); err != nil {
+		return fmt.Errorf("validating data: %w", err)
+	}
+
+	orgID, err := tenant.Get(ctx)
+	if err != nil {
+		return err
+	}
+
+	dbPrd, err := c.store.QueryByID(ctx, orgID, productID)
+	if err != nil {
+		if errors.Is(err, database.ErrDBNotFound) {
+			return ErrNotFound
+		}
+		return fmt.Errorf("updating product productID[%s]: %w", productID, err)
+	}
+
+	if up.Name != nil {
+		dbPrd.Name = *up.Name
+	}
+	if up.Description != nil {
+		dbPrd.Description = *up.Description
+	}
+	if up.Cost != nil {
+		if up.Cost.Currency != dbPrd.Currency {
+			if err := c.checkVariantCurrency(ctx, orgID, productID); err != nil {
+				return err
+			}
+		}
+		dbPrd.Cost = up.Cost.Amount
+		dbPrd.Currency = up.Cost.Currency
+	}
+	if up.Quantity != nil {
+		dbPrd.Quantity = *up.Quantity
+	}
+	if up.CategoryID != nil {
+		switch categoryID := *up.CategoryID; categoryID {
+		case "":
+			dbPrd.CategoryID = nil
+		default:
+			if err := c.checkCategory(ctx, orgID, categoryID); err != nil {
+				return err
+			}
+			dbPrd.CategoryID = &categoryID
+		}
+	}
+	dbPrd.DateUpdated = now
+
+	if err := c.store.Update(ctx, dbPrd); err != nil {
+		return fmt.Errorf("update: %w", err)
+	}
+
+	return nil
+}
+
+// Delete removes the product identified by a given ID.
+func (c Core) Delete(ctx context.Context, productID string) error {
+	if err := validate.CheckID(productID); err != nil {
+		return ErrInvalidID
+	}
+
+	orgID, err := tenant.Get(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := c.store.Delete(ctx, orgID, productID); err != nil {
+		return fmt.Errorf("delete: %w", err)
+	}
+
+	return nil
+}
+
+// Query gets the Products of the organization the context acts for that
+// match the filter from the database. Products are ranked by relevance when
+// the filter includes search text, which matches words in the name and
+// description by prefix.
+func (c Core) Query(ctx context.Context, filter QueryFilter, pageNumber int, rowsPerPage int) ([]Product, error) {
+	if err := validate.Check(filter); err != nil {
+		return nil, fmt.Errorf("validating filter: %w", err)
+	}
+
+	orgID, err := tenant.Get(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if (filter.MinCost != nil || filter.MaxCost != nil) && filter.Currency == nil {
+		return nil, validate.FieldErrors{
+			{Field: "currency", Error: "currency is required to filter by price"},
+		}
+	}
+
+	dbFilter := db.QueryFilter{
+		Text:       filter.Text,
+		MinCost:    filter.MinCost,
+		MaxCost:    filter.MaxCost,
+		Currency:   filter.Currency,
+		UserID:     filter.UserID,
+		InStock:    filter.InStock,
+		CategoryID: filter.CategoryID,
+	}
+
+	if filter.Tag != nil {
+		tag := strings.ToLower(strings.TrimSpace(*filter.Tag))
+		dbFilter.Tag = &tag
+	}
+
+	dbPrds, err := c.store.Query(ctx, orgID, dbFilter, pageNumber, rowsPerPage)
+	if err != nil {
+		return nil, fmt.Errorf("query: %w", err)
+	}
+
+	return toProductSlice(dbPrds), nil
+}
+
+// QueryByID finds the product identified by a given ID.
+func (c Core) QueryByID(ctx context.Context, productID string) (Product, error) {
+	if err := validate.CheckID(productID); err != nil {
+		return Product{}, ErrInvalidID
+	}
+
+	orgID, err := tenant.Get(ctx)
+	if err != nil {
+		return Product{}, err
+	}
+
+	dbPrd, err := c.store.QueryByID(ctx, orgID, productID)
+	if err != nil {
+		if errors.Is(err, database.ErrDBNotFound) {
+			return Product{}, ErrNotFound
+		}
+		return Product{}, fmt.Errorf("query: %w", err)
+	}
+
+	return toProduct(dbPrd), nil
+}
+
+// QueryByUserID finds the products owned by a given User ID.
+func (c Core) QueryByUserID(ctx context.Context, userID string) ([]Product, error) {
+	if err := validate.CheckID(userID); err != nil {
+		return nil, ErrInvalidID
+	}
+
+	orgID, err := tenant.Get(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	dbPrds, err := c.store.QueryByUserID(ctx, orgID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("query: %w", err)
+	}
+
+	return toProductSlice(dbPrds), nil
+}
+
+// =============================================================================
+
+// checkCategory makes sure a product is only ever placed in a category that
+// exists in its organization.
+func (c Core) checkCategory(ctx context.Context, orgID string, categoryID string) error {
+	if err := validate.CheckID(categoryID); err != nil {
+		return ErrInvalidID
+	}
+
+	if _, err := c.categoryStore.QueryByID(ctx, orgID, categoryID); err != nil {
+		if errors.Is(err, database.ErrDBNotFound) {
+			return ErrCategoryNotFound
+		}
+		return fmt.Errorf("query category: %w", err)
+	}
+
+	return nil
+}
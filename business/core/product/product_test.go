@@ -0,0 +1,417 @@
+package product_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/andrewyang17/service/business/core/organization"
+	"github.com/andrewyang17/service/business/core/product"
+	"github.com/andrewyang17/service/business/data/dbtest"
+	"github.com/andrewyang17/service/business/sys/money"
+	"github.com/andrewyang17/service/business/sys/tenant"
+	"github.com/andrewyang17/service/foundation/docker"
+	"github.com/google/go-cmp/cmp"
+)
+
+var c *docker.Container
+
+func TestMain(m *testing.M) {
+	var err error
+	c, err = dbtest.StartDB()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer dbtest.StopDB(c)
+
+	m.Run()
+}
+
+func TestProduct(t *testing.T) {
+	log, db, teardown := dbtest.NewUnit(t, c, "testproduct")
+	t.Cleanup(teardown)
+
+	core := product.NewCore(log, db)
+
+	t.Log("Given the need to work with Product records.")
+	{
+		testID := 0
+		t.Logf("\tTest %d:\tWhen handling a single Product.", testID)
+		{
+			ctx := tenant.Set(context.Background(), organization.DefaultID)
+			now := time.Date(2022, time.January, 8, 17, 56, 0, 0, time.UTC)
+
+			np := product.NewProduct{
+				Name:     "Comic Books",
+				Cost:     money.New(10, money.USD),
+				Quantity: 55,
+				UserID:   "5cf37266-3473-4006-984f-9325122678b7",
+			}
+
+			prd, err := core.Create(ctx, np, now)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to create a product : %s.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould be able to create a product.", dbtest.Success, testID)
+
+			saved, err := core.QueryByID(ctx, prd.ID)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to retrieve product by ID: %s.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould be able to retrieve product by ID.", dbtest.Success, testID)
+
+			if diff := cmp.Diff(prd, saved); diff != "" {
+				t.Fatalf("\t%s\tTest %d:\tShould get back the same product. Diff:\n%s", dbtest.Failed, testID, diff)
+			}
+			t.Logf("\t%s\tTest %d:\tShould get back the same product.", dbtest.Success, testID)
+
+			upd := product.UpdateProduct{
+				Name:     dbtest.StringPointer("Comics"),
+				Cost:     &money.Money{Amount: 50, Currency: money.EUR},
+				Quantity: dbtest.IntPointer(40),
+			}
+			updatedTime := time.Date(2022, time.January, 9, 1, 1, 1, 0, time.UTC)
+
+			if err := core.Update(ctx, prd.ID, upd, updatedTime); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to update product : %s.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould be able to update product.", dbtest.Success, testID)
+
+			products, err := core.QueryByUserID(ctx, np.UserID)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to retrieve products by user : %s.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould be able to retrieve products by user.", dbtest.Success, testID)
+
+			// Check specified fields were updated. Make a copy of the original product
+			// and change just the fields we expect then diff it with what was saved.
+			want := prd
+			want.Name = *upd.Name
+			want.Cost = *upd.Cost
+			want.Quantity = *upd.Quantity
+			want.DateUpdated = updatedTime
+
+			var idx = -1
+			for i, p := range products {
+				if p.ID == want.ID {
+					idx = i
+				}
+			}
+			if idx == -1 {
+				t.Fatalf("\t%s\tTest %d:\tShould find the product owned by the user.", dbtest.Failed, testID)
+			}
+
+			if diff := cmp.Diff(want, products[idx]); diff != "" {
+				t.Fatalf("\t%s\tTest %d:\tShould get back the same product. Diff:\n%s", dbtest.Failed, testID, diff)
+			}
+			t.Logf("\t%s\tTest %d:\tShould get back the same product.", dbtest.Success, testID)
+
+			upd = product.UpdateProduct{
+				Name: dbtest.StringPointer("Graphic Novels"),
+			}
+
+			if err := core.Update(ctx, prd.ID, upd, updatedTime); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to update just some fields of product : %s.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould be able to update just some fields of product.", dbtest.Success, testID)
+
+			saved, err = core.QueryByID(ctx, prd.ID)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to retrieve updated product : %s.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould be able to retrieve updated product.", dbtest.Success, testID)
+
+			if saved.Name != *upd.Name {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to see updated Name field : got %q want %q.", dbtest.Failed, testID, saved.Name, *upd.Name)
+			} else {
+				t.Logf("\t%s\tTest %d:\tShould be able to see updated Name field.", dbtest.Success, testID)
+			}
+
+			if err := core.Delete(ctx, prd.ID); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to delete product : %s.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould be able to delete product.", dbtest.Success, testID)
+
+			_, err = core.QueryByID(ctx, prd.ID)
+			if !errors.Is(err, product.ErrNotFound) {
+				t.Fatalf("\t%s\tTest %d:\tShould NOT be able to retrieve deleted product : %s.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould NOT be able to retrieve deleted product.", dbtest.Success, testID)
+		}
+	}
+}
+
+func TestPagingProduct(t *testing.T) {
+	log, db, teardown := dbtest.NewUnit(t, c, "testpagingproduct")
+	t.Cleanup(teardown)
+
+	core := product.NewCore(log, db)
+
+	t.Log("Given the need to page through Product records.")
+	{
+		testID := 0
+		t.Logf("\tTest %d:\tWhen paging through 2 products.", testID)
+		{
+			ctx := tenant.Set(context.Background(), organization.DefaultID)
+
+			products1, err := core.Query(ctx, product.QueryFilter{}, 1, 1)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to retrieve products for page 1 : %s.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould be able to retrieve products for page 1.", dbtest.Success, testID)
+
+			if len(products1) != 1 {
+				t.Fatalf("\t%s\tTest %d:\tShould have a single product : %d.", dbtest.Failed, testID, len(products1))
+			}
+			t.Logf("\t%s\tTest %d:\tShould have a single product.", dbtest.Success, testID)
+
+			products2, err := core.Query(ctx, product.QueryFilter{}, 2, 1)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to retrieve products for page 2 : %s.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould be able to retrieve products for page 2.", dbtest.Success, testID)
+
+			if len(products2) != 1 {
+				t.Fatalf("\t%s\tTest %d:\tShould have a single product : %d.", dbtest.Failed, testID, len(products2))
+			}
+			t.Logf("\t%s\tTest %d:\tShould have a single product.", dbtest.Success, testID)
+
+			if products1[0].ID == products2[0].ID {
+				t.Logf("\t\tTest %d:\tProduct1: %v", testID, products1[0].ID)
+				t.Logf("\t\tTest %d:\tProduct2: %v", testID, products2[0].ID)
+				t.Fatalf("\t%s\tTest %d:\tShould have different products.", dbtest.Failed, testID)
+			}
+			t.Logf("\t%s\tTest %d:\tShould have different products.", dbtest.Success, testID)
+		}
+	}
+}
+
+func TestSearchProduct(t *testing.T) {
+	log, db, teardown := dbtest.NewUnit(t, c, "testsearchproduct")
+	t.Cleanup(teardown)
+
+	core := product.NewCore(log, db)
+
+	t.Log("Given the need to search for Product records.")
+	{
+		testID := 0
+		t.Logf("\tTest %d:\tWhen searching the seeded products.", testID)
+		{
+			ctx := tenant.Set(context.Background(), organization.DefaultID)
+
+			text := "McDon"
+			products, err := core.Query(ctx, product.QueryFilter{Text: &text}, 1, 10)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to search by a prefix : %s.", dbtest.Failed, testID, err)
+			}
+
+			if len(products) != 1 || products[0].Name != "McDonalds Toys" {
+				t.Fatalf("\t%s\tTest %d:\tShould find the product by a prefix of its name : %+v.", dbtest.Failed, testID, products)
+			}
+			t.Logf("\t%s\tTest %d:\tShould find the product by a prefix of its name.", dbtest.Success, testID)
+
+			minCost := int64(60)
+			currency := money.USD
+			products, err = core.Query(ctx, product.QueryFilter{MinCost: &minCost, Currency: &currency}, 1, 10)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to filter by price : %s.", dbtest.Failed, testID, err)
+			}
+
+			if len(products) != 1 || products[0].Cost.Amount < minCost {
+				t.Fatalf("\t%s\tTest %d:\tShould only get products in the price range : %+v.", dbtest.Failed, testID, products)
+			}
+			t.Logf("\t%s\tTest %d:\tShould only get products in the price range.", dbtest.Success, testID)
+
+			if _, err := core.Query(ctx, product.QueryFilter{MinCost: &minCost}, 1, 10); err == nil {
+				t.Fatalf("\t%s\tTest %d:\tShould NOT be able to filter by price without a currency.", dbtest.Failed, testID)
+			}
+			t.Logf("\t%s\tTest %d:\tShould NOT be able to filter by price without a currency.", dbtest.Success, testID)
+		}
+	}
+}
+
+func TestReservation(t *testing.T) {
+	log, db, teardown := dbtest.NewUnit(t, c, "testreservation")
+	t.Cleanup(teardown)
+
+	core := product.NewCore(log, db)
+
+	t.Log("Given the need to hold stock for a buyer.")
+	{
+		testID := 0
+		t.Logf("\tTest %d:\tWhen reserving, confirming and expiring stock.", testID)
+		{
+			ctx := tenant.Set(context.Background(), organization.DefaultID)
+			now := time.Date(2022, time.January, 8, 17, 56, 0, 0, time.UTC)
+
+			prd, err := core.Create(ctx, product.NewProduct{Name: "Lamps", Cost: money.New(30, money.USD), Quantity: 5, UserID: "5cf37266-3473-4006-984f-9325122678b7"}, now)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to create a product : %s.", dbtest.Failed, testID, err)
+			}
+
+			nr := product.NewReservation{
+				ProductID: prd.ID,
+				UserID:    "45b5fbd3-755f-4379-8f07-a58d4a30fa2f",
+				Quantity:  3,
+			}
+
+			res1, err := core.Reserve(ctx, nr, time.Minute, now)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to reserve stock : %s.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould be able to reserve stock.", dbtest.Success, testID)
+
+			if _, err := core.Reserve(ctx, nr, time.Minute, now); !errors.Is(err, product.ErrInsufficientStock) {
+				t.Fatalf("\t%s\tTest %d:\tShould NOT be able to reserve held stock : %v.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould NOT be able to reserve held stock.", dbtest.Success, testID)
+
+			nr.Quantity = 2
+			res2, err := core.Reserve(ctx, nr, time.Minute, now)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to reserve the remaining stock : %s.", dbtest.Failed, testID, err)
+			}
+
+			confirmed, err := core.Confirm(ctx, res2.ID, now.Add(30*time.Second))
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to confirm a reservation : %s.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould be able to confirm a reservation.", dbtest.Success, testID)
+
+			if confirmed.Status != product.ReservationConfirmed || confirmed.SaleID == "" {
+				t.Fatalf("\t%s\tTest %d:\tShould record a sale for the reservation : %+v.", dbtest.Failed, testID, confirmed)
+			}
+			t.Logf("\t%s\tTest %d:\tShould record a sale for the reservation.", dbtest.Success, testID)
+
+			expired, err := core.ExpireReservations(ctx, now.Add(2*time.Minute))
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to expire reservations : %s.", dbtest.Failed, testID, err)
+			}
+
+			if expired != 1 {
+				t.Fatalf("\t%s\tTest %d:\tShould expire only the pending reservation : got %d.", dbtest.Failed, testID, expired)
+			}
+			t.Logf("\t%s\tTest %d:\tShould expire only the pending reservation.", dbtest.Success, testID)
+
+			saved, err := core.QueryByID(ctx, prd.ID)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to retrieve product : %s.", dbtest.Failed, testID, err)
+			}
+
+			if saved.Quantity != 3 {
+				t.Fatalf("\t%s\tTest %d:\tShould return expired stock : got %d want %d.", dbtest.Failed, testID, saved.Quantity, 3)
+			}
+			t.Logf("\t%s\tTest %d:\tShould return expired stock.", dbtest.Success, testID)
+
+			if err := core.Release(ctx, res1.ID, now); !errors.Is(err, product.ErrReservationNotPending) {
+				t.Fatalf("\t%s\tTest %d:\tShould NOT be able to release an expired reservation : %v.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould NOT be able to release an expired reservation.", dbtest.Success, testID)
+		}
+	}
+}
+
+func TestVariant(t *testing.T) {
+	log, db, teardown := dbtest.NewUnit(t, c, "testvariant")
+	t.Cleanup(teardown)
+
+	core := product.NewCore(log, db)
+
+	t.Log("Given the need to sell a product in several variants.")
+	{
+		testID := 0
+		t.Logf("\tTest %d:\tWhen handling the variants of a product.", testID)
+		{
+			ctx := tenant.Set(context.Background(), organization.DefaultID)
+			now := time.Date(2022, time.January, 8, 17, 56, 0, 0, time.UTC)
+
+			prd, err := core.Create(ctx, product.NewProduct{Name: "Shirts", Cost: money.New(20, money.USD), Quantity: 1, UserID: "5cf37266-3473-4006-984f-9325122678b7"}, now)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to create a product : %s.", dbtest.Failed, testID, err)
+			}
+
+			large := money.New(25, money.USD)
+			vrt, err := core.CreateVariant(ctx, prd.ID, product.NewVariant{SKU: "SHIRT-L", Name: "Large", Cost: &large, Quantity: 2}, now)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to create a variant : %s.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould be able to create a variant.", dbtest.Success, testID)
+
+			small, err := core.CreateVariant(ctx, prd.ID, product.NewVariant{SKU: "SHIRT-S", Name: "Small", Quantity: 4}, now)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to create a variant without a price : %s.", dbtest.Failed, testID, err)
+			}
+
+			if small.Cost != prd.Cost {
+				t.Fatalf("\t%s\tTest %d:\tShould sell at the product price without an override : got %v.", dbtest.Failed, testID, small.Cost)
+			}
+			t.Logf("\t%s\tTest %d:\tShould sell at the product price without an override.", dbtest.Success, testID)
+
+			if _, err := core.CreateVariant(ctx, prd.ID, product.NewVariant{SKU: "SHIRT-L", Name: "Also Large", Quantity: 1}, now); !errors.Is(err, product.ErrDuplicateSKU) {
+				t.Fatalf("\t%s\tTest %d:\tShould NOT be able to reuse a SKU : %v.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould NOT be able to reuse a SKU.", dbtest.Success, testID)
+
+			euros := money.New(25, money.EUR)
+			if _, err := core.CreateVariant(ctx, prd.ID, product.NewVariant{SKU: "SHIRT-XL", Name: "Extra Large", Cost: &euros, Quantity: 1}, now); !errors.Is(err, product.ErrVariantCurrency) {
+				t.Fatalf("\t%s\tTest %d:\tShould NOT be able to price a variant in another currency : %v.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould NOT be able to price a variant in another currency.", dbtest.Success, testID)
+
+			nr := product.NewReservation{
+				ProductID: prd.ID,
+				VariantID: vrt.ID,
+				UserID:    "45b5fbd3-755f-4379-8f07-a58d4a30fa2f",
+				Quantity:  2,
+			}
+
+			res, err := core.Reserve(ctx, nr, time.Minute, now)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to reserve a variant beyond the product's own stock : %s.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould be able to reserve a variant beyond the product's own stock.", dbtest.Success, testID)
+
+			if _, err := core.Confirm(ctx, res.ID, now); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to confirm a variant reservation : %s.", dbtest.Failed, testID, err)
+			}
+
+			saved, err := core.QueryVariantByID(ctx, vrt.ID)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to retrieve the variant : %s.", dbtest.Failed, testID, err)
+			}
+
+			if saved.Quantity != 0 {
+				t.Fatalf("\t%s\tTest %d:\tShould take stock from the variant : got %d want %d.", dbtest.Failed, testID, saved.Quantity, 0)
+			}
+			t.Logf("\t%s\tTest %d:\tShould take stock from the variant.", dbtest.Success, testID)
+
+			savedPrd, err := core.QueryByID(ctx, prd.ID)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to retrieve the product : %s.", dbtest.Failed, testID, err)
+			}
+
+			if savedPrd.Quantity != 1 {
+				t.Fatalf("\t%s\tTest %d:\tShould leave the product stock alone : got %d want %d.", dbtest.Failed, testID, savedPrd.Quantity, 1)
+			}
+			t.Logf("\t%s\tTest %d:\tShould leave the product stock alone.", dbtest.Success, testID)
+
+			if err := core.Update(ctx, prd.ID, product.UpdateProduct{Cost: &euros}, now); !errors.Is(err, product.ErrVariantCurrency) {
+				t.Fatalf("\t%s\tTest %d:\tShould NOT be able to change the currency under a price override : %v.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould NOT be able to change the currency under a price override.", dbtest.Success, testID)
+
+			vrts, err := core.QueryVariants(ctx, prd.ID)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to list the variants : %s.", dbtest.Failed, testID, err)
+			}
+
+			if len(vrts) != 2 || vrts[0].SKU != "SHIRT-L" {
+				t.Fatalf("\t%s\tTest %d:\tShould list the variants by SKU : %+v.", dbtest.Failed, testID, vrts)
+			}
+			t.Logf("\t%s\tTest %d:\tShould list the variants by SKU.", dbtest.Success, testID)
+		}
+	}
+}
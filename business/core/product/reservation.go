@@ -0,0 +1,365 @@
+package product
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/andrewyang17/service/business/core/product/db"
+	saleDB "github.com/andrewyang17/service/business/core/sale/db"
+	"github.com/andrewyang17/service/business/sys/database"
+	"github.com/andrewyang17/service/business/sys/tenant"
+	"github.com/andrewyang17/service/business/sys/validate"
+	"github.com/jmoiron/sqlx"
+)
+
+var (
+	ErrReservationNotFound   = errors.New("reservation not found")
+	ErrReservationNotPending = errors.New("reservation is no longer pending")
+	ErrReservationExpired    = errors.New("reservation has expired")
+)
+
+// DefaultReservationTTL is how long stock is held when no TTL is specified.
+const DefaultReservationTTL = 15 * time.Minute
+
+// expireBatchSize is the maximum number of reservations released by a single
+// transaction in ExpireReservations.
+const expireBatchSize = 100
+
+// Reserve holds stock of a product for a user until the reservation is
+// confirmed, released or expires. The held quantity is taken from the product,
+// or from the variant when one is specified, immediately so it can't be sold
+// to anyone else.
+func (c Core) Reserve(ctx context.Context, nr NewReservation, ttl time.Duration, now time.Time) (Reservation, error) {
+	if err := validate.Check(nr); err != nil {
+		return Reservation{}, fmt.Errorf("validating data: %w", err)
+	}
+
+	if err := validate.CheckID(nr.ProductID); err != nil {
+		return Reservation{}, ErrInvalidID
+	}
+
+	if ttl <= 0 {
+		ttl = DefaultReservationTTL
+	}
+
+	orgID, err := tenant.Get(ctx)
+	if err != nil {
+		return Reservation{}, err
+	}
+
+	dbRes := db.Reservation{
+		ID:          validate.GenerateID(),
+		OrgID:       orgID,
+		ProductID:   nr.ProductID,
+		UserID:      nr.UserID,
+		Quantity:    nr.Quantity,
+		Status:      ReservationPending,
+		DateExpires: now.Add(ttl),
+		DateCreated: now,
+		DateUpdated: now,
+	}
+	if nr.VariantID != "" {
+		dbRes.VariantID = &nr.VariantID
+	}
+
+	tran := func(tx sqlx.ExtContext) error {
+		store := c.store.Tran(tx)
+
+		dbPrd, err := store.QueryByIDForUpdate(ctx, orgID, nr.ProductID)
+		if err != nil {
+			if errors.Is(err, database.ErrDBNotFound) {
+				return ErrNotFound
+			}
+			return fmt.Errorf("lock product: %w", err)
+		}
+
+		switch nr.VariantID {
+		case "":
+			if dbPrd.Quantity < nr.Quantity {
+				return ErrInsufficientStock
+			}
+
+			if err := store.UpdateQuantity(ctx, dbPrd.ID, dbPrd.Quantity-nr.Quantity, now); err != nil {
+				return fmt.Errorf("update quantity: %w", err)
+			}
+
+		default:
+			dbVrt, err := store.QueryVariantByIDForUpdate(ctx, orgID, nr.VariantID)
+			if err != nil {
+				if errors.Is(err, database.ErrDBNotFound) {
+					return ErrVariantNotFound
+				}
+				return fmt.Errorf("lock variant: %w", err)
+			}
+
+			if dbVrt.ProductID != dbPrd.ID {
+				return ErrVariantNotFound
+			}
+
+			if dbVrt.Quantity < nr.Quantity {
+				return ErrInsufficientStock
+			}
+
+			if err := store.UpdateVariantQuantity(ctx, dbVrt.ID, dbVrt.Quantity-nr.Quantity, now); err != nil {
+				return fmt.Errorf("update variant quantity: %w", err)
+			}
+		}
+
+		if err := store.CreateReservation(ctx, dbRes); err != nil {
+			return fmt.Errorf("create reservation: %w", err)
+		}
+
+		return nil
+	}
+
+	if err := c.store.WithinTran(ctx, tran); err != nil {
+		return Reservation{}, fmt.Errorf("tran: %w", err)
+	}
+
+	return toReservation(dbRes), nil
+}
+
+// Confirm converts a pending reservation into a sale. The stock was already
+// taken when the reservation was made, so only the sale is recorded.
+func (c Core) Confirm(ctx context.Context, reservationID string, now time.Time) (Reservation, error) {
+	if err := validate.CheckID(reservationID); err != nil {
+		return Reservation{}, ErrInvalidID
+	}
+
+	orgID, err := tenant.Get(ctx)
+	if err != nil {
+		return Reservation{}, err
+	}
+
+	var dbRes db.Reservation
+
+	tran := func(tx sqlx.ExtContext) error {
+		store := c.store.Tran(tx)
+
+		var err error
+		dbRes, err = store.QueryReservationByIDForUpdate(ctx, orgID, reservationID)
+		if err != nil {
+			if errors.Is(err, database.ErrDBNotFound) {
+				return ErrReservationNotFound
+			}
+			return fmt.Errorf("lock reservation: %w", err)
+		}
+
+		if dbRes.Status != ReservationPending {
+			return ErrReservationNotPending
+		}
+
+		if !now.Before(dbRes.DateExpires) {
+			return ErrReservationExpired
+		}
+
+		dbPrd, err := store.QueryByID(ctx, orgID, dbRes.ProductID)
+		if err != nil {
+			return fmt.Errorf("query product: %w", err)
+		}
+
+		price := dbPrd.Cost
+		if dbRes.VariantID != nil {
+			dbVrt, err := store.QueryVariantByID(ctx, orgID, *dbRes.VariantID)
+			if err != nil {
+				return fmt.Errorf("query variant: %w", err)
+			}
+			if dbVrt.Cost != nil {
+				price = *dbVrt.Cost
+			}
+		}
+
+		dbSle := saleDB.Sale{
+			ID:          validate.GenerateID(),
+			OrgID:       orgID,
+			UserID:      dbRes.UserID,
+			ProductID:   dbRes.ProductID,
+			VariantID:   dbRes.VariantID,
+			Quantity:    dbRes.Quantity,
+			Paid:        price * int64(dbRes.Quantity),
+			Currency:    dbPrd.Currency,
+			DateCreated: now,
+		}
+
+		if err := c.saleStore.Tran(tx).Create(ctx, dbSle); err != nil {
+			return fmt.Errorf("create sale: %w", err)
+		}
+
+		dbRes.SaleID = &dbSle.ID
+		dbRes.Status = ReservationConfirmed
+		dbRes.DateUpdated = now
+
+		if err := store.UpdateReservation(ctx, dbRes); err != nil {
+			return fmt.Errorf("update reservation: %w", err)
+		}
+
+		return nil
+	}
+
+	if err := c.store.WithinTran(ctx, tran); err != nil {
+		return Reservation{}, fmt.Errorf("tran: %w", err)
+	}
+
+	return toReservation(dbRes), nil
+}
+
+// Release gives up a pending reservation and returns its stock to the product
+// or variant it was taken from.
+func (c Core) Release(ctx context.Context, reservationID string, now time.Time) error {
+	if err := validate.CheckID(reservationID); err != nil {
+		return ErrInvalidID
+	}
+
+	orgID, err := tenant.Get(ctx)
+	if err != nil {
+		return err
+	}
+
+	tran := func(tx sqlx.ExtContext) error {
+		store := c.store.Tran(tx)
+
+		dbRes, err := store.QueryReservationByIDForUpdate(ctx, orgID, reservationID)
+		if err != nil {
+			if errors.Is(err, database.ErrDBNotFound) {
+				return ErrReservationNotFound
+			}
+			return fmt.Errorf("lock reservation: %w", err)
+		}
+
+		if dbRes.Status != ReservationPending {
+			return ErrReservationNotPending
+		}
+
+		return c.releaseAll(ctx, store, []db.Reservation{dbRes}, ReservationReleased, now)
+	}
+
+	if err := c.store.WithinTran(ctx, tran); err != nil {
+		return fmt.Errorf("tran: %w", err)
+	}
+
+	return nil
+}
+
+// ExpireReservations releases the stock held by pending reservations that
+// have expired in every organization. Reservations are processed in batches,
+// each in its own transaction, until none are left. It returns the number of
+// reservations that were expired.
+func (c Core) ExpireReservations(ctx context.Context, now time.Time) (int, error) {
+	var total int
+
+	for {
+		var expired int
+
+		tran := func(tx sqlx.ExtContext) error {
+			store := c.store.Tran(tx)
+
+			dbRess, err := store.QueryExpiredReservationsForUpdate(ctx, ReservationPending, now, expireBatchSize)
+			if err != nil {
+				return fmt.Errorf("query expired: %w", err)
+			}
+
+			expired = len(dbRess)
+
+			return c.releaseAll(ctx, store, dbRess, ReservationExpired, now)
+		}
+
+		if err := c.store.WithinTran(ctx, tran); err != nil {
+			return total, fmt.Errorf("tran: %w", err)
+		}
+
+		total += expired
+		if expired < expireBatchSize {
+			return total, nil
+		}
+	}
+}
+
+// QueryReservationByID gets the specified reservation from the database.
+func (c Core) QueryReservationByID(ctx context.Context, reservationID string) (Reservation, error) {
+	if err := validate.CheckID(reservationID); err != nil {
+		return Reservation{}, ErrInvalidID
+	}
+
+	orgID, err := tenant.Get(ctx)
+	if err != nil {
+		return Reservation{}, err
+	}
+
+	dbRes, err := c.store.QueryReservationByID(ctx, orgID, reservationID)
+	if err != nil {
+		if errors.Is(err, database.ErrDBNotFound) {
+			return Reservation{}, ErrReservationNotFound
+		}
+		return Reservation{}, fmt.Errorf("query: %w", err)
+	}
+
+	return toReservation(dbRes), nil
+}
+
+// releaseAll returns the stock held by the locked reservations to their
+// products or variants and moves the reservations into the specified status.
+// Products and then variants are locked in a stable order to keep concurrent
+// releases from deadlocking.
+func (c Core) releaseAll(ctx context.Context, store db.Store, dbRess []db.Reservation, status string, now time.Time) error {
+	restock := make(map[string]int)
+	restockVariants := make(map[string]int)
+	orgs := make(map[string]string)
+	for _, dbRes := range dbRess {
+		switch dbRes.VariantID {
+		case nil:
+			restock[dbRes.ProductID] += dbRes.Quantity
+			orgs[dbRes.ProductID] = dbRes.OrgID
+		default:
+			restockVariants[*dbRes.VariantID] += dbRes.Quantity
+			orgs[*dbRes.VariantID] = dbRes.OrgID
+		}
+	}
+
+	productIDs := make([]string, 0, len(restock))
+	for productID := range restock {
+		productIDs = append(productIDs, productID)
+	}
+	sort.Strings(productIDs)
+
+	for _, productID := range productIDs {
+		dbPrd, err := store.QueryByIDForUpdate(ctx, orgs[productID], productID)
+		if err != nil {
+			return fmt.Errorf("lock product: %w", err)
+		}
+
+		if err := store.UpdateQuantity(ctx, productID, dbPrd.Quantity+restock[productID], now); err != nil {
+			return fmt.Errorf("update quantity: %w", err)
+		}
+	}
+
+	variantIDs := make([]string, 0, len(restockVariants))
+	for variantID := range restockVariants {
+		variantIDs = append(variantIDs, variantID)
+	}
+	sort.Strings(variantIDs)
+
+	for _, variantID := range variantIDs {
+		dbVrt, err := store.QueryVariantByIDForUpdate(ctx, orgs[variantID], variantID)
+		if err != nil {
+			return fmt.Errorf("lock variant: %w", err)
+		}
+
+		if err := store.UpdateVariantQuantity(ctx, variantID, dbVrt.Quantity+restockVariants[variantID], now); err != nil {
+			return fmt.Errorf("update variant quantity: %w", err)
+		}
+	}
+
+	for _, dbRes := range dbRess {
+		dbRes.Status = status
+		dbRes.DateUpdated = now
+
+		if err := store.UpdateReservation(ctx, dbRes); err != nil {
+			return fmt.Errorf("update reservation: %w", err)
+		}
+	}
+
+	return nil
+}
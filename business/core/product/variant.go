@@ -0,0 +1,241 @@
+package product
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/andrewyang17/service/business/core/product/db"
+	"github.com/andrewyang17/service/business/sys/database"
+	"github.com/andrewyang17/service/business/sys/tenant"
+	"github.com/andrewyang17/service/business/sys/validate"
+)
+
+var (
+	ErrVariantNotFound = errors.New("variant not found")
+	ErrDuplicateSKU    = errors.New("SKU is already in use")
+	ErrVariantCurrency = errors.New("variant price must be in the currency of its product")
+)
+
+// CreateVariant adds a Variant of the specified product to the database. It
+// returns the created Variant with fields like ID and DateCreated populated.
+func (c Core) CreateVariant(ctx context.Context, productID string, nv NewVariant, now time.Time) (Variant, error) {
+	if err := validate.CheckID(productID); err != nil {
+		return Variant{}, ErrInvalidID
+	}
+
+	if err := validate.Check(nv); err != nil {
+		return Variant{}, fmt.Errorf("validating data: %w", err)
+	}
+
+	orgID, err := tenant.Get(ctx)
+	if err != nil {
+		return Variant{}, err
+	}
+
+	dbPrd, err := c.store.QueryByID(ctx, orgID, productID)
+	if err != nil {
+		if errors.Is(err, database.ErrDBNotFound) {
+			return Variant{}, ErrNotFound
+		}
+		return Variant{}, fmt.Errorf("query product: %w", err)
+	}
+
+	dbVrt := db.Variant{
+		ID:          validate.GenerateID(),
+		OrgID:       orgID,
+		ProductID:   productID,
+		SKU:         strings.TrimSpace(nv.SKU),
+		Name:        nv.Name,
+		Quantity:    nv.Quantity,
+		DateCreated: now,
+		DateUpdated: now,
+	}
+
+	if nv.Cost != nil {
+		if nv.Cost.Currency != dbPrd.Currency {
+			return Variant{}, ErrVariantCurrency
+		}
+		dbVrt.Cost = &nv.Cost.Amount
+	}
+
+	if err := c.checkSKU(ctx, orgID, dbVrt.SKU, ""); err != nil {
+		return Variant{}, err
+	}
+
+	if err := c.store.CreateVariant(ctx, dbVrt); err != nil {
+		return Variant{}, fmt.Errorf("create: %w", err)
+	}
+
+	return toVariant(dbVrt, dbPrd), nil
+}
+
+// UpdateVariant modifies data about a Variant. It will error if the specified
+// ID is invalid or does not reference an existing Variant.
+func (c Core) UpdateVariant(ctx context.Context, variantID string, uv UpdateVariant, now time.Time) error {
+	if err := validate.CheckID(variantID); err != nil {
+		return ErrInvalidID
+	}
+
+	if err := validate.Check(uv); err != nil {
+		return fmt.Errorf("validating data: %w", err)
+	}
+
+	orgID, err := tenant.Get(ctx)
+	if err != nil {
+		return err
+	}
+
+	dbVrt, err := c.store.QueryVariantByID(ctx, orgID, variantID)
+	if err != nil {
+		if errors.Is(err, database.ErrDBNotFound) {
+			return ErrVariantNotFound
+		}
+		return fmt.Errorf("updating variant variantID[%s]: %w", variantID, err)
+	}
+
+	if uv.SKU != nil {
+		sku := strings.TrimSpace(*uv.SKU)
+		if err := c.checkSKU(ctx, orgID, sku, variantID); err != nil {
+			return err
+		}
+		dbVrt.SKU = sku
+	}
+	if uv.Name != nil {
+		dbVrt.Name = *uv.Name
+	}
+	switch {
+	case uv.ClearCost:
+		dbVrt.Cost = nil
+	case uv.Cost != nil:
+		dbPrd, err := c.store.QueryByID(ctx, orgID, dbVrt.ProductID)
+		if err != nil {
+			return fmt.Errorf("query product: %w", err)
+		}
+		if uv.Cost.Currency != dbPrd.Currency {
+			return ErrVariantCurrency
+		}
+		dbVrt.Cost = &uv.Cost.Amount
+	}
+	if uv.Quantity != nil {
+		dbVrt.Quantity = *uv.Quantity
+	}
+	dbVrt.DateUpdated = now
+
+	if err := c.store.UpdateVariant(ctx, dbVrt); err != nil {
+		return fmt.Errorf("update: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteVariant removes the variant identified by a given ID. Sales that were
+// made against the variant keep counting towards its product.
+func (c Core) DeleteVariant(ctx context.Context, variantID string) error {
+	if err := validate.CheckID(variantID); err != nil {
+		return ErrInvalidID
+	}
+
+	orgID, err := tenant.Get(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := c.store.DeleteVariant(ctx, orgID, variantID); err != nil {
+		return fmt.Errorf("delete: %w", err)
+	}
+
+	return nil
+}
+
+// QueryVariants gets the variants of the specified product.
+func (c Core) QueryVariants(ctx context.Context, productID string) ([]Variant, error) {
+	if err := validate.CheckID(productID); err != nil {
+		return nil, ErrInvalidID
+	}
+
+	orgID, err := tenant.Get(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	dbPrd, err := c.store.QueryByID(ctx, orgID, productID)
+	if err != nil {
+		if errors.Is(err, database.ErrDBNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("query product: %w", err)
+	}
+
+	dbVrts, err := c.store.QueryVariants(ctx, orgID, productID)
+	if err != nil {
+		return nil, fmt.Errorf("query: %w", err)
+	}
+
+	return toVariantSlice(dbVrts, dbPrd), nil
+}
+
+// QueryVariantByID finds the variant identified by a given ID.
+func (c Core) QueryVariantByID(ctx context.Context, variantID string) (Variant, error) {
+	if err := validate.CheckID(variantID); err != nil {
+		return Variant{}, ErrInvalidID
+	}
+
+	orgID, err := tenant.Get(ctx)
+	if err != nil {
+		return Variant{}, err
+	}
+
+	dbVrt, err := c.store.QueryVariantByID(ctx, orgID, variantID)
+	if err != nil {
+		if errors.Is(err, database.ErrDBNotFound) {
+			return Variant{}, ErrVariantNotFound
+		}
+		return Variant{}, fmt.Errorf("query: %w", err)
+	}
+
+	dbPrd, err := c.store.QueryByID(ctx, orgID, dbVrt.ProductID)
+	if err != nil {
+		return Variant{}, fmt.Errorf("query product: %w", err)
+	}
+
+	return toVariant(dbVrt, dbPrd), nil
+}
+
+// =============================================================================
+
+// checkSKU makes sure a SKU isn't already used in the organization by a
+// variant other than the one being changed.
+func (c Core) checkSKU(ctx context.Context, orgID string, sku string, variantID string) error {
+	dbVrt, err := c.store.QueryVariantBySKU(ctx, orgID, sku)
+	switch {
+	case errors.Is(err, database.ErrDBNotFound):
+		return nil
+	case err != nil:
+		return fmt.Errorf("query sku: %w", err)
+	case dbVrt.ID != variantID:
+		return ErrDuplicateSKU
+	}
+
+	return nil
+}
+
+// checkVariantCurrency makes sure the currency of a product isn't changed
+// while any of its variants override the price, since the overrides are kept
+// in the currency of the product.
+func (c Core) checkVariantCurrency(ctx context.Context, orgID string, productID string) error {
+	dbVrts, err := c.store.QueryVariants(ctx, orgID, productID)
+	if err != nil {
+		return fmt.Errorf("query variants: %w", err)
+	}
+
+	for _, dbVrt := range dbVrts {
+		if dbVrt.Cost != nil {
+			return ErrVariantCurrency
+		}
+	}
+
+	return nil
+}
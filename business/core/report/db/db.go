@@ -0,0 +1,274 @@
+// Package db contains the sales aggregation queries used for reporting.
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/andrewyang17/service/business/sys/database"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+type Store struct {
+	log *zap.SugaredLogger
+	db  sqlx.ExtContext
+}
+
+func NewStore(log *zap.SugaredLogger, db *sqlx.DB) Store {
+	return Store{
+		log: log,
+		db:  db,
+	}
+}
+
+// params is the set of parameters shared by every report query. Only sales of
+// the products of the organization are included. The start of the range is
+// inclusive and the end is exclusive. Revenue is converted into the specified
+// currency.
+type params struct {
+	OrgID    string    `db:"org_id"`
+	Currency string    `db:"currency"`
+	Start    time.Time `db:"start"`
+	End      time.Time `db:"end"`
+}
+
+// ByProduct returns units sold and revenue grouped by product. Sales in a
+// currency without a rate to the target currency are left out, so callers
+// should check QueryMissingRates first.
+func (s Store) ByProduct(ctx context.Context, orgID string, currency string, start time.Time, end time.Time) ([]ProductSummary, error) {
+	data := params{
+		OrgID:    orgID,
+		Currency: currency,
+		Start:    start,
+		End:      end,
+	}
+
+	const q = `
+	SELECT
+		p.product_id,
+		p.name,
+		COALESCE(SUM(s.quantity), 0) AS units,
+		CAST(ROUND(COALESCE(SUM(s.paid * r.rate), 0)) AS BIGINT) AS revenue
+	FROM
+		sold_items AS s
+	JOIN
+		exchange_rates AS r ON r.from_currency = s.currency AND r.to_currency = :currency
+	JOIN
+		products AS p ON p.product_id = s.product_id
+	WHERE
+		p.org_id = :org_id AND s.date_created >= :start AND s.date_created < :end
+	GROUP BY
+		p.product_id, p.name
+	ORDER BY
+		revenue DESC, p.product_id`
+
+	var sums []ProductSummary
+	if err := database.NamedQuerySlice(ctx, s.log, s.db, q, data, &sums); err != nil {
+		return nil, fmt.Errorf("selecting product totals: %w", err)
+	}
+
+	return sums, nil
+}
+
+// ByVariant returns units sold and revenue grouped by product variant. Sales
+// of a product that weren't for a variant are grouped together, as are sales
+// of variants that have since been deleted.
+func (s Store) ByVariant(ctx context.Context, orgID string, currency string, start time.Time, end time.Time) ([]VariantSummary, error) {
+	data := params{
+		OrgID:    orgID,
+		Currency: currency,
+		Start:    start,
+		End:      end,
+	}
+
+	const q = `
+	SELECT
+		p.product_id,
+		p.name AS product_name,
+		COALESCE(CAST(v.variant_id AS TEXT), '') AS variant_id,
+		COALESCE(v.sku, '') AS sku,
+		COALESCE(v.name, '') AS name,
+		COALESCE(SUM(s.quantity), 0) AS units,
+		CAST(ROUND(COALESCE(SUM(s.paid * r.rate), 0)) AS BIGINT) AS revenue
+	FROM
+		sold_items AS s
+	JOIN
+		exchange_rates AS r ON r.from_currency = s.currency AND r.to_currency = :currency
+	JOIN
+		products AS p ON p.product_id = s.product_id
+	LEFT JOIN
+		product_variants AS v ON v.variant_id = s.variant_id
+	WHERE
+		p.org_id = :org_id AND s.date_created >= :start AND s.date_created < :end
+	GROUP BY
+		p.product_id, p.name, v.variant_id, v.sku, v.name
+	ORDER BY
+		p.product_id, revenue DESC, sku`
+
+	var sums []VariantSummary
+	if err := database.NamedQuerySlice(ctx, s.log, s.db, q, data, &sums); err != nil {
+		return nil, fmt.Errorf("selecting variant totals: %w", err)
+	}
+
+	return sums, nil
+}
+
+// BySeller returns units sold and revenue grouped by the user who owns the
+// products that were sold.
+func (s Store) BySeller(ctx context.Context, orgID string, currency string, start time.Time, end time.Time) ([]SellerSummary, error) {
+	data := params{
+		OrgID:    orgID,
+		Currency: currency,
+		Start:    start,
+		End:      end,
+	}
+
+	const q = `
+	SELECT
+		u.user_id,
+		u.name,
+		COALESCE(SUM(s.quantity), 0) AS units,
+		CAST(ROUND(COALESCE(SUM(s.paid * r.rate), 0)) AS BIGINT) AS revenue
+	FROM
+		sold_items AS s
+	JOIN
+		exchange_rates AS r ON r.from_currency = s.currency AND r.to_currency = :currency
+	JOIN
+		products AS p ON p.product_id = s.product_id
+	JOIN
+		users AS u ON u.user_id = p.user_id
+	WHERE
+		p.org_id = :org_id AND s.date_created >= :start AND s.date_created < :end
+	GROUP BY
+		u.user_id, u.name
+	ORDER BY
+		revenue DESC, u.user_id`
+
+	var sums []SellerSummary
+	if err := database.NamedQuerySlice(ctx, s.log, s.db, q, data, &sums); err != nil {
+		return nil, fmt.Errorf("selecting seller totals: %w", err)
+	}
+
+	return sums, nil
+}
+
+// ByPeriod returns units sold and revenue grouped by the specified period,
+// which must be one of the precision names understood by date_trunc.
+func (s Store) ByPeriod(ctx context.Context, orgID string, period string, currency string, start time.Time, end time.Time) ([]PeriodSummary, error) {
+	data := struct {
+		OrgID    string    `db:"org_id"`
+		Period   string    `db:"period"`
+		Currency string    `db:"currency"`
+		Start    time.Time `db:"start"`
+		End      time.Time `db:"end"`
+	}{
+		OrgID:    orgID,
+		Period:   period,
+		Currency: currency,
+		Start:    start,
+		End:      end,
+	}
+
+	const q = `
+	SELECT
+		date_trunc(:period, s.date_created) AS period,
+		COALESCE(SUM(s.quantity), 0) AS units,
+		CAST(ROUND(COALESCE(SUM(s.paid * r.rate), 0)) AS BIGINT) AS revenue
+	FROM
+		sold_items AS s
+	JOIN
+		exchange_rates AS r ON r.from_currency = s.currency AND r.to_currency = :currency
+	JOIN
+		products AS p ON p.product_id = s.product_id
+	WHERE
+		p.org_id = :org_id AND s.date_created >= :start AND s.date_created < :end
+	GROUP BY
+		1
+	ORDER BY
+		1`
+
+	var sums []PeriodSummary
+	if err := database.NamedQuerySlice(ctx, s.log, s.db, q, data, &sums); err != nil {
+		return nil, fmt.Errorf("selecting %s totals: %w", period, err)
+	}
+
+	return sums, nil
+}
+
+// QueryMissingRates returns the currencies of sales made in [start, end) that
+// have no exchange rate into the specified currency.
+func (s Store) QueryMissingRates(ctx context.Context, orgID string, currency string, start time.Time, end time.Time) ([]string, error) {
+	data := params{
+		OrgID:    orgID,
+		Currency: currency,
+		Start:    start,
+		End:      end,
+	}
+
+	const q = `
+	SELECT DISTINCT
+		s.currency
+	FROM
+		sold_items AS s
+	JOIN
+		products AS p ON p.product_id = s.product_id
+	LEFT JOIN
+		exchange_rates AS r ON r.from_currency = s.currency AND r.to_currency = :currency
+	WHERE
+		p.org_id = :org_id AND s.date_created >= :start AND s.date_created < :end AND
+		r.rate IS NULL
+	ORDER BY
+		s.currency`
+
+	var rows []struct {
+		Currency string `db:"currency"`
+	}
+	if err := database.NamedQuerySlice(ctx, s.log, s.db, q, data, &rows); err != nil {
+		return nil, fmt.Errorf("selecting missing rates: %w", err)
+	}
+
+	currencies := make([]string, len(rows))
+	for i, row := range rows {
+		currencies[i] = row.Currency
+	}
+
+	return currencies, nil
+}
+
+// QueryRates returns every exchange rate.
+func (s Store) QueryRates(ctx context.Context) ([]ExchangeRate, error) {
+	const q = `
+	SELECT
+		*
+	FROM
+		exchange_rates
+	ORDER BY
+		from_currency, to_currency`
+
+	var rates []ExchangeRate
+	if err := database.NamedQuerySlice(ctx, s.log, s.db, q, struct{}{}, &rates); err != nil {
+		return nil, fmt.Errorf("selecting rates: %w", err)
+	}
+
+	return rates, nil
+}
+
+// SaveRate adds or replaces the rate for converting between two currencies.
+func (s Store) SaveRate(ctx context.Context, rate ExchangeRate) error {
+	const q = `
+	INSERT INTO exchange_rates
+		(from_currency, to_currency, rate, date_updated)
+	VALUES
+		(:from_currency, :to_currency, :rate, :date_updated)
+	ON CONFLICT (from_currency, to_currency) DO UPDATE SET
+		rate = EXCLUDED.rate,
+		date_updated = EXCLUDED.date_updated`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, rate); err != nil {
+		return fmt.Errorf("saving rate from[%s] to[%s]: %w", rate.From, rate.To, err)
+	}
+
+	return nil
+}
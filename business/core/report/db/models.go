@@ -0,0 +1,48 @@
+package db
+
+import "time"
+
+// ProductSummary represents sales totals for a single product.
+type ProductSummary struct {
+	ProductID string `db:"product_id"`
+	Name      string `db:"name"`
+	Units     int    `db:"units"`
+	Revenue   int64  `db:"revenue"`
+}
+
+// VariantSummary represents sales totals for a single variant of a product.
+// Sales of the product that weren't for a variant have an empty VariantID.
+type VariantSummary struct {
+	ProductID   string `db:"product_id"`
+	ProductName string `db:"product_name"`
+	VariantID   string `db:"variant_id"`
+	SKU         string `db:"sku"`
+	Name        string `db:"name"`
+	Units       int    `db:"units"`
+	Revenue     int64  `db:"revenue"`
+}
+
+// SellerSummary represents sales totals for the products owned by a single
+// user.
+type SellerSummary struct {
+	UserID  string `db:"user_id"`
+	Name    string `db:"name"`
+	Units   int    `db:"units"`
+	Revenue int64  `db:"revenue"`
+}
+
+// PeriodSummary represents sales totals for a single day, week or month.
+type PeriodSummary struct {
+	Period  time.Time `db:"period"`
+	Units   int       `db:"units"`
+	Revenue int64     `db:"revenue"`
+}
+
+// ExchangeRate represents the rate for converting an amount from one currency
+// into another.
+type ExchangeRate struct {
+	From        string    `db:"from_currency"`
+	To          string    `db:"to_currency"`
+	Rate        float64   `db:"rate"`
+	DateUpdated time.Time `db:"date_updated"`
+}
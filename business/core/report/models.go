@@ -0,0 +1,134 @@
+package report
+
+import (
+	"time"
+
+	"github.com/andrewyang17/service/business/core/report/db"
+	"github.com/andrewyang17/service/business/sys/money"
+)
+
+// Set of periods sales can be grouped by.
+const (
+	PeriodDay   = "day"
+	PeriodWeek  = "week"
+	PeriodMonth = "month"
+)
+
+// ProductSummary represents sales totals for a single product.
+type ProductSummary struct {
+	ProductID string      `json:"product_id"`
+	Name      string      `json:"name"`
+	Units     int         `json:"units"`
+	Revenue   money.Money `json:"revenue"`
+}
+
+// VariantSummary represents sales totals for a single variant of a product.
+// Sales of the product that weren't for a variant are reported with an empty
+// VariantID, so the summaries for a product add up to its ProductSummary.
+type VariantSummary struct {
+	ProductID   string      `json:"product_id"`
+	ProductName string      `json:"product_name"`
+	VariantID   string      `json:"variant_id,omitempty"`
+	SKU         string      `json:"sku,omitempty"`
+	Name        string      `json:"name,omitempty"`
+	Units       int         `json:"units"`
+	Revenue     money.Money `json:"revenue"`
+}
+
+// SellerSummary represents sales totals for the products owned by a single
+// user.
+type SellerSummary struct {
+	UserID  string      `json:"user_id"`
+	Name    string      `json:"name"`
+	Units   int         `json:"units"`
+	Revenue money.Money `json:"revenue"`
+}
+
+// PeriodSummary represents sales totals for a single day, week or month. The
+// period is identified by the time it starts.
+type PeriodSummary struct {
+	Period  time.Time   `json:"period"`
+	Units   int         `json:"units"`
+	Revenue money.Money `json:"revenue"`
+}
+
+// ExchangeRate represents the rate used to convert revenue from one currency
+// into another.
+type ExchangeRate struct {
+	From        string    `json:"from"`
+	To          string    `json:"to"`
+	Rate        float64   `json:"rate"`
+	DateUpdated time.Time `json:"date_updated"`
+}
+
+// NewExchangeRate is what we require from clients when setting the rate
+// between two currencies.
+type NewExchangeRate struct {
+	From string  `json:"from" validate:"required,currency"`
+	To   string  `json:"to" validate:"required,currency"`
+	Rate float64 `json:"rate" validate:"gt=0"`
+}
+
+// =============================================================================
+
+func toProductSummarySlice(dbSums []db.ProductSummary, currency string) []ProductSummary {
+	sums := make([]ProductSummary, len(dbSums))
+	for i, dbSum := range dbSums {
+		sums[i] = ProductSummary{
+			ProductID: dbSum.ProductID,
+			Name:      dbSum.Name,
+			Units:     dbSum.Units,
+			Revenue:   money.New(dbSum.Revenue, currency),
+		}
+	}
+	return sums
+}
+
+func toVariantSummarySlice(dbSums []db.VariantSummary, currency string) []VariantSummary {
+	sums := make([]VariantSummary, len(dbSums))
+	for i, dbSum := range dbSums {
+		sums[i] = VariantSummary{
+			ProductID:   dbSum.ProductID,
+			ProductName: dbSum.ProductName,
+			VariantID:   dbSum.VariantID,
+			SKU:         dbSum.SKU,
+			Name:        dbSum.Name,
+			Units:       dbSum.Units,
+			Revenue:     money.New(dbSum.Revenue, currency),
+		}
+	}
+	return sums
+}
+
+func toSellerSummarySlice(dbSums []db.SellerSummary, currency string) []SellerSummary {
+	sums := make([]SellerSummary, len(dbSums))
+	for i, dbSum := range dbSums {
+		sums[i] = SellerSummary{
+			UserID:  dbSum.UserID,
+			Name:    dbSum.Name,
+			Units:   dbSum.Units,
+			Revenue: money.New(dbSum.Revenue, currency),
+		}
+	}
+	return sums
+}
+
+func toPeriodSummarySlice(dbSums []db.PeriodSummary, currency string) []PeriodSummary {
+	sums := make([]PeriodSummary, len(dbSums))
+	for i, dbSum := range dbSums {
+		sums[i] = PeriodSummary{
+			Period:  dbSum.Period,
+			Units:   dbSum.Units,
+			Revenue: money.New(dbSum.Revenue, currency),
+		}
+	}
+	return sums
+}
+
+func toExchangeRateSlice(dbRates []db.ExchangeRate) []ExchangeRate {
+	rates := make([]ExchangeRate, len(dbRates))
+	for i, dbRate := range dbRates {
+		rates[i] = ExchangeRate(dbRate)
+	}
+	return rates
+}
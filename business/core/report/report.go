@@ -0,0 +1,183 @@
+// Package report provides the core business API for sales reporting.
+package report
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/andrewyang17/service/business/core/report/db"
+	"github.com/andrewyang17/service/business/sys/money"
+	"github.com/andrewyang17/service/business/sys/tenant"
+	"github.com/andrewyang17/service/business/sys/validate"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrInvalidPeriod   = errors.New("period must be one of day, week or month")
+	ErrInvalidRange    = errors.New("start of the date range must be before the end")
+	ErrInvalidCurrency = errors.New("currency is not supported")
+	ErrMissingRate     = errors.New("no exchange rate into the report currency")
+)
+
+// Core manages the set of APIs for sales reporting.
+type Core struct {
+	store db.Store
+}
+
+// NewCore constructs a core for reporting api access.
+func NewCore(log *zap.SugaredLogger, sqlxDB *sqlx.DB) Core {
+	return Core{
+		store: db.NewStore(log, sqlxDB),
+	}
+}
+
+// ByProduct returns units sold and revenue per product for sales made in
+// [start, end). Sales of every variant of a product are rolled up into its
+// totals. Refunds issued in the range are subtracted from the totals. Revenue
+// is converted into the specified currency.
+func (c Core) ByProduct(ctx context.Context, currency string, start time.Time, end time.Time) ([]ProductSummary, error) {
+	orgID, err := tenant.Get(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.check(ctx, orgID, currency, start, end); err != nil {
+		return nil, err
+	}
+
+	dbSums, err := c.store.ByProduct(ctx, orgID, currency, start, end)
+	if err != nil {
+		return nil, fmt.Errorf("query: %w", err)
+	}
+
+	return toProductSummarySlice(dbSums, currency), nil
+}
+
+// ByVariant returns units sold and revenue per product variant for sales made
+// in [start, end), breaking down the totals reported by ByProduct.
+func (c Core) ByVariant(ctx context.Context, currency string, start time.Time, end time.Time) ([]VariantSummary, error) {
+	orgID, err := tenant.Get(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.check(ctx, orgID, currency, start, end); err != nil {
+		return nil, err
+	}
+
+	dbSums, err := c.store.ByVariant(ctx, orgID, currency, start, end)
+	if err != nil {
+		return nil, fmt.Errorf("query: %w", err)
+	}
+
+	return toVariantSummarySlice(dbSums, currency), nil
+}
+
+// BySeller returns units sold and revenue per seller for sales made in
+// [start, end). The seller of a sale is the user that owns the product.
+func (c Core) BySeller(ctx context.Context, currency string, start time.Time, end time.Time) ([]SellerSummary, error) {
+	orgID, err := tenant.Get(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.check(ctx, orgID, currency, start, end); err != nil {
+		return nil, err
+	}
+
+	dbSums, err := c.store.BySeller(ctx, orgID, currency, start, end)
+	if err != nil {
+		return nil, fmt.Errorf("query: %w", err)
+	}
+
+	return toSellerSummarySlice(dbSums, currency), nil
+}
+
+// ByPeriod returns units sold and revenue per day, week or month for sales
+// made in [start, end).
+func (c Core) ByPeriod(ctx context.Context, period string, currency string, start time.Time, end time.Time) ([]PeriodSummary, error) {
+	switch period {
+	case PeriodDay, PeriodWeek, PeriodMonth:
+	default:
+		return nil, ErrInvalidPeriod
+	}
+
+	orgID, err := tenant.Get(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.check(ctx, orgID, currency, start, end); err != nil {
+		return nil, err
+	}
+
+	dbSums, err := c.store.ByPeriod(ctx, orgID, period, currency, start, end)
+	if err != nil {
+		return nil, fmt.Errorf("query: %w", err)
+	}
+
+	return toPeriodSummarySlice(dbSums, currency), nil
+}
+
+// QueryRates returns the exchange rates used to convert report revenue.
+func (c Core) QueryRates(ctx context.Context) ([]ExchangeRate, error) {
+	dbRates, err := c.store.QueryRates(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("query: %w", err)
+	}
+
+	return toExchangeRateSlice(dbRates), nil
+}
+
+// SaveRate adds or replaces the rate for converting between two currencies.
+func (c Core) SaveRate(ctx context.Context, nr NewExchangeRate, now time.Time) (ExchangeRate, error) {
+	nr.From = strings.ToUpper(nr.From)
+	nr.To = strings.ToUpper(nr.To)
+
+	if err := validate.Check(nr); err != nil {
+		return ExchangeRate{}, fmt.Errorf("validating data: %w", err)
+	}
+
+	dbRate := db.ExchangeRate{
+		From:        nr.From,
+		To:          nr.To,
+		Rate:        nr.Rate,
+		DateUpdated: now,
+	}
+
+	if err := c.store.SaveRate(ctx, dbRate); err != nil {
+		return ExchangeRate{}, fmt.Errorf("save: %w", err)
+	}
+
+	return ExchangeRate(dbRate), nil
+}
+
+// =============================================================================
+
+// check validates the parameters shared by every report and makes sure all
+// the revenue of the organization in the range can be converted into the
+// report currency, so a report never silently leaves sales out.
+func (c Core) check(ctx context.Context, orgID string, currency string, start time.Time, end time.Time) error {
+	if !money.IsSupported(currency) {
+		return ErrInvalidCurrency
+	}
+
+	if !start.Before(end) {
+		return ErrInvalidRange
+	}
+
+	missing, err := c.store.QueryMissingRates(ctx, orgID, currency, start, end)
+	if err != nil {
+		return fmt.Errorf("query: %w", err)
+	}
+
+	if len(missing) > 0 {
+		return fmt.Errorf("%w: %s to %s", ErrMissingRate, strings.Join(missing, ", "), currency)
+	}
+
+	return nil
+}
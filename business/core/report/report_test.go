@@ -0,0 +1,96 @@
+package report_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/andrewyang17/service/business/core/organization"
+	"github.com/andrewyang17/service/business/core/report"
+	"github.com/andrewyang17/service/business/data/dbtest"
+	"github.com/andrewyang17/service/business/sys/money"
+	"github.com/andrewyang17/service/business/sys/tenant"
+	"github.com/andrewyang17/service/foundation/docker"
+)
+
+var c *docker.Container
+
+func TestMain(m *testing.M) {
+	var err error
+	c, err = dbtest.StartDB()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer dbtest.StopDB(c)
+
+	m.Run()
+}
+
+func TestReport(t *testing.T) {
+	log, db, teardown := dbtest.NewUnit(t, c, "testreport")
+	t.Cleanup(teardown)
+
+	core := report.NewCore(log, db)
+
+	t.Log("Given the need to aggregate the seeded sales.")
+	{
+		testID := 0
+		t.Logf("\tTest %d:\tWhen reporting on January 2019.", testID)
+		{
+			ctx := tenant.Set(context.Background(), organization.DefaultID)
+			start := time.Date(2019, time.January, 1, 0, 0, 0, 0, time.UTC)
+			end := start.AddDate(0, 1, 0)
+
+			products, err := core.ByProduct(ctx, money.USD, start, end)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to report by product : %s.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould be able to report by product.", dbtest.Success, testID)
+
+			if len(products) != 2 || products[0].Units != 7 || products[0].Revenue != money.New(350, money.USD) {
+				t.Fatalf("\t%s\tTest %d:\tShould get the expected product totals : %+v.", dbtest.Failed, testID, products)
+			}
+			t.Logf("\t%s\tTest %d:\tShould get the expected product totals.", dbtest.Success, testID)
+
+			sellers, err := core.BySeller(ctx, money.USD, start, end)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to report by seller : %s.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould be able to report by seller.", dbtest.Success, testID)
+
+			if len(sellers) != 1 || sellers[0].Units != 10 || sellers[0].Revenue != money.New(575, money.USD) {
+				t.Fatalf("\t%s\tTest %d:\tShould get the expected seller totals : %+v.", dbtest.Failed, testID, sellers)
+			}
+			t.Logf("\t%s\tTest %d:\tShould get the expected seller totals.", dbtest.Success, testID)
+
+			days, err := core.ByPeriod(ctx, report.PeriodDay, money.USD, start, end)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to report by day : %s.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould be able to report by day.", dbtest.Success, testID)
+
+			if len(days) != 1 || !days[0].Period.Equal(start) || days[0].Revenue != money.New(575, money.USD) {
+				t.Fatalf("\t%s\tTest %d:\tShould get the expected daily totals : %+v.", dbtest.Failed, testID, days)
+			}
+			t.Logf("\t%s\tTest %d:\tShould get the expected daily totals.", dbtest.Success, testID)
+
+			if _, err := core.ByPeriod(ctx, "year; DROP TABLE sales", money.USD, start, end); !errors.Is(err, report.ErrInvalidPeriod) {
+				t.Fatalf("\t%s\tTest %d:\tShould reject an unknown period : %v.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould reject an unknown period.", dbtest.Success, testID)
+
+			sellers, err = core.BySeller(ctx, money.EUR, start, end)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to report in another currency : %s.", dbtest.Failed, testID, err)
+			}
+
+			if len(sellers) != 1 || sellers[0].Revenue != money.New(535, money.EUR) {
+				t.Fatalf("\t%s\tTest %d:\tShould convert revenue with the exchange rate : %+v.", dbtest.Failed, testID, sellers)
+			}
+			t.Logf("\t%s\tTest %d:\tShould convert revenue with the exchange rate.", dbtest.Success, testID)
+		}
+	}
+}
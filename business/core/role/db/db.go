@@ -0,0 +1,284 @@
+// Package db contains role and permission related CRUD functionality.
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/andrewyang17/service/business/sys/database"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+)
+
+type Store struct {
+	log          *zap.SugaredLogger
+	tr           database.Transactor
+	db           sqlx.ExtContext
+	isWithinTran bool
+}
+
+func NewStore(log *zap.SugaredLogger, db *sqlx.DB) Store {
+	return Store{
+		log: log,
+		tr:  db,
+		db:  db,
+	}
+}
+
+// WithinTran runs passed function and do commit/rollback at the end.
+func (s Store) WithinTran(ctx context.Context, fn func(extContext sqlx.ExtContext) error) error {
+	if s.isWithinTran {
+		return fn(s.db)
+	}
+	return database.WithinTran(ctx, s.log, s.tr, fn)
+}
+
+// Tran return new Store with transaction in it.
+func (s Store) Tran(tx sqlx.ExtContext) Store {
+	return Store{
+		log:          s.log,
+		tr:           s.tr,
+		db:           tx,
+		isWithinTran: true,
+	}
+}
+
+// Create adds a Role to the database. The permissions of the role are saved
+// with SetPermissions.
+func (s Store) Create(ctx context.Context, role Role) error {
+	const q = `
+	INSERT INTO roles
+		(name, description, date_created, date_updated)
+	VALUES
+		(:name, :description, :date_created, :date_updated)`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, role); err != nil {
+		return fmt.Errorf("inserting role: %w", err)
+	}
+
+	return nil
+}
+
+// Update replaces the description of a role in the database.
+func (s Store) Update(ctx context.Context, role Role) error {
+	const q = `
+	UPDATE
+		roles
+	SET
+		"description" = :description,
+		"date_updated" = :date_updated
+	WHERE
+		name = :name`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, role); err != nil {
+		return fmt.Errorf("updating role name[%q]: %w", role.Name, err)
+	}
+
+	return nil
+}
+
+// SetPermissions replaces the permissions granted by a role. Permissions
+// listed more than once are only granted once.
+func (s Store) SetPermissions(ctx context.Context, name string, permissions []string) error {
+	data := struct {
+		Name        string         `db:"name"`
+		Permissions pq.StringArray `db:"permissions"`
+	}{
+		Name:        name,
+		Permissions: permissions,
+	}
+
+	const qDelete = `
+	DELETE FROM
+		role_permissions
+	WHERE
+		role_name = :name`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, qDelete, data); err != nil {
+		return fmt.Errorf("deleting permissions role name[%q]: %w", name, err)
+	}
+
+	const qInsert = `
+	INSERT INTO role_permissions
+		(role_name, permission)
+	SELECT DISTINCT
+		CAST(:name AS TEXT), p
+	FROM
+		UNNEST(CAST(:permissions AS TEXT[])) AS p`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, qInsert, data); err != nil {
+		return fmt.Errorf("inserting permissions role name[%q]: %w", name, err)
+	}
+
+	return nil
+}
+
+// Delete removes the role with the given name from the database.
+func (s Store) Delete(ctx context.Context, name string) error {
+	data := struct {
+		Name string `db:"name"`
+	}{
+		Name: name,
+	}
+
+	const q = `
+	DELETE FROM
+		roles
+	WHERE
+		name = :name`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, data); err != nil {
+		return fmt.Errorf("deleting role name[%q]: %w", name, err)
+	}
+
+	return nil
+}
+
+// selectRoles selects roles together with the permissions they grant.
+const selectRoles = `
+	SELECT
+		r.name,
+		r.description,
+		COALESCE(ARRAY_AGG(rp.permission ORDER BY rp.permission) FILTER (WHERE rp.permission IS NOT NULL), '{}') AS permissions,
+		r.date_created,
+		r.date_updated
+	FROM
+		roles AS r
+	LEFT JOIN
+		role_permissions AS rp ON rp.role_name = r.name`
+
+// Query gets all Roles from the database.
+func (s Store) Query(ctx context.Context) ([]Role, error) {
+	const q = selectRoles + `
+	GROUP BY
+		r.name
+	ORDER BY
+		r.name`
+
+	var roles []Role
+	if err := database.NamedQuerySlice(ctx, s.log, s.db, q, struct{}{}, &roles); err != nil {
+		return nil, fmt.Errorf("selecting roles: %w", err)
+	}
+
+	return roles, nil
+}
+
+// QueryByName finds the role with the given name.
+func (s Store) QueryByName(ctx context.Context, name string) (Role, error) {
+	data := struct {
+		Name string `db:"name"`
+	}{
+		Name: name,
+	}
+
+	const q = selectRoles + `
+	WHERE
+		r.name = :name
+	GROUP BY
+		r.name`
+
+	var role Role
+	if err := database.NamedQueryStruct(ctx, s.log, s.db, q, data, &role); err != nil {
+		return Role{}, fmt.Errorf("selecting role name[%q]: %w", name, err)
+	}
+
+	return role, nil
+}
+
+// QueryNames returns which of the given role names are defined.
+func (s Store) QueryNames(ctx context.Context, names []string) ([]string, error) {
+	data := struct {
+		Names pq.StringArray `db:"names"`
+	}{
+		Names: names,
+	}
+
+	const q = `
+	SELECT
+		COALESCE(ARRAY_AGG(name), '{}') AS names
+	FROM
+		roles
+	WHERE
+		name = ANY(CAST(:names AS TEXT[]))`
+
+	var result struct {
+		Names pq.StringArray `db:"names"`
+	}
+	if err := database.NamedQueryStruct(ctx, s.log, s.db, q, data, &result); err != nil {
+		return nil, fmt.Errorf("selecting role names: %w", err)
+	}
+
+	return result.Names, nil
+}
+
+// QueryPermissions returns the permissions granted by any of the given roles.
+func (s Store) QueryPermissions(ctx context.Context, roles []string) ([]string, error) {
+	data := struct {
+		Roles pq.StringArray `db:"roles"`
+	}{
+		Roles: roles,
+	}
+
+	const q = `
+	SELECT
+		COALESCE(ARRAY_AGG(DISTINCT permission), '{}') AS permissions
+	FROM
+		role_permissions
+	WHERE
+		role_name = ANY(CAST(:roles AS TEXT[]))`
+
+	var result struct {
+		Permissions pq.StringArray `db:"permissions"`
+	}
+	if err := database.NamedQueryStruct(ctx, s.log, s.db, q, data, &result); err != nil {
+		return nil, fmt.Errorf("selecting permissions roles%v: %w", roles, err)
+	}
+
+	return result.Permissions, nil
+}
+
+// CountUsers returns how many users, deleted or not, hold the role in any
+// organization.
+func (s Store) CountUsers(ctx context.Context, name string) (int, error) {
+	data := struct {
+		Name string `db:"name"`
+	}{
+		Name: name,
+	}
+
+	const q = `
+	SELECT
+		COUNT(DISTINCT user_id) AS count
+	FROM
+		user_orgs
+	WHERE
+		:name = ANY(roles)`
+
+	var result struct {
+		Count int `db:"count"`
+	}
+	if err := database.NamedQueryStruct(ctx, s.log, s.db, q, data, &result); err != nil {
+		return 0, fmt.Errorf("counting users role name[%q]: %w", name, err)
+	}
+
+	return result.Count, nil
+}
+
+// QueryPermissionList gets every permission a role can grant.
+func (s Store) QueryPermissionList(ctx context.Context) ([]Permission, error) {
+	const q = `
+	SELECT
+		*
+	FROM
+		permissions
+	ORDER BY
+		name`
+
+	var permissions []Permission
+	if err := database.NamedQuerySlice(ctx, s.log, s.db, q, struct{}{}, &permissions); err != nil {
+		return nil, fmt.Errorf("selecting permissions: %w", err)
+	}
+
+	return permissions, nil
+}
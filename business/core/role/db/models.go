@@ -0,0 +1,23 @@
+package db
+
+import (
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Role represents the structure we need for moving data
+// between the app and the database.
+type Role struct {
+	Name        string         `db:"name"`
+	Description string         `db:"description"`
+	Permissions pq.StringArray `db:"permissions"`
+	DateCreated time.Time      `db:"date_created"`
+	DateUpdated time.Time      `db:"date_updated"`
+}
+
+// Permission is an action a role can be allowed to take.
+type Permission struct {
+	Name        string `db:"name"`
+	Description string `db:"description"`
+}
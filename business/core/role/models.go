@@ -0,0 +1,62 @@
+package role
+
+import (
+	"time"
+	"unsafe"
+
+	"github.com/andrewyang17/service/business/core/role/db"
+)
+
+// Role is a named set of permissions that users can be given.
+type Role struct {
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	Permissions []string  `json:"permissions"`
+	DateCreated time.Time `json:"date_created"`
+	DateUpdated time.Time `json:"date_updated"`
+}
+
+// NewRole contains information needed to define a new Role. Names are case
+// insensitive and stored in upper case.
+type NewRole struct {
+	Name        string   `json:"name" validate:"required,max=50,excludesall= "`
+	Description string   `json:"description"`
+	Permissions []string `json:"permissions"`
+}
+
+// UpdateRole defines what information may be provided to modify an existing
+// Role. All fields are optional so clients can send just the fields they want
+// changed. Permissions replace the ones the role granted before.
+type UpdateRole struct {
+	Description *string  `json:"description"`
+	Permissions []string `json:"permissions"`
+}
+
+// Permission is an action a role can be allowed to take.
+type Permission struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// =============================================================================
+
+func toRole(dbRole db.Role) Role {
+	pr := (*Role)(unsafe.Pointer(&dbRole))
+	return *pr
+}
+
+func toRoleSlice(dbRoles []db.Role) []Role {
+	roles := make([]Role, len(dbRoles))
+	for i, dbRole := range dbRoles {
+		roles[i] = toRole(dbRole)
+	}
+	return roles
+}
+
+func toPermissionSlice(dbPerms []db.Permission) []Permission {
+	perms := make([]Permission, len(dbPerms))
+	for i, dbPerm := range dbPerms {
+		perms[i] = Permission(dbPerm)
+	}
+	return perms
+}
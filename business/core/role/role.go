@@ -0,0 +1,239 @@
+// Package role provides the core business API for the roles users can hold
+// and the permissions they grant.
+package role
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/andrewyang17/service/business/core/role/db"
+	"github.com/andrewyang17/service/business/sys/auth"
+	"github.com/andrewyang17/service/business/sys/database"
+	"github.com/andrewyang17/service/business/sys/validate"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrNotFound      = errors.New("role not found")
+	ErrDuplicateName = errors.New("role already exists")
+	ErrBuiltIn       = errors.New("built-in role can not be changed this way")
+	ErrInUse         = errors.New("role is held by users")
+)
+
+// Core manages the set of APIs for role access.
+type Core struct {
+	store db.Store
+}
+
+// NewCore constructs a core for role api access.
+func NewCore(log *zap.SugaredLogger, sqlxDB *sqlx.DB) Core {
+	return Core{
+		store: db.NewStore(log, sqlxDB),
+	}
+}
+
+// Create defines a new role that grants the specified permissions.
+func (c Core) Create(ctx context.Context, nr NewRole, now time.Time) (Role, error) {
+	nr.Name = normalizeName(nr.Name)
+
+	if err := validate.Check(nr); err != nil {
+		return Role{}, fmt.Errorf("validating data: %w", err)
+	}
+
+	if err := c.checkPermissions(ctx, nr.Permissions); err != nil {
+		return Role{}, err
+	}
+
+	dbRole := db.Role{
+		Name:        nr.Name,
+		Description: nr.Description,
+		DateCreated: now,
+		DateUpdated: now,
+	}
+
+	tran := func(tx sqlx.ExtContext) error {
+		store := c.store.Tran(tx)
+
+		if err := store.Create(ctx, dbRole); err != nil {
+			if errors.Is(err, database.ErrDBDuplicatedEntry) {
+				return ErrDuplicateName
+			}
+			return fmt.Errorf("create: %w", err)
+		}
+
+		if err := store.SetPermissions(ctx, dbRole.Name, nr.Permissions); err != nil {
+			return fmt.Errorf("set permissions: %w", err)
+		}
+
+		return nil
+	}
+
+	if err := c.store.WithinTran(ctx, tran); err != nil {
+		return Role{}, fmt.Errorf("tran: %w", err)
+	}
+
+	return c.QueryByName(ctx, dbRole.Name)
+}
+
+// Update modifies the description and permissions of a role. The permissions
+// of the ADMIN role can not be changed, so it always grants every permission.
+func (c Core) Update(ctx context.Context, name string, ur UpdateRole, now time.Time) error {
+	name = normalizeName(name)
+
+	if name == auth.RoleAdmin && ur.Permissions != nil {
+		return ErrBuiltIn
+	}
+
+	if err := validate.Check(ur); err != nil {
+		return fmt.Errorf("validating data: %w", err)
+	}
+
+	if ur.Permissions != nil {
+		if err := c.checkPermissions(ctx, ur.Permissions); err != nil {
+			return err
+		}
+	}
+
+	tran := func(tx sqlx.ExtContext) error {
+		store := c.store.Tran(tx)
+
+		dbRole, err := store.QueryByName(ctx, name)
+		if err != nil {
+			if errors.Is(err, database.ErrDBNotFound) {
+				return ErrNotFound
+			}
+			return fmt.Errorf("query: %w", err)
+		}
+
+		if ur.Description != nil {
+			dbRole.Description = *ur.Description
+		}
+		dbRole.DateUpdated = now
+
+		if err := store.Update(ctx, dbRole); err != nil {
+			return fmt.Errorf("update: %w", err)
+		}
+
+		if ur.Permissions != nil {
+			if err := store.SetPermissions(ctx, name, ur.Permissions); err != nil {
+				return fmt.Errorf("set permissions: %w", err)
+			}
+		}
+
+		return nil
+	}
+
+	if err := c.store.WithinTran(ctx, tran); err != nil {
+		return fmt.Errorf("tran: %w", err)
+	}
+
+	return nil
+}
+
+// Delete removes a role. The built-in roles and roles that users still hold
+// can not be deleted.
+func (c Core) Delete(ctx context.Context, name string) error {
+	name = normalizeName(name)
+
+	if name == auth.RoleAdmin || name == auth.RoleUser {
+		return ErrBuiltIn
+	}
+
+	tran := func(tx sqlx.ExtContext) error {
+		store := c.store.Tran(tx)
+
+		if _, err := store.QueryByName(ctx, name); err != nil {
+			if errors.Is(err, database.ErrDBNotFound) {
+				return ErrNotFound
+			}
+			return fmt.Errorf("query: %w", err)
+		}
+
+		count, err := store.CountUsers(ctx, name)
+		if err != nil {
+			return fmt.Errorf("count users: %w", err)
+		}
+		if count > 0 {
+			return ErrInUse
+		}
+
+		if err := store.Delete(ctx, name); err != nil {
+			return fmt.Errorf("delete: %w", err)
+		}
+
+		return nil
+	}
+
+	if err := c.store.WithinTran(ctx, tran); err != nil {
+		return fmt.Errorf("tran: %w", err)
+	}
+
+	return nil
+}
+
+// Query gets all Roles from the database.
+func (c Core) Query(ctx context.Context) ([]Role, error) {
+	dbRoles, err := c.store.Query(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("query: %w", err)
+	}
+
+	return toRoleSlice(dbRoles), nil
+}
+
+// QueryByName finds the role with the given name.
+func (c Core) QueryByName(ctx context.Context, name string) (Role, error) {
+	dbRole, err := c.store.QueryByName(ctx, normalizeName(name))
+	if err != nil {
+		if errors.Is(err, database.ErrDBNotFound) {
+			return Role{}, ErrNotFound
+		}
+		return Role{}, fmt.Errorf("query: %w", err)
+	}
+
+	return toRole(dbRole), nil
+}
+
+// QueryPermissions gets every permission a role can grant.
+func (c Core) QueryPermissions(ctx context.Context) ([]Permission, error) {
+	dbPerms, err := c.store.QueryPermissionList(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("query: %w", err)
+	}
+
+	return toPermissionSlice(dbPerms), nil
+}
+
+// =============================================================================
+
+// checkPermissions makes sure every permission in the list is defined.
+func (c Core) checkPermissions(ctx context.Context, permissions []string) error {
+	dbPerms, err := c.store.QueryPermissionList(ctx)
+	if err != nil {
+		return fmt.Errorf("query permissions: %w", err)
+	}
+
+	defined := make(map[string]bool, len(dbPerms))
+	for _, p := range dbPerms {
+		defined[p.Name] = true
+	}
+
+	for _, p := range permissions {
+		if !defined[p] {
+			return validate.FieldErrors{
+				{Field: "permissions", Error: fmt.Sprintf("permission %q is not defined", p)},
+			}
+		}
+	}
+
+	return nil
+}
+
+// normalizeName puts a role name in the form it is stored in.
+func normalizeName(name string) string {
+	return strings.ToUpper(strings.TrimSpace(name))
+}
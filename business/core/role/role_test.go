@@ -0,0 +1,154 @@
+package role_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/andrewyang17/service/business/core/role"
+	"github.com/andrewyang17/service/business/core/user"
+	"github.com/andrewyang17/service/business/data/dbtest"
+	"github.com/andrewyang17/service/business/sys/auth"
+	"github.com/andrewyang17/service/business/sys/mail"
+	"github.com/andrewyang17/service/business/sys/validate"
+	"github.com/andrewyang17/service/foundation/docker"
+)
+
+var c *docker.Container
+
+func TestMain(m *testing.M) {
+	var err error
+	c, err = dbtest.StartDB()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer dbtest.StopDB(c)
+
+	m.Run()
+}
+
+func TestRole(t *testing.T) {
+	log, db, teardown := dbtest.NewUnit(t, c, "testrole")
+	t.Cleanup(teardown)
+
+	core := role.NewCore(log, db)
+	usrCore := user.NewCore(log, db, mail.NewLogMailer(log), dbtest.NewHasher(t))
+
+	t.Log("Given the need to work with Role records.")
+	{
+		testID := 0
+		t.Logf("\tTest %d:\tWhen handling a new SUPPORT role.", testID)
+		{
+			ctx := context.Background()
+			now := time.Date(2022, time.January, 8, 17, 56, 0, 0, time.UTC)
+
+			nr := role.NewRole{
+				Name:        " support ",
+				Description: "Customer support",
+				Permissions: []string{auth.PermUsersRead, auth.PermOrdersRead, auth.PermUsersRead},
+			}
+
+			rol, err := core.Create(ctx, nr, now)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to create role : %s.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould be able to create role.", dbtest.Success, testID)
+
+			if rol.Name != "SUPPORT" || len(rol.Permissions) != 2 {
+				t.Fatalf("\t%s\tTest %d:\tShould store the name in upper case with each permission once : %+v.", dbtest.Failed, testID, rol)
+			}
+			t.Logf("\t%s\tTest %d:\tShould store the name in upper case with each permission once.", dbtest.Success, testID)
+
+			if _, err := core.Create(ctx, nr, now); !errors.Is(err, role.ErrDuplicateName) {
+				t.Fatalf("\t%s\tTest %d:\tShould NOT be able to create the role twice : %v.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould NOT be able to create the role twice.", dbtest.Success, testID)
+
+			bad := role.NewRole{Name: "FINANCE", Permissions: []string{"money:print"}}
+			if _, err := core.Create(ctx, bad, now); !validate.IsFieldErrors(err) {
+				t.Fatalf("\t%s\tTest %d:\tShould NOT be able to grant an undefined permission : %v.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould NOT be able to grant an undefined permission.", dbtest.Success, testID)
+
+			nu := user.NewUser{
+				Name:            "Support Agent",
+				Email:           "support@ardanlabs.com",
+				Roles:           []string{"SUPPORT"},
+				Password:        "Gophers2022",
+				PasswordConfirm: "Gophers2022",
+			}
+
+			if _, err := usrCore.Create(ctx, nu, now); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to create a user with the role : %s.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould be able to create a user with the role.", dbtest.Success, testID)
+
+			claims, err := usrCore.Authenticate(ctx, now, nu.Email, nu.Password, "")
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to authenticate : %s.", dbtest.Failed, testID, err)
+			}
+
+			if !claims.HasPermission(auth.PermUsersRead) || claims.HasPermission(auth.PermUsersWrite) {
+				t.Fatalf("\t%s\tTest %d:\tShould get the permissions of the role in the claims : %v.", dbtest.Failed, testID, claims.Permissions)
+			}
+			t.Logf("\t%s\tTest %d:\tShould get the permissions of the role in the claims.", dbtest.Success, testID)
+
+			nu.Email, nu.Roles = "intern@ardanlabs.com", []string{"INTERN"}
+			if _, err := usrCore.Create(ctx, nu, now); !validate.IsFieldErrors(err) {
+				t.Fatalf("\t%s\tTest %d:\tShould NOT be able to create a user with an undefined role : %v.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould NOT be able to create a user with an undefined role.", dbtest.Success, testID)
+
+			desc := "Customer support staff"
+			ur := role.UpdateRole{
+				Description: &desc,
+				Permissions: []string{auth.PermUsersWrite},
+			}
+
+			if err := core.Update(ctx, "SUPPORT", ur, now); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to update role : %s.", dbtest.Failed, testID, err)
+			}
+
+			saved, err := core.QueryByName(ctx, "support")
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to retrieve role by name : %s.", dbtest.Failed, testID, err)
+			}
+
+			if saved.Description != desc || len(saved.Permissions) != 1 || saved.Permissions[0] != auth.PermUsersWrite {
+				t.Fatalf("\t%s\tTest %d:\tShould see the updated role : %+v.", dbtest.Failed, testID, saved)
+			}
+			t.Logf("\t%s\tTest %d:\tShould be able to update role.", dbtest.Success, testID)
+
+			if err := core.Update(ctx, auth.RoleAdmin, ur, now); !errors.Is(err, role.ErrBuiltIn) {
+				t.Fatalf("\t%s\tTest %d:\tShould NOT be able to change the ADMIN permissions : %v.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould NOT be able to change the ADMIN permissions.", dbtest.Success, testID)
+
+			if err := core.Delete(ctx, auth.RoleUser); !errors.Is(err, role.ErrBuiltIn) {
+				t.Fatalf("\t%s\tTest %d:\tShould NOT be able to delete a built-in role : %v.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould NOT be able to delete a built-in role.", dbtest.Success, testID)
+
+			if err := core.Delete(ctx, "SUPPORT"); !errors.Is(err, role.ErrInUse) {
+				t.Fatalf("\t%s\tTest %d:\tShould NOT be able to delete a role users hold : %v.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould NOT be able to delete a role users hold.", dbtest.Success, testID)
+
+			if _, err := core.Create(ctx, role.NewRole{Name: "FINANCE"}, now); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to create role : %s.", dbtest.Failed, testID, err)
+			}
+
+			if err := core.Delete(ctx, "FINANCE"); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to delete role : %s.", dbtest.Failed, testID, err)
+			}
+
+			if _, err := core.QueryByName(ctx, "FINANCE"); !errors.Is(err, role.ErrNotFound) {
+				t.Fatalf("\t%s\tTest %d:\tShould NOT be able to retrieve deleted role : %v.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould be able to delete role.", dbtest.Success, testID)
+		}
+	}
+}
@@ -0,0 +1,204 @@
+// Package db contains sale related CRUD functionality.
+package db
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/andrewyang17/service/business/data/order"
+	"github.com/andrewyang17/service/business/sys/database"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+type Store struct {
+	log          *zap.SugaredLogger
+	tr           database.Transactor
+	db           sqlx.ExtContext
+	isWithinTran bool
+}
+
+func NewStore(log *zap.SugaredLogger, db *sqlx.DB) Store {
+	return Store{
+		log: log,
+		tr:  db,
+		db:  db,
+	}
+}
+
+// WithinTran runs passed function and do commit/rollback at the end.
+func (s Store) WithinTran(ctx context.Context, fn func(extContext sqlx.ExtContext) error) error {
+	if s.isWithinTran {
+		return fn(s.db)
+	}
+	return database.WithinTran(ctx, s.log, s.tr, fn)
+}
+
+// Tran return new Store with transaction in it.
+func (s Store) Tran(tx sqlx.ExtContext) Store {
+	return Store{
+		log:          s.log,
+		tr:           s.tr,
+		db:           tx,
+		isWithinTran: true,
+	}
+}
+
+// Create records a Sale in the database.
+func (s Store) Create(ctx context.Context, sle Sale) error {
+	const q = `
+	INSERT INTO sales
+		(sale_id, org_id, user_id, product_id, variant_id, quantity, paid, currency, date_created)
+	VALUES
+		(:sale_id, :org_id, :user_id, :product_id, :variant_id, :quantity, :paid, :currency, :date_created)`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, sle); err != nil {
+		return fmt.Errorf("inserting sale: %w", err)
+	}
+
+	return nil
+}
+
+// Query retrieves a list of the existing sales of an organization from the
+// database.
+func (s Store) Query(ctx context.Context, orgID string, pageNumber int, rowsPerPage int) ([]Sale, error) {
+	data := struct {
+		OrgID       string `db:"org_id"`
+		Offset      int    `db:"offset"`
+		RowsPerPage int    `db:"rows_per_page"`
+	}{
+		OrgID:       orgID,
+		Offset:      (pageNumber - 1) * rowsPerPage,
+		RowsPerPage: rowsPerPage,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		sales
+	WHERE
+		org_id = :org_id
+	ORDER BY
+		date_created DESC, sale_id
+	OFFSET :offset ROWS FETCH NEXT :rows_per_page ROWS ONLY`
+
+	var sles []Sale
+	if err := database.NamedQuerySlice(ctx, s.log, s.db, q, data, &sles); err != nil {
+		return nil, fmt.Errorf("selecting sales: %w", err)
+	}
+
+	return sles, nil
+}
+
+// QueryByUserID retrieves a list of the sales of an organization made to the
+// specified user.
+func (s Store) QueryByUserID(ctx context.Context, orgID string, userID string, pageNumber int, rowsPerPage int) ([]Sale, error) {
+	data := struct {
+		OrgID       string `db:"org_id"`
+		UserID      string `db:"user_id"`
+		Offset      int    `db:"offset"`
+		RowsPerPage int    `db:"rows_per_page"`
+	}{
+		OrgID:       orgID,
+		UserID:      userID,
+		Offset:      (pageNumber - 1) * rowsPerPage,
+		RowsPerPage: rowsPerPage,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		sales
+	WHERE
+		org_id = :org_id AND user_id = :user_id
+	ORDER BY
+		date_created DESC, sale_id
+	OFFSET :offset ROWS FETCH NEXT :rows_per_page ROWS ONLY`
+
+	var sles []Sale
+	if err := database.NamedQuerySlice(ctx, s.log, s.db, q, data, &sles); err != nil {
+		return nil, fmt.Errorf("selecting sales userID[%q]: %w", userID, err)
+	}
+
+	return sles, nil
+}
+
+// QueryKeyset retrieves up to limit sales of an organization, newest first,
+// that come after the keyset position. Only the sales made to userID are
+// returned unless it is empty. When the keyset is Backward the sales before
+// the position are returned, nearest first. A nil keyset starts at the newest
+// sale.
+func (s Store) QueryKeyset(ctx context.Context, orgID string, userID string, keyset *Keyset, limit int) ([]Sale, error) {
+	data := map[string]interface{}{
+		"org_id": orgID,
+		"limit":  limit,
+	}
+
+	wc := []string{"org_id = :org_id"}
+	if userID != "" {
+		data["user_id"] = userID
+		wc = append(wc, "user_id = :user_id")
+	}
+
+	direction := order.DESC
+	if keyset != nil {
+		op := "<"
+		if keyset.Backward {
+			op = ">"
+			direction = order.ASC
+		}
+
+		data["keyset_date_created"] = keyset.DateCreated
+		data["keyset_sale_id"] = keyset.SaleID
+		wc = append(wc, "(date_created, sale_id) "+op+" (:keyset_date_created, :keyset_sale_id)")
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		sales`
+
+	buf := bytes.NewBufferString(q)
+	buf.WriteString(" WHERE ")
+	buf.WriteString(strings.Join(wc, " AND "))
+	buf.WriteString(" ORDER BY date_created " + direction + ", sale_id " + direction)
+	buf.WriteString(" FETCH FIRST :limit ROWS ONLY")
+
+	var sles []Sale
+	if err := database.NamedQuerySlice(ctx, s.log, s.db, buf.String(), data, &sles); err != nil {
+		return nil, fmt.Errorf("selecting sales: %w", err)
+	}
+
+	return sles, nil
+}
+
+// QueryByID gets the specified sale of an organization from the database.
+func (s Store) QueryByID(ctx context.Context, orgID string, saleID string) (Sale, error) {
+	data := struct {
+		OrgID  string `db:"org_id"`
+		SaleID string `db:"sale_id"`
+	}{
+		OrgID:  orgID,
+		SaleID: saleID,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		sales
+	WHERE
+		org_id = :org_id AND sale_id = :sale_id`
+
+	var sle Sale
+	if err := database.NamedQueryStruct(ctx, s.log, s.db, q, data, &sle); err != nil {
+		return Sale{}, fmt.Errorf("selecting saleID[%q]: %w", saleID, err)
+	}
+
+	return sle, nil
+}
@@ -0,0 +1,45 @@
+package db
+
+import "time"
+
+// Sale represents the structure we need for moving data
+// between the app and the database.
+type Sale struct {
+	ID          string    `db:"sale_id"`
+	OrgID       string    `db:"org_id"`
+	UserID      string    `db:"user_id"`
+	ProductID   string    `db:"product_id"`
+	VariantID   *string   `db:"variant_id"`
+	Quantity    int       `db:"quantity"`
+	Paid        int64     `db:"paid"`
+	Currency    string    `db:"currency"`
+	DateCreated time.Time `db:"date_created"`
+}
+
+// Keyset is the position in the list of sales, newest first, to continue a
+// query from. When Backward is set the sales before the position are
+// returned instead of those after it.
+type Keyset struct {
+	DateCreated time.Time
+	SaleID      string
+	Backward    bool
+}
+
+// Refund represents the structure we need for moving data
+// between the app and the database.
+type Refund struct {
+	ID          string    `db:"refund_id"`
+	SaleID      string    `db:"sale_id"`
+	UserID      string    `db:"user_id"`
+	Amount      int64     `db:"amount"`
+	Currency    string    `db:"currency"`
+	Quantity    int       `db:"quantity"`
+	Reason      string    `db:"reason"`
+	DateCreated time.Time `db:"date_created"`
+}
+
+// RefundTotal represents everything refunded so far against a sale.
+type RefundTotal struct {
+	Amount   int64 `db:"amount"`
+	Quantity int   `db:"quantity"`
+}
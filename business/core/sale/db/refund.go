@@ -0,0 +1,109 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/andrewyang17/service/business/sys/database"
+)
+
+// QueryByIDForUpdate gets the specified sale of an organization and locks the
+// row until the surrounding transaction completes. It must be called from a
+// Store returned by Tran.
+func (s Store) QueryByIDForUpdate(ctx context.Context, orgID string, saleID string) (Sale, error) {
+	data := struct {
+		OrgID  string `db:"org_id"`
+		SaleID string `db:"sale_id"`
+	}{
+		OrgID:  orgID,
+		SaleID: saleID,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		sales
+	WHERE
+		org_id = :org_id AND sale_id = :sale_id
+	FOR UPDATE`
+
+	var sle Sale
+	if err := database.NamedQueryStruct(ctx, s.log, s.db, q, data, &sle); err != nil {
+		return Sale{}, fmt.Errorf("selecting saleID[%q] for update: %w", saleID, err)
+	}
+
+	return sle, nil
+}
+
+// CreateRefund records a Refund in the database.
+func (s Store) CreateRefund(ctx context.Context, ref Refund) error {
+	const q = `
+	INSERT INTO refunds
+		(refund_id, sale_id, user_id, amount, currency, quantity, reason, date_created)
+	VALUES
+		(:refund_id, :sale_id, :user_id, :amount, :currency, :quantity, :reason, :date_created)`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, ref); err != nil {
+		return fmt.Errorf("inserting refund: %w", err)
+	}
+
+	return nil
+}
+
+// QueryRefunds retrieves the refunds recorded against the specified sale of
+// an organization.
+func (s Store) QueryRefunds(ctx context.Context, orgID string, saleID string) ([]Refund, error) {
+	data := struct {
+		OrgID  string `db:"org_id"`
+		SaleID string `db:"sale_id"`
+	}{
+		OrgID:  orgID,
+		SaleID: saleID,
+	}
+
+	const q = `
+	SELECT
+		r.*
+	FROM
+		refunds AS r
+	JOIN
+		sales AS s ON s.sale_id = r.sale_id
+	WHERE
+		s.org_id = :org_id AND r.sale_id = :sale_id
+	ORDER BY
+		r.date_created, r.refund_id`
+
+	var refs []Refund
+	if err := database.NamedQuerySlice(ctx, s.log, s.db, q, data, &refs); err != nil {
+		return nil, fmt.Errorf("selecting refunds saleID[%q]: %w", saleID, err)
+	}
+
+	return refs, nil
+}
+
+// QueryRefundTotal sums the amount and quantity refunded against the
+// specified sale.
+func (s Store) QueryRefundTotal(ctx context.Context, saleID string) (RefundTotal, error) {
+	data := struct {
+		SaleID string `db:"sale_id"`
+	}{
+		SaleID: saleID,
+	}
+
+	const q = `
+	SELECT
+		COALESCE(SUM(amount), 0) AS amount,
+		COALESCE(SUM(quantity), 0) AS quantity
+	FROM
+		refunds
+	WHERE
+		sale_id = :sale_id`
+
+	var total RefundTotal
+	if err := database.NamedQueryStruct(ctx, s.log, s.db, q, data, &total); err != nil {
+		return RefundTotal{}, fmt.Errorf("selecting refund total saleID[%q]: %w", saleID, err)
+	}
+
+	return total, nil
+}
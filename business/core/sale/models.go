@@ -0,0 +1,108 @@
+package sale
+
+import (
+	"time"
+
+	"github.com/andrewyang17/service/business/core/sale/db"
+	"github.com/andrewyang17/service/business/sys/money"
+)
+
+// Sale represents a purchase of some quantity of a product.
+type Sale struct {
+	ID          string      `json:"id"`
+	UserID      string      `json:"user_id"`
+	ProductID   string      `json:"product_id"`
+	VariantID   string      `json:"variant_id,omitempty"`
+	Quantity    int         `json:"quantity"`
+	Paid        money.Money `json:"paid"`
+	DateCreated time.Time   `json:"date_created"`
+}
+
+// NewSale is what we require from clients when recording a Sale. The amount
+// paid is always calculated from the product's cost at the time of the sale.
+// When a VariantID is provided the variant is sold, at its own price when it
+// overrides the product's, and the stock is taken from the variant.
+type NewSale struct {
+	ProductID string `json:"product_id" validate:"required,uuid"`
+	VariantID string `json:"variant_id" validate:"omitempty,uuid"`
+	Quantity  int    `json:"quantity" validate:"gte=1"`
+	UserID    string `json:"user_id" validate:"required"`
+}
+
+// Page is a page of sales retrieved with a cursor. Next and Prev are opaque
+// tokens for the pages after and before this one, and are empty when there
+// is no such page.
+type Page struct {
+	Items []Sale `json:"items"`
+	Next  string `json:"next,omitempty"`
+	Prev  string `json:"prev,omitempty"`
+}
+
+// =============================================================================
+
+func toSale(dbSle db.Sale) Sale {
+	var variantID string
+	if dbSle.VariantID != nil {
+		variantID = *dbSle.VariantID
+	}
+
+	return Sale{
+		ID:          dbSle.ID,
+		UserID:      dbSle.UserID,
+		ProductID:   dbSle.ProductID,
+		VariantID:   variantID,
+		Quantity:    dbSle.Quantity,
+		Paid:        money.New(dbSle.Paid, dbSle.Currency),
+		DateCreated: dbSle.DateCreated,
+	}
+}
+
+func toSaleSlice(dbSles []db.Sale) []Sale {
+	sles := make([]Sale, len(dbSles))
+	for i, dbSle := range dbSles {
+		sles[i] = toSale(dbSle)
+	}
+	return sles
+}
+
+// Refund represents money, and optionally stock, returned against a sale.
+// UserID identifies the user that issued the refund.
+type Refund struct {
+	ID          string      `json:"id"`
+	SaleID      string      `json:"sale_id"`
+	UserID      string      `json:"user_id"`
+	Amount      money.Money `json:"amount"`
+	Quantity    int         `json:"quantity"`
+	Reason      string      `json:"reason"`
+	DateCreated time.Time   `json:"date_created"`
+}
+
+// NewRefund is what we require from clients when refunding a sale. Amount is
+// in minor units of the currency the sale was paid in. Quantity is the number
+// of units to put back into stock and may be zero when nothing is returned.
+type NewRefund struct {
+	UserID   string `json:"-" validate:"required"`
+	Amount   int64  `json:"amount" validate:"gte=1"`
+	Quantity int    `json:"quantity" validate:"gte=0"`
+	Reason   string `json:"reason" validate:"required"`
+}
+
+func toRefund(dbRef db.Refund) Refund {
+	return Refund{
+		ID:          dbRef.ID,
+		SaleID:      dbRef.SaleID,
+		UserID:      dbRef.UserID,
+		Amount:      money.New(dbRef.Amount, dbRef.Currency),
+		Quantity:    dbRef.Quantity,
+		Reason:      dbRef.Reason,
+		DateCreated: dbRef.DateCreated,
+	}
+}
+
+func toRefundSlice(dbRefs []db.Refund) []Refund {
+	refs := make([]Refund, len(dbRefs))
+	for i, dbRef := range dbRefs {
+		refs[i] = toRefund(dbRef)
+	}
+	return refs
+}
@@ -0,0 +1,140 @@
+package sale
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/andrewyang17/service/business/core/sale/db"
+	"github.com/andrewyang17/service/business/sys/database"
+	"github.com/andrewyang17/service/business/sys/tenant"
+	"github.com/andrewyang17/service/business/sys/validate"
+	"github.com/jmoiron/sqlx"
+)
+
+var (
+	ErrRefundExceedsPaid     = errors.New("refund amount exceeds what remains of the amount paid")
+	ErrRefundExceedsQuantity = errors.New("returned quantity exceeds what remains of the quantity sold")
+)
+
+// Refund records a full or partial refund against a sale. The sale row is
+// locked while earlier refunds are totalled, so concurrent refunds can never
+// return more than was paid or restock more than was sold.
+func (c Core) Refund(ctx context.Context, saleID string, nr NewRefund, now time.Time) (Refund, error) {
+	if err := validate.CheckID(saleID); err != nil {
+		return Refund{}, ErrInvalidID
+	}
+
+	if err := validate.Check(nr); err != nil {
+		return Refund{}, fmt.Errorf("validating data: %w", err)
+	}
+
+	orgID, err := tenant.Get(ctx)
+	if err != nil {
+		return Refund{}, err
+	}
+
+	dbRef := db.Refund{
+		ID:          validate.GenerateID(),
+		SaleID:      saleID,
+		UserID:      nr.UserID,
+		Amount:      nr.Amount,
+		Quantity:    nr.Quantity,
+		Reason:      nr.Reason,
+		DateCreated: now,
+	}
+
+	tran := func(tx sqlx.ExtContext) error {
+		store := c.store.Tran(tx)
+
+		dbSle, err := store.QueryByIDForUpdate(ctx, orgID, saleID)
+		if err != nil {
+			if errors.Is(err, database.ErrDBNotFound) {
+				return ErrNotFound
+			}
+			return fmt.Errorf("lock sale: %w", err)
+		}
+
+		total, err := store.QueryRefundTotal(ctx, saleID)
+		if err != nil {
+			return fmt.Errorf("refund total: %w", err)
+		}
+
+		if total.Amount+nr.Amount > dbSle.Paid {
+			return ErrRefundExceedsPaid
+		}
+
+		if total.Quantity+nr.Quantity > dbSle.Quantity {
+			return ErrRefundExceedsQuantity
+		}
+
+		// Money is always returned in the currency it was paid in.
+		dbRef.Currency = dbSle.Currency
+
+		// Returned stock goes back to the variant that was sold, or to the
+		// product when the sale wasn't for a variant.
+		switch {
+		case nr.Quantity > 0 && dbSle.VariantID != nil:
+			products := c.productStore.Tran(tx)
+
+			dbVrt, err := products.QueryVariantByIDForUpdate(ctx, orgID, *dbSle.VariantID)
+			if err != nil {
+				if errors.Is(err, database.ErrDBNotFound) {
+					return ErrVariantNotFound
+				}
+				return fmt.Errorf("lock variant: %w", err)
+			}
+
+			if err := products.UpdateVariantQuantity(ctx, dbVrt.ID, dbVrt.Quantity+nr.Quantity, now); err != nil {
+				return fmt.Errorf("update variant quantity: %w", err)
+			}
+
+		case nr.Quantity > 0:
+			products := c.productStore.Tran(tx)
+
+			dbPrd, err := products.QueryByIDForUpdate(ctx, orgID, dbSle.ProductID)
+			if err != nil {
+				if errors.Is(err, database.ErrDBNotFound) {
+					return ErrProductNotFound
+				}
+				return fmt.Errorf("lock product: %w", err)
+			}
+
+			if err := products.UpdateQuantity(ctx, dbPrd.ID, dbPrd.Quantity+nr.Quantity, now); err != nil {
+				return fmt.Errorf("update quantity: %w", err)
+			}
+		}
+
+		if err := store.CreateRefund(ctx, dbRef); err != nil {
+			return fmt.Errorf("create refund: %w", err)
+		}
+
+		return nil
+	}
+
+	if err := c.store.WithinTran(ctx, tran); err != nil {
+		return Refund{}, fmt.Errorf("tran: %w", err)
+	}
+
+	return toRefund(dbRef), nil
+}
+
+// QueryRefunds retrieves the refunds recorded against the specified sale.
+func (c Core) QueryRefunds(ctx context.Context, saleID string) ([]Refund, error) {
+	if err := validate.CheckID(saleID); err != nil {
+		return nil, ErrInvalidID
+	}
+
+	orgID, err := tenant.Get(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	dbRefs, err := c.store.QueryRefunds(ctx, orgID, saleID)
+	if err != nil {
+		return nil, fmt.Errorf("query: %w", err)
+	}
+
+	return toRefundSlice(dbRefs), nil
+}
@@ -0,0 +1,287 @@
+// Package sale provides the core business API for recording purchases.
+package sale
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	productDB "github.com/andrewyang17/service/business/core/product/db"
+	"github.com/andrewyang17/service/business/core/sale/db"
+	"github.com/andrewyang17/service/business/data/cursor"
+	"github.com/andrewyang17/service/business/data/order"
+	"github.com/andrewyang17/service/business/sys/database"
+	"github.com/andrewyang17/service/business/sys/tenant"
+	"github.com/andrewyang17/service/business/sys/validate"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrNotFound          = errors.New("sale not found")
+	ErrInvalidID         = errors.New("ID is not in its proper form")
+	ErrProductNotFound   = errors.New("product not found")
+	ErrVariantNotFound   = errors.New("variant not found")
+	ErrInsufficientStock = errors.New("not enough product in stock")
+)
+
+// Core manages the set of APIs for sale access.
+type Core struct {
+	store        db.Store
+	productStore productDB.Store
+}
+
+// NewCore constructs a core for sale api access.
+func NewCore(log *zap.SugaredLogger, sqlxDB *sqlx.DB) Core {
+	return Core{
+		store:        db.NewStore(log, sqlxDB),
+		productStore: productDB.NewStore(log, sqlxDB),
+	}
+}
+
+// Create records a new sale. The product row, and the variant row when a
+// variant is sold, is locked for the life of the transaction so concurrent
+// purchases of the same product are serialized and the quantity on hand can
+// never drop below zero.
+func (c Core) Create(ctx context.Context, ns NewSale, now time.Time) (Sale, error) {
+	if err := validate.Check(ns); err != nil {
+		return Sale{}, fmt.Errorf("validating data: %w", err)
+	}
+
+	if err := validate.CheckID(ns.ProductID); err != nil {
+		return Sale{}, ErrInvalidID
+	}
+
+	orgID, err := tenant.Get(ctx)
+	if err != nil {
+		return Sale{}, err
+	}
+
+	var dbSle db.Sale
+
+	tran := func(tx sqlx.ExtContext) error {
+		products := c.productStore.Tran(tx)
+
+		dbPrd, err := products.QueryByIDForUpdate(ctx, orgID, ns.ProductID)
+		if err != nil {
+			if errors.Is(err, database.ErrDBNotFound) {
+				return ErrProductNotFound
+			}
+			return fmt.Errorf("lock product: %w", err)
+		}
+
+		dbSle = db.Sale{
+			ID:          validate.GenerateID(),
+			OrgID:       orgID,
+			UserID:      ns.UserID,
+			ProductID:   dbPrd.ID,
+			Quantity:    ns.Quantity,
+			Currency:    dbPrd.Currency,
+			DateCreated: now,
+		}
+
+		price := dbPrd.Cost
+
+		switch ns.VariantID {
+		case "":
+			if dbPrd.Quantity < ns.Quantity {
+				return ErrInsufficientStock
+			}
+
+			if err := products.UpdateQuantity(ctx, dbPrd.ID, dbPrd.Quantity-ns.Quantity, now); err != nil {
+				return fmt.Errorf("update quantity: %w", err)
+			}
+
+		default:
+			dbVrt, err := products.QueryVariantByIDForUpdate(ctx, orgID, ns.VariantID)
+			if err != nil {
+				if errors.Is(err, database.ErrDBNotFound) {
+					return ErrVariantNotFound
+				}
+				return fmt.Errorf("lock variant: %w", err)
+			}
+
+			if dbVrt.ProductID != dbPrd.ID {
+				return ErrVariantNotFound
+			}
+
+			if dbVrt.Quantity < ns.Quantity {
+				return ErrInsufficientStock
+			}
+
+			if err := products.UpdateVariantQuantity(ctx, dbVrt.ID, dbVrt.Quantity-ns.Quantity, now); err != nil {
+				return fmt.Errorf("update variant quantity: %w", err)
+			}
+
+			if dbVrt.Cost != nil {
+				price = *dbVrt.Cost
+			}
+			dbSle.VariantID = &dbVrt.ID
+		}
+
+		dbSle.Paid = price * int64(ns.Quantity)
+
+		if err := c.store.Tran(tx).Create(ctx, dbSle); err != nil {
+			return fmt.Errorf("create: %w", err)
+		}
+
+		return nil
+	}
+
+	if err := c.store.WithinTran(ctx, tran); err != nil {
+		return Sale{}, fmt.Errorf("tran: %w", err)
+	}
+
+	return toSale(dbSle), nil
+}
+
+// Query retrieves a list of the existing sales of the organization the
+// context acts for from the database.
+func (c Core) Query(ctx context.Context, pageNumber int, rowsPerPage int) ([]Sale, error) {
+	orgID, err := tenant.Get(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	dbSles, err := c.store.Query(ctx, orgID, pageNumber, rowsPerPage)
+	if err != nil {
+		return nil, fmt.Errorf("query: %w", err)
+	}
+
+	return toSaleSlice(dbSles), nil
+}
+
+// QueryByUserID retrieves a list of sales made to the specified user.
+func (c Core) QueryByUserID(ctx context.Context, userID string, pageNumber int, rowsPerPage int) ([]Sale, error) {
+	if err := validate.CheckID(userID); err != nil {
+		return nil, ErrInvalidID
+	}
+
+	orgID, err := tenant.Get(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	dbSles, err := c.store.QueryByUserID(ctx, orgID, userID, pageNumber, rowsPerPage)
+	if err != nil {
+		return nil, fmt.Errorf("query: %w", err)
+	}
+
+	return toSaleSlice(dbSles), nil
+}
+
+// QueryCursor retrieves a page of sales, newest first, starting from the
+// position marked by the cursor token. An empty token starts at the newest
+// sale. Only the sales made to userID are returned unless it is empty.
+func (c Core) QueryCursor(ctx context.Context, userID string, token string, rows int) (Page, error) {
+	if userID != "" {
+		if err := validate.CheckID(userID); err != nil {
+			return Page{}, ErrInvalidID
+		}
+	}
+
+	keyset, err := decodeKeyset(token)
+	if err != nil {
+		return Page{}, err
+	}
+
+	orgID, err := tenant.Get(ctx)
+	if err != nil {
+		return Page{}, err
+	}
+
+	// One extra sale is asked for to find out if there is another page.
+	dbSles, err := c.store.QueryKeyset(ctx, orgID, userID, keyset, rows+1)
+	if err != nil {
+		return Page{}, fmt.Errorf("query: %w", err)
+	}
+
+	more := len(dbSles) > rows
+	if more {
+		dbSles = dbSles[:rows]
+	}
+
+	backward := keyset != nil && keyset.Backward
+	if backward {
+		for i, j := 0, len(dbSles)-1; i < j; i, j = i+1, j-1 {
+			dbSles[i], dbSles[j] = dbSles[j], dbSles[i]
+		}
+	}
+
+	page := Page{
+		Items: toSaleSlice(dbSles),
+	}
+
+	if len(dbSles) == 0 {
+		return page, nil
+	}
+
+	first, last := dbSles[0], dbSles[len(dbSles)-1]
+
+	// Moving backward means there are always sales after this page, and
+	// moving forward from a cursor means there are always sales before it.
+	if more || backward {
+		page.Next = cursor.New(cursorOrder, last.DateCreated.Format(time.RFC3339Nano), last.ID, false).Encode()
+	}
+	if (more && backward) || (keyset != nil && !backward) {
+		page.Prev = cursor.New(cursorOrder, first.DateCreated.Format(time.RFC3339Nano), first.ID, true).Encode()
+	}
+
+	return page, nil
+}
+
+// QueryByID gets the specified sale from the database.
+func (c Core) QueryByID(ctx context.Context, saleID string) (Sale, error) {
+	if err := validate.CheckID(saleID); err != nil {
+		return Sale{}, ErrInvalidID
+	}
+
+	orgID, err := tenant.Get(ctx)
+	if err != nil {
+		return Sale{}, err
+	}
+
+	dbSle, err := c.store.QueryByID(ctx, orgID, saleID)
+	if err != nil {
+		if errors.Is(err, database.ErrDBNotFound) {
+			return Sale{}, ErrNotFound
+		}
+		return Sale{}, fmt.Errorf("query: %w", err)
+	}
+
+	return toSale(dbSle), nil
+}
+
+// =============================================================================
+
+// cursorOrder is the order sales are paged through with a cursor.
+var cursorOrder = order.NewBy("date_created", order.DESC)
+
+// decodeKeyset turns a cursor token into the position in the list of sales
+// to continue from. An empty token starts at the newest sale.
+func decodeKeyset(token string) (*db.Keyset, error) {
+	if token == "" {
+		return nil, nil
+	}
+
+	cur, err := cursor.Decode(token)
+	if err != nil {
+		return nil, err
+	}
+
+	dateCreated, err := time.Parse(time.RFC3339Nano, cur.Value)
+	if err != nil || cur.OrderBy() != cursorOrder {
+		return nil, validate.FieldErrors{
+			{Field: "cursor", Error: "cursor is not valid"},
+		}
+	}
+
+	keyset := db.Keyset{
+		DateCreated: dateCreated,
+		SaleID:      cur.ID,
+		Backward:    cur.Backward,
+	}
+
+	return &keyset, nil
+}
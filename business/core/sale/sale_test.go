@@ -0,0 +1,252 @@
+package sale_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/andrewyang17/service/business/core/organization"
+	"github.com/andrewyang17/service/business/core/product"
+	"github.com/andrewyang17/service/business/core/sale"
+	"github.com/andrewyang17/service/business/data/dbtest"
+	"github.com/andrewyang17/service/business/sys/money"
+	"github.com/andrewyang17/service/business/sys/tenant"
+	"github.com/andrewyang17/service/business/sys/validate"
+	"github.com/andrewyang17/service/foundation/docker"
+)
+
+var c *docker.Container
+
+func TestMain(m *testing.M) {
+	var err error
+	c, err = dbtest.StartDB()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer dbtest.StopDB(c)
+
+	m.Run()
+}
+
+const (
+	adminID = "5cf37266-3473-4006-984f-9325122678b7"
+	userID  = "45b5fbd3-755f-4379-8f07-a58d4a30fa2f"
+)
+
+func TestSale(t *testing.T) {
+	log, db, teardown := dbtest.NewUnit(t, c, "testsale")
+	t.Cleanup(teardown)
+
+	prdCore := product.NewCore(log, db)
+	core := sale.NewCore(log, db)
+
+	t.Log("Given the need to work with Sale records.")
+	{
+		testID := 0
+		t.Logf("\tTest %d:\tWhen handling a single Sale.", testID)
+		{
+			ctx := tenant.Set(context.Background(), organization.DefaultID)
+			now := time.Date(2022, time.January, 8, 17, 56, 0, 0, time.UTC)
+
+			prd, err := prdCore.Create(ctx, product.NewProduct{Name: "Puzzles", Cost: money.New(15, money.USD), Quantity: 10, UserID: adminID}, now)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to create a product : %s.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould be able to create a product.", dbtest.Success, testID)
+
+			ns := sale.NewSale{
+				ProductID: prd.ID,
+				Quantity:  4,
+				UserID:    userID,
+			}
+
+			sle, err := core.Create(ctx, ns, now)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to create a sale : %s.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould be able to create a sale.", dbtest.Success, testID)
+
+			if sle.Paid != money.New(60, money.USD) {
+				t.Fatalf("\t%s\tTest %d:\tShould charge cost times quantity : got %v want %v.", dbtest.Failed, testID, sle.Paid, money.New(60, money.USD))
+			}
+			t.Logf("\t%s\tTest %d:\tShould charge cost times quantity.", dbtest.Success, testID)
+
+			saved, err := prdCore.QueryByID(ctx, prd.ID)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to retrieve product : %s.", dbtest.Failed, testID, err)
+			}
+
+			if saved.Quantity != 6 {
+				t.Fatalf("\t%s\tTest %d:\tShould decrement the product quantity : got %d want %d.", dbtest.Failed, testID, saved.Quantity, 6)
+			}
+			t.Logf("\t%s\tTest %d:\tShould decrement the product quantity.", dbtest.Success, testID)
+
+			ns.Quantity = 7
+			if _, err := core.Create(ctx, ns, now); !errors.Is(err, sale.ErrInsufficientStock) {
+				t.Fatalf("\t%s\tTest %d:\tShould NOT be able to oversell a product : %v.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould NOT be able to oversell a product.", dbtest.Success, testID)
+
+			bad := sale.NewSale{ProductID: ns.ProductID, VariantID: "not-a-uuid", Quantity: 1, UserID: userID}
+
+			var fieldErrors validate.FieldErrors
+			if _, err := core.Create(ctx, bad, now); !errors.As(err, &fieldErrors) {
+				t.Fatalf("\t%s\tTest %d:\tShould NOT be able to sell a malformed variant : %v.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould NOT be able to sell a malformed variant.", dbtest.Success, testID)
+
+			sales, err := core.QueryByUserID(ctx, userID, 1, 10)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to retrieve sales by user : %s.", dbtest.Failed, testID, err)
+			}
+
+			if len(sales) != 1 || sales[0].ID != sle.ID {
+				t.Fatalf("\t%s\tTest %d:\tShould get back the single sale for the user : %+v.", dbtest.Failed, testID, sales)
+			}
+			t.Logf("\t%s\tTest %d:\tShould get back the single sale for the user.", dbtest.Success, testID)
+		}
+	}
+}
+
+func TestSaleConcurrent(t *testing.T) {
+	log, db, teardown := dbtest.NewUnit(t, c, "testsaleconcurrent")
+	t.Cleanup(teardown)
+
+	prdCore := product.NewCore(log, db)
+	core := sale.NewCore(log, db)
+
+	t.Log("Given the need to sell a product to many buyers at once.")
+	{
+		testID := 0
+		t.Logf("\tTest %d:\tWhen 20 buyers compete for 10 units.", testID)
+		{
+			ctx := tenant.Set(context.Background(), organization.DefaultID)
+			now := time.Now()
+
+			prd, err := prdCore.Create(ctx, product.NewProduct{Name: "Tickets", Cost: money.New(1, money.USD), Quantity: 10, UserID: adminID}, now)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to create a product : %s.", dbtest.Failed, testID, err)
+			}
+
+			const buyers = 20
+
+			var (
+				wg   sync.WaitGroup
+				mu   sync.Mutex
+				sold int
+			)
+			wg.Add(buyers)
+			for i := 0; i < buyers; i++ {
+				go func() {
+					defer wg.Done()
+
+					_, err := core.Create(ctx, sale.NewSale{ProductID: prd.ID, Quantity: 1, UserID: userID}, now)
+					switch {
+					case err == nil:
+						mu.Lock()
+						sold++
+						mu.Unlock()
+					case errors.Is(err, sale.ErrInsufficientStock):
+					default:
+						t.Errorf("\t%s\tTest %d:\tShould not get an unexpected error : %s.", dbtest.Failed, testID, err)
+					}
+				}()
+			}
+			wg.Wait()
+
+			if sold != 10 {
+				t.Fatalf("\t%s\tTest %d:\tShould sell exactly 10 units : got %d.", dbtest.Failed, testID, sold)
+			}
+			t.Logf("\t%s\tTest %d:\tShould sell exactly 10 units.", dbtest.Success, testID)
+
+			saved, err := prdCore.QueryByID(ctx, prd.ID)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to retrieve product : %s.", dbtest.Failed, testID, err)
+			}
+
+			if saved.Quantity != 0 {
+				t.Fatalf("\t%s\tTest %d:\tShould have no stock left : got %d.", dbtest.Failed, testID, saved.Quantity)
+			}
+			t.Logf("\t%s\tTest %d:\tShould have no stock left.", dbtest.Success, testID)
+		}
+	}
+}
+
+func TestRefund(t *testing.T) {
+	log, db, teardown := dbtest.NewUnit(t, c, "testrefund")
+	t.Cleanup(teardown)
+
+	prdCore := product.NewCore(log, db)
+	core := sale.NewCore(log, db)
+
+	t.Log("Given the need to refund Sale records.")
+	{
+		testID := 0
+		t.Logf("\tTest %d:\tWhen refunding part of a single Sale.", testID)
+		{
+			ctx := tenant.Set(context.Background(), organization.DefaultID)
+			now := time.Date(2022, time.January, 8, 17, 56, 0, 0, time.UTC)
+
+			prd, err := prdCore.Create(ctx, product.NewProduct{Name: "Kites", Cost: money.New(20, money.USD), Quantity: 10, UserID: adminID}, now)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to create a product : %s.", dbtest.Failed, testID, err)
+			}
+
+			sle, err := core.Create(ctx, sale.NewSale{ProductID: prd.ID, Quantity: 3, UserID: userID}, now)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to create a sale : %s.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould be able to create a sale.", dbtest.Success, testID)
+
+			nr := sale.NewRefund{
+				UserID:   adminID,
+				Amount:   40,
+				Quantity: 2,
+				Reason:   "damaged in transit",
+			}
+
+			if _, err := core.Refund(ctx, sle.ID, nr, now); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to refund part of a sale : %s.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould be able to refund part of a sale.", dbtest.Success, testID)
+
+			saved, err := prdCore.QueryByID(ctx, prd.ID)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to retrieve product : %s.", dbtest.Failed, testID, err)
+			}
+
+			if saved.Quantity != 9 {
+				t.Fatalf("\t%s\tTest %d:\tShould restock the returned units : got %d want %d.", dbtest.Failed, testID, saved.Quantity, 9)
+			}
+			t.Logf("\t%s\tTest %d:\tShould restock the returned units.", dbtest.Success, testID)
+
+			nr.Amount = 21
+			nr.Quantity = 0
+			if _, err := core.Refund(ctx, sle.ID, nr, now); !errors.Is(err, sale.ErrRefundExceedsPaid) {
+				t.Fatalf("\t%s\tTest %d:\tShould NOT be able to refund more than was paid : %v.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould NOT be able to refund more than was paid.", dbtest.Success, testID)
+
+			nr.Amount = 20
+			nr.Quantity = 2
+			if _, err := core.Refund(ctx, sle.ID, nr, now); !errors.Is(err, sale.ErrRefundExceedsQuantity) {
+				t.Fatalf("\t%s\tTest %d:\tShould NOT be able to return more than was sold : %v.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould NOT be able to return more than was sold.", dbtest.Success, testID)
+
+			refs, err := core.QueryRefunds(ctx, sle.ID)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to retrieve refunds : %s.", dbtest.Failed, testID, err)
+			}
+
+			if len(refs) != 1 || refs[0].Amount != money.New(40, money.USD) {
+				t.Fatalf("\t%s\tTest %d:\tShould get back the single refund : %+v.", dbtest.Failed, testID, refs)
+			}
+			t.Logf("\t%s\tTest %d:\tShould get back the single refund.", dbtest.Success, testID)
+		}
+	}
+}
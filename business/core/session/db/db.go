@@ -0,0 +1,294 @@
+// Package db contains session related CRUD functionality.
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/andrewyang17/service/business/sys/database"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+type Store struct {
+	log          *zap.SugaredLogger
+	tr           database.Transactor
+	db           sqlx.ExtContext
+	isWithinTran bool
+}
+
+func NewStore(log *zap.SugaredLogger, db *sqlx.DB) Store {
+	return Store{
+		log: log,
+		tr:  db,
+		db:  db,
+	}
+}
+
+// WithinTran runs passed function and do commit/rollback at the end.
+func (s Store) WithinTran(ctx context.Context, fn func(extContext sqlx.ExtContext) error) error {
+	if s.isWithinTran {
+		return fn(s.db)
+	}
+	return database.WithinTran(ctx, s.log, s.tr, fn)
+}
+
+// Tran return new Store with transaction in it.
+func (s Store) Tran(tx sqlx.ExtContext) Store {
+	return Store{
+		log:          s.log,
+		tr:           s.tr,
+		db:           tx,
+		isWithinTran: true,
+	}
+}
+
+// Create adds a Session to the database.
+func (s Store) Create(ctx context.Context, ses Session) error {
+	const q = `
+	INSERT INTO sessions
+		(session_id, user_id, org_id, date_expires, date_revoked, date_created)
+	VALUES
+		(:session_id, :user_id, :org_id, :date_expires, :date_revoked, :date_created)`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, ses); err != nil {
+		return fmt.Errorf("inserting session: %w", err)
+	}
+
+	return nil
+}
+
+// QueryByID gets the specified session from the database.
+func (s Store) QueryByID(ctx context.Context, sessionID string) (Session, error) {
+	data := struct {
+		SessionID string `db:"session_id"`
+	}{
+		SessionID: sessionID,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		sessions
+	WHERE
+		session_id = :session_id`
+
+	var ses Session
+	if err := database.NamedQueryStruct(ctx, s.log, s.db, q, data, &ses); err != nil {
+		return Session{}, fmt.Errorf("selecting sessionID[%q]: %w", sessionID, err)
+	}
+
+	return ses, nil
+}
+
+// QueryByIDForUpdate gets the specified session from the database and locks
+// the row until the surrounding transaction completes. It must be called from
+// a Store returned by Tran.
+func (s Store) QueryByIDForUpdate(ctx context.Context, sessionID string) (Session, error) {
+	data := struct {
+		SessionID string `db:"session_id"`
+	}{
+		SessionID: sessionID,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		sessions
+	WHERE
+		session_id = :session_id
+	FOR UPDATE`
+
+	var ses Session
+	if err := database.NamedQueryStruct(ctx, s.log, s.db, q, data, &ses); err != nil {
+		return Session{}, fmt.Errorf("selecting sessionID[%q] for update: %w", sessionID, err)
+	}
+
+	return ses, nil
+}
+
+// Revoke marks the specified session as revoked. A session that is already
+// revoked keeps its original date.
+func (s Store) Revoke(ctx context.Context, sessionID string, now time.Time) error {
+	data := struct {
+		SessionID   string    `db:"session_id"`
+		DateRevoked time.Time `db:"date_revoked"`
+	}{
+		SessionID:   sessionID,
+		DateRevoked: now,
+	}
+
+	const q = `
+	UPDATE
+		sessions
+	SET
+		"date_revoked" = :date_revoked
+	WHERE
+		session_id = :session_id AND
+		date_revoked IS NULL`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, data); err != nil {
+		return fmt.Errorf("revoking sessionID[%q]: %w", sessionID, err)
+	}
+
+	return nil
+}
+
+// RevokeByUserID marks every session of the user that is not already revoked
+// as revoked.
+func (s Store) RevokeByUserID(ctx context.Context, userID string, now time.Time) error {
+	data := struct {
+		UserID      string    `db:"user_id"`
+		DateRevoked time.Time `db:"date_revoked"`
+	}{
+		UserID:      userID,
+		DateRevoked: now,
+	}
+
+	const q = `
+	UPDATE
+		sessions
+	SET
+		"date_revoked" = :date_revoked
+	WHERE
+		user_id = :user_id AND
+		date_revoked IS NULL`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, data); err != nil {
+		return fmt.Errorf("revoking sessions userID[%q]: %w", userID, err)
+	}
+
+	return nil
+}
+
+// CreateRefreshToken adds a RefreshToken to the database.
+func (s Store) CreateRefreshToken(ctx context.Context, tkn RefreshToken) error {
+	const q = `
+	INSERT INTO refresh_tokens
+		(token_id, session_id, token_hash, date_expires, date_used, date_created)
+	VALUES
+		(:token_id, :session_id, :token_hash, :date_expires, :date_used, :date_created)`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, tkn); err != nil {
+		return fmt.Errorf("inserting refresh token: %w", err)
+	}
+
+	return nil
+}
+
+// QueryRefreshTokenByHashForUpdate finds the refresh token with the specified
+// hash and locks the row until the surrounding transaction completes. It must
+// be called from a Store returned by Tran.
+func (s Store) QueryRefreshTokenByHashForUpdate(ctx context.Context, tokenHash string) (RefreshToken, error) {
+	data := struct {
+		TokenHash string `db:"token_hash"`
+	}{
+		TokenHash: tokenHash,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		refresh_tokens
+	WHERE
+		token_hash = :token_hash
+	FOR UPDATE`
+
+	var tkn RefreshToken
+	if err := database.NamedQueryStruct(ctx, s.log, s.db, q, data, &tkn); err != nil {
+		return RefreshToken{}, fmt.Errorf("selecting refresh token: %w", err)
+	}
+
+	return tkn, nil
+}
+
+// UseRefreshToken marks the specified refresh token as used.
+func (s Store) UseRefreshToken(ctx context.Context, tokenID string, now time.Time) error {
+	data := struct {
+		TokenID  string    `db:"token_id"`
+		DateUsed time.Time `db:"date_used"`
+	}{
+		TokenID:  tokenID,
+		DateUsed: now,
+	}
+
+	const q = `
+	UPDATE
+		refresh_tokens
+	SET
+		"date_used" = :date_used
+	WHERE
+		token_id = :token_id`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, data); err != nil {
+		return fmt.Errorf("using refresh tokenID[%q]: %w", tokenID, err)
+	}
+
+	return nil
+}
+
+// CreateRevokedToken adds a RevokedToken to the database. Revoking a token
+// twice is not an error.
+func (s Store) CreateRevokedToken(ctx context.Context, tkn RevokedToken) error {
+	const q = `
+	INSERT INTO revoked_tokens
+		(token_id, date_expires, date_created)
+	VALUES
+		(:token_id, :date_expires, :date_created)
+	ON CONFLICT (token_id) DO NOTHING`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, tkn); err != nil {
+		return fmt.Errorf("inserting revoked token: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteExpiredRevokedTokens removes the revoked tokens that have expired,
+// since they can no longer be used anyway.
+func (s Store) DeleteExpiredRevokedTokens(ctx context.Context, now time.Time) error {
+	data := struct {
+		Now time.Time `db:"now"`
+	}{
+		Now: now,
+	}
+
+	const q = `
+	DELETE FROM
+		revoked_tokens
+	WHERE
+		date_expires <= :now`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, data); err != nil {
+		return fmt.Errorf("deleting expired revoked tokens: %w", err)
+	}
+
+	return nil
+}
+
+// IsTokenRevoked reports whether the token with the specified ID has been
+// revoked.
+func (s Store) IsTokenRevoked(ctx context.Context, tokenID string) (bool, error) {
+	data := struct {
+		TokenID string `db:"token_id"`
+	}{
+		TokenID: tokenID,
+	}
+
+	const q = `
+	SELECT
+		EXISTS (SELECT 1 FROM revoked_tokens WHERE token_id = :token_id) AS revoked`
+
+	var result struct {
+		Revoked bool `db:"revoked"`
+	}
+	if err := database.NamedQueryStruct(ctx, s.log, s.db, q, data, &result); err != nil {
+		return false, fmt.Errorf("selecting revoked tokenID[%q]: %w", tokenID, err)
+	}
+
+	return result.Revoked, nil
+}
@@ -0,0 +1,33 @@
+package db
+
+import "time"
+
+// Session represents the structure we need for moving data
+// between the app and the database.
+type Session struct {
+	ID          string     `db:"session_id"`
+	UserID      string     `db:"user_id"`
+	OrgID       string     `db:"org_id"`
+	DateExpires time.Time  `db:"date_expires"`
+	DateRevoked *time.Time `db:"date_revoked"`
+	DateCreated time.Time  `db:"date_created"`
+}
+
+// RefreshToken represents the structure we need for moving data
+// between the app and the database.
+type RefreshToken struct {
+	ID          string     `db:"token_id"`
+	SessionID   string     `db:"session_id"`
+	TokenHash   string     `db:"token_hash"`
+	DateExpires time.Time  `db:"date_expires"`
+	DateUsed    *time.Time `db:"date_used"`
+	DateCreated time.Time  `db:"date_created"`
+}
+
+// RevokedToken represents the structure we need for moving data
+// between the app and the database.
+type RevokedToken struct {
+	ID          string    `db:"token_id"`
+	DateExpires time.Time `db:"date_expires"`
+	DateCreated time.Time `db:"date_created"`
+}
@@ -0,0 +1,6 @@
+package session
+
+// Refresh is what we require from clients to redeem a refresh token.
+type Refresh struct {
+	Token string `json:"refresh_token" validate:"required"`
+}
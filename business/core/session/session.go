@@ -0,0 +1,327 @@
+// Package session provides the core business API for the sessions users start
+// when they log in. A session hands out refresh tokens that are rotated on
+// every use and keeps track of the access tokens that have been revoked.
+package session
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	roleDB "github.com/andrewyang17/service/business/core/role/db"
+	"github.com/andrewyang17/service/business/core/session/db"
+	userDB "github.com/andrewyang17/service/business/core/user/db"
+	"github.com/andrewyang17/service/business/sys/auth"
+	"github.com/andrewyang17/service/business/sys/database"
+	"github.com/andrewyang17/service/business/sys/validate"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrInvalidID    = errors.New("ID is not in its proper form")
+	ErrInvalidToken = errors.New("refresh token is invalid or has expired")
+	ErrTokenReused  = errors.New("refresh token was already used, the session has been revoked")
+)
+
+// Set of lifetimes for the tokens of a session. A refresh token is replaced
+// every time it is used, but no session outlives sessionTTL.
+const (
+	accessTTL  = time.Hour
+	refreshTTL = 7 * 24 * time.Hour
+	sessionTTL = 30 * 24 * time.Hour
+)
+
+// Core manages the set of APIs for session access.
+type Core struct {
+	store     db.Store
+	userStore userDB.Store
+	roleStore roleDB.Store
+}
+
+// NewCore constructs a core for session api access.
+func NewCore(log *zap.SugaredLogger, sqlxDB *sqlx.DB) Core {
+	return Core{
+		store:     db.NewStore(log, sqlxDB),
+		userStore: userDB.NewStore(log, sqlxDB),
+		roleStore: roleDB.NewStore(log, sqlxDB),
+	}
+}
+
+// Create starts a session for the user and organization of the claims the
+// user just authenticated with. The claims are returned tied to the session
+// along with the first refresh token of the session. Only a hash of the
+// refresh token is stored, so the token returned here is the only copy of it.
+func (c Core) Create(ctx context.Context, claims auth.Claims, now time.Time) (auth.Claims, string, error) {
+	dbSes := db.Session{
+		ID:          validate.GenerateID(),
+		UserID:      claims.Subject,
+		OrgID:       claims.OrgID,
+		DateExpires: now.Add(sessionTTL),
+		DateCreated: now,
+	}
+
+	var token string
+	tran := func(tx sqlx.ExtContext) error {
+		store := c.store.Tran(tx)
+
+		if err := store.Create(ctx, dbSes); err != nil {
+			return fmt.Errorf("create: %w", err)
+		}
+
+		var err error
+		token, err = issueRefreshToken(ctx, store, dbSes, now)
+		if err != nil {
+			return fmt.Errorf("issue refresh token: %w", err)
+		}
+
+		return nil
+	}
+
+	if err := c.store.WithinTran(ctx, tran); err != nil {
+		return auth.Claims{}, "", fmt.Errorf("tran: %w", err)
+	}
+
+	if claims.ID == "" {
+		claims.ID = validate.GenerateID()
+	}
+	claims.SessionID = dbSes.ID
+
+	return claims, token, nil
+}
+
+// Refresh redeems a refresh token for new claims and a new refresh token of
+// the same session. The claims pick up any change to the roles of the user.
+// A refresh token can only be used once, presenting it again means it was
+// stolen, so the whole session is revoked and ErrTokenReused is returned.
+func (c Core) Refresh(ctx context.Context, rf Refresh, now time.Time) (auth.Claims, string, error) {
+	if err := validate.Check(rf); err != nil {
+		return auth.Claims{}, "", fmt.Errorf("validating data: %w", err)
+	}
+
+	var claims auth.Claims
+	var token string
+	var reused bool
+
+	tran := func(tx sqlx.ExtContext) error {
+		store := c.store.Tran(tx)
+
+		dbTkn, err := store.QueryRefreshTokenByHashForUpdate(ctx, hashToken(rf.Token))
+		if err != nil {
+			if errors.Is(err, database.ErrDBNotFound) {
+				return ErrInvalidToken
+			}
+			return fmt.Errorf("lock refresh token: %w", err)
+		}
+
+		dbSes, err := store.QueryByIDForUpdate(ctx, dbTkn.SessionID)
+		if err != nil {
+			return fmt.Errorf("lock session: %w", err)
+		}
+
+		if dbSes.DateRevoked != nil || !now.Before(dbSes.DateExpires) {
+			return ErrInvalidToken
+		}
+
+		// The revocation has to be committed, so the error is only returned
+		// once the transaction is done.
+		if dbTkn.DateUsed != nil {
+			reused = true
+			if err := store.Revoke(ctx, dbSes.ID, now); err != nil {
+				return fmt.Errorf("revoke: %w", err)
+			}
+			return nil
+		}
+
+		if !now.Before(dbTkn.DateExpires) {
+			return ErrInvalidToken
+		}
+
+		if err := store.UseRefreshToken(ctx, dbTkn.ID, now); err != nil {
+			return fmt.Errorf("use refresh token: %w", err)
+		}
+
+		claims, err = c.claims(ctx, dbSes, now)
+		if err != nil {
+			return err
+		}
+
+		token, err = issueRefreshToken(ctx, store, dbSes, now)
+		if err != nil {
+			return fmt.Errorf("issue refresh token: %w", err)
+		}
+
+		return nil
+	}
+
+	if err := c.store.WithinTran(ctx, tran); err != nil {
+		if errors.Is(err, ErrInvalidToken) {
+			return auth.Claims{}, "", ErrInvalidToken
+		}
+		return auth.Claims{}, "", fmt.Errorf("tran: %w", err)
+	}
+
+	if reused {
+		return auth.Claims{}, "", ErrTokenReused
+	}
+
+	return claims, token, nil
+}
+
+// Logout revokes the access token the claims were parsed from along with the
+// session it belongs to.
+func (c Core) Logout(ctx context.Context, claims auth.Claims, now time.Time) error {
+	if validate.CheckID(claims.ID) == nil && claims.ExpiresAt != nil {
+		dbTkn := db.RevokedToken{
+			ID:          claims.ID,
+			DateExpires: claims.ExpiresAt.Time,
+			DateCreated: now,
+		}
+
+		if err := c.store.CreateRevokedToken(ctx, dbTkn); err != nil {
+			return fmt.Errorf("revoke token: %w", err)
+		}
+
+		if err := c.store.DeleteExpiredRevokedTokens(ctx, now); err != nil {
+			return fmt.Errorf("delete expired revoked tokens: %w", err)
+		}
+	}
+
+	if validate.CheckID(claims.SessionID) == nil {
+		if err := c.store.Revoke(ctx, claims.SessionID, now); err != nil {
+			return fmt.Errorf("revoke session: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// RevokeByUserID revokes every session of the user, which logs them out
+// everywhere.
+func (c Core) RevokeByUserID(ctx context.Context, userID string, now time.Time) error {
+	if err := validate.CheckID(userID); err != nil {
+		return ErrInvalidID
+	}
+
+	if err := c.store.RevokeByUserID(ctx, userID, now); err != nil {
+		return fmt.Errorf("revoke: %w", err)
+	}
+
+	return nil
+}
+
+// IsRevoked reports whether the access token the claims were parsed from has
+// been revoked, either on its own or along with its session. It implements
+// auth.RevocationStore.
+func (c Core) IsRevoked(ctx context.Context, claims auth.Claims) (bool, error) {
+	if validate.CheckID(claims.ID) == nil {
+		revoked, err := c.store.IsTokenRevoked(ctx, claims.ID)
+		if err != nil {
+			return false, fmt.Errorf("query token: %w", err)
+		}
+		if revoked {
+			return true, nil
+		}
+	}
+
+	if claims.SessionID == "" {
+		return false, nil
+	}
+
+	if err := validate.CheckID(claims.SessionID); err != nil {
+		return true, nil
+	}
+
+	dbSes, err := c.store.QueryByID(ctx, claims.SessionID)
+	if err != nil {
+		if errors.Is(err, database.ErrDBNotFound) {
+			return true, nil
+		}
+		return false, fmt.Errorf("query session: %w", err)
+	}
+
+	return dbSes.DateRevoked != nil, nil
+}
+
+// =============================================================================
+
+// claims builds fresh claims for the user of the session. ErrInvalidToken is
+// returned when the user can no longer act for the organization of the
+// session.
+func (c Core) claims(ctx context.Context, dbSes db.Session, now time.Time) (auth.Claims, error) {
+	// Users removed from the organization are not found as members.
+	dbUsr, err := c.userStore.QueryMemberByID(ctx, dbSes.OrgID, dbSes.UserID)
+	if err != nil {
+		if errors.Is(err, database.ErrDBNotFound) {
+			return auth.Claims{}, ErrInvalidToken
+		}
+		return auth.Claims{}, fmt.Errorf("query user: %w", err)
+	}
+
+	if dbUsr.DateLockedUntil != nil && now.Before(*dbUsr.DateLockedUntil) {
+		return auth.Claims{}, ErrInvalidToken
+	}
+
+	perms, err := c.roleStore.QueryPermissions(ctx, dbUsr.Roles)
+	if err != nil {
+		return auth.Claims{}, fmt.Errorf("query permissions: %w", err)
+	}
+
+	claims := auth.Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    "service project",
+			Subject:   dbUsr.ID,
+			ExpiresAt: jwt.NewNumericDate(now.Add(accessTTL)),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ID:        validate.GenerateID(),
+		},
+		Roles:       dbUsr.Roles,
+		Permissions: perms,
+		OrgID:       dbSes.OrgID,
+		SessionID:   dbSes.ID,
+		Verified:    dbUsr.DateVerified != nil,
+	}
+
+	return claims, nil
+}
+
+// issueRefreshToken creates a refresh token for the session. It never
+// outlives the session.
+func issueRefreshToken(ctx context.Context, store db.Store, dbSes db.Session, now time.Time) (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generating token: %w", err)
+	}
+	token := base64.RawURLEncoding.EncodeToString(b)
+
+	expires := now.Add(refreshTTL)
+	if dbSes.DateExpires.Before(expires) {
+		expires = dbSes.DateExpires
+	}
+
+	dbTkn := db.RefreshToken{
+		ID:          validate.GenerateID(),
+		SessionID:   dbSes.ID,
+		TokenHash:   hashToken(token),
+		DateExpires: expires,
+		DateCreated: now,
+	}
+
+	if err := store.CreateRefreshToken(ctx, dbTkn); err != nil {
+		return "", fmt.Errorf("create refresh token: %w", err)
+	}
+
+	return token, nil
+}
+
+// hashToken returns the hash of a refresh token that is stored in its place.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,158 @@
+package session_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/andrewyang17/service/business/core/session"
+	"github.com/andrewyang17/service/business/core/user"
+	"github.com/andrewyang17/service/business/data/dbtest"
+	"github.com/andrewyang17/service/business/sys/mail"
+	"github.com/andrewyang17/service/foundation/docker"
+)
+
+var c *docker.Container
+
+func TestMain(m *testing.M) {
+	var err error
+	c, err = dbtest.StartDB()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer dbtest.StopDB(c)
+
+	m.Run()
+}
+
+func TestSession(t *testing.T) {
+	log, db, teardown := dbtest.NewUnit(t, c, "testsession")
+	t.Cleanup(teardown)
+
+	core := session.NewCore(log, db)
+	usrCore := user.NewCore(log, db, mail.NewLogMailer(log), dbtest.NewHasher(t))
+
+	t.Log("Given the need to work with sessions.")
+	{
+		testID := 0
+		t.Logf("\tTest %d:\tWhen handling a single session.", testID)
+		{
+			ctx := context.Background()
+			now := time.Date(2022, time.January, 8, 17, 56, 0, 0, time.UTC)
+
+			claims, err := usrCore.Authenticate(ctx, now, "user@example.com", "gophers", "")
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to authenticate : %s.", dbtest.Failed, testID, err)
+			}
+
+			if claims.ID == "" {
+				t.Fatalf("\t%s\tTest %d:\tShould get a token ID in the claims.", dbtest.Failed, testID)
+			}
+			t.Logf("\t%s\tTest %d:\tShould get a token ID in the claims.", dbtest.Success, testID)
+
+			claims, refresh, err := core.Create(ctx, claims, now)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to start session : %s.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould be able to start session.", dbtest.Success, testID)
+
+			refreshed, next, err := core.Refresh(ctx, session.Refresh{Token: refresh}, now.Add(time.Hour))
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to refresh token : %s.", dbtest.Failed, testID, err)
+			}
+
+			if next == refresh || refreshed.ID == claims.ID || refreshed.SessionID != claims.SessionID || refreshed.Subject != claims.Subject {
+				t.Fatalf("\t%s\tTest %d:\tShould rotate the tokens of the session : %+v.", dbtest.Failed, testID, refreshed)
+			}
+			t.Logf("\t%s\tTest %d:\tShould rotate the tokens of the session.", dbtest.Success, testID)
+
+			if _, _, err := core.Refresh(ctx, session.Refresh{Token: refresh}, now.Add(2*time.Hour)); !errors.Is(err, session.ErrTokenReused) {
+				t.Fatalf("\t%s\tTest %d:\tShould detect a reused refresh token : %v.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould detect a reused refresh token.", dbtest.Success, testID)
+
+			if _, _, err := core.Refresh(ctx, session.Refresh{Token: next}, now.Add(2*time.Hour)); !errors.Is(err, session.ErrInvalidToken) {
+				t.Fatalf("\t%s\tTest %d:\tShould revoke the session of a reused refresh token : %v.", dbtest.Failed, testID, err)
+			}
+
+			revoked, err := core.IsRevoked(ctx, refreshed)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to check for revocation : %s.", dbtest.Failed, testID, err)
+			}
+
+			if !revoked {
+				t.Fatalf("\t%s\tTest %d:\tShould revoke the access tokens of the session.", dbtest.Failed, testID)
+			}
+			t.Logf("\t%s\tTest %d:\tShould revoke the session of a reused refresh token.", dbtest.Success, testID)
+		}
+	}
+}
+
+func TestLogout(t *testing.T) {
+	log, db, teardown := dbtest.NewUnit(t, c, "testlogout")
+	t.Cleanup(teardown)
+
+	core := session.NewCore(log, db)
+	usrCore := user.NewCore(log, db, mail.NewLogMailer(log), dbtest.NewHasher(t))
+
+	t.Log("Given the need to log users out.")
+	{
+		testID := 0
+		t.Logf("\tTest %d:\tWhen handling a user with two sessions.", testID)
+		{
+			ctx := context.Background()
+			now := time.Date(2022, time.January, 8, 17, 56, 0, 0, time.UTC)
+
+			var refresh [2]string
+			for i := range refresh {
+				claims, err := usrCore.Authenticate(ctx, now, "user@example.com", "gophers", "")
+				if err != nil {
+					t.Fatalf("\t%s\tTest %d:\tShould be able to authenticate : %s.", dbtest.Failed, testID, err)
+				}
+
+				claims, refresh[i], err = core.Create(ctx, claims, now)
+				if err != nil {
+					t.Fatalf("\t%s\tTest %d:\tShould be able to start session : %s.", dbtest.Failed, testID, err)
+				}
+
+				if i == 0 {
+					if err := core.Logout(ctx, claims, now); err != nil {
+						t.Fatalf("\t%s\tTest %d:\tShould be able to log out : %s.", dbtest.Failed, testID, err)
+					}
+
+					revoked, err := core.IsRevoked(ctx, claims)
+					if err != nil {
+						t.Fatalf("\t%s\tTest %d:\tShould be able to check for revocation : %s.", dbtest.Failed, testID, err)
+					}
+
+					if !revoked {
+						t.Fatalf("\t%s\tTest %d:\tShould revoke the token on logout.", dbtest.Failed, testID)
+					}
+					t.Logf("\t%s\tTest %d:\tShould revoke the token on logout.", dbtest.Success, testID)
+				}
+			}
+
+			if _, _, err := core.Refresh(ctx, session.Refresh{Token: refresh[0]}, now); !errors.Is(err, session.ErrInvalidToken) {
+				t.Fatalf("\t%s\tTest %d:\tShould NOT be able to refresh after logout : %v.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould NOT be able to refresh after logout.", dbtest.Success, testID)
+
+			claims, err := usrCore.Authenticate(ctx, now, "user@example.com", "gophers", "")
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to authenticate : %s.", dbtest.Failed, testID, err)
+			}
+
+			if err := core.RevokeByUserID(ctx, claims.Subject, now); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to revoke the sessions of a user : %s.", dbtest.Failed, testID, err)
+			}
+
+			if _, _, err := core.Refresh(ctx, session.Refresh{Token: refresh[1]}, now); !errors.Is(err, session.ErrInvalidToken) {
+				t.Fatalf("\t%s\tTest %d:\tShould NOT be able to refresh a revoked session : %v.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould NOT be able to refresh a revoked session.", dbtest.Success, testID)
+		}
+	}
+}
@@ -0,0 +1,250 @@
+// Package db contains tag related CRUD functionality.
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/andrewyang17/service/business/sys/database"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+type Store struct {
+	log          *zap.SugaredLogger
+	tr           database.Transactor
+	db           sqlx.ExtContext
+	isWithinTran bool
+}
+
+func NewStore(log *zap.SugaredLogger, db *sqlx.DB) Store {
+	return Store{
+		log: log,
+		tr:  db,
+		db:  db,
+	}
+}
+
+// WithinTran runs passed function and do commit/rollback at the end.
+func (s Store) WithinTran(ctx context.Context, fn func(extContext sqlx.ExtContext) error) error {
+	if s.isWithinTran {
+		return fn(s.db)
+	}
+	return database.WithinTran(ctx, s.log, s.tr, fn)
+}
+
+// Tran return new Store with transaction in it.
+func (s Store) Tran(tx sqlx.ExtContext) Store {
+	return Store{
+		log:          s.log,
+		tr:           s.tr,
+		db:           tx,
+		isWithinTran: true,
+	}
+}
+
+// Create adds a Tag to the database unless its organization already has a
+// tag with the same name.
+func (s Store) Create(ctx context.Context, tag Tag) error {
+	const q = `
+	INSERT INTO tags
+		(tag_id, org_id, name, date_created)
+	VALUES
+		(:tag_id, :org_id, :name, :date_created)
+	ON CONFLICT (org_id, name) DO NOTHING`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, tag); err != nil {
+		return fmt.Errorf("inserting tag: %w", err)
+	}
+
+	return nil
+}
+
+// Delete removes the tag identified by a given ID in an organization from the
+// database and from every product it is attached to.
+func (s Store) Delete(ctx context.Context, orgID string, tagID string) error {
+	data := struct {
+		OrgID string `db:"org_id"`
+		TagID string `db:"tag_id"`
+	}{
+		OrgID: orgID,
+		TagID: tagID,
+	}
+
+	const q = `
+	DELETE FROM
+		tags
+	WHERE
+		org_id = :org_id AND tag_id = :tag_id`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, data); err != nil {
+		return fmt.Errorf("deleting tagID[%q]: %w", tagID, err)
+	}
+
+	return nil
+}
+
+// Query gets all Tags of an organization from the database.
+func (s Store) Query(ctx context.Context, orgID string, pageNumber int, rowsPerPage int) ([]Tag, error) {
+	data := struct {
+		OrgID       string `db:"org_id"`
+		Offset      int    `db:"offset"`
+		RowsPerPage int    `db:"rows_per_page"`
+	}{
+		OrgID:       orgID,
+		Offset:      (pageNumber - 1) * rowsPerPage,
+		RowsPerPage: rowsPerPage,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		tags
+	WHERE
+		org_id = :org_id
+	ORDER BY
+		name
+	OFFSET :offset ROWS FETCH NEXT :rows_per_page ROWS ONLY`
+
+	var tags []Tag
+	if err := database.NamedQuerySlice(ctx, s.log, s.db, q, data, &tags); err != nil {
+		return nil, fmt.Errorf("selecting tags: %w", err)
+	}
+
+	return tags, nil
+}
+
+// QueryByID finds the tag identified by a given ID in an organization.
+func (s Store) QueryByID(ctx context.Context, orgID string, tagID string) (Tag, error) {
+	data := struct {
+		OrgID string `db:"org_id"`
+		TagID string `db:"tag_id"`
+	}{
+		OrgID: orgID,
+		TagID: tagID,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		tags
+	WHERE
+		org_id = :org_id AND tag_id = :tag_id`
+
+	var tag Tag
+	if err := database.NamedQueryStruct(ctx, s.log, s.db, q, data, &tag); err != nil {
+		return Tag{}, fmt.Errorf("selecting tagID[%q]: %w", tagID, err)
+	}
+
+	return tag, nil
+}
+
+// QueryByName finds the tag of an organization with the given name.
+func (s Store) QueryByName(ctx context.Context, orgID string, name string) (Tag, error) {
+	data := struct {
+		OrgID string `db:"org_id"`
+		Name  string `db:"name"`
+	}{
+		OrgID: orgID,
+		Name:  name,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		tags
+	WHERE
+		org_id = :org_id AND name = :name`
+
+	var tag Tag
+	if err := database.NamedQueryStruct(ctx, s.log, s.db, q, data, &tag); err != nil {
+		return Tag{}, fmt.Errorf("selecting tag name[%q]: %w", name, err)
+	}
+
+	return tag, nil
+}
+
+// QueryByProductID gets the tags of an organization attached to the
+// specified product.
+func (s Store) QueryByProductID(ctx context.Context, orgID string, productID string) ([]Tag, error) {
+	data := struct {
+		OrgID     string `db:"org_id"`
+		ProductID string `db:"product_id"`
+	}{
+		OrgID:     orgID,
+		ProductID: productID,
+	}
+
+	const q = `
+	SELECT
+		t.*
+	FROM
+		tags AS t
+	JOIN
+		product_tags AS pt ON pt.tag_id = t.tag_id
+	WHERE
+		pt.product_id = :product_id AND t.org_id = :org_id
+	ORDER BY
+		t.name`
+
+	var tags []Tag
+	if err := database.NamedQuerySlice(ctx, s.log, s.db, q, data, &tags); err != nil {
+		return nil, fmt.Errorf("selecting tags productID[%q]: %w", productID, err)
+	}
+
+	return tags, nil
+}
+
+// AddProductTag attaches a tag to a product. Attaching a tag that is already
+// attached does nothing.
+func (s Store) AddProductTag(ctx context.Context, productID string, tagID string) error {
+	data := struct {
+		ProductID string `db:"product_id"`
+		TagID     string `db:"tag_id"`
+	}{
+		ProductID: productID,
+		TagID:     tagID,
+	}
+
+	const q = `
+	INSERT INTO product_tags
+		(product_id, tag_id)
+	VALUES
+		(:product_id, :tag_id)
+	ON CONFLICT DO NOTHING`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, data); err != nil {
+		return fmt.Errorf("tagging productID[%q] tagID[%q]: %w", productID, tagID, err)
+	}
+
+	return nil
+}
+
+// RemoveProductTag detaches a tag of an organization from a product.
+func (s Store) RemoveProductTag(ctx context.Context, orgID string, productID string, tagID string) error {
+	data := struct {
+		OrgID     string `db:"org_id"`
+		ProductID string `db:"product_id"`
+		TagID     string `db:"tag_id"`
+	}{
+		OrgID:     orgID,
+		ProductID: productID,
+		TagID:     tagID,
+	}
+
+	const q = `
+	DELETE FROM
+		product_tags
+	WHERE
+		product_id = :product_id AND tag_id = :tag_id AND
+		EXISTS (SELECT 1 FROM tags AS t WHERE t.tag_id = product_tags.tag_id AND t.org_id = :org_id)`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, data); err != nil {
+		return fmt.Errorf("untagging productID[%q] tagID[%q]: %w", productID, tagID, err)
+	}
+
+	return nil
+}
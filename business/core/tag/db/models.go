@@ -0,0 +1,12 @@
+package db
+
+import "time"
+
+// Tag represents the structure we need for moving data
+// between the app and the database.
+type Tag struct {
+	ID          string    `db:"tag_id"`
+	OrgID       string    `db:"org_id"`
+	Name        string    `db:"name"`
+	DateCreated time.Time `db:"date_created"`
+}
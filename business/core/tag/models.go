@@ -0,0 +1,37 @@
+package tag
+
+import (
+	"time"
+	"unsafe"
+
+	"github.com/andrewyang17/service/business/core/tag/db"
+)
+
+// Tag represents a free-form label that can be attached to products.
+type Tag struct {
+	ID          string    `json:"id"`
+	OrgID       string    `json:"org_id"`
+	Name        string    `json:"name"`
+	DateCreated time.Time `json:"date_created"`
+}
+
+// NewTag is what we require from clients when attaching a tag to a product.
+// Names are case insensitive and stored in lower case.
+type NewTag struct {
+	Name string `json:"name" validate:"required,max=50"`
+}
+
+// =============================================================================
+
+func toTag(dbTag db.Tag) Tag {
+	pt := (*Tag)(unsafe.Pointer(&dbTag))
+	return *pt
+}
+
+func toTagSlice(dbTags []db.Tag) []Tag {
+	tags := make([]Tag, len(dbTags))
+	for i, dbTag := range dbTags {
+		tags[i] = toTag(dbTag)
+	}
+	return tags
+}
@@ -0,0 +1,200 @@
+// Package tag provides the core business API for tagging products.
+package tag
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	productDB "github.com/andrewyang17/service/business/core/product/db"
+	"github.com/andrewyang17/service/business/core/tag/db"
+	"github.com/andrewyang17/service/business/sys/database"
+	"github.com/andrewyang17/service/business/sys/tenant"
+	"github.com/andrewyang17/service/business/sys/validate"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrNotFound        = errors.New("tag not found")
+	ErrInvalidID       = errors.New("ID is not in its proper form")
+	ErrProductNotFound = errors.New("product not found")
+)
+
+// Core manages the set of APIs for tag access.
+type Core struct {
+	store        db.Store
+	productStore productDB.Store
+}
+
+// NewCore constructs a core for tag api access.
+func NewCore(log *zap.SugaredLogger, sqlxDB *sqlx.DB) Core {
+	return Core{
+		store:        db.NewStore(log, sqlxDB),
+		productStore: productDB.NewStore(log, sqlxDB),
+	}
+}
+
+// TagProduct attaches the named tag to a product of the organization the
+// context acts for, creating the tag the first time the organization uses
+// the name.
+func (c Core) TagProduct(ctx context.Context, productID string, nt NewTag, now time.Time) (Tag, error) {
+	if err := validate.CheckID(productID); err != nil {
+		return Tag{}, ErrInvalidID
+	}
+
+	nt.Name = strings.ToLower(strings.TrimSpace(nt.Name))
+
+	if err := validate.Check(nt); err != nil {
+		return Tag{}, fmt.Errorf("validating data: %w", err)
+	}
+
+	orgID, err := tenant.Get(ctx)
+	if err != nil {
+		return Tag{}, err
+	}
+
+	var dbTag db.Tag
+
+	tran := func(tx sqlx.ExtContext) error {
+		if _, err := c.productStore.Tran(tx).QueryByID(ctx, orgID, productID); err != nil {
+			if errors.Is(err, database.ErrDBNotFound) {
+				return ErrProductNotFound
+			}
+			return fmt.Errorf("query product: %w", err)
+		}
+
+		store := c.store.Tran(tx)
+
+		newTag := db.Tag{
+			ID:          validate.GenerateID(),
+			OrgID:       orgID,
+			Name:        nt.Name,
+			DateCreated: now,
+		}
+
+		if err := store.Create(ctx, newTag); err != nil {
+			return fmt.Errorf("create: %w", err)
+		}
+
+		// The tag may have existed already, so read back the stored one.
+		var err error
+		if dbTag, err = store.QueryByName(ctx, orgID, nt.Name); err != nil {
+			return fmt.Errorf("query: %w", err)
+		}
+
+		if err := store.AddProductTag(ctx, productID, dbTag.ID); err != nil {
+			return fmt.Errorf("add product tag: %w", err)
+		}
+
+		return nil
+	}
+
+	if err := c.store.WithinTran(ctx, tran); err != nil {
+		return Tag{}, fmt.Errorf("tran: %w", err)
+	}
+
+	return toTag(dbTag), nil
+}
+
+// UntagProduct detaches a tag of the organization the context acts for from
+// a product.
+func (c Core) UntagProduct(ctx context.Context, productID string, tagID string) error {
+	if err := validate.CheckID(productID); err != nil {
+		return ErrInvalidID
+	}
+
+	if err := validate.CheckID(tagID); err != nil {
+		return ErrInvalidID
+	}
+
+	orgID, err := tenant.Get(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := c.store.RemoveProductTag(ctx, orgID, productID, tagID); err != nil {
+		return fmt.Errorf("remove product tag: %w", err)
+	}
+
+	return nil
+}
+
+// Delete removes the tag identified by a given ID in the organization the
+// context acts for from every product.
+func (c Core) Delete(ctx context.Context, tagID string) error {
+	if err := validate.CheckID(tagID); err != nil {
+		return ErrInvalidID
+	}
+
+	orgID, err := tenant.Get(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := c.store.Delete(ctx, orgID, tagID); err != nil {
+		return fmt.Errorf("delete: %w", err)
+	}
+
+	return nil
+}
+
+// Query gets all Tags of the organization the context acts for from the
+// database.
+func (c Core) Query(ctx context.Context, pageNumber int, rowsPerPage int) ([]Tag, error) {
+	orgID, err := tenant.Get(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	dbTags, err := c.store.Query(ctx, orgID, pageNumber, rowsPerPage)
+	if err != nil {
+		return nil, fmt.Errorf("query: %w", err)
+	}
+
+	return toTagSlice(dbTags), nil
+}
+
+// QueryByID finds the tag identified by a given ID.
+func (c Core) QueryByID(ctx context.Context, tagID string) (Tag, error) {
+	if err := validate.CheckID(tagID); err != nil {
+		return Tag{}, ErrInvalidID
+	}
+
+	orgID, err := tenant.Get(ctx)
+	if err != nil {
+		return Tag{}, err
+	}
+
+	dbTag, err := c.store.QueryByID(ctx, orgID, tagID)
+	if err != nil {
+		if errors.Is(err, database.ErrDBNotFound) {
+			return Tag{}, ErrNotFound
+		}
+		return Tag{}, fmt.Errorf("query: %w", err)
+	}
+
+	return toTag(dbTag), nil
+}
+
+// QueryByProductID gets the tags of the organization the context acts for
+// that are attached to the specified product.
+func (c Core) QueryByProductID(ctx context.Context, productID string) ([]Tag, error) {
+	if err := validate.CheckID(productID); err != nil {
+		return nil, ErrInvalidID
+	}
+
+	orgID, err := tenant.Get(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	dbTags, err := c.store.QueryByProductID(ctx, orgID, productID)
+	if err != nil {
+		return nil, fmt.Errorf("query: %w", err)
+	}
+
+	return toTagSlice(dbTags), nil
+}
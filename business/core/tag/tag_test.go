@@ -0,0 +1,170 @@
+package tag_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/andrewyang17/service/business/core/organization"
+	"github.com/andrewyang17/service/business/core/product"
+	"github.com/andrewyang17/service/business/core/tag"
+	"github.com/andrewyang17/service/business/data/dbtest"
+	"github.com/andrewyang17/service/business/sys/tenant"
+	"github.com/andrewyang17/service/foundation/docker"
+)
+
+var c *docker.Container
+
+func TestMain(m *testing.M) {
+	var err error
+	c, err = dbtest.StartDB()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer dbtest.StopDB(c)
+
+	m.Run()
+}
+
+func TestTag(t *testing.T) {
+	log, db, teardown := dbtest.NewUnit(t, c, "testtag")
+	t.Cleanup(teardown)
+
+	core := tag.NewCore(log, db)
+	prdCore := product.NewCore(log, db)
+
+	t.Log("Given the need to tag Product records.")
+	{
+		testID := 0
+		t.Logf("\tTest %d:\tWhen tagging a seeded product.", testID)
+		{
+			ctx := tenant.Set(context.Background(), organization.DefaultID)
+			now := time.Date(2022, time.January, 8, 17, 56, 0, 0, time.UTC)
+
+			const productID = "a2b0639f-2cc6-44b8-b97b-15d69dbb511e"
+
+			tg, err := core.TagProduct(ctx, productID, tag.NewTag{Name: " Collectible "}, now)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to tag a product : %s.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould be able to tag a product.", dbtest.Success, testID)
+
+			again, err := core.TagProduct(ctx, productID, tag.NewTag{Name: "COLLECTIBLE"}, now)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to tag a product twice : %s.", dbtest.Failed, testID, err)
+			}
+
+			if again.ID != tg.ID || tg.Name != "collectible" {
+				t.Fatalf("\t%s\tTest %d:\tShould reuse the tag regardless of case : %+v %+v.", dbtest.Failed, testID, tg, again)
+			}
+			t.Logf("\t%s\tTest %d:\tShould reuse the tag regardless of case.", dbtest.Success, testID)
+
+			name := "Collectible"
+			products, err := prdCore.Query(ctx, product.QueryFilter{Tag: &name}, 1, 10)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to query products by tag : %s.", dbtest.Failed, testID, err)
+			}
+
+			if len(products) != 1 || products[0].ID != productID {
+				t.Fatalf("\t%s\tTest %d:\tShould find the tagged product : %+v.", dbtest.Failed, testID, products)
+			}
+			t.Logf("\t%s\tTest %d:\tShould find the tagged product.", dbtest.Success, testID)
+
+			if err := core.UntagProduct(ctx, productID, tg.ID); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to untag a product : %s.", dbtest.Failed, testID, err)
+			}
+
+			tags, err := core.QueryByProductID(ctx, productID)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to query tags by product : %s.", dbtest.Failed, testID, err)
+			}
+
+			if len(tags) != 0 {
+				t.Fatalf("\t%s\tTest %d:\tShould have no tags left on the product : %+v.", dbtest.Failed, testID, tags)
+			}
+			t.Logf("\t%s\tTest %d:\tShould have no tags left on the product.", dbtest.Success, testID)
+		}
+	}
+}
+
+func TestOrganizationTag(t *testing.T) {
+	log, db, teardown := dbtest.NewUnit(t, c, "testorgtag")
+	t.Cleanup(teardown)
+
+	core := tag.NewCore(log, db)
+	orgCore := organization.NewCore(log, db)
+
+	t.Log("Given the need to keep the tags of organizations apart.")
+	{
+		testID := 0
+		t.Logf("\tTest %d:\tWhen handling a tag of another organization.", testID)
+		{
+			ctx := tenant.Set(context.Background(), organization.DefaultID)
+			now := time.Date(2022, time.January, 8, 17, 56, 0, 0, time.UTC)
+
+			const productID = "a2b0639f-2cc6-44b8-b97b-15d69dbb511e"
+
+			org, err := orgCore.Create(ctx, organization.NewOrganization{Name: "Other"}, "5cf37266-3473-4006-984f-9325122678b7", now)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to create organization : %s.", dbtest.Failed, testID, err)
+			}
+			otherCtx := tenant.Set(context.Background(), org.ID)
+
+			tg, err := core.TagProduct(ctx, productID, tag.NewTag{Name: "collectible"}, now)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to tag a product : %s.", dbtest.Failed, testID, err)
+			}
+
+			tags, err := core.Query(otherCtx, 1, 10)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to query tags : %s.", dbtest.Failed, testID, err)
+			}
+
+			if len(tags) != 0 {
+				t.Fatalf("\t%s\tTest %d:\tShould NOT list the tag : %+v.", dbtest.Failed, testID, tags)
+			}
+			t.Logf("\t%s\tTest %d:\tShould NOT list the tag.", dbtest.Success, testID)
+
+			if _, err := core.QueryByID(otherCtx, tg.ID); !errors.Is(err, tag.ErrNotFound) {
+				t.Fatalf("\t%s\tTest %d:\tShould NOT be able to retrieve the tag : %v.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould NOT be able to retrieve the tag.", dbtest.Success, testID)
+
+			tags, err = core.QueryByProductID(otherCtx, productID)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to query tags by product : %s.", dbtest.Failed, testID, err)
+			}
+
+			if len(tags) != 0 {
+				t.Fatalf("\t%s\tTest %d:\tShould NOT list the tags of the product : %+v.", dbtest.Failed, testID, tags)
+			}
+			t.Logf("\t%s\tTest %d:\tShould NOT list the tags of the product.", dbtest.Success, testID)
+
+			if _, err := core.TagProduct(otherCtx, productID, tag.NewTag{Name: "stolen"}, now); !errors.Is(err, tag.ErrProductNotFound) {
+				t.Fatalf("\t%s\tTest %d:\tShould NOT be able to tag the product : %v.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould NOT be able to tag the product.", dbtest.Success, testID)
+
+			if err := core.UntagProduct(otherCtx, productID, tg.ID); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to call untag : %s.", dbtest.Failed, testID, err)
+			}
+
+			if err := core.Delete(otherCtx, tg.ID); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to call delete : %s.", dbtest.Failed, testID, err)
+			}
+
+			tags, err = core.QueryByProductID(ctx, productID)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to query tags by product : %s.", dbtest.Failed, testID, err)
+			}
+
+			if len(tags) != 1 || tags[0].ID != tg.ID {
+				t.Fatalf("\t%s\tTest %d:\tShould NOT be able to untag or delete the tag : %+v.", dbtest.Failed, testID, tags)
+			}
+			t.Logf("\t%s\tTest %d:\tShould NOT be able to untag or delete the tag.", dbtest.Success, testID)
+		}
+	}
+}
@@ -2,9 +2,12 @@
 package db
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"time"
 
+	"github.com/andrewyang17/service/business/data/order"
 	"github.com/andrewyang17/service/business/sys/database"
 	"github.com/jmoiron/sqlx"
 	"go.uber.org/zap"
@@ -47,9 +50,9 @@ func (s Store) Tran(tx sqlx.ExtContext) Store {
 func (s Store) Create(ctx context.Context, usr User) error {
 	const q = `
 	INSERT INTO users 
-		(user_id, name, email, password_hash, roles, date_created, date_updated) 
+		(user_id, name, email, password_hash, date_created, date_updated, date_verified, version) 
 	VALUES 
-		(:user_id, :name, :email, :password_hash, :roles, :date_created, :date_updated)`
+		(:user_id, :name, :email, :password_hash, :date_created, :date_updated, :date_verified, :version)`
 
 	if err := database.NamedExecContext(ctx, s.log, s.db, q, usr); err != nil {
 		return fmt.Errorf("inserting user: %w", err)
@@ -58,7 +61,9 @@ func (s Store) Create(ctx context.Context, usr User) error {
 	return nil
 }
 
-// Update replaces a user document in the database.
+// Update replaces a user document in the database and moves it on to the
+// next version. Nothing is written if the user is no longer at the version
+// it was read at, in which case database.ErrDBNotFound is returned.
 func (s Store) Update(ctx context.Context, usr User) error {
 	const q = `
 	UPDATE 
@@ -66,21 +71,120 @@ func (s Store) Update(ctx context.Context, usr User) error {
 	SET 
 		"name" = :name,
 		"email" = :email,
-		"roles" = :roles,
 		"password_hash" = :password_hash,
-		"date_updated" = :date_updated 
+		"date_updated" = :date_updated,
+		"date_verified" = :date_verified,
+		"version" = version + 1
 	WHERE
-		user_id = :user_id`
+		user_id = :user_id AND
+		version = :version
+	RETURNING
+		version`
 
-	if err := database.NamedExecContext(ctx, s.log, s.db, q, usr); err != nil {
+	var result struct {
+		Version int `db:"version"`
+	}
+	if err := database.NamedQueryStruct(ctx, s.log, s.db, q, usr, &result); err != nil {
 		return fmt.Errorf("updating userID[%q]: %w", usr.ID, err)
 	}
 
 	return nil
 }
 
-// Delete removes a user from the database.
-func (s Store) Delete(ctx context.Context, userID string) error {
+// Delete marks the user identified by a given ID in an organization as
+// deleted. The user is kept, along with their products and sales, until it
+// is purged.
+func (s Store) Delete(ctx context.Context, orgID string, userID string, now time.Time) error {
+	data := struct {
+		OrgID       string    `db:"org_id"`
+		UserID      string    `db:"user_id"`
+		DateDeleted time.Time `db:"date_deleted"`
+	}{
+		OrgID:       orgID,
+		UserID:      userID,
+		DateDeleted: now,
+	}
+
+	const q = `
+	UPDATE
+		users
+	SET
+		"date_deleted" = :date_deleted
+	WHERE
+		user_id = :user_id AND
+		date_deleted IS NULL AND
+		` + memberOf
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, data); err != nil {
+		return fmt.Errorf("deleting userID[%q]: %w", userID, err)
+	}
+
+	return nil
+}
+
+// Restore clears the deleted mark from the user identified by a given ID in
+// an organization.
+func (s Store) Restore(ctx context.Context, orgID string, userID string, now time.Time) error {
+	data := struct {
+		OrgID       string    `db:"org_id"`
+		UserID      string    `db:"user_id"`
+		DateUpdated time.Time `db:"date_updated"`
+	}{
+		OrgID:       orgID,
+		UserID:      userID,
+		DateUpdated: now,
+	}
+
+	const q = `
+	UPDATE
+		users
+	SET
+		"date_deleted" = NULL,
+		"date_updated" = :date_updated
+	WHERE
+		user_id = :user_id AND
+		` + memberOf
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, data); err != nil {
+		return fmt.Errorf("restoring userID[%q]: %w", userID, err)
+	}
+
+	return nil
+}
+
+// UpdateLogins records the number of failed logins for the user identified
+// by a given ID and how long the user is locked out for.
+func (s Store) UpdateLogins(ctx context.Context, userID string, failedLogins int, lockedUntil *time.Time) error {
+	data := struct {
+		UserID          string     `db:"user_id"`
+		FailedLogins    int        `db:"failed_logins"`
+		DateLockedUntil *time.Time `db:"date_locked_until"`
+	}{
+		UserID:          userID,
+		FailedLogins:    failedLogins,
+		DateLockedUntil: lockedUntil,
+	}
+
+	const q = `
+	UPDATE
+		users
+	SET
+		"failed_logins" = :failed_logins,
+		"date_locked_until" = :date_locked_until
+	WHERE
+		user_id = :user_id`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, data); err != nil {
+		return fmt.Errorf("updating logins userID[%q]: %w", userID, err)
+	}
+
+	return nil
+}
+
+// IncrementFailedLogins adds one to the failed logins of the user identified
+// by a given ID in a single statement and returns the new count, so
+// concurrent failures are never lost.
+func (s Store) IncrementFailedLogins(ctx context.Context, userID string) (int, error) {
 	data := struct {
 		UserID string `db:"user_id"`
 	}{
@@ -88,39 +192,186 @@ func (s Store) Delete(ctx context.Context, userID string) error {
 	}
 
 	const q = `
-	DELETE FROM 
-		users 
-	WHERE 
+	UPDATE
+		users
+	SET
+		"failed_logins" = failed_logins + 1
+	WHERE
+		user_id = :user_id AND
+		date_deleted IS NULL
+	RETURNING
+		failed_logins`
+
+	var result struct {
+		FailedLogins int `db:"failed_logins"`
+	}
+	if err := database.NamedQueryStruct(ctx, s.log, s.db, q, data, &result); err != nil {
+		return 0, fmt.Errorf("incrementing failed logins userID[%q]: %w", userID, err)
+	}
+
+	return result.FailedLogins, nil
+}
+
+// UpdateLockedUntil records how long the user identified by a given ID is
+// locked out for, leaving the count of failed logins as it is.
+func (s Store) UpdateLockedUntil(ctx context.Context, userID string, lockedUntil time.Time) error {
+	data := struct {
+		UserID          string    `db:"user_id"`
+		DateLockedUntil time.Time `db:"date_locked_until"`
+	}{
+		UserID:          userID,
+		DateLockedUntil: lockedUntil,
+	}
+
+	const q = `
+	UPDATE
+		users
+	SET
+		"date_locked_until" = :date_locked_until
+	WHERE
 		user_id = :user_id`
 
 	if err := database.NamedExecContext(ctx, s.log, s.db, q, data); err != nil {
-		return fmt.Errorf("deleting userID[%q]: %w", userID, err)
+		return fmt.Errorf("updating locked until userID[%q]: %w", userID, err)
 	}
 
 	return nil
 }
 
-// Query retrieves a list of existing users from the database.
-func (s Store) Query(ctx context.Context, pageNumber int, rowsPerPage int) ([]User, error) {
+// UpdatePasswordHash replaces the password hash of the user identified by a
+// given ID. The user is otherwise left as it is.
+func (s Store) UpdatePasswordHash(ctx context.Context, userID string, hash []byte) error {
 	data := struct {
-		Offset      int `db:"offset"`
-		RowsPerPage int `db:"rows_per_page"`
+		UserID       string `db:"user_id"`
+		PasswordHash []byte `db:"password_hash"`
 	}{
-		Offset:      (pageNumber - 1) * rowsPerPage,
-		RowsPerPage: rowsPerPage,
+		UserID:       userID,
+		PasswordHash: hash,
 	}
 
 	const q = `
-	SELECT 
-		* 
-	FROM 
-		users 
-	ORDER BY 
-		user_id 
-	OFFSET :offset ROWS FETCH NEXT :rows_per_page ROWS ONLY`
+	UPDATE
+		users
+	SET
+		"password_hash" = :password_hash
+	WHERE
+		user_id = :user_id`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, data); err != nil {
+		return fmt.Errorf("updating password hash userID[%q]: %w", userID, err)
+	}
+
+	return nil
+}
+
+// Purge removes the users that were deleted before the specified time from
+// the database for good, along with everything that belongs to them. Users
+// that still belong to more than one organization are never removed. It
+// returns the IDs of the users that were removed.
+func (s Store) Purge(ctx context.Context, before time.Time) ([]string, error) {
+	data := struct {
+		Before time.Time `db:"before"`
+	}{
+		Before: before,
+	}
+
+	const q = `
+	DELETE FROM
+		users
+	WHERE
+		date_deleted < :before AND
+		(SELECT COUNT(*) FROM user_orgs AS uo WHERE uo.user_id = users.user_id) <= 1
+	RETURNING
+		user_id`
+
+	var purged []struct {
+		UserID string `db:"user_id"`
+	}
+	if err := database.NamedQuerySlice(ctx, s.log, s.db, q, data, &purged); err != nil {
+		return nil, fmt.Errorf("purging users: %w", err)
+	}
+
+	userIDs := make([]string, len(purged))
+	for i, p := range purged {
+		userIDs[i] = p.UserID
+	}
+
+	return userIDs, nil
+}
+
+// Query retrieves a list of existing users that match the filter from the
+// database that are members of an organization. The order by field must be a
+// column name vetted by the caller, user_id is always added, in the same
+// direction, to keep the paging stable.
+func (s Store) Query(ctx context.Context, orgID string, filter QueryFilter, orderBy order.By, pageNumber int, rowsPerPage int) ([]User, error) {
+	data := map[string]interface{}{
+		"offset":        (pageNumber - 1) * rowsPerPage,
+		"rows_per_page": rowsPerPage,
+	}
+
+	const q = `
+	SELECT
+		` + memberColumns + `
+	FROM
+		users`
+
+	buf := bytes.NewBufferString(q)
+	applyFilter(orgID, filter, data, buf)
+	buf.WriteString(orderByClause(orderBy))
+	buf.WriteString(" OFFSET :offset ROWS FETCH NEXT :rows_per_page ROWS ONLY")
+
+	var usrs []User
+	if err := database.NamedQuerySlice(ctx, s.log, s.db, buf.String(), data, &usrs); err != nil {
+		return nil, fmt.Errorf("selecting users: %w", err)
+	}
+
+	return usrs, nil
+}
+
+// QueryKeyset retrieves up to limit users of an organization that match the
+// filter and come after the keyset position in the specified order. Unlike Query the cost
+// doesn't grow with the depth of the page. When the keyset is Backward the
+// users before the position are returned, nearest first. A nil keyset starts
+// at the beginning of the list.
+func (s Store) QueryKeyset(ctx context.Context, orgID string, filter QueryFilter, orderBy order.By, keyset *Keyset, limit int) ([]User, error) {
+	data := map[string]interface{}{
+		"limit": limit,
+	}
+
+	const q = `
+	SELECT
+		` + memberColumns + `
+	FROM
+		users`
+
+	var conditions []string
+	if keyset != nil {
+		if keyset.Backward {
+			orderBy.Direction = reverse(orderBy.Direction)
+		}
+
+		op := ">"
+		if orderBy.Direction == order.DESC {
+			op = "<"
+		}
+
+		data["keyset_user_id"] = keyset.UserID
+		switch orderBy.Field {
+		case "user_id":
+			conditions = append(conditions, "user_id "+op+" :keyset_user_id")
+		default:
+			data["keyset_value"] = keyset.Value
+			conditions = append(conditions, "("+orderBy.Field+", user_id) "+op+" (:keyset_value, :keyset_user_id)")
+		}
+	}
+
+	buf := bytes.NewBufferString(q)
+	applyFilter(orgID, filter, data, buf, conditions...)
+	buf.WriteString(orderByClause(orderBy))
+	buf.WriteString(" FETCH FIRST :limit ROWS ONLY")
 
 	var usrs []User
-	if err := database.NamedQuerySlice(ctx, s.log, s.db, q, data, &usrs); err != nil {
+	if err := database.NamedQuerySlice(ctx, s.log, s.db, buf.String(), data, &usrs); err != nil {
 		return nil, fmt.Errorf("selecting users: %w", err)
 	}
 
@@ -141,7 +392,8 @@ func (s Store) QueryByID(ctx context.Context, userID string) (User, error) {
 	FROM 
 		users 
 	WHERE 
-		user_id = :user_id`
+		user_id = :user_id AND
+		date_deleted IS NULL`
 
 	var usr User
 	if err := database.NamedQueryStruct(ctx, s.log, s.db, q, data, &usr); err != nil {
@@ -151,6 +403,96 @@ func (s Store) QueryByID(ctx context.Context, userID string) (User, error) {
 	return usr, nil
 }
 
+// QueryMemberByID gets the specified user from the database with the roles
+// they hold in an organization, provided the user is a member of it.
+func (s Store) QueryMemberByID(ctx context.Context, orgID string, userID string) (User, error) {
+	data := struct {
+		OrgID  string `db:"org_id"`
+		UserID string `db:"user_id"`
+	}{
+		OrgID:  orgID,
+		UserID: userID,
+	}
+
+	const q = `
+	SELECT
+		` + memberColumns + `
+	FROM
+		users
+	WHERE
+		user_id = :user_id AND
+		date_deleted IS NULL AND
+		` + memberOf
+
+	var usr User
+	if err := database.NamedQueryStruct(ctx, s.log, s.db, q, data, &usr); err != nil {
+		return User{}, fmt.Errorf("selecting userID[%q]: %w", userID, err)
+	}
+
+	return usr, nil
+}
+
+// QueryMemberByIDForUpdate gets the specified user from the database, provided
+// the user is a member of an organization, and locks the row until the
+// surrounding transaction completes. It must be called from a Store returned
+// by Tran.
+func (s Store) QueryMemberByIDForUpdate(ctx context.Context, orgID string, userID string) (User, error) {
+	data := struct {
+		OrgID  string `db:"org_id"`
+		UserID string `db:"user_id"`
+	}{
+		OrgID:  orgID,
+		UserID: userID,
+	}
+
+	const q = `
+	SELECT
+		` + memberColumns + `
+	FROM
+		users
+	WHERE
+		user_id = :user_id AND
+		date_deleted IS NULL AND
+		` + memberOf + `
+	FOR UPDATE`
+
+	var usr User
+	if err := database.NamedQueryStruct(ctx, s.log, s.db, q, data, &usr); err != nil {
+		return User{}, fmt.Errorf("selecting userID[%q] for update: %w", userID, err)
+	}
+
+	return usr, nil
+}
+
+// QueryDeletedByID gets the specified user from the database, provided the
+// user has been deleted and is a member of an organization.
+func (s Store) QueryDeletedByID(ctx context.Context, orgID string, userID string) (User, error) {
+	data := struct {
+		OrgID  string `db:"org_id"`
+		UserID string `db:"user_id"`
+	}{
+		OrgID:  orgID,
+		UserID: userID,
+	}
+
+	const q = `
+	SELECT
+		` + memberColumns + `
+	FROM
+		users
+	WHERE
+		user_id = :user_id AND
+		date_deleted IS NOT NULL AND
+		` + memberOf
+
+	var usr User
+	if err := database.NamedQueryStruct(ctx, s.log, s.db, q, data, &usr); err != nil {
+		return User{}, fmt.Errorf("selecting deleted userID[%q]: %w", userID, err)
+	}
+
+	return usr, nil
+}
+
 // QueryByEmail gets the specified user from the database by email.
 func (s Store) QueryByEmail(ctx context.Context, email string) (User, error) {
 	data := struct {
@@ -165,7 +507,8 @@ func (s Store) QueryByEmail(ctx context.Context, email string) (User, error) {
 	FROM 
 		users 
 	WHERE 
-		email = :email`
+		email = :email AND
+		date_deleted IS NULL`
 
 	var usr User
 	if err := database.NamedQueryStruct(ctx, s.log, s.db, q, data, &usr); err != nil {
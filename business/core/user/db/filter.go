@@ -0,0 +1,85 @@
+package db
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/andrewyang17/service/business/data/order"
+)
+
+// memberOf is the condition that limits users to the members of the
+// organization in the org_id parameter.
+const memberOf = "EXISTS (SELECT 1 FROM user_orgs AS uo WHERE uo.user_id = users.user_id AND uo.org_id = :org_id)"
+
+// memberColumns selects the columns of a user along with the roles the user
+// holds in the organization in the org_id parameter.
+const memberColumns = "users.*, (SELECT uo.roles FROM user_orgs AS uo WHERE uo.user_id = users.user_id AND uo.org_id = :org_id) AS roles"
+
+// applyFilter adds a WHERE clause for the organization, the filter and any
+// extra conditions to the query in buf and the parameters it uses to data.
+// Values only ever reach the query as parameters.
+func applyFilter(orgID string, filter QueryFilter, data map[string]interface{}, buf *bytes.Buffer, conditions ...string) {
+	data["org_id"] = orgID
+	wc := append([]string{memberOf}, conditions...)
+
+	switch filter.Deleted {
+	case true:
+		wc = append(wc, "date_deleted IS NOT NULL")
+	default:
+		wc = append(wc, "date_deleted IS NULL")
+	}
+
+	if filter.Name != nil {
+		data["name"] = "%" + escapeLike(*filter.Name) + "%"
+		wc = append(wc, "name ILIKE :name")
+	}
+
+	if filter.Email != nil {
+		data["email"] = "%" + escapeLike(*filter.Email) + "%"
+		wc = append(wc, "email ILIKE :email")
+	}
+
+	if filter.Role != nil {
+		data["role"] = strings.ToUpper(*filter.Role)
+		wc = append(wc, "EXISTS (SELECT 1 FROM user_orgs AS uo WHERE uo.user_id = users.user_id AND uo.org_id = :org_id AND :role = ANY(uo.roles))")
+	}
+
+	if filter.StartCreatedDate != nil {
+		data["start_created_date"] = *filter.StartCreatedDate
+		wc = append(wc, "date_created >= :start_created_date")
+	}
+
+	if filter.EndCreatedDate != nil {
+		data["end_created_date"] = *filter.EndCreatedDate
+		wc = append(wc, "date_created < :end_created_date")
+	}
+
+	buf.WriteString(" WHERE ")
+	buf.WriteString(strings.Join(wc, " AND "))
+}
+
+// likeEscaper escapes the characters that have a special meaning in a LIKE
+// pattern so user input only ever matches literally.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+func escapeLike(s string) string {
+	return likeEscaper.Replace(s)
+}
+
+// orderByClause returns the ORDER BY clause for the vetted column in orderBy,
+// with user_id as a tie breaker so rows always come back in the same order.
+func orderByClause(orderBy order.By) string {
+	clause := " ORDER BY " + orderBy.Field + " " + orderBy.Direction
+	if orderBy.Field != "user_id" {
+		clause += ", user_id " + orderBy.Direction
+	}
+	return clause
+}
+
+// reverse returns the opposite of the specified direction.
+func reverse(direction string) string {
+	if direction == order.DESC {
+		return order.ASC
+	}
+	return order.DESC
+}
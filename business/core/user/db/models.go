@@ -7,13 +7,54 @@ import (
 )
 
 // User represents the structure we need for moving data
-// between the app and the database.
+// between the app and the database. Roles are held per organization, so they
+// are only filled in by the queries for the members of an organization and
+// are never written with the user.
 type User struct {
-	ID           string         `db:"user_id"`
-	Name         string         `db:"name"`
-	Email        string         `db:"email"`
-	Roles        pq.StringArray `db:"roles"`
-	PasswordHash []byte         `db:"password_hash"`
-	DateCreated  time.Time      `db:"date_created"`
-	DateUpdated  time.Time      `db:"date_updated"`
+	ID              string         `db:"user_id"`
+	Name            string         `db:"name"`
+	Email           string         `db:"email"`
+	Roles           pq.StringArray `db:"roles"`
+	PasswordHash    []byte         `db:"password_hash"`
+	DateCreated     time.Time      `db:"date_created"`
+	DateUpdated     time.Time      `db:"date_updated"`
+	DateDeleted     *time.Time     `db:"date_deleted"`
+	DateVerified    *time.Time     `db:"date_verified"`
+	FailedLogins    int            `db:"failed_logins"`
+	DateLockedUntil *time.Time     `db:"date_locked_until"`
+	Version         int            `db:"version"`
+}
+
+// Keyset is the position in an ordered list of users to continue a query
+// from. Value holds the value of the column the list is ordered by for the
+// user identified by UserID. When Backward is set the users before the
+// position are returned instead of those after it.
+type Keyset struct {
+	Value    string
+	UserID   string
+	Backward bool
+}
+
+// QueryFilter holds the fields users can be filtered on when querying the
+// database. Nil fields are not applied. Only users that have not been deleted
+// are matched unless Deleted is set, in which case only deleted users are.
+type QueryFilter struct {
+	Deleted          bool
+	Name             *string
+	Email            *string
+	Role             *string
+	StartCreatedDate *time.Time
+	EndCreatedDate   *time.Time
+}
+
+// Token represents a single-use token issued to a user for a purpose such as
+// resetting their password. Only a hash of the token is stored.
+type Token struct {
+	ID          string     `db:"token_id"`
+	UserID      string     `db:"user_id"`
+	Purpose     string     `db:"purpose"`
+	TokenHash   string     `db:"token_hash"`
+	DateExpires time.Time  `db:"date_expires"`
+	DateUsed    *time.Time `db:"date_used"`
+	DateCreated time.Time  `db:"date_created"`
 }
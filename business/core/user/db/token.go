@@ -0,0 +1,117 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/andrewyang17/service/business/sys/database"
+)
+
+// CreateToken adds a Token to the database.
+func (s Store) CreateToken(ctx context.Context, tkn Token) error {
+	const q = `
+	INSERT INTO user_tokens
+		(token_id, user_id, purpose, token_hash, date_expires, date_used, date_created)
+	VALUES
+		(:token_id, :user_id, :purpose, :token_hash, :date_expires, :date_used, :date_created)`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, tkn); err != nil {
+		return fmt.Errorf("inserting token: %w", err)
+	}
+
+	return nil
+}
+
+// QueryTokenByHashForUpdate finds the token with the specified hash and
+// locks the row until the surrounding transaction completes. It must be
+// called from a Store returned by Tran.
+func (s Store) QueryTokenByHashForUpdate(ctx context.Context, purpose string, tokenHash string) (Token, error) {
+	data := struct {
+		Purpose   string `db:"purpose"`
+		TokenHash string `db:"token_hash"`
+	}{
+		Purpose:   purpose,
+		TokenHash: tokenHash,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		user_tokens
+	WHERE
+		purpose = :purpose AND
+		token_hash = :token_hash
+	FOR UPDATE`
+
+	var tkn Token
+	if err := database.NamedQueryStruct(ctx, s.log, s.db, q, data, &tkn); err != nil {
+		return Token{}, fmt.Errorf("selecting token: %w", err)
+	}
+
+	return tkn, nil
+}
+
+// CountTokensSince returns the number of tokens issued to the user for the
+// purpose since the specified time.
+func (s Store) CountTokensSince(ctx context.Context, userID string, purpose string, since time.Time) (int, error) {
+	data := struct {
+		UserID  string    `db:"user_id"`
+		Purpose string    `db:"purpose"`
+		Since   time.Time `db:"since"`
+	}{
+		UserID:  userID,
+		Purpose: purpose,
+		Since:   since,
+	}
+
+	const q = `
+	SELECT
+		COUNT(*) AS count
+	FROM
+		user_tokens
+	WHERE
+		user_id = :user_id AND
+		purpose = :purpose AND
+		date_created >= :since`
+
+	var result struct {
+		Count int `db:"count"`
+	}
+	if err := database.NamedQueryStruct(ctx, s.log, s.db, q, data, &result); err != nil {
+		return 0, fmt.Errorf("counting tokens userID[%q]: %w", userID, err)
+	}
+
+	return result.Count, nil
+}
+
+// UseTokens marks every unused token issued to the user for the purpose as
+// used, so none of them can be redeemed again.
+func (s Store) UseTokens(ctx context.Context, userID string, purpose string, now time.Time) error {
+	data := struct {
+		UserID   string    `db:"user_id"`
+		Purpose  string    `db:"purpose"`
+		DateUsed time.Time `db:"date_used"`
+	}{
+		UserID:   userID,
+		Purpose:  purpose,
+		DateUsed: now,
+	}
+
+	const q = `
+	UPDATE
+		user_tokens
+	SET
+		"date_used" = :date_used
+	WHERE
+		user_id = :user_id AND
+		purpose = :purpose AND
+		date_used IS NULL`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, data); err != nil {
+		return fmt.Errorf("using tokens userID[%q]: %w", userID, err)
+	}
+
+	return nil
+}
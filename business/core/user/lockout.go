@@ -0,0 +1,98 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/andrewyang17/service/business/sys/database"
+	"github.com/andrewyang17/service/business/sys/metrics"
+	"github.com/andrewyang17/service/business/sys/tenant"
+	"github.com/andrewyang17/service/business/sys/validate"
+)
+
+// ErrAccountLocked is returned when a user tries to authenticate while they
+// are locked out after too many failed logins.
+var ErrAccountLocked = errors.New("account is temporarily locked")
+
+// Set of limits on failed logins. A user is locked out once they reach
+// maxFailedLogins failures in a row, and every further failure doubles the
+// time they are locked out for, up to maxLockDuration.
+const (
+	maxFailedLogins  = 5
+	baseLockDuration = time.Minute
+	maxLockDuration  = 24 * time.Hour
+)
+
+// Unlock clears the failed logins of the user identified by a given ID so
+// they can authenticate again straight away.
+func (c Core) Unlock(ctx context.Context, userID string) error {
+	if err := validate.CheckID(userID); err != nil {
+		return ErrInvalidID
+	}
+
+	orgID, err := tenant.Get(ctx)
+	if err != nil {
+		return err
+	}
+
+	if _, err := c.store.QueryMemberByID(ctx, orgID, userID); err != nil {
+		if errors.Is(err, database.ErrDBNotFound) {
+			return ErrNotFound
+		}
+		return fmt.Errorf("query: %w", err)
+	}
+
+	if err := c.store.UpdateLogins(ctx, userID, 0, nil); err != nil {
+		return fmt.Errorf("update logins: %w", err)
+	}
+
+	c.log.Infow("authenticate", "status", "account unlocked", "userID", userID)
+
+	return nil
+}
+
+// =============================================================================
+
+// recordFailedLogin counts a failed login against the user and locks them out
+// once they have failed too many times in a row. The count is incremented in
+// the database and the lock is decided on the count it returns, so concurrent
+// failures can't overwrite each other.
+func (c Core) recordFailedLogin(ctx context.Context, userID string, now time.Time) error {
+	failedLogins, err := c.store.IncrementFailedLogins(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("increment failed logins: %w", err)
+	}
+
+	metrics.AddFailedLogins(ctx)
+	c.log.Infow("authenticate", "status", "failed login", "userID", userID, "failedLogins", failedLogins)
+
+	if failedLogins < maxFailedLogins {
+		return nil
+	}
+
+	lockedUntil := now.Add(lockDuration(failedLogins))
+	if err := c.store.UpdateLockedUntil(ctx, userID, lockedUntil); err != nil {
+		return fmt.Errorf("update locked until: %w", err)
+	}
+
+	metrics.AddLockouts(ctx)
+	c.log.Infow("authenticate", "status", "account locked", "userID", userID, "until", lockedUntil)
+
+	return nil
+}
+
+// lockDuration returns how long a user is locked out for after the specified
+// number of failed logins in a row.
+func lockDuration(failedLogins int) time.Duration {
+	d := baseLockDuration
+	for i := maxFailedLogins; i < failedLogins; i++ {
+		d *= 2
+		if d >= maxLockDuration {
+			return maxLockDuration
+		}
+	}
+
+	return d
+}
@@ -9,13 +9,18 @@ import (
 
 // User represents an individual user.
 type User struct {
-	ID           string    `json:"id"`
-	Name         string    `json:"name"`
-	Email        string    `json:"email"`
-	Roles        []string  `json:"roles"`
-	PasswordHash []byte    `json:"-"`
-	DateCreated  time.Time `json:"date_created"`
-	DateUpdated  time.Time `json:"date_updated"`
+	ID              string     `json:"id"`
+	Name            string     `json:"name"`
+	Email           string     `json:"email"`
+	Roles           []string   `json:"roles"`
+	PasswordHash    []byte     `json:"-"`
+	DateCreated     time.Time  `json:"date_created"`
+	DateUpdated     time.Time  `json:"date_updated"`
+	DateDeleted     *time.Time `json:"date_deleted,omitempty"`
+	DateVerified    *time.Time `json:"date_verified,omitempty"`
+	FailedLogins    int        `json:"failed_logins"`
+	DateLockedUntil *time.Time `json:"date_locked_until,omitempty"`
+	Version         int        `json:"version"`
 }
 
 // NewUser contains information needed to create a new User.
@@ -23,10 +28,22 @@ type NewUser struct {
 	Name            string   `json:"name" validate:"required"`
 	Email           string   `json:"email" validate:"required,email"`
 	Roles           []string `json:"roles" validate:"required"`
-	Password        string   `json:"password" validate:"required"`
+	Password        string   `json:"password" validate:"required,password"`
 	PasswordConfirm string   `json:"password_confirm" validate:"eqfield=Password"`
 }
 
+// NewRegistration contains the information a customer provides to sign up.
+// Registered users are never given more than the USER role. Customers join
+// the organization of the storefront they sign up at, or the default one when
+// none is given.
+type NewRegistration struct {
+	Name            string `json:"name" validate:"required"`
+	Email           string `json:"email" validate:"required,email"`
+	Password        string `json:"password" validate:"required,password"`
+	PasswordConfirm string `json:"password_confirm" validate:"eqfield=Password"`
+	OrgID           string `json:"org_id" validate:"omitempty,uuid"`
+}
+
 // UpdateUser defines what information may be provided to modify an existing
 // User. All fields are optional so clients can send just the fields they want
 // changed. It uses pointer fields so we can differentiate between a field that
@@ -37,10 +54,33 @@ type UpdateUser struct {
 	Name            *string  `json:"name"`
 	Email           *string  `json:"email" validate:"omitempty,email"`
 	Roles           []string `json:"roles"`
-	Password        *string  `json:"password"`
+	Password        *string  `json:"password" validate:"omitempty,password"`
 	PasswordConfirm *string  `json:"password_confirm" validate:"omitempty,eqfield=Password"`
 }
 
+// QueryFilter holds the available fields a query for users can be filtered
+// on. Nil fields are not applied. Name and Email match any part of the value,
+// ignoring case. The created date range includes the start and excludes the
+// end. Deleted users are left out unless Deleted is set, which returns only
+// the deleted users.
+type QueryFilter struct {
+	Deleted          bool       `json:"deleted"`
+	Name             *string    `json:"name" validate:"omitempty,max=100"`
+	Email            *string    `json:"email" validate:"omitempty,max=100"`
+	Role             *string    `json:"role" validate:"omitempty,max=50"`
+	StartCreatedDate *time.Time `json:"start_created_date"`
+	EndCreatedDate   *time.Time `json:"end_created_date"`
+}
+
+// Page is a page of users retrieved with a cursor. Next and Prev are opaque
+// tokens for the pages after and before this one, and are empty when there
+// is no such page.
+type Page struct {
+	Items []User `json:"items"`
+	Next  string `json:"next,omitempty"`
+	Prev  string `json:"prev,omitempty"`
+}
+
 // =============================================================================
 
 func toUser(dbUser db.User) User {
@@ -54,4 +94,12 @@ func toUserSlice(dbUsrs []db.User) []User {
 		users[i] = toUser(dbUsr)
 	}
 	return users
-}
\ No newline at end of file
+}
+
+// PasswordReset contains the information needed to reset a user's password
+// with a token they were sent.
+type PasswordReset struct {
+	Token           string `json:"token" validate:"required"`
+	Password        string `json:"password" validate:"required,password"`
+	PasswordConfirm string `json:"password_confirm" validate:"eqfield=Password"`
+}
@@ -0,0 +1,118 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/andrewyang17/service/business/sys/database"
+	"github.com/andrewyang17/service/business/sys/mail"
+	"github.com/andrewyang17/service/business/sys/validate"
+	"github.com/jmoiron/sqlx"
+)
+
+// PurposePasswordReset marks the tokens issued to reset a password.
+const PurposePasswordReset = "password_reset"
+
+// passwordResetTTL is how long a password reset token can be used for.
+const passwordResetTTL = time.Hour
+
+// RequestPasswordReset issues a password reset token to the user with the
+// specified email and mails it to them. Only a hash of the token is kept. A
+// user can only be sent a few tokens an hour. So the caller can't tell which
+// emails belong to users, no error is returned for an unknown email or when
+// the user has been sent too many tokens.
+func (c Core) RequestPasswordReset(ctx context.Context, email string, now time.Time) error {
+	dbUsr, err := c.store.QueryByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, database.ErrDBNotFound) {
+			return nil
+		}
+		return fmt.Errorf("query: %w", err)
+	}
+
+	if err := checkTokenLimit(ctx, c.store, dbUsr.ID, PurposePasswordReset, now); err != nil {
+		if errors.Is(err, ErrTokenLimit) {
+			return nil
+		}
+		return err
+	}
+
+	token, err := issueToken(ctx, c.store, dbUsr.ID, PurposePasswordReset, passwordResetTTL, now)
+	if err != nil {
+		return fmt.Errorf("issue token: %w", err)
+	}
+
+	msg := mail.Message{
+		To:      dbUsr.Email,
+		Subject: "Reset your password",
+		Body:    fmt.Sprintf("Use this token to reset your password. It expires in %s.\n\n%s", passwordResetTTL, token),
+	}
+
+	if err := c.mailer.Send(ctx, msg); err != nil {
+		return fmt.Errorf("send mail: %w", err)
+	}
+
+	return nil
+}
+
+// ResetPassword sets a new password for the user a password reset token was
+// issued to. The token can only be used once and every other outstanding
+// reset token for the user is used up along with it. Any lockout from failed
+// logins is lifted and every session of the user is revoked, which also stops
+// their refresh tokens from being used.
+func (c Core) ResetPassword(ctx context.Context, pr PasswordReset, now time.Time) error {
+	if err := validate.Check(pr); err != nil {
+		return fmt.Errorf("validating data: %w", err)
+	}
+
+	pw, err := c.hasher.Hash(pr.Password)
+	if err != nil {
+		return fmt.Errorf("generating password hash: %w", err)
+	}
+
+	tran := func(tx sqlx.ExtContext) error {
+		store := c.store.Tran(tx)
+
+		dbTkn, err := redeemToken(ctx, store, PurposePasswordReset, pr.Token, now)
+		if err != nil {
+			return err
+		}
+
+		dbUsr, err := store.QueryByID(ctx, dbTkn.UserID)
+		if err != nil {
+			if errors.Is(err, database.ErrDBNotFound) {
+				return ErrInvalidToken
+			}
+			return fmt.Errorf("query user: %w", err)
+		}
+
+		dbUsr.PasswordHash = pw
+		dbUsr.DateUpdated = now
+
+		if err := store.Update(ctx, dbUsr); err != nil {
+			if errors.Is(err, database.ErrDBNotFound) {
+				return ErrVersionConflict
+			}
+			return fmt.Errorf("update: %w", err)
+		}
+
+		// Proving access to the email is enough to lift a lockout.
+		if err := store.UpdateLogins(ctx, dbUsr.ID, 0, nil); err != nil {
+			return fmt.Errorf("update logins: %w", err)
+		}
+
+		if err := c.sessionStore.Tran(tx).RevokeByUserID(ctx, dbUsr.ID, now); err != nil {
+			return fmt.Errorf("revoke sessions: %w", err)
+		}
+
+		return nil
+	}
+
+	if err := c.store.WithinTran(ctx, tran); err != nil {
+		return fmt.Errorf("tran: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,97 @@
+package user
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/andrewyang17/service/business/core/user/db"
+	"github.com/andrewyang17/service/business/sys/database"
+	"github.com/andrewyang17/service/business/sys/validate"
+)
+
+var (
+	ErrInvalidToken = errors.New("token is invalid or has expired")
+	ErrTokenLimit   = errors.New("too many tokens requested, try again later")
+)
+
+// Set of limits on how many tokens a user can be sent for a purpose.
+const (
+	tokenLimit       = 3
+	tokenLimitPeriod = time.Hour
+)
+
+// issueToken creates a token for the user that can be used for the purpose
+// until the ttl runs out. Only a hash of the token is stored, so the token
+// returned here is the only copy of it.
+func issueToken(ctx context.Context, store db.Store, userID string, purpose string, ttl time.Duration, now time.Time) (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generating token: %w", err)
+	}
+	token := base64.RawURLEncoding.EncodeToString(b)
+
+	dbTkn := db.Token{
+		ID:          validate.GenerateID(),
+		UserID:      userID,
+		Purpose:     purpose,
+		TokenHash:   hashToken(token),
+		DateExpires: now.Add(ttl),
+		DateCreated: now,
+	}
+
+	if err := store.CreateToken(ctx, dbTkn); err != nil {
+		return "", fmt.Errorf("create token: %w", err)
+	}
+
+	return token, nil
+}
+
+// checkTokenLimit returns ErrTokenLimit when the user has already been sent
+// as many tokens for the purpose as they are allowed in the current period.
+func checkTokenLimit(ctx context.Context, store db.Store, userID string, purpose string, now time.Time) error {
+	count, err := store.CountTokensSince(ctx, userID, purpose, now.Add(-tokenLimitPeriod))
+	if err != nil {
+		return fmt.Errorf("count tokens: %w", err)
+	}
+
+	if count >= tokenLimit {
+		return ErrTokenLimit
+	}
+
+	return nil
+}
+
+// redeemToken locks the token with the purpose and returns it if it can
+// still be used. Every outstanding token the user has for the purpose is
+// used up along with it. It must be called from a Store returned by Tran.
+func redeemToken(ctx context.Context, store db.Store, purpose string, token string, now time.Time) (db.Token, error) {
+	dbTkn, err := store.QueryTokenByHashForUpdate(ctx, purpose, hashToken(token))
+	if err != nil {
+		if errors.Is(err, database.ErrDBNotFound) {
+			return db.Token{}, ErrInvalidToken
+		}
+		return db.Token{}, fmt.Errorf("lock token: %w", err)
+	}
+
+	if dbTkn.DateUsed != nil || !now.Before(dbTkn.DateExpires) {
+		return db.Token{}, ErrInvalidToken
+	}
+
+	if err := store.UseTokens(ctx, dbTkn.UserID, purpose, now); err != nil {
+		return db.Token{}, fmt.Errorf("use tokens: %w", err)
+	}
+
+	return dbTkn, nil
+}
+
+// hashToken returns the hash of a token that is stored in its place.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -7,41 +7,98 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/andrewyang17/service/business/core/organization"
+	orgDB "github.com/andrewyang17/service/business/core/organization/db"
+	roleDB "github.com/andrewyang17/service/business/core/role/db"
+	sessionDB "github.com/andrewyang17/service/business/core/session/db"
 	"github.com/andrewyang17/service/business/core/user/db"
+	"github.com/andrewyang17/service/business/data/cursor"
+	"github.com/andrewyang17/service/business/data/order"
 	"github.com/andrewyang17/service/business/sys/auth"
 	"github.com/andrewyang17/service/business/sys/database"
+	"github.com/andrewyang17/service/business/sys/mail"
+	"github.com/andrewyang17/service/business/sys/password"
+	"github.com/andrewyang17/service/business/sys/tenant"
 	"github.com/andrewyang17/service/business/sys/validate"
 	"github.com/golang-jwt/jwt/v4"
 	"github.com/jmoiron/sqlx"
 	"go.uber.org/zap"
-	"golang.org/x/crypto/bcrypt"
 )
 
 var (
 	ErrNotFound              = errors.New("user not found")
 	ErrInvalidID             = errors.New("ID is not in its proper form")
 	ErrAuthenticationFailure = errors.New("authentication failed")
+	ErrDuplicateEmail        = errors.New("email is already in use")
+	ErrVersionConflict       = errors.New("user was changed by someone else")
+	ErrNotMember             = errors.New("user is not a member of the organization")
 )
 
+// Set of fields users can be ordered by.
+const (
+	OrderByID          = "id"
+	OrderByName        = "name"
+	OrderByEmail       = "email"
+	OrderByDateCreated = "date_created"
+)
+
+// DefaultOrderBy is the order users are returned in when none is requested.
+var DefaultOrderBy = order.NewBy(OrderByID, order.ASC)
+
+// orderByFields maps the fields users can be ordered by to the columns that
+// hold them. Only columns listed here ever make it into a query.
+var orderByFields = map[string]string{
+	OrderByID:          "user_id",
+	OrderByName:        "name",
+	OrderByEmail:       "email",
+	OrderByDateCreated: "date_created",
+}
+
 // Core manages the set of APIs for user access.
 type Core struct {
-	store db.Store
+	log          *zap.SugaredLogger
+	store        db.Store
+	roleStore    roleDB.Store
+	orgStore     orgDB.Store
+	sessionStore sessionDB.Store
+	mailer       mail.Mailer
+	hasher       password.Hasher
 }
 
-// NewCore constructs a core for user api access.
-func NewCore(log *zap.SugaredLogger, sqlxDB *sqlx.DB) Core {
+// NewCore constructs a core for user api access. The mailer is used to send
+// users their verification and password reset tokens and the hasher is used
+// to hash their passwords.
+func NewCore(log *zap.SugaredLogger, sqlxDB *sqlx.DB, mailer mail.Mailer, hasher password.Hasher) Core {
 	return Core{
-		store: db.NewStore(log, sqlxDB),
+		log:          log,
+		store:        db.NewStore(log, sqlxDB),
+		roleStore:    roleDB.NewStore(log, sqlxDB),
+		orgStore:     orgDB.NewStore(log, sqlxDB),
+		sessionStore: sessionDB.NewStore(log, sqlxDB),
+		mailer:       mailer,
+		hasher:       hasher,
 	}
 }
 
-// Create inserts a new user into the database.
+// Create inserts a new user into the database. The user joins the
+// organization the context acts for, or the default organization when it
+// acts for none. The user starts out unverified and is mailed a token to
+// verify their email with.
 func (c Core) Create(ctx context.Context, nu NewUser, now time.Time) (User, error) {
 	if err := validate.Check(nu); err != nil {
 		return User{}, fmt.Errorf("validating data: %w", err)
 	}
 
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(nu.Password), bcrypt.DefaultCost)
+	orgID, err := tenant.Get(ctx)
+	if err != nil {
+		orgID = organization.DefaultID
+	}
+
+	if err := c.checkRoles(ctx, nu.Roles); err != nil {
+		return User{}, err
+	}
+
+	hashedPassword, err := c.hasher.Hash(nu.Password)
 	if err != nil {
 		return User{}, fmt.Errorf("generating password hash: %w", err)
 	}
@@ -54,12 +111,38 @@ func (c Core) Create(ctx context.Context, nu NewUser, now time.Time) (User, erro
 		PasswordHash: hashedPassword,
 		DateCreated:  now,
 		DateUpdated:  now,
+		Version:      1,
 	}
 
+	var token string
+
 	tran := func(tx sqlx.ExtContext) error {
-		if err := c.store.Tran(tx).Create(ctx, dbUsr); err != nil {
+		store := c.store.Tran(tx)
+
+		if err := store.Create(ctx, dbUsr); err != nil {
+			if errors.Is(err, database.ErrDBDuplicatedEntry) {
+				return ErrDuplicateEmail
+			}
 			return fmt.Errorf("create: %w", err)
 		}
+
+		dbMbr := orgDB.Member{
+			UserID:      dbUsr.ID,
+			OrgID:       orgID,
+			Roles:       nu.Roles,
+			DateCreated: now,
+		}
+
+		if err := c.orgStore.Tran(tx).AddMember(ctx, dbMbr); err != nil {
+			return fmt.Errorf("add member: %w", err)
+		}
+
+		var err error
+		token, err = issueToken(ctx, store, dbUsr.ID, PurposeVerifyEmail, verifyEmailTTL, now)
+		if err != nil {
+			return fmt.Errorf("issue token: %w", err)
+		}
+
 		return nil
 	}
 
@@ -67,11 +150,49 @@ func (c Core) Create(ctx context.Context, nu NewUser, now time.Time) (User, erro
 		return User{}, fmt.Errorf("tran: %w", err)
 	}
 
+	c.sendVerification(ctx, dbUsr, token)
+
 	return toUser(dbUsr), nil
 }
 
-// Update replaces a user document in the database.
-func (c Core) Update(ctx context.Context, userID string, uu UpdateUser, now time.Time) error {
+// Register signs up a new customer. The user is only given the USER role and
+// has to verify their email like any other new user.
+func (c Core) Register(ctx context.Context, nr NewRegistration, now time.Time) (User, error) {
+	if err := validate.Check(nr); err != nil {
+		return User{}, fmt.Errorf("validating data: %w", err)
+	}
+
+	orgID := organization.DefaultID
+	if nr.OrgID != "" {
+		if _, err := c.orgStore.QueryByID(ctx, nr.OrgID); err != nil {
+			if errors.Is(err, database.ErrDBNotFound) {
+				return User{}, validate.FieldErrors{
+					{Field: "org_id", Error: "organization does not exist"},
+				}
+			}
+			return User{}, fmt.Errorf("query organization: %w", err)
+		}
+		orgID = nr.OrgID
+	}
+
+	nu := NewUser{
+		Name:            nr.Name,
+		Email:           nr.Email,
+		Roles:           []string{auth.RoleUser},
+		Password:        nr.Password,
+		PasswordConfirm: nr.PasswordConfirm,
+	}
+
+	return c.Create(tenant.Set(ctx, orgID), nu, now)
+}
+
+// Update replaces a user document in the database. Roles only change in the
+// organization the context acts for. Changing the email marks the user
+// unverified and mails a token to verify the new email with, changing the
+// password revokes every session of the user. When version isn't zero the
+// user is only updated if it is still at that version. ErrVersionConflict is
+// returned when the user was changed in the meantime.
+func (c Core) Update(ctx context.Context, userID string, uu UpdateUser, version int, now time.Time) error {
 	if err := validate.CheckID(userID); err != nil {
 		return ErrInvalidID
 	}
@@ -80,24 +201,42 @@ func (c Core) Update(ctx context.Context, userID string, uu UpdateUser, now time
 		return fmt.Errorf("validating data: %w", err)
 	}
 
-	dbUsr, err := c.store.QueryByID(ctx, userID)
+	if uu.Roles != nil {
+		if err := c.checkRoles(ctx, uu.Roles); err != nil {
+			return err
+		}
+	}
+
+	orgID, err := tenant.Get(ctx)
+	if err != nil {
+		return err
+	}
+
+	dbUsr, err := c.store.QueryMemberByID(ctx, orgID, userID)
 	if err != nil {
 		if errors.Is(err, database.ErrDBNotFound) {
-			return fmt.Errorf("updating user userID[%q]: %w", userID, err)
+			return ErrNotFound
 		}
+		return fmt.Errorf("updating user userID[%q]: %w", userID, err)
+	}
+
+	if version != 0 && dbUsr.Version != version {
+		return ErrVersionConflict
 	}
 
 	if uu.Name != nil {
 		dbUsr.Name = *uu.Name
 	}
-	if uu.Email != nil {
+	emailChanged := uu.Email != nil && *uu.Email != dbUsr.Email
+	if emailChanged {
 		dbUsr.Email = *uu.Email
+		dbUsr.DateVerified = nil
 	}
 	if uu.Roles != nil {
 		dbUsr.Roles = uu.Roles
 	}
 	if uu.Password != nil {
-		pw, err := bcrypt.GenerateFromPassword([]byte(*uu.Password), bcrypt.DefaultCost)
+		pw, err := c.hasher.Hash(*uu.Password)
 		if err != nil {
 			return fmt.Errorf("generating password hash: %w", err)
 		}
@@ -105,29 +244,184 @@ func (c Core) Update(ctx context.Context, userID string, uu UpdateUser, now time
 	}
 	dbUsr.DateUpdated = now
 
-	if err := c.store.Update(ctx, dbUsr); err != nil {
-		return fmt.Errorf("update: %w", err)
+	var token string
+
+	tran := func(tx sqlx.ExtContext) error {
+		store := c.store.Tran(tx)
+
+		if err := store.Update(ctx, dbUsr); err != nil {
+			switch {
+			case errors.Is(err, database.ErrDBDuplicatedEntry):
+				return ErrDuplicateEmail
+			case errors.Is(err, database.ErrDBNotFound):
+				return ErrVersionConflict
+			}
+			return fmt.Errorf("update: %w", err)
+		}
+
+		if uu.Roles != nil {
+			if err := c.orgStore.Tran(tx).UpdateRoles(ctx, orgID, dbUsr.ID, uu.Roles); err != nil {
+				return fmt.Errorf("update roles: %w", err)
+			}
+		}
+
+		// Whoever knew the old password must not stay logged in with it.
+		if uu.Password != nil {
+			if err := c.sessionStore.Tran(tx).RevokeByUserID(ctx, dbUsr.ID, now); err != nil {
+				return fmt.Errorf("revoke sessions: %w", err)
+			}
+		}
+
+		if !emailChanged {
+			return nil
+		}
+
+		// Tokens sent to the old email must not verify the new one.
+		if err := store.UseTokens(ctx, dbUsr.ID, PurposeVerifyEmail, now); err != nil {
+			return fmt.Errorf("use tokens: %w", err)
+		}
+
+		var err error
+		token, err = issueToken(ctx, store, dbUsr.ID, PurposeVerifyEmail, verifyEmailTTL, now)
+		if err != nil {
+			return fmt.Errorf("issue token: %w", err)
+		}
+
+		return nil
+	}
+
+	if err := c.store.WithinTran(ctx, tran); err != nil {
+		return fmt.Errorf("tran: %w", err)
+	}
+
+	if emailChanged {
+		c.sendVerification(ctx, dbUsr, token)
+	}
+
+	return nil
+}
+
+// Delete removes the user identified by a given ID from the organization the
+// context acts for. A user who belongs to other organizations only loses
+// their membership of this one. Otherwise the user is marked as deleted and
+// can no longer be found or authenticate, but is kept until they are purged
+// so they can be restored.
+func (c Core) Delete(ctx context.Context, userID string, now time.Time) error {
+	if err := validate.CheckID(userID); err != nil {
+		return ErrInvalidID
+	}
+
+	orgID, err := tenant.Get(ctx)
+	if err != nil {
+		return err
+	}
+
+	tran := func(tx sqlx.ExtContext) error {
+		store := c.store.Tran(tx)
+		orgStore := c.orgStore.Tran(tx)
+
+		// Locking the user keeps two organizations from removing the user at
+		// the same time and leaving them in none.
+		if _, err := store.QueryMemberByIDForUpdate(ctx, orgID, userID); err != nil {
+			if errors.Is(err, database.ErrDBNotFound) {
+				return nil
+			}
+			return fmt.Errorf("lock user: %w", err)
+		}
+
+		dbOrgs, err := orgStore.QueryByUserID(ctx, userID)
+		if err != nil {
+			return fmt.Errorf("query organizations: %w", err)
+		}
+
+		if len(dbOrgs) > 1 {
+			if err := orgStore.RemoveMember(ctx, orgID, userID); err != nil {
+				return fmt.Errorf("remove member: %w", err)
+			}
+			return nil
+		}
+
+		if err := store.Delete(ctx, orgID, userID, now); err != nil {
+			return fmt.Errorf("delete: %w", err)
+		}
+
+		return nil
+	}
+
+	if err := c.store.WithinTran(ctx, tran); err != nil {
+		return fmt.Errorf("tran: %w", err)
 	}
 
 	return nil
 }
 
-// Delete removes a user from the database.
-func (c Core) Delete(ctx context.Context, userID string) error {
+// Restore brings back a deleted user that has not been purged yet.
+func (c Core) Restore(ctx context.Context, userID string, now time.Time) error {
 	if err := validate.CheckID(userID); err != nil {
 		return ErrInvalidID
 	}
 
-	if err := c.store.Delete(ctx, userID); err != nil {
-		return fmt.Errorf("delete: %w", err)
+	orgID, err := tenant.Get(ctx)
+	if err != nil {
+		return err
+	}
+
+	if _, err := c.store.QueryDeletedByID(ctx, orgID, userID); err != nil {
+		if errors.Is(err, database.ErrDBNotFound) {
+			return ErrNotFound
+		}
+		return fmt.Errorf("query: %w", err)
+	}
+
+	if err := c.store.Restore(ctx, orgID, userID, now); err != nil {
+		return fmt.Errorf("restore: %w", err)
 	}
 
 	return nil
 }
 
-// Query retrieves a list of existing users from the database.
-func (c Core) Query(ctx context.Context, pageNumber int, rowsPerPage int) ([]User, error) {
-	dbUsers, err := c.store.Query(ctx, pageNumber, rowsPerPage)
+// Purge removes the users that were deleted before the specified time for
+// good. Their products and sales are removed with them. Users are only
+// marked deleted when they leave their last organization, so no other
+// organization loses a member. It returns the number of users that were
+// purged.
+func (c Core) Purge(ctx context.Context, before time.Time) (int, error) {
+	userIDs, err := c.store.Purge(ctx, before)
+	if err != nil {
+		return 0, fmt.Errorf("purge: %w", err)
+	}
+
+	return len(userIDs), nil
+}
+
+// Query retrieves a list of existing users of the organization the context
+// acts for that match the filter from the database, in the specified order.
+func (c Core) Query(ctx context.Context, filter QueryFilter, orderBy order.By, pageNumber int, rowsPerPage int) ([]User, error) {
+	if err := validate.Check(filter); err != nil {
+		return nil, fmt.Errorf("validating filter: %w", err)
+	}
+
+	column, exists := orderByFields[orderBy.Field]
+	if !exists {
+		return nil, validate.FieldErrors{
+			{Field: "order_by", Error: fmt.Sprintf("users can't be ordered by %q", orderBy.Field)},
+		}
+	}
+
+	dbFilter := db.QueryFilter{
+		Name:             filter.Name,
+		Email:            filter.Email,
+		Role:             filter.Role,
+		StartCreatedDate: filter.StartCreatedDate,
+		EndCreatedDate:   filter.EndCreatedDate,
+	}
+
+	orgID, err := tenant.Get(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	dbUsers, err := c.store.Query(ctx, orgID, dbFilter, order.NewBy(column, orderBy.Direction), pageNumber, rowsPerPage)
 	if err != nil {
 		return nil, fmt.Errorf("query: %w", err)
 	}
@@ -135,13 +429,110 @@ func (c Core) Query(ctx context.Context, pageNumber int, rowsPerPage int) ([]Use
 	return toUserSlice(dbUsers), nil
 }
 
-// QueryByID gets the specified user from the database.
+// QueryCursor retrieves a page of users that match the filter, starting from
+// the position marked by the cursor token. An empty token starts at the
+// beginning of the list in the specified order, otherwise the order the
+// token was created with is used. Clients should send the same filter with
+// every token they received for it.
+func (c Core) QueryCursor(ctx context.Context, filter QueryFilter, orderBy order.By, token string, rows int) (Page, error) {
+	if err := validate.Check(filter); err != nil {
+		return Page{}, fmt.Errorf("validating filter: %w", err)
+	}
+
+	var keyset *db.Keyset
+	if token != "" {
+		cur, err := cursor.Decode(token)
+		if err != nil {
+			return Page{}, err
+		}
+
+		orderBy = cur.OrderBy()
+		keyset = &db.Keyset{
+			Value:    cur.Value,
+			UserID:   cur.ID,
+			Backward: cur.Backward,
+		}
+	}
+
+	column, exists := orderByFields[orderBy.Field]
+	if !exists {
+		return Page{}, validate.FieldErrors{
+			{Field: "order_by", Error: fmt.Sprintf("users can't be ordered by %q", orderBy.Field)},
+		}
+	}
+
+	if keyset != nil && !validCursorValue(orderBy.Field, keyset.Value) {
+		return Page{}, validate.FieldErrors{
+			{Field: "cursor", Error: "cursor is not valid"},
+		}
+	}
+
+	dbFilter := db.QueryFilter{
+		Name:             filter.Name,
+		Email:            filter.Email,
+		Role:             filter.Role,
+		StartCreatedDate: filter.StartCreatedDate,
+		EndCreatedDate:   filter.EndCreatedDate,
+	}
+
+	orgID, err := tenant.Get(ctx)
+	if err != nil {
+		return Page{}, err
+	}
+
+	// One extra user is asked for to find out if there is another page.
+	dbUsers, err := c.store.QueryKeyset(ctx, orgID, dbFilter, order.NewBy(column, orderBy.Direction), keyset, rows+1)
+	if err != nil {
+		return Page{}, fmt.Errorf("query: %w", err)
+	}
+
+	more := len(dbUsers) > rows
+	if more {
+		dbUsers = dbUsers[:rows]
+	}
+
+	backward := keyset != nil && keyset.Backward
+	if backward {
+		for i, j := 0, len(dbUsers)-1; i < j; i, j = i+1, j-1 {
+			dbUsers[i], dbUsers[j] = dbUsers[j], dbUsers[i]
+		}
+	}
+
+	page := Page{
+		Items: toUserSlice(dbUsers),
+	}
+
+	if len(dbUsers) == 0 {
+		return page, nil
+	}
+
+	first, last := dbUsers[0], dbUsers[len(dbUsers)-1]
+
+	// Moving backward means there are always users after this page, and
+	// moving forward from a cursor means there are always users before it.
+	if more || backward {
+		page.Next = cursor.New(orderBy, cursorValue(last, orderBy.Field), last.ID, false).Encode()
+	}
+	if (more && backward) || (keyset != nil && !backward) {
+		page.Prev = cursor.New(orderBy, cursorValue(first, orderBy.Field), first.ID, true).Encode()
+	}
+
+	return page, nil
+}
+
+// QueryByID gets the specified user from the database, provided they are a
+// member of the organization the context acts for.
 func (c Core) QueryByID(ctx context.Context, userID string) (User, error) {
 	if err := validate.CheckID(userID); err != nil {
 		return User{}, ErrInvalidID
 	}
 
-	dbUsr, err := c.store.QueryByID(ctx, userID)
+	orgID, err := tenant.Get(ctx)
+	if err != nil {
+		return User{}, err
+	}
+
+	dbUsr, err := c.store.QueryMemberByID(ctx, orgID, userID)
 	if err != nil {
 		if errors.Is(err, database.ErrDBNotFound) {
 			return User{}, ErrNotFound
@@ -152,7 +543,8 @@ func (c Core) QueryByID(ctx context.Context, userID string) (User, error) {
 	return toUser(dbUsr), nil
 }
 
-// QueryByEmail gets the specified user from the database by email.
+// QueryByEmail gets the specified user from the database by email, provided
+// they are a member of the organization the context acts for.
 func (c Core) QueryByEmail(ctx context.Context, email string) (User, error) {
 
 	// Add Email Validate function in validate
@@ -160,6 +552,11 @@ func (c Core) QueryByEmail(ctx context.Context, email string) (User, error) {
 	// 	return User{}, ErrInvalidEmail
 	// }
 
+	orgID, err := tenant.Get(ctx)
+	if err != nil {
+		return User{}, err
+	}
+
 	dbUsr, err := c.store.QueryByEmail(ctx, email)
 	if err != nil {
 		if errors.Is(err, database.ErrDBNotFound) {
@@ -168,13 +565,24 @@ func (c Core) QueryByEmail(ctx context.Context, email string) (User, error) {
 		return User{}, fmt.Errorf("query: %w", err)
 	}
 
+	dbUsr, err = c.store.QueryMemberByID(ctx, orgID, dbUsr.ID)
+	if err != nil {
+		if errors.Is(err, database.ErrDBNotFound) {
+			return User{}, ErrNotFound
+		}
+		return User{}, fmt.Errorf("query member: %w", err)
+	}
+
 	return toUser(dbUsr), nil
 }
 
 // Authenticate finds a user by their email and verifies their password. On
-// success it returns a Claims User representing this user. The claims can be
-// used to generate a token for future authentication.
-func (c Core) Authenticate(ctx context.Context, now time.Time, email, password string) (auth.Claims, error) {
+// success it returns a Claims User representing this user acting for the
+// specified organization, or the first organization they joined when orgID is
+// empty, with the roles they hold there. The claims can be used to generate a token for future
+// authentication. Failed attempts are counted and the user is locked out for
+// a while after too many in a row.
+func (c Core) Authenticate(ctx context.Context, now time.Time, email, pass string, orgID string) (auth.Claims, error) {
 	dbUsr, err := c.store.QueryByEmail(ctx, email)
 	if err != nil {
 		if errors.Is(err, database.ErrDBNotFound) {
@@ -183,19 +591,161 @@ func (c Core) Authenticate(ctx context.Context, now time.Time, email, password s
 		return auth.Claims{}, fmt.Errorf("query: %w", err)
 	}
 
-	if err := bcrypt.CompareHashAndPassword(dbUsr.PasswordHash, []byte(password)); err != nil {
+	if dbUsr.DateLockedUntil != nil && now.Before(*dbUsr.DateLockedUntil) {
+		c.log.Infow("authenticate", "status", "rejected locked account", "userID", dbUsr.ID, "until", *dbUsr.DateLockedUntil)
+		return auth.Claims{}, ErrAccountLocked
+	}
+
+	if err := password.Compare(dbUsr.PasswordHash, pass); err != nil {
+		if !errors.Is(err, password.ErrMismatch) {
+			return auth.Claims{}, fmt.Errorf("compare password: %w", err)
+		}
+		if err := c.recordFailedLogin(ctx, dbUsr.ID, now); err != nil {
+			return auth.Claims{}, fmt.Errorf("record failed login: %w", err)
+		}
 		return auth.Claims{}, ErrAuthenticationFailure
 	}
 
+	orgID, err = c.selectOrg(ctx, dbUsr.ID, orgID)
+	if err != nil {
+		return auth.Claims{}, err
+	}
+
+	// Upgrade hashes made with outdated settings while the password is at
+	// hand. The user is already authenticated, so a failure is only logged.
+	if c.hasher.NeedsRehash(dbUsr.PasswordHash) {
+		if err := c.rehash(ctx, dbUsr.ID, pass); err != nil {
+			c.log.Errorw("authenticate", "status", "rehash password", "userID", dbUsr.ID, "ERROR", err)
+		}
+	}
+
+	if dbUsr.FailedLogins > 0 || dbUsr.DateLockedUntil != nil {
+		if err := c.store.UpdateLogins(ctx, dbUsr.ID, 0, nil); err != nil {
+			return auth.Claims{}, fmt.Errorf("reset failed logins: %w", err)
+		}
+	}
+
+	// The roles come with the membership of the organization.
+	dbMbr, err := c.store.QueryMemberByID(ctx, orgID, dbUsr.ID)
+	if err != nil {
+		return auth.Claims{}, fmt.Errorf("query member: %w", err)
+	}
+
+	perms, err := c.roleStore.QueryPermissions(ctx, dbMbr.Roles)
+	if err != nil {
+		return auth.Claims{}, fmt.Errorf("query permissions: %w", err)
+	}
+
 	claims := auth.Claims{
 		RegisteredClaims: jwt.RegisteredClaims{
 			Issuer:    "service project",
 			Subject:   dbUsr.ID,
 			ExpiresAt: jwt.NewNumericDate(time.Now().UTC().Add(time.Hour)),
 			IssuedAt:  jwt.NewNumericDate(time.Now().UTC()),
+			ID:        validate.GenerateID(),
 		},
-		Roles: dbUsr.Roles,
+		Roles:       dbMbr.Roles,
+		Permissions: perms,
+		OrgID:       orgID,
+		Verified:    dbUsr.DateVerified != nil,
 	}
 
 	return claims, nil
 }
+
+// selectOrg returns the organization a user acts for. When orgID is empty
+// the first organization the user joined is picked. ErrNotMember is returned
+// when the user doesn't belong to the organization or to any at all.
+func (c Core) selectOrg(ctx context.Context, userID string, orgID string) (string, error) {
+	dbOrgs, err := c.orgStore.QueryByUserID(ctx, userID)
+	if err != nil {
+		return "", fmt.Errorf("query organizations: %w", err)
+	}
+
+	if len(dbOrgs) == 0 {
+		return "", ErrNotMember
+	}
+
+	if orgID == "" {
+		return dbOrgs[0].ID, nil
+	}
+
+	for _, dbOrg := range dbOrgs {
+		if dbOrg.ID == orgID {
+			return orgID, nil
+		}
+	}
+
+	return "", ErrNotMember
+}
+
+// =============================================================================
+
+// checkRoles makes sure every role in the list is defined.
+func (c Core) checkRoles(ctx context.Context, roles []string) error {
+	names, err := c.roleStore.QueryNames(ctx, roles)
+	if err != nil {
+		return fmt.Errorf("query roles: %w", err)
+	}
+
+	defined := make(map[string]bool, len(names))
+	for _, name := range names {
+		defined[name] = true
+	}
+
+	for _, role := range roles {
+		if !defined[role] {
+			return validate.FieldErrors{
+				{Field: "roles", Error: fmt.Sprintf("role %q is not defined", role)},
+			}
+		}
+	}
+
+	return nil
+}
+
+// rehash replaces the password hash of the specified user with one made by
+// the current hasher.
+func (c Core) rehash(ctx context.Context, userID string, pass string) error {
+	hash, err := c.hasher.Hash(pass)
+	if err != nil {
+		return fmt.Errorf("hash: %w", err)
+	}
+
+	if err := c.store.UpdatePasswordHash(ctx, userID, hash); err != nil {
+		return fmt.Errorf("update password hash: %w", err)
+	}
+
+	c.log.Infow("authenticate", "status", "rehashed password", "userID", userID)
+
+	return nil
+}
+
+// cursorValue returns the value of the field a list of users is ordered by
+// for the specified user, in the form kept in a cursor.
+func cursorValue(dbUsr db.User, field string) string {
+	switch field {
+	case OrderByName:
+		return dbUsr.Name
+	case OrderByEmail:
+		return dbUsr.Email
+	case OrderByDateCreated:
+		return dbUsr.DateCreated.Format(time.RFC3339Nano)
+	default:
+		return dbUsr.ID
+	}
+}
+
+// validCursorValue reports whether a value taken from a cursor fits the field
+// the list is ordered by, so a crafted cursor never reaches the database.
+func validCursorValue(field string, value string) bool {
+	switch field {
+	case OrderByDateCreated:
+		_, err := time.Parse(time.RFC3339Nano, value)
+		return err == nil
+	case OrderByID:
+		return validate.CheckID(value) == nil
+	default:
+		return true
+	}
+}
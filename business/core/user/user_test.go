@@ -4,13 +4,24 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/andrewyang17/service/business/core/organization"
+	"github.com/andrewyang17/service/business/core/session"
 	"github.com/andrewyang17/service/business/core/user"
 	"github.com/andrewyang17/service/business/data/dbschema"
 	"github.com/andrewyang17/service/business/data/dbtest"
+	"github.com/andrewyang17/service/business/data/order"
 	"github.com/andrewyang17/service/business/sys/auth"
+	"github.com/andrewyang17/service/business/sys/mail"
+	"github.com/andrewyang17/service/business/sys/password"
+	"github.com/andrewyang17/service/business/sys/tenant"
+	"github.com/andrewyang17/service/business/sys/validate"
 	"github.com/andrewyang17/service/foundation/docker"
 	"github.com/google/go-cmp/cmp"
 )
@@ -33,22 +44,22 @@ func TestUser(t *testing.T) {
 	log, db, teardown := dbtest.NewUnit(t, c, "testuser")
 	t.Cleanup(teardown)
 
-	core := user.NewCore(log, db)
+	core := user.NewCore(log, db, mail.NewLogMailer(log), dbtest.NewHasher(t))
 
 	t.Log("Given the need to work with User records.")
 	{
 		testID := 0
 		t.Logf("\tTest %d:\tWhen handling a single User.", testID)
 		{
-			ctx := context.Background()
+			ctx := tenant.Set(context.Background(), organization.DefaultID)
 			now := time.Date(2022, time.January, 8, 17, 56, 0, 0, time.UTC)
 
 			nu := user.NewUser{
 				Name:            "Andrew Yang",
 				Email:           "yangzhisiangg@gmail.com",
 				Roles:           []string{auth.RoleAdmin},
-				Password:        "gophers",
-				PasswordConfirm: "gophers",
+				Password:        "Gophers2022",
+				PasswordConfirm: "Gophers2022",
 			}
 
 			usr, err := core.Create(ctx, nu, now)
@@ -73,11 +84,16 @@ func TestUser(t *testing.T) {
 				Email: dbtest.StringPointer("yangzhisiang@hotmail.com"),
 			}
 
-			if err := core.Update(ctx, usr.ID, upd, now); err != nil {
+			if err := core.Update(ctx, usr.ID, upd, usr.Version, now); err != nil {
 				t.Fatalf("\t%s\tTest %d:\tShould be able to update user : %s.", dbtest.Failed, testID, err)
 			}
 			t.Logf("\t%s\tTest %d:\tShould be able to update user.", dbtest.Success, testID)
 
+			if err := core.Update(ctx, usr.ID, upd, usr.Version, now); !errors.Is(err, user.ErrVersionConflict) {
+				t.Fatalf("\t%s\tTest %d:\tShould NOT be able to update a stale version : %s.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould NOT be able to update a stale version.", dbtest.Success, testID)
+
 			saved, err = core.QueryByEmail(ctx, *upd.Email)
 			if err != nil {
 				t.Fatalf("\t%s\tTest %d:\tShould be able to retrieve user by Email : %s.", dbtest.Failed, testID, err)
@@ -100,7 +116,7 @@ func TestUser(t *testing.T) {
 				t.Logf("\t%s\tTest %d:\tShould be able to see updates to Email.", dbtest.Success, testID)
 			}
 
-			if err := core.Delete(ctx, usr.ID); err != nil {
+			if err := core.Delete(ctx, usr.ID, now); err != nil {
 				t.Fatalf("\t%s\tTest %d:\tShould be able to delete user : %s.", dbtest.Failed, testID, err)
 			}
 			t.Logf("\t%s\tTest %d:\tShould be able to delete user.", dbtest.Success, testID)
@@ -110,6 +126,41 @@ func TestUser(t *testing.T) {
 				t.Fatalf("\t%s\tTest %d:\tShould NOT be able to retrieve user : %s.", dbtest.Failed, testID, err)
 			}
 			t.Logf("\t%s\tTest %d:\tShould NOT be able to retrieve user.", dbtest.Success, testID)
+
+			if err := core.Restore(ctx, usr.ID, now); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to restore user : %s.", dbtest.Failed, testID, err)
+			}
+
+			if _, err := core.QueryByID(ctx, usr.ID); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to retrieve a restored user : %s.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould be able to retrieve a restored user.", dbtest.Success, testID)
+
+			if err := core.Restore(ctx, usr.ID, now); !errors.Is(err, user.ErrNotFound) {
+				t.Fatalf("\t%s\tTest %d:\tShould NOT be able to restore a user that isn't deleted : %v.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould NOT be able to restore a user that isn't deleted.", dbtest.Success, testID)
+
+			if err := core.Delete(ctx, usr.ID, now); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to delete user again : %s.", dbtest.Failed, testID, err)
+			}
+
+			purged, err := core.Purge(ctx, now)
+			if err != nil || purged != 0 {
+				t.Fatalf("\t%s\tTest %d:\tShould NOT purge users within the retention period : %d %v.", dbtest.Failed, testID, purged, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould NOT purge users within the retention period.", dbtest.Success, testID)
+
+			purged, err = core.Purge(ctx, now.Add(time.Second))
+			if err != nil || purged != 1 {
+				t.Fatalf("\t%s\tTest %d:\tShould purge users deleted before the cutoff : %d %v.", dbtest.Failed, testID, purged, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould purge users deleted before the cutoff.", dbtest.Success, testID)
+
+			if err := core.Restore(ctx, usr.ID, now); !errors.Is(err, user.ErrNotFound) {
+				t.Fatalf("\t%s\tTest %d:\tShould NOT be able to restore a purged user : %v.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould NOT be able to restore a purged user.", dbtest.Success, testID)
 		}
 	}
 }
@@ -123,16 +174,16 @@ func TestPagingUser(t *testing.T) {
 
 	dbschema.Seed(ctx, db)
 
-	user := user.NewCore(log, db)
+	core := user.NewCore(log, db, mail.NewLogMailer(log), dbtest.NewHasher(t))
 
 	t.Log("Given the need to page through User records.")
 	{
 		testID := 0
 		t.Logf("\tTest %d:\tWhen paging through 2 users.", testID)
 		{
-			ctx := context.Background()
+			ctx := tenant.Set(context.Background(), organization.DefaultID)
 
-			users1, err := user.Query(ctx, 1, 1)
+			users1, err := core.Query(ctx, user.QueryFilter{}, user.DefaultOrderBy, 1, 1)
 			if err != nil {
 				t.Fatalf("\t%s\tTest %d:\tShould be able to retrieve users for page 1 : %s.", dbtest.Failed, testID, err)
 			}
@@ -143,7 +194,7 @@ func TestPagingUser(t *testing.T) {
 			}
 			t.Logf("\t%s\tTest %d:\tShould have a single user.", dbtest.Success, testID)
 
-			users2, err := user.Query(ctx, 2, 1)
+			users2, err := core.Query(ctx, user.QueryFilter{}, user.DefaultOrderBy, 2, 1)
 			if err != nil {
 				t.Fatalf("\t%s\tTest %d:\tShould be able to retrieve users for page 2 : %s.", dbtest.Failed, testID, err)
 			}
@@ -163,3 +214,677 @@ func TestPagingUser(t *testing.T) {
 		}
 	}
 }
+
+func TestPasswordReset(t *testing.T) {
+	log, db, teardown := dbtest.NewUnit(t, c, "testreset")
+	t.Cleanup(teardown)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	dbschema.Seed(ctx, db)
+
+	dir := t.TempDir()
+	mailer, err := mail.NewFileMailer(dir)
+	if err != nil {
+		t.Fatalf("\t%s\tShould be able to construct the mailer: %s.", dbtest.Failed, err)
+	}
+
+	core := user.NewCore(log, db, mailer, dbtest.NewHasher(t))
+
+	t.Log("Given the need to reset a user's password.")
+	{
+		testID := 0
+		t.Logf("\tTest %d:\tWhen redeeming a reset token.", testID)
+		{
+			now := time.Date(2022, time.January, 8, 17, 56, 0, 0, time.UTC)
+			const email = "user@example.com"
+
+			if err := core.RequestPasswordReset(ctx, "unknown@example.com", now); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould quietly accept an unknown email : %s.", dbtest.Failed, testID, err)
+			}
+			if tokens := readTokens(t, dir); len(tokens) != 0 {
+				t.Fatalf("\t%s\tTest %d:\tShould not send mail for an unknown email : got %d.", dbtest.Failed, testID, len(tokens))
+			}
+			t.Logf("\t%s\tTest %d:\tShould quietly accept an unknown email.", dbtest.Success, testID)
+
+			for i := 0; i < 4; i++ {
+				if err := core.RequestPasswordReset(ctx, email, now.Add(time.Duration(i)*time.Minute)); err != nil {
+					t.Fatalf("\t%s\tTest %d:\tShould be able to request a reset : %s.", dbtest.Failed, testID, err)
+				}
+			}
+			tokens := readTokens(t, dir)
+			if len(tokens) != 3 {
+				t.Fatalf("\t%s\tTest %d:\tShould only send 3 tokens an hour : got %d.", dbtest.Failed, testID, len(tokens))
+			}
+			t.Logf("\t%s\tTest %d:\tShould only send 3 tokens an hour.", dbtest.Success, testID)
+
+			pr := user.PasswordReset{
+				Token:           tokens[0],
+				Password:        "New Gophers 2022",
+				PasswordConfirm: "New Gophers 2022",
+			}
+
+			if err := core.ResetPassword(ctx, pr, now.Add(2*time.Hour)); !errors.Is(err, user.ErrInvalidToken) {
+				t.Fatalf("\t%s\tTest %d:\tShould NOT be able to use an expired token : %s.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould NOT be able to use an expired token.", dbtest.Success, testID)
+
+			claims, err := core.Authenticate(ctx, now, email, "gophers", "")
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to authenticate with the old password : %s.", dbtest.Failed, testID, err)
+			}
+
+			sesCore := session.NewCore(log, db)
+			_, refresh, err := sesCore.Create(ctx, claims, now)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to start session : %s.", dbtest.Failed, testID, err)
+			}
+
+			if err := core.ResetPassword(ctx, pr, now.Add(10*time.Minute)); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to reset the password : %s.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould be able to reset the password.", dbtest.Success, testID)
+
+			if _, _, err := sesCore.Refresh(ctx, session.Refresh{Token: refresh}, now.Add(20*time.Minute)); !errors.Is(err, session.ErrInvalidToken) {
+				t.Fatalf("\t%s\tTest %d:\tShould NOT be able to refresh a session from before the reset : %v.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould NOT be able to refresh a session from before the reset.", dbtest.Success, testID)
+
+			if _, err := core.Authenticate(ctx, now, email, "New Gophers 2022", ""); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to authenticate with the new password : %s.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould be able to authenticate with the new password.", dbtest.Success, testID)
+
+			for _, token := range tokens {
+				pr.Token = token
+				if err := core.ResetPassword(ctx, pr, now.Add(10*time.Minute)); !errors.Is(err, user.ErrInvalidToken) {
+					t.Fatalf("\t%s\tTest %d:\tShould NOT be able to use a token again : %s.", dbtest.Failed, testID, err)
+				}
+			}
+			t.Logf("\t%s\tTest %d:\tShould NOT be able to use any token again.", dbtest.Success, testID)
+		}
+	}
+}
+
+func TestVerifyUser(t *testing.T) {
+	log, db, teardown := dbtest.NewUnit(t, c, "testverify")
+	t.Cleanup(teardown)
+
+	dir := t.TempDir()
+	mailer, err := mail.NewFileMailer(dir)
+	if err != nil {
+		t.Fatalf("\t%s\tShould be able to construct the mailer: %s.", dbtest.Failed, err)
+	}
+
+	core := user.NewCore(log, db, mailer, dbtest.NewHasher(t))
+
+	t.Log("Given the need to verify a user's email.")
+	{
+		testID := 0
+		t.Logf("\tTest %d:\tWhen redeeming a verification token.", testID)
+		{
+			ctx := tenant.Set(context.Background(), organization.DefaultID)
+			now := time.Date(2022, time.January, 8, 17, 56, 0, 0, time.UTC)
+
+			nu := user.NewUser{
+				Name:            "Andrew Yang",
+				Email:           "yangzhisiangg@gmail.com",
+				Roles:           []string{auth.RoleUser},
+				Password:        "Gophers2022",
+				PasswordConfirm: "Gophers2022",
+			}
+
+			usr, err := core.Create(ctx, nu, now)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to create user : %s.", dbtest.Failed, testID, err)
+			}
+
+			claims, err := core.Authenticate(ctx, now, nu.Email, nu.Password, "")
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to authenticate : %s.", dbtest.Failed, testID, err)
+			}
+			if usr.DateVerified != nil || claims.Verified {
+				t.Fatalf("\t%s\tTest %d:\tShould start out unverified.", dbtest.Failed, testID)
+			}
+			t.Logf("\t%s\tTest %d:\tShould start out unverified.", dbtest.Success, testID)
+
+			tokens := readTokens(t, dir)
+			if len(tokens) != 1 {
+				t.Fatalf("\t%s\tTest %d:\tShould be sent a verification token : got %d.", dbtest.Failed, testID, len(tokens))
+			}
+			t.Logf("\t%s\tTest %d:\tShould be sent a verification token.", dbtest.Success, testID)
+
+			if err := core.VerifyEmail(ctx, tokens[0], now.Add(time.Hour)); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to verify the email : %s.", dbtest.Failed, testID, err)
+			}
+
+			claims, err = core.Authenticate(ctx, now, nu.Email, nu.Password, "")
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to authenticate : %s.", dbtest.Failed, testID, err)
+			}
+			if !claims.Verified {
+				t.Fatalf("\t%s\tTest %d:\tShould be verified.", dbtest.Failed, testID)
+			}
+			t.Logf("\t%s\tTest %d:\tShould be verified.", dbtest.Success, testID)
+
+			if err := core.VerifyEmail(ctx, tokens[0], now.Add(time.Hour)); !errors.Is(err, user.ErrInvalidToken) {
+				t.Fatalf("\t%s\tTest %d:\tShould NOT be able to use the token again : %s.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould NOT be able to use the token again.", dbtest.Success, testID)
+
+			if err := core.ResendVerification(ctx, usr.ID, now); !errors.Is(err, user.ErrAlreadyVerified) {
+				t.Fatalf("\t%s\tTest %d:\tShould NOT be sent another token once verified : %s.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould NOT be sent another token once verified.", dbtest.Success, testID)
+
+			upd := user.UpdateUser{
+				Email: dbtest.StringPointer("yangzhisiang@hotmail.com"),
+			}
+
+			if err := core.Update(ctx, usr.ID, upd, 0, now); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to update the email : %s.", dbtest.Failed, testID, err)
+			}
+
+			saved, err := core.QueryByID(ctx, usr.ID)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to retrieve user by ID : %s.", dbtest.Failed, testID, err)
+			}
+			if saved.DateVerified != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be unverified after changing the email.", dbtest.Failed, testID)
+			}
+			if tokens := readTokens(t, dir); len(tokens) != 2 {
+				t.Fatalf("\t%s\tTest %d:\tShould be sent a token for the new email : got %d.", dbtest.Failed, testID, len(tokens))
+			}
+			t.Logf("\t%s\tTest %d:\tShould be unverified and sent a token after changing the email.", dbtest.Success, testID)
+		}
+	}
+}
+
+func TestLockoutUser(t *testing.T) {
+	log, db, teardown := dbtest.NewUnit(t, c, "testlockout")
+	t.Cleanup(teardown)
+
+	core := user.NewCore(log, db, mail.NewLogMailer(log), dbtest.NewHasher(t))
+
+	t.Log("Given the need to lock users out after failed logins.")
+	{
+		testID := 0
+		t.Logf("\tTest %d:\tWhen failing to authenticate repeatedly.", testID)
+		{
+			ctx := tenant.Set(context.Background(), organization.DefaultID)
+			now := time.Date(2022, time.January, 8, 17, 56, 0, 0, time.UTC)
+
+			nu := user.NewUser{
+				Name:            "Andrew Yang",
+				Email:           "yangzhisiangg@gmail.com",
+				Roles:           []string{auth.RoleUser},
+				Password:        "Gophers2022",
+				PasswordConfirm: "Gophers2022",
+			}
+
+			usr, err := core.Create(ctx, nu, now)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to create user : %s.", dbtest.Failed, testID, err)
+			}
+
+			fail := func() {
+				for i := 0; i < 5; i++ {
+					if _, err := core.Authenticate(ctx, now, nu.Email, "wrong", ""); !errors.Is(err, user.ErrAuthenticationFailure) {
+						t.Fatalf("\t%s\tTest %d:\tShould fail with a wrong password : %s.", dbtest.Failed, testID, err)
+					}
+				}
+			}
+
+			fail()
+			if _, err := core.Authenticate(ctx, now, nu.Email, nu.Password, ""); !errors.Is(err, user.ErrAccountLocked) {
+				t.Fatalf("\t%s\tTest %d:\tShould be locked out after 5 failures : %s.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould be locked out after 5 failures.", dbtest.Success, testID)
+
+			if _, err := core.Authenticate(ctx, now.Add(2*time.Minute), nu.Email, nu.Password, ""); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to authenticate once the lock runs out : %s.", dbtest.Failed, testID, err)
+			}
+
+			saved, err := core.QueryByID(ctx, usr.ID)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to retrieve user by ID : %s.", dbtest.Failed, testID, err)
+			}
+			if saved.FailedLogins != 0 || saved.DateLockedUntil != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould clear the failures after authenticating : %d.", dbtest.Failed, testID, saved.FailedLogins)
+			}
+			t.Logf("\t%s\tTest %d:\tShould be able to authenticate once the lock runs out.", dbtest.Success, testID)
+
+			fail()
+			if err := core.Unlock(ctx, usr.ID); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to unlock the user : %s.", dbtest.Failed, testID, err)
+			}
+			if _, err := core.Authenticate(ctx, now, nu.Email, nu.Password, ""); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to authenticate once unlocked : %s.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould be able to authenticate once unlocked.", dbtest.Success, testID)
+		}
+
+		testID = 1
+		t.Logf("\tTest %d:\tWhen failing to authenticate concurrently.", testID)
+		{
+			ctx := tenant.Set(context.Background(), organization.DefaultID)
+			now := time.Date(2022, time.January, 8, 17, 56, 0, 0, time.UTC)
+
+			nu := user.NewUser{
+				Name:            "Jill Yang",
+				Email:           "jill@gmail.com",
+				Roles:           []string{auth.RoleUser},
+				Password:        "Gophers2022",
+				PasswordConfirm: "Gophers2022",
+			}
+
+			usr, err := core.Create(ctx, nu, now)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to create user : %s.", dbtest.Failed, testID, err)
+			}
+
+			const attempts = 4
+
+			var wg sync.WaitGroup
+			wg.Add(attempts)
+			for i := 0; i < attempts; i++ {
+				go func() {
+					defer wg.Done()
+					if _, err := core.Authenticate(ctx, now, nu.Email, "wrong", ""); !errors.Is(err, user.ErrAuthenticationFailure) {
+						t.Errorf("\t%s\tTest %d:\tShould fail with a wrong password : %s.", dbtest.Failed, testID, err)
+					}
+				}()
+			}
+			wg.Wait()
+
+			saved, err := core.QueryByID(ctx, usr.ID)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to retrieve user by ID : %s.", dbtest.Failed, testID, err)
+			}
+			if saved.FailedLogins != attempts {
+				t.Fatalf("\t%s\tTest %d:\tShould count every concurrent failure : got %d want %d.", dbtest.Failed, testID, saved.FailedLogins, attempts)
+			}
+			t.Logf("\t%s\tTest %d:\tShould count every concurrent failure.", dbtest.Success, testID)
+		}
+	}
+}
+
+func TestDuplicateEmailUser(t *testing.T) {
+	log, db, teardown := dbtest.NewUnit(t, c, "testduplicate")
+	t.Cleanup(teardown)
+
+	core := user.NewCore(log, db, mail.NewLogMailer(log), dbtest.NewHasher(t))
+
+	t.Log("Given the need to keep the emails of users unique.")
+	{
+		testID := 0
+		t.Logf("\tTest %d:\tWhen changing the email of a user to one in use.", testID)
+		{
+			ctx := tenant.Set(context.Background(), organization.DefaultID)
+			now := time.Date(2022, time.January, 8, 17, 56, 0, 0, time.UTC)
+
+			const userID = "45b5fbd3-755f-4379-8f07-a58d4a30fa2f"
+
+			upd := user.UpdateUser{
+				Email: dbtest.StringPointer("admin@example.com"),
+			}
+
+			if err := core.Update(ctx, userID, upd, 0, now); !errors.Is(err, user.ErrDuplicateEmail) {
+				t.Fatalf("\t%s\tTest %d:\tShould NOT be able to take the email of another user : %v.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould NOT be able to take the email of another user.", dbtest.Success, testID)
+
+			saved, err := core.QueryByID(ctx, userID)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to retrieve user by ID : %s.", dbtest.Failed, testID, err)
+			}
+
+			if saved.Email != "user@example.com" {
+				t.Fatalf("\t%s\tTest %d:\tShould keep the email of the user : %s.", dbtest.Failed, testID, saved.Email)
+			}
+			t.Logf("\t%s\tTest %d:\tShould keep the email of the user.", dbtest.Success, testID)
+		}
+	}
+}
+
+func TestOrganizationUser(t *testing.T) {
+	log, db, teardown := dbtest.NewUnit(t, c, "testorguser")
+	t.Cleanup(teardown)
+
+	core := user.NewCore(log, db, mail.NewLogMailer(log), dbtest.NewHasher(t))
+	orgCore := organization.NewCore(log, db)
+
+	t.Log("Given the need to keep the users of organizations apart.")
+	{
+		testID := 0
+		t.Logf("\tTest %d:\tWhen handling a user of another organization.", testID)
+		{
+			ctx := tenant.Set(context.Background(), organization.DefaultID)
+			now := time.Date(2022, time.January, 8, 17, 56, 0, 0, time.UTC)
+
+			org, err := orgCore.Create(ctx, organization.NewOrganization{Name: "Other"}, "5cf37266-3473-4006-984f-9325122678b7", now)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to create organization : %s.", dbtest.Failed, testID, err)
+			}
+
+			nu := user.NewUser{
+				Name:            "Other User",
+				Email:           "other@example.com",
+				Roles:           []string{auth.RoleUser},
+				Password:        "Gophers2022",
+				PasswordConfirm: "Gophers2022",
+			}
+
+			usr, err := core.Create(tenant.Set(context.Background(), org.ID), nu, now)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to create user : %s.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould be able to create user in the other organization.", dbtest.Success, testID)
+
+			if _, err := core.QueryByID(ctx, usr.ID); !errors.Is(err, user.ErrNotFound) {
+				t.Fatalf("\t%s\tTest %d:\tShould NOT be able to retrieve the user by ID : %v.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould NOT be able to retrieve the user by ID.", dbtest.Success, testID)
+
+			filter := user.QueryFilter{Email: dbtest.StringPointer("other@example.com")}
+			users, err := core.Query(ctx, filter, user.DefaultOrderBy, 1, 10)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to query users : %s.", dbtest.Failed, testID, err)
+			}
+
+			if len(users) != 0 {
+				t.Fatalf("\t%s\tTest %d:\tShould NOT list the user : %+v.", dbtest.Failed, testID, users)
+			}
+			t.Logf("\t%s\tTest %d:\tShould NOT list the user.", dbtest.Success, testID)
+
+			upd := user.UpdateUser{
+				Name: dbtest.StringPointer("Taken Over"),
+			}
+
+			if err := core.Update(ctx, usr.ID, upd, 0, now); !errors.Is(err, user.ErrNotFound) {
+				t.Fatalf("\t%s\tTest %d:\tShould NOT be able to update the user : %v.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould NOT be able to update the user.", dbtest.Success, testID)
+
+			if err := core.Delete(ctx, usr.ID, now); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to call delete : %s.", dbtest.Failed, testID, err)
+			}
+
+			saved, err := core.QueryByID(tenant.Set(context.Background(), org.ID), usr.ID)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould NOT be able to delete the user : %s.", dbtest.Failed, testID, err)
+			}
+
+			if saved.Name != nu.Name {
+				t.Fatalf("\t%s\tTest %d:\tShould NOT be able to update the user : %s.", dbtest.Failed, testID, saved.Name)
+			}
+			t.Logf("\t%s\tTest %d:\tShould NOT be able to delete the user.", dbtest.Success, testID)
+		}
+
+		testID = 1
+		t.Logf("\tTest %d:\tWhen deleting a user who belongs to several organizations.", testID)
+		{
+			ctx := tenant.Set(context.Background(), organization.DefaultID)
+			now := time.Date(2022, time.January, 8, 17, 56, 0, 0, time.UTC)
+
+			org, err := orgCore.Create(ctx, organization.NewOrganization{Name: "Shared"}, "5cf37266-3473-4006-984f-9325122678b7", now)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to create organization : %s.", dbtest.Failed, testID, err)
+			}
+			orgCtx := tenant.Set(context.Background(), org.ID)
+
+			nu := user.NewUser{
+				Name:            "Shared User",
+				Email:           "shared@example.com",
+				Roles:           []string{auth.RoleUser},
+				Password:        "Gophers2022",
+				PasswordConfirm: "Gophers2022",
+			}
+
+			usr, err := core.Create(ctx, nu, now)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to create user : %s.", dbtest.Failed, testID, err)
+			}
+
+			if _, err := orgCore.AddMember(ctx, org.ID, organization.NewMember{UserID: usr.ID}, now); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to add the user to the other organization : %s.", dbtest.Failed, testID, err)
+			}
+
+			if err := core.Delete(ctx, usr.ID, now); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to delete the user : %s.", dbtest.Failed, testID, err)
+			}
+
+			if _, err := core.QueryByID(ctx, usr.ID); !errors.Is(err, user.ErrNotFound) {
+				t.Fatalf("\t%s\tTest %d:\tShould remove the user from the organization : %v.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould remove the user from the organization.", dbtest.Success, testID)
+
+			if _, err := core.Purge(ctx, now.Add(time.Hour)); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to purge users : %s.", dbtest.Failed, testID, err)
+			}
+
+			if _, err := core.QueryByID(orgCtx, usr.ID); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould keep the user in the other organization : %s.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould keep the user in the other organization.", dbtest.Success, testID)
+
+			if err := core.Delete(orgCtx, usr.ID, now); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to delete the user : %s.", dbtest.Failed, testID, err)
+			}
+
+			purged, err := core.Purge(ctx, now.Add(time.Hour))
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to purge users : %s.", dbtest.Failed, testID, err)
+			}
+
+			if purged != 1 {
+				t.Fatalf("\t%s\tTest %d:\tShould purge the user once they left their last organization : got %d.", dbtest.Failed, testID, purged)
+			}
+			t.Logf("\t%s\tTest %d:\tShould purge the user once they left their last organization.", dbtest.Success, testID)
+		}
+	}
+}
+
+func TestRehashUser(t *testing.T) {
+	log, db, teardown := dbtest.NewUnit(t, c, "testrehash")
+	t.Cleanup(teardown)
+
+	argon2id, err := password.NewArgon2id(password.Argon2Params{Time: 1, Memory: 1024, Threads: 1, KeyLen: 32, SaltLen: 16})
+	if err != nil {
+		t.Fatalf("\t%s\tShould be able to construct the hasher: %s.", dbtest.Failed, err)
+	}
+
+	old := user.NewCore(log, db, mail.NewLogMailer(log), dbtest.NewHasher(t))
+	core := user.NewCore(log, db, mail.NewLogMailer(log), argon2id)
+
+	t.Log("Given the need to upgrade password hashes.")
+	{
+		testID := 0
+		t.Logf("\tTest %d:\tWhen authenticating with an outdated hash.", testID)
+		{
+			ctx := tenant.Set(context.Background(), organization.DefaultID)
+			now := time.Date(2022, time.January, 8, 17, 56, 0, 0, time.UTC)
+
+			nu := user.NewUser{
+				Name:            "Andrew Yang",
+				Email:           "yangzhisiangg@gmail.com",
+				Roles:           []string{auth.RoleUser},
+				Password:        "Gophers2022",
+				PasswordConfirm: "Gophers2022",
+			}
+
+			usr, err := old.Create(ctx, nu, now)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to create user : %s.", dbtest.Failed, testID, err)
+			}
+
+			if _, err := core.Authenticate(ctx, now, nu.Email, nu.Password, ""); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to authenticate with the old hash : %s.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould be able to authenticate with the old hash.", dbtest.Success, testID)
+
+			saved, err := core.QueryByID(ctx, usr.ID)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to retrieve user by ID : %s.", dbtest.Failed, testID, err)
+			}
+			if argon2id.NeedsRehash(saved.PasswordHash) {
+				t.Fatalf("\t%s\tTest %d:\tShould have rehashed the password : %s.", dbtest.Failed, testID, saved.PasswordHash)
+			}
+			t.Logf("\t%s\tTest %d:\tShould have rehashed the password.", dbtest.Success, testID)
+
+			if _, err := core.Authenticate(ctx, now, nu.Email, nu.Password, ""); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to authenticate with the new hash : %s.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould be able to authenticate with the new hash.", dbtest.Success, testID)
+		}
+	}
+}
+
+// readTokens returns the tokens in the messages written by a FileMailer, in
+// the order they were sent. The token is the last line of each message.
+func readTokens(t *testing.T, dir string) []string {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("\t%s\tShould be able to read the mail directory: %s.", dbtest.Failed, err)
+	}
+
+	var tokens []string
+	for _, entry := range entries {
+		data, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			t.Fatalf("\t%s\tShould be able to read the message: %s.", dbtest.Failed, err)
+		}
+
+		lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+		tokens = append(tokens, strings.TrimSpace(lines[len(lines)-1]))
+	}
+
+	return tokens
+}
+
+func TestFilterUser(t *testing.T) {
+	log, db, teardown := dbtest.NewUnit(t, c, "testfilter")
+	t.Cleanup(teardown)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	dbschema.Seed(ctx, db)
+
+	core := user.NewCore(log, db, mail.NewLogMailer(log), dbtest.NewHasher(t))
+
+	t.Log("Given the need to find User records.")
+	{
+		testID := 0
+		t.Logf("\tTest %d:\tWhen filtering and ordering the seeded users.", testID)
+		{
+			ctx := tenant.Set(context.Background(), organization.DefaultID)
+
+			email := "USER@"
+			users, err := core.Query(ctx, user.QueryFilter{Email: &email}, user.DefaultOrderBy, 1, 10)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to filter by email : %s.", dbtest.Failed, testID, err)
+			}
+
+			if len(users) != 1 || users[0].Email != "user@example.com" {
+				t.Fatalf("\t%s\tTest %d:\tShould match part of the email ignoring case : %+v.", dbtest.Failed, testID, users)
+			}
+			t.Logf("\t%s\tTest %d:\tShould match part of the email ignoring case.", dbtest.Success, testID)
+
+			role := auth.RoleAdmin
+			users, err = core.Query(ctx, user.QueryFilter{Role: &role}, user.DefaultOrderBy, 1, 10)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to filter by role : %s.", dbtest.Failed, testID, err)
+			}
+
+			if len(users) != 1 || users[0].Name != "Admin" {
+				t.Fatalf("\t%s\tTest %d:\tShould only get users with the role : %+v.", dbtest.Failed, testID, users)
+			}
+			t.Logf("\t%s\tTest %d:\tShould only get users with the role.", dbtest.Success, testID)
+
+			wildcard := "%"
+			users, err = core.Query(ctx, user.QueryFilter{Name: &wildcard}, user.DefaultOrderBy, 1, 10)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to filter by name : %s.", dbtest.Failed, testID, err)
+			}
+
+			if len(users) != 0 {
+				t.Fatalf("\t%s\tTest %d:\tShould match wildcards literally : %+v.", dbtest.Failed, testID, users)
+			}
+			t.Logf("\t%s\tTest %d:\tShould match wildcards literally.", dbtest.Success, testID)
+
+			users, err = core.Query(ctx, user.QueryFilter{}, order.NewBy(user.OrderByName, order.DESC), 1, 10)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to order by name : %s.", dbtest.Failed, testID, err)
+			}
+
+			if len(users) != 2 || users[0].Name != "User" {
+				t.Fatalf("\t%s\tTest %d:\tShould order by name descending : %+v.", dbtest.Failed, testID, users)
+			}
+			t.Logf("\t%s\tTest %d:\tShould order by name descending.", dbtest.Success, testID)
+
+			if _, err := core.Query(ctx, user.QueryFilter{}, order.NewBy("password_hash", order.ASC), 1, 10); !validate.IsFieldErrors(err) {
+				t.Fatalf("\t%s\tTest %d:\tShould NOT be able to order by an unknown field : %v.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould NOT be able to order by an unknown field.", dbtest.Success, testID)
+		}
+	}
+}
+
+func TestCursorUser(t *testing.T) {
+	log, db, teardown := dbtest.NewUnit(t, c, "testcursor")
+	t.Cleanup(teardown)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	dbschema.Seed(ctx, db)
+
+	core := user.NewCore(log, db, mail.NewLogMailer(log), dbtest.NewHasher(t))
+
+	t.Log("Given the need to page through User records with a cursor.")
+	{
+		testID := 0
+		t.Logf("\tTest %d:\tWhen moving forward and back through 2 users.", testID)
+		{
+			ctx := tenant.Set(context.Background(), organization.DefaultID)
+			orderBy := order.NewBy(user.OrderByEmail, order.DESC)
+
+			page1, err := core.QueryCursor(ctx, user.QueryFilter{}, orderBy, "", 1)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to retrieve the first page : %s.", dbtest.Failed, testID, err)
+			}
+
+			if len(page1.Items) != 1 || page1.Items[0].Email != "user@example.com" || page1.Next == "" || page1.Prev != "" {
+				t.Fatalf("\t%s\tTest %d:\tShould get the first user and only a next cursor : %+v.", dbtest.Failed, testID, page1)
+			}
+			t.Logf("\t%s\tTest %d:\tShould get the first user and only a next cursor.", dbtest.Success, testID)
+
+			page2, err := core.QueryCursor(ctx, user.QueryFilter{}, user.DefaultOrderBy, page1.Next, 1)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to retrieve the next page : %s.", dbtest.Failed, testID, err)
+			}
+
+			if len(page2.Items) != 1 || page2.Items[0].Email != "admin@example.com" || page2.Next != "" || page2.Prev == "" {
+				t.Fatalf("\t%s\tTest %d:\tShould keep the order of the cursor and only get a prev cursor : %+v.", dbtest.Failed, testID, page2)
+			}
+			t.Logf("\t%s\tTest %d:\tShould keep the order of the cursor and only get a prev cursor.", dbtest.Success, testID)
+
+			back, err := core.QueryCursor(ctx, user.QueryFilter{}, user.DefaultOrderBy, page2.Prev, 1)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to retrieve the previous page : %s.", dbtest.Failed, testID, err)
+			}
+
+			if len(back.Items) != 1 || back.Items[0].ID != page1.Items[0].ID || back.Next == "" || back.Prev != "" {
+				t.Fatalf("\t%s\tTest %d:\tShould get back to the first page : %+v.", dbtest.Failed, testID, back)
+			}
+			t.Logf("\t%s\tTest %d:\tShould get back to the first page.", dbtest.Success, testID)
+
+			if _, err := core.QueryCursor(ctx, user.QueryFilter{}, user.DefaultOrderBy, "garbage", 1); !validate.IsFieldErrors(err) {
+				t.Fatalf("\t%s\tTest %d:\tShould NOT accept a malformed cursor : %v.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould NOT accept a malformed cursor.", dbtest.Success, testID)
+		}
+	}
+}
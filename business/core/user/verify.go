@@ -0,0 +1,122 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/andrewyang17/service/business/core/user/db"
+	"github.com/andrewyang17/service/business/sys/database"
+	"github.com/andrewyang17/service/business/sys/mail"
+	"github.com/andrewyang17/service/business/sys/validate"
+	"github.com/jmoiron/sqlx"
+)
+
+// ErrAlreadyVerified is returned when a verification token is requested for a
+// user whose email is already verified.
+var ErrAlreadyVerified = errors.New("email is already verified")
+
+// PurposeVerifyEmail marks the tokens issued to verify an email.
+const PurposeVerifyEmail = "verify_email"
+
+// verifyEmailTTL is how long an email verification token can be used for.
+const verifyEmailTTL = 48 * time.Hour
+
+// VerifyEmail marks the user a verification token was issued to as verified.
+// The token can only be used once.
+func (c Core) VerifyEmail(ctx context.Context, token string, now time.Time) error {
+	if token == "" {
+		return ErrInvalidToken
+	}
+
+	tran := func(tx sqlx.ExtContext) error {
+		store := c.store.Tran(tx)
+
+		dbTkn, err := redeemToken(ctx, store, PurposeVerifyEmail, token, now)
+		if err != nil {
+			return err
+		}
+
+		dbUsr, err := store.QueryByID(ctx, dbTkn.UserID)
+		if err != nil {
+			if errors.Is(err, database.ErrDBNotFound) {
+				return ErrInvalidToken
+			}
+			return fmt.Errorf("query user: %w", err)
+		}
+
+		dbUsr.DateVerified = &now
+		dbUsr.DateUpdated = now
+
+		if err := store.Update(ctx, dbUsr); err != nil {
+			if errors.Is(err, database.ErrDBNotFound) {
+				return ErrVersionConflict
+			}
+			return fmt.Errorf("update: %w", err)
+		}
+
+		return nil
+	}
+
+	if err := c.store.WithinTran(ctx, tran); err != nil {
+		return fmt.Errorf("tran: %w", err)
+	}
+
+	return nil
+}
+
+// ResendVerification mails the specified user a new token to verify their
+// email with. A user can only be sent a few tokens an hour.
+func (c Core) ResendVerification(ctx context.Context, userID string, now time.Time) error {
+	if err := validate.CheckID(userID); err != nil {
+		return ErrInvalidID
+	}
+
+	dbUsr, err := c.store.QueryByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, database.ErrDBNotFound) {
+			return ErrNotFound
+		}
+		return fmt.Errorf("query: %w", err)
+	}
+
+	if dbUsr.DateVerified != nil {
+		return ErrAlreadyVerified
+	}
+
+	if err := checkTokenLimit(ctx, c.store, dbUsr.ID, PurposeVerifyEmail, now); err != nil {
+		return err
+	}
+
+	token, err := issueToken(ctx, c.store, dbUsr.ID, PurposeVerifyEmail, verifyEmailTTL, now)
+	if err != nil {
+		return fmt.Errorf("issue token: %w", err)
+	}
+
+	if err := c.mailer.Send(ctx, verificationMessage(dbUsr, token)); err != nil {
+		return fmt.Errorf("send mail: %w", err)
+	}
+
+	return nil
+}
+
+// =============================================================================
+
+// sendVerification mails a verification token to a user whose account was
+// just saved. The account is kept when the mail can't be sent since the user
+// can ask for another token, so the failure is only logged.
+func (c Core) sendVerification(ctx context.Context, dbUsr db.User, token string) {
+	if err := c.mailer.Send(ctx, verificationMessage(dbUsr, token)); err != nil {
+		c.log.Errorw("send verification", "userID", dbUsr.ID, "ERROR", err)
+	}
+}
+
+// verificationMessage builds the mail that sends a verification token.
+func verificationMessage(dbUsr db.User, token string) mail.Message {
+	return mail.Message{
+		To:      dbUsr.Email,
+		Subject: "Verify your email",
+		Body:    fmt.Sprintf("Use this token to verify your email. It expires in %s.\n\n%s", verifyEmailTTL, token),
+	}
+}
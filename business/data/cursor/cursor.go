@@ -0,0 +1,79 @@
+// Package cursor provides support for keyset pagination using opaque cursor
+// tokens that mark a position in an ordered list of results.
+package cursor
+
+import (
+	"encoding/base64"
+	"encoding/json"
+
+	"github.com/andrewyang17/service/business/data/order"
+	"github.com/andrewyang17/service/business/sys/validate"
+)
+
+// Cursor marks the position of a row in an ordered list of results. The
+// position is given by the value of the field the list is ordered by and the
+// ID of the row, which breaks ties between rows with the same value. When
+// Backward is set the cursor points at the rows before the position rather
+// than the rows after it.
+type Cursor struct {
+	Field     string `json:"f"`
+	Direction string `json:"d"`
+	Value     string `json:"v"`
+	ID        string `json:"i"`
+	Backward  bool   `json:"b,omitempty"`
+}
+
+// New constructs a cursor for the row with the specified value and ID in a
+// list in the specified order.
+func New(orderBy order.By, value string, id string, backward bool) Cursor {
+	return Cursor{
+		Field:     orderBy.Field,
+		Direction: orderBy.Direction,
+		Value:     value,
+		ID:        id,
+		Backward:  backward,
+	}
+}
+
+// OrderBy returns the order of the list the cursor belongs to.
+func (c Cursor) OrderBy() order.By {
+	return order.NewBy(c.Field, c.Direction)
+}
+
+// Encode returns the cursor as an opaque token that is safe to use in a URL.
+func (c Cursor) Encode() string {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return ""
+	}
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// Decode parses a token produced by Encode. Tokens are provided by clients,
+// so anything that doesn't decode to a complete cursor, with an ID in its
+// proper form, is reported as a field error.
+func Decode(token string) (Cursor, error) {
+	invalid := validate.FieldErrors{
+		{Field: "cursor", Error: "cursor is not valid"},
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return Cursor{}, invalid
+	}
+
+	var c Cursor
+	if err := json.Unmarshal(data, &c); err != nil {
+		return Cursor{}, invalid
+	}
+
+	if c.Field == "" || (c.Direction != order.ASC && c.Direction != order.DESC) {
+		return Cursor{}, invalid
+	}
+
+	if err := validate.CheckID(c.ID); err != nil {
+		return Cursor{}, invalid
+	}
+
+	return c, nil
+}
@@ -0,0 +1,49 @@
+package cursor_test
+
+import (
+	"testing"
+
+	"github.com/andrewyang17/service/business/data/cursor"
+	"github.com/andrewyang17/service/business/data/order"
+	"github.com/andrewyang17/service/business/sys/validate"
+)
+
+// Success and failure markers.
+const (
+	success = "\u2713"
+	failed  = "\u2717"
+)
+
+func TestCursor(t *testing.T) {
+	t.Log("Given the need to pass positions in a list to clients.")
+	{
+		testID := 0
+		t.Logf("\tTest %d:\tWhen encoding and decoding cursors.", testID)
+		{
+			cur := cursor.New(order.NewBy("name", order.DESC), "Admin", "5cf37266-3473-4006-984f-9325122678b7", true)
+
+			got, err := cursor.Decode(cur.Encode())
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to decode an encoded cursor : %s.", failed, testID, err)
+			}
+
+			if got != cur {
+				t.Fatalf("\t%s\tTest %d:\tShould get back the same cursor : got %+v want %+v.", failed, testID, got, cur)
+			}
+			t.Logf("\t%s\tTest %d:\tShould get back the same cursor.", success, testID)
+
+			if got.OrderBy() != order.NewBy("name", order.DESC) {
+				t.Fatalf("\t%s\tTest %d:\tShould keep the order of the list : got %+v.", failed, testID, got.OrderBy())
+			}
+			t.Logf("\t%s\tTest %d:\tShould keep the order of the list.", success, testID)
+
+			crafted := cursor.New(order.NewBy("name", order.ASC), "Admin", "1' OR '1'='1", false).Encode()
+			for _, token := range []string{"", "not a cursor", "e30", crafted} {
+				if _, err := cursor.Decode(token); !validate.IsFieldErrors(err) {
+					t.Fatalf("\t%s\tTest %d:\tShould reject the token %q : %v.", failed, testID, token, err)
+				}
+			}
+			t.Logf("\t%s\tTest %d:\tShould reject malformed tokens.", success, testID)
+		}
+	}
+}
@@ -1,3 +1,17 @@
+DELETE FROM refunds;
+DELETE FROM reservations;
+DELETE FROM order_items;
+DELETE FROM orders;
 DELETE FROM sales;
+DELETE FROM product_tags;
+DELETE FROM tags;
+DELETE FROM product_variants;
 DELETE FROM products;
+DELETE FROM categories;
+DELETE FROM revoked_tokens;
+DELETE FROM refresh_tokens;
+DELETE FROM sessions;
+DELETE FROM api_keys;
+DELETE FROM user_tokens;
+DELETE FROM user_orgs;
 DELETE FROM users;
\ No newline at end of file
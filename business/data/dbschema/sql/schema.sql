@@ -40,4 +40,483 @@ CREATE TABLE sales (
     PRIMARY KEY (sale_id),
     FOREIGN KEY (user_id) REFERENCES users(user_id) ON DELETE CASCADE,
     FOREIGN KEY (product_id) REFERENCES products(product_id) ON DELETE CASCADE
-);
\ No newline at end of file
+);
+-- Version: 1.3
+-- Description: Create table orders
+CREATE TABLE orders (
+    order_id UUID,
+    user_id UUID,
+    status TEXT,
+    total INT,
+    date_created TIMESTAMP,
+    date_updated TIMESTAMP,
+
+    PRIMARY KEY (order_id),
+    FOREIGN KEY (user_id) REFERENCES users(user_id) ON DELETE CASCADE
+);
+
+-- Version: 1.4
+-- Description: Create table order_items
+CREATE TABLE order_items (
+    order_item_id UUID,
+    order_id UUID,
+    product_id UUID,
+    quantity INT,
+    unit_price INT,
+    total INT,
+
+    PRIMARY KEY (order_item_id),
+    FOREIGN KEY (order_id) REFERENCES orders(order_id) ON DELETE CASCADE,
+    FOREIGN KEY (product_id) REFERENCES products(product_id) ON DELETE CASCADE
+);
+
+-- Version: 1.5
+-- Description: Create view sold_items combining sales and order lines
+CREATE VIEW sold_items AS
+    SELECT
+        product_id,
+        quantity,
+        paid,
+        date_created
+    FROM
+        sales
+    UNION ALL
+    SELECT
+        oi.product_id,
+        oi.quantity,
+        oi.total AS paid,
+        o.date_created
+    FROM
+        order_items AS oi
+    JOIN
+        orders AS o ON o.order_id = oi.order_id
+    WHERE
+        o.status <> 'CANCELLED';
+
+-- Version: 1.6
+-- Description: Create table reservations
+CREATE TABLE reservations (
+    reservation_id UUID,
+    product_id UUID,
+    user_id UUID,
+    sale_id UUID,
+    quantity INT,
+    status TEXT,
+    date_expires TIMESTAMP,
+    date_created TIMESTAMP,
+    date_updated TIMESTAMP,
+
+    PRIMARY KEY (reservation_id),
+    FOREIGN KEY (product_id) REFERENCES products(product_id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(user_id) ON DELETE CASCADE,
+    FOREIGN KEY (sale_id) REFERENCES sales(sale_id) ON DELETE SET NULL
+);
+CREATE INDEX reservations_status_expires_idx ON reservations (status, date_expires);
+
+-- Version: 1.7
+-- Description: Create table refunds
+CREATE TABLE refunds (
+    refund_id UUID,
+    sale_id UUID,
+    user_id UUID,
+    amount INT,
+    quantity INT,
+    reason TEXT,
+    date_created TIMESTAMP,
+
+    PRIMARY KEY (refund_id),
+    FOREIGN KEY (sale_id) REFERENCES sales(sale_id) ON DELETE CASCADE
+);
+
+-- Version: 1.8
+-- Description: Include refunds in view sold_items
+CREATE OR REPLACE VIEW sold_items AS
+    SELECT
+        product_id,
+        quantity,
+        paid,
+        date_created
+    FROM
+        sales
+    UNION ALL
+    SELECT
+        oi.product_id,
+        oi.quantity,
+        oi.total AS paid,
+        o.date_created
+    FROM
+        order_items AS oi
+    JOIN
+        orders AS o ON o.order_id = oi.order_id
+    WHERE
+        o.status <> 'CANCELLED'
+    UNION ALL
+    SELECT
+        s.product_id,
+        -r.quantity AS quantity,
+        -r.amount AS paid,
+        r.date_created
+    FROM
+        refunds AS r
+    JOIN
+        sales AS s ON s.sale_id = r.sale_id;
+
+-- Version: 1.9
+-- Description: Add currencies to prices and payments
+DROP VIEW sold_items;
+ALTER TABLE products ALTER COLUMN cost TYPE BIGINT, ADD COLUMN currency TEXT NOT NULL DEFAULT 'USD';
+ALTER TABLE sales ALTER COLUMN paid TYPE BIGINT, ADD COLUMN currency TEXT NOT NULL DEFAULT 'USD';
+ALTER TABLE orders ALTER COLUMN total TYPE BIGINT, ADD COLUMN currency TEXT NOT NULL DEFAULT 'USD';
+ALTER TABLE order_items ALTER COLUMN unit_price TYPE BIGINT, ALTER COLUMN total TYPE BIGINT;
+ALTER TABLE refunds ALTER COLUMN amount TYPE BIGINT, ADD COLUMN currency TEXT NOT NULL DEFAULT 'USD';
+CREATE VIEW sold_items AS
+    SELECT
+        product_id,
+        quantity,
+        paid,
+        date_created,
+        currency
+    FROM
+        sales
+    UNION ALL
+    SELECT
+        oi.product_id,
+        oi.quantity,
+        oi.total AS paid,
+        o.date_created,
+        o.currency
+    FROM
+        order_items AS oi
+    JOIN
+        orders AS o ON o.order_id = oi.order_id
+    WHERE
+        o.status <> 'CANCELLED'
+    UNION ALL
+    SELECT
+        s.product_id,
+        -r.quantity AS quantity,
+        -r.amount AS paid,
+        r.date_created,
+        r.currency
+    FROM
+        refunds AS r
+    JOIN
+        sales AS s ON s.sale_id = r.sale_id;
+
+-- Version: 2.0
+-- Description: Create table exchange_rates
+CREATE TABLE exchange_rates (
+    from_currency TEXT,
+    to_currency TEXT,
+    rate NUMERIC(18, 8),
+    date_updated TIMESTAMP,
+
+    PRIMARY KEY (from_currency, to_currency)
+);
+INSERT INTO exchange_rates (from_currency, to_currency, rate, date_updated) VALUES
+    ('USD', 'USD', 1, now()),
+    ('EUR', 'EUR', 1, now()),
+    ('GBP', 'GBP', 1, now());
+
+-- Version: 2.1
+-- Description: Add product descriptions and full-text search
+ALTER TABLE products ADD COLUMN description TEXT NOT NULL DEFAULT '';
+CREATE INDEX products_search_idx ON products USING GIN (to_tsvector('english', name || ' ' || description));
+
+-- Version: 2.2
+-- Description: Create table categories
+CREATE TABLE categories (
+    category_id UUID,
+    parent_id UUID,
+    name TEXT,
+    date_created TIMESTAMP,
+    date_updated TIMESTAMP,
+
+    PRIMARY KEY (category_id),
+    FOREIGN KEY (parent_id) REFERENCES categories(category_id)
+);
+CREATE INDEX categories_parent_idx ON categories (parent_id);
+ALTER TABLE products ADD COLUMN category_id UUID REFERENCES categories(category_id) ON DELETE SET NULL;
+CREATE INDEX products_category_idx ON products (category_id);
+
+-- Version: 2.3
+-- Description: Create tables tags and product_tags
+CREATE TABLE tags (
+    tag_id UUID,
+    name TEXT UNIQUE,
+    date_created TIMESTAMP,
+
+    PRIMARY KEY (tag_id)
+);
+CREATE TABLE product_tags (
+    product_id UUID,
+    tag_id UUID,
+
+    PRIMARY KEY (product_id, tag_id),
+    FOREIGN KEY (product_id) REFERENCES products(product_id) ON DELETE CASCADE,
+    FOREIGN KEY (tag_id) REFERENCES tags(tag_id) ON DELETE CASCADE
+);
+CREATE INDEX product_tags_tag_idx ON product_tags (tag_id);
+
+-- Version: 2.4
+-- Description: Create table product_variants
+CREATE TABLE product_variants (
+    variant_id UUID,
+    product_id UUID,
+    sku TEXT UNIQUE,
+    name TEXT,
+    cost BIGINT,
+    quantity INT,
+    date_created TIMESTAMP,
+    date_updated TIMESTAMP,
+
+    PRIMARY KEY (variant_id),
+    FOREIGN KEY (product_id) REFERENCES products(product_id) ON DELETE CASCADE
+);
+CREATE INDEX product_variants_product_idx ON product_variants (product_id);
+
+-- Version: 2.5
+-- Description: Record the variant sold, ordered and reserved
+ALTER TABLE sales ADD COLUMN variant_id UUID REFERENCES product_variants(variant_id) ON DELETE SET NULL;
+ALTER TABLE order_items ADD COLUMN variant_id UUID REFERENCES product_variants(variant_id) ON DELETE SET NULL;
+ALTER TABLE reservations ADD COLUMN variant_id UUID REFERENCES product_variants(variant_id) ON DELETE SET NULL;
+CREATE OR REPLACE VIEW sold_items AS
+    SELECT
+        product_id,
+        quantity,
+        paid,
+        date_created,
+        currency,
+        variant_id
+    FROM
+        sales
+    UNION ALL
+    SELECT
+        oi.product_id,
+        oi.quantity,
+        oi.total AS paid,
+        o.date_created,
+        o.currency,
+        oi.variant_id
+    FROM
+        order_items AS oi
+    JOIN
+        orders AS o ON o.order_id = oi.order_id
+    WHERE
+        o.status <> 'CANCELLED'
+    UNION ALL
+    SELECT
+        s.product_id,
+        -r.quantity AS quantity,
+        -r.amount AS paid,
+        r.date_created,
+        r.currency,
+        s.variant_id
+    FROM
+        refunds AS r
+    JOIN
+        sales AS s ON s.sale_id = r.sale_id;
+
+-- Version: 2.6
+-- Description: Record when users are deleted
+ALTER TABLE users ADD COLUMN date_deleted TIMESTAMP;
+CREATE INDEX users_date_deleted_idx ON users (date_deleted) WHERE date_deleted IS NOT NULL;
+
+-- Version: 2.7
+-- Description: Create table user_tokens
+CREATE TABLE user_tokens (
+    token_id UUID,
+    user_id UUID,
+    purpose TEXT,
+    token_hash TEXT UNIQUE,
+    date_expires TIMESTAMP,
+    date_used TIMESTAMP,
+    date_created TIMESTAMP,
+
+    PRIMARY KEY (token_id),
+    FOREIGN KEY (user_id) REFERENCES users(user_id) ON DELETE CASCADE
+);
+CREATE INDEX user_tokens_user_idx ON user_tokens (user_id, purpose, date_created);
+
+-- Version: 2.8
+-- Description: Record when users verify their email
+ALTER TABLE users ADD COLUMN date_verified TIMESTAMP;
+UPDATE users SET date_verified = date_created;
+
+-- Version: 2.9
+-- Description: Track failed logins and lock users out
+ALTER TABLE users ADD COLUMN failed_logins INT NOT NULL DEFAULT 0, ADD COLUMN date_locked_until TIMESTAMP;
+
+-- Version: 3.0
+-- Description: Add versions to users
+ALTER TABLE users ADD COLUMN version INT NOT NULL DEFAULT 1;
+
+-- Version: 3.1
+-- Description: Create tables for roles and permissions
+CREATE TABLE permissions (
+    name TEXT,
+    description TEXT,
+
+    PRIMARY KEY (name)
+);
+CREATE TABLE roles (
+    name TEXT,
+    description TEXT,
+    date_created TIMESTAMP,
+    date_updated TIMESTAMP,
+
+    PRIMARY KEY (name)
+);
+CREATE TABLE role_permissions (
+    role_name TEXT,
+    permission TEXT,
+
+    PRIMARY KEY (role_name, permission),
+    FOREIGN KEY (role_name) REFERENCES roles(name) ON DELETE CASCADE,
+    FOREIGN KEY (permission) REFERENCES permissions(name) ON DELETE CASCADE
+);
+INSERT INTO permissions (name, description) VALUES
+    ('users:read', 'List and view any user'),
+    ('users:write', 'Create, change, delete, restore and unlock any user'),
+    ('roles:manage', 'Define roles and the permissions they grant'),
+    ('products:write', 'Change, delete and tag any product and use any reservation'),
+    ('categories:write', 'Create, change and delete categories'),
+    ('tags:delete', 'Delete tags from every product'),
+    ('sales:read', 'List and view any sale and its refunds'),
+    ('sales:refund', 'Refund sales'),
+    ('orders:read', 'List and view any order'),
+    ('orders:write', 'Cancel any order'),
+    ('reports:read', 'View sales reports and exchange rates'),
+    ('rates:write', 'Set exchange rates');
+INSERT INTO roles (name, description, date_created, date_updated) VALUES
+    ('ADMIN', 'Administrators with every permission', NOW(), NOW()),
+    ('USER', 'Customers who manage their own data', NOW(), NOW());
+INSERT INTO roles (name, description, date_created, date_updated)
+    SELECT DISTINCT UNNEST(roles), '', NOW(), NOW() FROM users
+    ON CONFLICT DO NOTHING;
+INSERT INTO role_permissions (role_name, permission)
+    SELECT 'ADMIN', name FROM permissions;
+
+-- Version: 3.2
+-- Description: Create organizations and scope products, sales, orders, categories and tags to them
+CREATE TABLE organizations (
+    org_id UUID,
+    name TEXT,
+    date_created TIMESTAMP,
+    date_updated TIMESTAMP,
+
+    PRIMARY KEY (org_id)
+);
+CREATE TABLE user_orgs (
+    user_id UUID,
+    org_id UUID,
+    date_created TIMESTAMP,
+
+    PRIMARY KEY (user_id, org_id),
+    FOREIGN KEY (user_id) REFERENCES users(user_id) ON DELETE CASCADE,
+    FOREIGN KEY (org_id) REFERENCES organizations(org_id) ON DELETE CASCADE
+);
+CREATE INDEX user_orgs_org_idx ON user_orgs (org_id);
+INSERT INTO organizations (org_id, name, date_created, date_updated) VALUES
+    ('7c5e6f32-0a1d-4b8e-9f2c-3d4a5b6c7d8e', 'Default', NOW(), NOW());
+INSERT INTO user_orgs (user_id, org_id, date_created)
+    SELECT user_id, '7c5e6f32-0a1d-4b8e-9f2c-3d4a5b6c7d8e', NOW() FROM users;
+ALTER TABLE products ADD COLUMN org_id UUID REFERENCES organizations(org_id);
+UPDATE products SET org_id = '7c5e6f32-0a1d-4b8e-9f2c-3d4a5b6c7d8e';
+ALTER TABLE products ALTER COLUMN org_id SET NOT NULL;
+CREATE INDEX products_org_idx ON products (org_id);
+ALTER TABLE product_variants ADD COLUMN org_id UUID REFERENCES organizations(org_id);
+UPDATE product_variants AS v SET org_id = p.org_id FROM products AS p WHERE p.product_id = v.product_id;
+ALTER TABLE product_variants ALTER COLUMN org_id SET NOT NULL;
+ALTER TABLE product_variants DROP CONSTRAINT product_variants_sku_key;
+ALTER TABLE product_variants ADD CONSTRAINT product_variants_org_sku_key UNIQUE (org_id, sku);
+ALTER TABLE reservations ADD COLUMN org_id UUID REFERENCES organizations(org_id);
+UPDATE reservations AS r SET org_id = p.org_id FROM products AS p WHERE p.product_id = r.product_id;
+ALTER TABLE reservations ALTER COLUMN org_id SET NOT NULL;
+ALTER TABLE sales ADD COLUMN org_id UUID REFERENCES organizations(org_id);
+UPDATE sales SET org_id = '7c5e6f32-0a1d-4b8e-9f2c-3d4a5b6c7d8e';
+ALTER TABLE sales ALTER COLUMN org_id SET NOT NULL;
+CREATE INDEX sales_org_idx ON sales (org_id);
+ALTER TABLE orders ADD COLUMN org_id UUID REFERENCES organizations(org_id);
+UPDATE orders SET org_id = '7c5e6f32-0a1d-4b8e-9f2c-3d4a5b6c7d8e';
+ALTER TABLE orders ALTER COLUMN org_id SET NOT NULL;
+CREATE INDEX orders_org_idx ON orders (org_id);
+ALTER TABLE categories ADD COLUMN org_id UUID REFERENCES organizations(org_id);
+UPDATE categories SET org_id = '7c5e6f32-0a1d-4b8e-9f2c-3d4a5b6c7d8e';
+ALTER TABLE categories ALTER COLUMN org_id SET NOT NULL;
+CREATE INDEX categories_org_idx ON categories (org_id);
+ALTER TABLE tags ADD COLUMN org_id UUID REFERENCES organizations(org_id);
+UPDATE tags SET org_id = '7c5e6f32-0a1d-4b8e-9f2c-3d4a5b6c7d8e';
+ALTER TABLE tags ALTER COLUMN org_id SET NOT NULL;
+ALTER TABLE tags DROP CONSTRAINT tags_name_key;
+ALTER TABLE tags ADD CONSTRAINT tags_org_name_key UNIQUE (org_id, name);
+INSERT INTO permissions (name, description) VALUES
+    ('orgs:manage', 'Create organizations and manage their members');
+INSERT INTO role_permissions (role_name, permission) VALUES
+    ('ADMIN', 'orgs:manage');
+
+-- Version: 3.3
+-- Description: Create table api_keys
+CREATE TABLE api_keys (
+    key_id UUID,
+    org_id UUID NOT NULL,
+    user_id UUID NOT NULL,
+    name TEXT NOT NULL,
+    prefix TEXT NOT NULL,
+    key_hash TEXT UNIQUE NOT NULL,
+    roles TEXT[] NOT NULL,
+    date_expires TIMESTAMP,
+    date_revoked TIMESTAMP,
+    date_last_used TIMESTAMP,
+    date_created TIMESTAMP,
+
+    PRIMARY KEY (key_id),
+    FOREIGN KEY (org_id) REFERENCES organizations(org_id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(user_id) ON DELETE CASCADE
+);
+CREATE INDEX api_keys_org_user_idx ON api_keys (org_id, user_id);
+INSERT INTO permissions (name, description) VALUES
+    ('apikeys:manage', 'List and revoke the API keys of any user');
+INSERT INTO role_permissions (role_name, permission) VALUES
+    ('ADMIN', 'apikeys:manage');
+
+-- Version: 3.4
+-- Description: Create tables for sessions, refresh tokens and revoked tokens
+CREATE TABLE sessions (
+    session_id UUID,
+    user_id UUID NOT NULL,
+    org_id UUID NOT NULL,
+    date_expires TIMESTAMP NOT NULL,
+    date_revoked TIMESTAMP,
+    date_created TIMESTAMP,
+
+    PRIMARY KEY (session_id),
+    FOREIGN KEY (user_id) REFERENCES users(user_id) ON DELETE CASCADE,
+    FOREIGN KEY (org_id) REFERENCES organizations(org_id) ON DELETE CASCADE
+);
+CREATE INDEX sessions_user_idx ON sessions (user_id);
+CREATE TABLE refresh_tokens (
+    token_id UUID,
+    session_id UUID NOT NULL,
+    token_hash TEXT UNIQUE NOT NULL,
+    date_expires TIMESTAMP NOT NULL,
+    date_used TIMESTAMP,
+    date_created TIMESTAMP,
+
+    PRIMARY KEY (token_id),
+    FOREIGN KEY (session_id) REFERENCES sessions(session_id) ON DELETE CASCADE
+);
+CREATE TABLE revoked_tokens (
+    token_id UUID,
+    date_expires TIMESTAMP,
+    date_created TIMESTAMP,
+
+    PRIMARY KEY (token_id)
+);
+
+-- Version: 3.5
+-- Description: Move the roles of users onto their memberships of organizations
+ALTER TABLE user_orgs ADD COLUMN roles TEXT[] NOT NULL DEFAULT '{}';
+UPDATE user_orgs AS uo SET roles = u.roles FROM users AS u WHERE u.user_id = uo.user_id;
+ALTER TABLE users DROP COLUMN roles;
+
@@ -1,15 +1,28 @@
-INSERT INTO users (user_id, name, email, roles, password_hash, date_created, date_updated) VALUES
-	('5cf37266-3473-4006-984f-9325122678b7', 'Admin', 'admin@example.com', '{ADMIN,USER}', '$2a$10$1ggfMVZV6Js0ybvJufLRUOWHS5f6KneuP0XwwHpJ8L8ipdry9f2/a', '2019-03-24 00:00:00', '2019-03-24 00:00:00'),
-	('45b5fbd3-755f-4379-8f07-a58d4a30fa2f', 'User', 'user@example.com', '{USER}', '$2a$10$9/XASPKBbJKVfCAZKDH.UuhsuALDr5vVm6VrYA9VFR8rccK86C1hW', '2019-03-24 00:00:00', '2019-03-24 00:00:00')
+INSERT INTO users (user_id, name, email, password_hash, date_created, date_updated, date_verified) VALUES
+	('5cf37266-3473-4006-984f-9325122678b7', 'Admin', 'admin@example.com', '$2a$10$1ggfMVZV6Js0ybvJufLRUOWHS5f6KneuP0XwwHpJ8L8ipdry9f2/a', '2019-03-24 00:00:00', '2019-03-24 00:00:00', '2019-03-24 00:00:00'),
+	('45b5fbd3-755f-4379-8f07-a58d4a30fa2f', 'User', 'user@example.com', '$2a$10$9/XASPKBbJKVfCAZKDH.UuhsuALDr5vVm6VrYA9VFR8rccK86C1hW', '2019-03-24 00:00:00', '2019-03-24 00:00:00', '2019-03-24 00:00:00')
 	ON CONFLICT DO NOTHING;
 
-INSERT INTO products (product_id, user_id, name, cost, quantity, date_created, date_updated) VALUES
-	('a2b0639f-2cc6-44b8-b97b-15d69dbb511e', '45b5fbd3-755f-4379-8f07-a58d4a30fa2f', 'Comic Books', 50, 42, '2019-01-01 00:00:01.000001+00', '2019-01-01 00:00:01.000001+00'),
-	('72f8b983-3eb4-48db-9ed0-e45cc6bd716b', '45b5fbd3-755f-4379-8f07-a58d4a30fa2f', 'McDonalds Toys', 75, 120, '2019-01-01 00:00:02.000001+00', '2019-01-01 00:00:02.000001+00')
+INSERT INTO user_orgs (user_id, org_id, roles, date_created) VALUES
+	('5cf37266-3473-4006-984f-9325122678b7', '7c5e6f32-0a1d-4b8e-9f2c-3d4a5b6c7d8e', '{ADMIN,USER}', '2019-03-24 00:00:00'),
+	('45b5fbd3-755f-4379-8f07-a58d4a30fa2f', '7c5e6f32-0a1d-4b8e-9f2c-3d4a5b6c7d8e', '{USER}', '2019-03-24 00:00:00')
 	ON CONFLICT DO NOTHING;
 
-INSERT INTO sales (sale_id, product_id, quantity, paid, date_created) VALUES
-	('98b6d4b8-f04b-4c79-8c2e-a0aef46854b7', 'a2b0639f-2cc6-44b8-b97b-15d69dbb511e', 2, 100, '2019-01-01 00:00:03.000001+00'),
-	('85f6fb09-eb05-4874-ae39-82d1a30fe0d7', 'a2b0639f-2cc6-44b8-b97b-15d69dbb511e', 5, 250, '2019-01-01 00:00:04.000001+00'),
-	('a235be9e-ab5d-44e6-a987-fa1c749264c7', '72f8b983-3eb4-48db-9ed0-e45cc6bd716b', 3, 225, '2019-01-01 00:00:05.000001+00')
-	ON CONFLICT DO NOTHING;
\ No newline at end of file
+INSERT INTO products (product_id, org_id, user_id, name, cost, quantity, date_created, date_updated) VALUES
+	('a2b0639f-2cc6-44b8-b97b-15d69dbb511e', '7c5e6f32-0a1d-4b8e-9f2c-3d4a5b6c7d8e', '45b5fbd3-755f-4379-8f07-a58d4a30fa2f', 'Comic Books', 50, 42, '2019-01-01 00:00:01.000001+00', '2019-01-01 00:00:01.000001+00'),
+	('72f8b983-3eb4-48db-9ed0-e45cc6bd716b', '7c5e6f32-0a1d-4b8e-9f2c-3d4a5b6c7d8e', '45b5fbd3-755f-4379-8f07-a58d4a30fa2f', 'McDonalds Toys', 75, 120, '2019-01-01 00:00:02.000001+00', '2019-01-01 00:00:02.000001+00')
+	ON CONFLICT DO NOTHING;
+
+INSERT INTO sales (sale_id, org_id, user_id, product_id, quantity, paid, date_created) VALUES
+	('98b6d4b8-f04b-4c79-8c2e-a0aef46854b7', '7c5e6f32-0a1d-4b8e-9f2c-3d4a5b6c7d8e', '5cf37266-3473-4006-984f-9325122678b7', 'a2b0639f-2cc6-44b8-b97b-15d69dbb511e', 2, 100, '2019-01-01 00:00:03.000001+00'),
+	('85f6fb09-eb05-4874-ae39-82d1a30fe0d7', '7c5e6f32-0a1d-4b8e-9f2c-3d4a5b6c7d8e', '5cf37266-3473-4006-984f-9325122678b7', 'a2b0639f-2cc6-44b8-b97b-15d69dbb511e', 5, 250, '2019-01-01 00:00:04.000001+00'),
+	('a235be9e-ab5d-44e6-a987-fa1c749264c7', '7c5e6f32-0a1d-4b8e-9f2c-3d4a5b6c7d8e', '5cf37266-3473-4006-984f-9325122678b7', '72f8b983-3eb4-48db-9ed0-e45cc6bd716b', 3, 225, '2019-01-01 00:00:05.000001+00')
+	ON CONFLICT DO NOTHING;
+INSERT INTO exchange_rates (from_currency, to_currency, rate, date_updated) VALUES
+	('EUR', 'USD', 1.08, '2019-01-01 00:00:00'),
+	('GBP', 'USD', 1.27, '2019-01-01 00:00:00'),
+	('USD', 'EUR', 0.93, '2019-01-01 00:00:00'),
+	('GBP', 'EUR', 1.17, '2019-01-01 00:00:00'),
+	('USD', 'GBP', 0.79, '2019-01-01 00:00:00'),
+	('EUR', 'GBP', 0.85, '2019-01-01 00:00:00')
+	ON CONFLICT DO NOTHING;
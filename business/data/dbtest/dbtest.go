@@ -10,16 +10,21 @@ import (
 	"testing"
 	"time"
 
+	dbOrg "github.com/andrewyang17/service/business/core/organization/db"
+	dbRole "github.com/andrewyang17/service/business/core/role/db"
+	"github.com/andrewyang17/service/business/core/session"
 	dbUser "github.com/andrewyang17/service/business/core/user/db"
 	"github.com/andrewyang17/service/business/data/dbschema"
 	"github.com/andrewyang17/service/business/sys/auth"
 	"github.com/andrewyang17/service/business/sys/database"
+	"github.com/andrewyang17/service/business/sys/password"
 	"github.com/andrewyang17/service/foundation/docker"
 	"github.com/andrewyang17/service/foundation/keystore"
 	"github.com/golang-jwt/jwt/v4"
 	"github.com/jmoiron/sqlx"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
+	"golang.org/x/crypto/bcrypt"
 )
 
 // Success and failure markers.
@@ -130,6 +135,7 @@ type Test struct {
 	DB       *sqlx.DB
 	Log      *zap.SugaredLogger
 	Auth     *auth.Auth
+	Hasher   password.Hasher
 	Teardown func()
 
 	t *testing.T
@@ -146,8 +152,9 @@ func NewIntegration(t *testing.T, c *docker.Container, dbName string) *Test {
 		t.Fatal(err)
 	}
 
-	// Build an authenticator using this private key and id for the key store.
-	auth, err := auth.New(keyID, keystore.NewMap(map[string]*rsa.PrivateKey{keyID: privateKey}))
+	// Build an authenticator using this private key and id for the key store
+	// that checks tokens against the sessions in the test database.
+	auth, err := auth.New(keyID, keystore.NewMap(map[string]*rsa.PrivateKey{keyID: privateKey}), session.NewCore(log, db))
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -156,6 +163,7 @@ func NewIntegration(t *testing.T, c *docker.Container, dbName string) *Test {
 		DB:       db,
 		Log:      log,
 		Auth:     auth,
+		Hasher:   NewHasher(t),
 		Teardown: teardown,
 		t:        t,
 	}
@@ -163,7 +171,8 @@ func NewIntegration(t *testing.T, c *docker.Container, dbName string) *Test {
 	return &test
 }
 
-// Token generates an authenticated token for a user.
+// Token generates an authenticated token for a user acting for the first
+// organization they joined.
 func (test *Test) Token(email, pass string) string {
 	test.t.Log("Generating token for test...")
 
@@ -173,6 +182,27 @@ func (test *Test) Token(email, pass string) string {
 		return ""
 	}
 
+	orgs, err := dbOrg.NewStore(test.Log, test.DB).QueryByUserID(context.Background(), dbUsr.ID)
+	if err != nil {
+		test.t.Fatal(err)
+	}
+
+	var orgID string
+	if len(orgs) > 0 {
+		orgID = orgs[0].ID
+
+		// The roles come with the membership of the organization.
+		dbUsr, err = store.QueryMemberByID(context.Background(), orgID, dbUsr.ID)
+		if err != nil {
+			test.t.Fatal(err)
+		}
+	}
+
+	perms, err := dbRole.NewStore(test.Log, test.DB).QueryPermissions(context.Background(), dbUsr.Roles)
+	if err != nil {
+		test.t.Fatal(err)
+	}
+
 	claims := auth.Claims{
 		RegisteredClaims: jwt.RegisteredClaims{
 			Issuer:    "service project",
@@ -180,7 +210,10 @@ func (test *Test) Token(email, pass string) string {
 			ExpiresAt: jwt.NewNumericDate(time.Now().UTC().Add(time.Hour)),
 			IssuedAt:  jwt.NewNumericDate(time.Now().UTC()),
 		},
-		Roles: dbUsr.Roles,
+		Roles:       dbUsr.Roles,
+		Permissions: perms,
+		OrgID:       orgID,
+		Verified:    dbUsr.DateVerified != nil,
 	}
 
 	token, err := test.Auth.GenerateToken(claims)
@@ -191,6 +224,16 @@ func (test *Test) Token(email, pass string) string {
 	return token
 }
 
+// NewHasher constructs a password hasher that is cheap enough to use in tests.
+func NewHasher(t *testing.T) password.Hasher {
+	hasher, err := password.NewBcrypt(bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("constructing hasher: %s", err)
+	}
+
+	return hasher
+}
+
 // StringPointer is a helper to get a *string from a string. It is in the tests
 // package because we normally don't want to deal with pointers to basic types,
 // but it's useful in some tests.
@@ -0,0 +1,67 @@
+// Package order provides support for describing how the results of a query
+// should be ordered.
+package order
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/andrewyang17/service/business/sys/validate"
+)
+
+// Set of directions results can be ordered in.
+const (
+	ASC  = "ASC"
+	DESC = "DESC"
+)
+
+var directions = map[string]string{
+	ASC:  "ASC",
+	DESC: "DESC",
+}
+
+// By represents a field used to order by and the direction of the ordering.
+type By struct {
+	Field     string
+	Direction string
+}
+
+// NewBy constructs a new By value with no checks.
+func NewBy(field string, direction string) By {
+	return By{
+		Field:     field,
+		Direction: direction,
+	}
+}
+
+// Parse constructs a By value from a string in the form "field" or
+// "field,direction". The default order is returned when the string is empty.
+// Only the syntax and the direction are checked here, it's up to the caller
+// to check the field against the fields it allows ordering by.
+func Parse(value string, defaultOrder By) (By, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return defaultOrder, nil
+	}
+
+	parts := strings.Split(value, ",")
+	if len(parts) > 2 {
+		return By{}, validate.FieldErrors{
+			{Field: "order_by", Error: fmt.Sprintf("unknown order %q", value)},
+		}
+	}
+
+	by := NewBy(strings.TrimSpace(parts[0]), ASC)
+
+	if len(parts) == 2 {
+		dir, exists := directions[strings.ToUpper(strings.TrimSpace(parts[1]))]
+		if !exists {
+			return By{}, validate.FieldErrors{
+				{Field: "order_by", Error: fmt.Sprintf("unknown direction %q", parts[1])},
+			}
+		}
+		by.Direction = dir
+	}
+
+	return by, nil
+}
@@ -0,0 +1,53 @@
+package order_test
+
+import (
+	"testing"
+
+	"github.com/andrewyang17/service/business/data/order"
+	"github.com/andrewyang17/service/business/sys/validate"
+)
+
+// Success and failure markers.
+const (
+	success = "\u2713"
+	failed  = "\u2717"
+)
+
+func TestParse(t *testing.T) {
+	defaultOrder := order.NewBy("id", order.ASC)
+
+	t.Log("Given the need to parse the order of a query.")
+	{
+		testID := 0
+		t.Logf("\tTest %d:\tWhen handling order by values.", testID)
+		{
+			by, err := order.Parse("", defaultOrder)
+			if err != nil || by != defaultOrder {
+				t.Fatalf("\t%s\tTest %d:\tShould get the default order for an empty value : %v %v.", failed, testID, by, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould get the default order for an empty value.", success, testID)
+
+			by, err = order.Parse("name", defaultOrder)
+			if err != nil || by != order.NewBy("name", order.ASC) {
+				t.Fatalf("\t%s\tTest %d:\tShould default to ascending : %v %v.", failed, testID, by, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould default to ascending.", success, testID)
+
+			by, err = order.Parse("email, desc", defaultOrder)
+			if err != nil || by != order.NewBy("email", order.DESC) {
+				t.Fatalf("\t%s\tTest %d:\tShould parse the direction : %v %v.", failed, testID, by, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould parse the direction.", success, testID)
+
+			if _, err := order.Parse("email,sideways", defaultOrder); !validate.IsFieldErrors(err) {
+				t.Fatalf("\t%s\tTest %d:\tShould reject an unknown direction : %v.", failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould reject an unknown direction.", success, testID)
+
+			if _, err := order.Parse("email,desc;DROP TABLE users,asc", defaultOrder); !validate.IsFieldErrors(err) {
+				t.Fatalf("\t%s\tTest %d:\tShould reject a malformed value : %v.", failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould reject a malformed value.", success, testID)
+		}
+	}
+}
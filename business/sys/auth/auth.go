@@ -2,6 +2,7 @@
 package auth
 
 import (
+	"context"
 	"crypto/rsa"
 	"errors"
 	"fmt"
@@ -10,7 +11,10 @@ import (
 )
 
 var (
-	ErrForbidden = errors.New("attempted action is not allowed")
+	ErrForbidden    = errors.New("attempted action is not allowed")
+	ErrInvalidToken = errors.New("token is invalid")
+	ErrRevoked      = errors.New("token has been revoked")
+	ErrInvalidKey   = errors.New("api key is invalid, expired or revoked")
 )
 
 type KeyLookup interface {
@@ -18,6 +22,12 @@ type KeyLookup interface {
 	PublicKey(kid string) (*rsa.PublicKey, error)
 }
 
+// RevocationStore reports whether the token the claims were parsed from has
+// been revoked, so tokens can be killed before they expire.
+type RevocationStore interface {
+	IsRevoked(ctx context.Context, claims Claims) (bool, error)
+}
+
 // Auth is used to authenticate clients. It can generate a token for a
 // set of user claims and recreate the claims by parsing the token.
 type Auth struct {
@@ -26,9 +36,13 @@ type Auth struct {
 	method    jwt.SigningMethod
 	keyFunc   func(t *jwt.Token) (interface{}, error)
 	parser    jwt.Parser
+	revoked   RevocationStore
 }
 
-func New(activeKID string, keyLookup KeyLookup) (*Auth, error) {
+// New constructs an Auth that signs tokens with the active key. When a
+// revocation store is provided every token is checked against it during
+// validation.
+func New(activeKID string, keyLookup KeyLookup, revoked RevocationStore) (*Auth, error) {
 	_, err := keyLookup.PrivateKey(activeKID)
 	if err != nil {
 		return nil, errors.New("active KID does not exist in store")
@@ -62,6 +76,7 @@ func New(activeKID string, keyLookup KeyLookup) (*Auth, error) {
 		method:    method,
 		keyFunc:   keyFunc,
 		parser:    parser,
+		revoked:   revoked,
 	}
 
 	return &a, nil
@@ -84,16 +99,30 @@ func (a *Auth) GenerateToken(claims Claims) (string, error) {
 	return tokenStr, nil
 }
 
-func (a *Auth) ValidateToken(tokenStr string) (Claims, error) {
+// ValidateToken parses the token and returns its claims when the token is
+// valid and has not been revoked. An error wrapping ErrInvalidToken or
+// ErrRevoked is returned for tokens that can't be used, any other error means
+// the revocation store could not be checked.
+func (a *Auth) ValidateToken(ctx context.Context, tokenStr string) (Claims, error) {
 	var claims Claims
 
 	token, err := a.parser.ParseWithClaims(tokenStr, &claims, a.keyFunc)
 	if err != nil {
-		return Claims{}, fmt.Errorf("parsing token: %w", err)
+		return Claims{}, fmt.Errorf("%w: parsing token: %v", ErrInvalidToken, err)
 	}
 
 	if !token.Valid {
-		return Claims{}, errors.New("invalid token")
+		return Claims{}, ErrInvalidToken
+	}
+
+	if a.revoked != nil {
+		revoked, err := a.revoked.IsRevoked(ctx, claims)
+		if err != nil {
+			return Claims{}, fmt.Errorf("checking revocation: %w", err)
+		}
+		if revoked {
+			return Claims{}, ErrRevoked
+		}
 	}
 
 	return claims, nil
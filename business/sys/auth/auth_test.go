@@ -1,8 +1,10 @@
 package auth_test
 
 import (
+	"context"
 	"crypto/rand"
 	"crypto/rsa"
+	"errors"
 	"testing"
 	"time"
 
@@ -29,7 +31,9 @@ func TestAuth(t *testing.T) {
 			}
 			t.Logf("\t%s\tTest %d:\tShould be able to create a private key.", success, testID)
 
-			a, err := auth.New(keyID, &keyStore{pk: privateKey})
+			revoked := revocationStore{}
+
+			a, err := auth.New(keyID, &keyStore{pk: privateKey}, revoked)
 			if err != nil {
 				t.Fatalf("\t%s\tTest %d:\tShould be able to create an authenticator: %v", failed, testID, err)
 			}
@@ -41,6 +45,7 @@ func TestAuth(t *testing.T) {
 					Subject:   "5cf37266-3473-4006-984f-9325122678b7",
 					ExpiresAt: jwt.NewNumericDate(time.Now().UTC().Add(time.Hour)),
 					NotBefore: jwt.NewNumericDate(time.Now().UTC()),
+					ID:        "b8a7d4d5-3c2f-4f8e-9e5a-6d1c2b3a4f5e",
 				},
 				Roles: []string{auth.RoleAdmin},
 			}
@@ -51,7 +56,7 @@ func TestAuth(t *testing.T) {
 			}
 			t.Logf("\t%s\tTest %d:\tShould be able to generate a JWT.", success, testID)
 
-			parsedClaims, err := a.ValidateToken(token)
+			parsedClaims, err := a.ValidateToken(context.Background(), token)
 			if err != nil {
 				t.Fatalf("\t%s\tTest %d:\tShould be able to parse the claims: %v", failed, testID, err)
 			}
@@ -70,6 +75,29 @@ func TestAuth(t *testing.T) {
 				t.Fatalf("\t%s\tTest %d:\tShould have the expected roles: %v", failed, testID, err)
 			}
 			t.Logf("\t%s\tTest %d:\tShould have the expected roles.", success, testID)
+
+			revoked[claims.ID] = true
+
+			if _, err := a.ValidateToken(context.Background(), token); !errors.Is(err, auth.ErrRevoked) {
+				t.Fatalf("\t%s\tTest %d:\tShould NOT be able to use a revoked token: %v", failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould NOT be able to use a revoked token.", success, testID)
+
+			if _, err := a.ValidateToken(context.Background(), token+"x"); !errors.Is(err, auth.ErrInvalidToken) {
+				t.Fatalf("\t%s\tTest %d:\tShould NOT be able to use a tampered token: %v", failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould NOT be able to use a tampered token.", success, testID)
+
+			broken, err := auth.New(keyID, &keyStore{pk: privateKey}, brokenStore{})
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to create an authenticator: %v", failed, testID, err)
+			}
+
+			_, err = broken.ValidateToken(context.Background(), token)
+			if err == nil || errors.Is(err, auth.ErrInvalidToken) || errors.Is(err, auth.ErrRevoked) {
+				t.Fatalf("\t%s\tTest %d:\tShould tell a failing revocation store from an invalid token: %v", failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould tell a failing revocation store from an invalid token.", success, testID)
 		}
 	}
 }
@@ -87,3 +115,15 @@ func (ks *keyStore) PrivateKey(kid string) (*rsa.PrivateKey, error) {
 func (ks *keyStore) PublicKey(kid string) (*rsa.PublicKey, error) {
 	return &ks.pk.PublicKey, nil
 }
+
+type revocationStore map[string]bool
+
+func (rs revocationStore) IsRevoked(ctx context.Context, claims auth.Claims) (bool, error) {
+	return rs[claims.ID], nil
+}
+
+type brokenStore struct{}
+
+func (brokenStore) IsRevoked(ctx context.Context, claims auth.Claims) (bool, error) {
+	return false, errors.New("database is down")
+}
@@ -7,6 +7,8 @@ import (
 	"github.com/golang-jwt/jwt/v4"
 )
 
+// Set of roles that are always defined. Other roles can be added in the
+// database.
 const (
 	RoleAdmin = "ADMIN"
 	RoleUser  = "USER"
@@ -14,7 +16,18 @@ const (
 
 type Claims struct {
 	jwt.RegisteredClaims
-	Roles []string `json:"roles"`
+	Roles       []string `json:"roles"`
+	Permissions []string `json:"permissions"`
+	OrgID       string   `json:"org_id"`
+	SessionID   string   `json:"sid,omitempty"`
+	APIKeyID    string   `json:"api_key_id,omitempty"`
+	Verified    bool     `json:"verified"`
+}
+
+// FromAPIKey reports whether the claims were derived from an API key rather
+// than a token.
+func (c Claims) FromAPIKey() bool {
+	return c.APIKeyID != ""
 }
 
 func (c Claims) Authorized(roles ...string) bool {
@@ -28,6 +41,17 @@ func (c Claims) Authorized(roles ...string) bool {
 	return false
 }
 
+// HasPermission reports whether the roles of the claims grant the specified
+// permission.
+func (c Claims) HasPermission(permission string) bool {
+	for _, has := range c.Permissions {
+		if has == permission {
+			return true
+		}
+	}
+	return false
+}
+
 type ctxKey int
 
 const key ctxKey = 1
@@ -0,0 +1,22 @@
+package auth
+
+// Set of permissions the API checks for. Roles are granted permissions in the
+// database, so new roles can be defined without code changes. Adding a
+// permission here requires a migration that inserts it into the permissions
+// table and grants it to the ADMIN role.
+const (
+	PermUsersRead       = "users:read"
+	PermUsersWrite      = "users:write"
+	PermRolesManage     = "roles:manage"
+	PermProductsWrite   = "products:write"
+	PermCategoriesWrite = "categories:write"
+	PermTagsDelete      = "tags:delete"
+	PermSalesRead       = "sales:read"
+	PermSalesRefund     = "sales:refund"
+	PermOrdersRead      = "orders:read"
+	PermOrdersWrite     = "orders:write"
+	PermReportsRead     = "reports:read"
+	PermRatesWrite      = "rates:write"
+	PermOrgsManage      = "orgs:manage"
+	PermAPIKeysManage   = "apikeys:manage"
+)
@@ -12,7 +12,7 @@ import (
 
 	"github.com/andrewyang17/service/foundation/web"
 	"github.com/jmoiron/sqlx"
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 	"go.uber.org/zap"
 )
 
@@ -21,6 +21,9 @@ var (
 	ErrDBDuplicatedEntry = errors.New("duplicated entry")
 )
 
+// uniqueViolation is the postgres error code for a duplicated unique key.
+const uniqueViolation = "23505"
+
 type Config struct {
 	User         string
 	Password     string
@@ -129,7 +132,7 @@ func NamedExecContext(ctx context.Context, log *zap.SugaredLogger, db sqlx.ExtCo
 	log.Infow("database.NameExecContext", "traceID", web.GetTraceID(ctx), "query", q)
 
 	if _, err := sqlx.NamedExecContext(ctx, db, query, data); err != nil {
-		return err
+		return mapError(err)
 	}
 
 	return nil
@@ -148,7 +151,7 @@ func NamedQuerySlice(ctx context.Context, log *zap.SugaredLogger, db sqlx.ExtCon
 
 	rows, err := sqlx.NamedQueryContext(ctx, db, query, data)
 	if err != nil {
-		return err
+		return mapError(err)
 	}
 	defer rows.Close()
 
@@ -161,6 +164,10 @@ func NamedQuerySlice(ctx context.Context, log *zap.SugaredLogger, db sqlx.ExtCon
 		slice.Set(reflect.Append(slice, v.Elem()))
 	}
 
+	if err := rows.Err(); err != nil {
+		return mapError(err)
+	}
+
 	return nil
 }
 
@@ -172,11 +179,14 @@ func NamedQueryStruct(ctx context.Context, log *zap.SugaredLogger, db sqlx.ExtCo
 
 	rows, err := sqlx.NamedQueryContext(ctx, db, query, data)
 	if err != nil {
-		return err
+		return mapError(err)
 	}
 	defer rows.Close()
 
 	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return mapError(err)
+		}
 		return ErrDBNotFound
 	}
 
@@ -187,6 +197,16 @@ func NamedQueryStruct(ctx context.Context, log *zap.SugaredLogger, db sqlx.ExtCo
 	return nil
 }
 
+// mapError maps the errors of the database that callers handle to the errors
+// of this package.
+func mapError(err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == uniqueViolation {
+		return ErrDBDuplicatedEntry
+	}
+	return err
+}
+
 // queryString provides a pretty print version of the query and parameters.
 func queryString(query string, args ...interface{}) string {
 	query, params, err := sqlx.Named(query, args)
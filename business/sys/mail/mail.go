@@ -0,0 +1,90 @@
+// Package mail provides support for sending email to users. Messages are
+// sent through a Mailer so the way mail is delivered can be swapped out
+// without touching the code that sends it.
+package mail
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Message is an email to be sent to a single recipient.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer is the behavior required to send email.
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// =============================================================================
+
+// LogMailer writes messages to the log instead of sending them. It's meant
+// for local development.
+type LogMailer struct {
+	log *zap.SugaredLogger
+}
+
+// NewLogMailer constructs a mailer that writes messages to the log.
+func NewLogMailer(log *zap.SugaredLogger) *LogMailer {
+	return &LogMailer{
+		log: log,
+	}
+}
+
+// Send writes the message to the log.
+func (m *LogMailer) Send(ctx context.Context, msg Message) error {
+	m.log.Infow("mail", "to", msg.To, "subject", msg.Subject, "body", msg.Body)
+	return nil
+}
+
+// =============================================================================
+
+// FileMailer writes each message to its own file in a directory instead of
+// sending it. It's meant for development and tests, where the messages can
+// be read back from the directory.
+type FileMailer struct {
+	dir string
+	seq uint64
+}
+
+// NewFileMailer constructs a mailer that writes messages to files in the
+// specified directory, creating it when it doesn't exist.
+func NewFileMailer(dir string) (*FileMailer, error) {
+	if dir == "" {
+		return nil, errors.New("mail directory is required")
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("creating mail directory: %w", err)
+	}
+
+	return &FileMailer{
+		dir: dir,
+	}, nil
+}
+
+// Send writes the message to a new file in the mailer's directory. Files are
+// named so they sort in the order the messages were sent.
+func (m *FileMailer) Send(ctx context.Context, msg Message) error {
+	seq := atomic.AddUint64(&m.seq, 1)
+	name := fmt.Sprintf("%d-%06d.eml", time.Now().UTC().UnixNano(), seq)
+
+	data := fmt.Sprintf("To: %s\r\nSubject: %s\r\n\r\n%s\r\n", msg.To, msg.Subject, msg.Body)
+
+	if err := os.WriteFile(filepath.Join(m.dir, name), []byte(data), 0o600); err != nil {
+		return fmt.Errorf("writing message: %w", err)
+	}
+
+	return nil
+}
@@ -18,6 +18,8 @@ type metrics struct {
 	requests   *expvar.Int
 	errors     *expvar.Int
 	panics     *expvar.Int
+	logins     *expvar.Int
+	lockouts   *expvar.Int
 }
 
 // init constructs the metrics value that will be used to capture metrics.
@@ -29,6 +31,8 @@ func init() {
 		requests:   expvar.NewInt("requests"),
 		errors:     expvar.NewInt("errors"),
 		panics:     expvar.NewInt("panics"),
+		logins:     expvar.NewInt("failed_logins"),
+		lockouts:   expvar.NewInt("lockouts"),
 	}
 }
 
@@ -65,3 +69,15 @@ func AddPanics(ctx context.Context) {
 		v.panics.Add(1)
 	}
 }
+
+func AddFailedLogins(ctx context.Context) {
+	if v, ok := ctx.Value(key).(*metrics); ok {
+		v.logins.Add(1)
+	}
+}
+
+func AddLockouts(ctx context.Context) {
+	if v, ok := ctx.Value(key).(*metrics); ok {
+		v.lockouts.Add(1)
+	}
+}
@@ -0,0 +1,94 @@
+// Package money provides support for amounts of money in a specific currency.
+package money
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Set of currencies the service can price and sell in.
+const (
+	USD = "USD"
+	EUR = "EUR"
+	GBP = "GBP"
+)
+
+// supported lists the supported currencies. Every supported currency has two
+// digits of minor units, which lets amounts be converted between currencies
+// with a single exchange rate.
+var supported = []string{USD, EUR, GBP}
+
+// ErrCurrencyMismatch is returned when combining amounts of different
+// currencies.
+var ErrCurrencyMismatch = errors.New("currency mismatch")
+
+// Money represents an amount of money in minor units (cents, pence) of the
+// specified ISO 4217 currency.
+type Money struct {
+	Amount   int64  `json:"amount" validate:"gte=0"`
+	Currency string `json:"currency" validate:"required,currency"`
+}
+
+// New constructs a Money value for the amount in minor units.
+func New(amount int64, currency string) Money {
+	return Money{
+		Amount:   amount,
+		Currency: currency,
+	}
+}
+
+// Supported returns the list of supported currency codes.
+func Supported() []string {
+	return append([]string(nil), supported...)
+}
+
+// IsSupported reports whether the currency code is one the service can use.
+func IsSupported(currency string) bool {
+	for _, cur := range supported {
+		if cur == currency {
+			return true
+		}
+	}
+	return false
+}
+
+// Add returns the sum of the two amounts, which must share a currency.
+func (m Money) Add(o Money) (Money, error) {
+	if m.Currency != o.Currency {
+		return Money{}, fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, m.Currency, o.Currency)
+	}
+	return New(m.Amount+o.Amount, m.Currency), nil
+}
+
+// Mul returns the amount multiplied by n, such as a unit price by a quantity.
+func (m Money) Mul(n int) Money {
+	return New(m.Amount*int64(n), m.Currency)
+}
+
+// String formats the amount in major units followed by the currency code.
+func (m Money) String() string {
+	sign := ""
+	amount := m.Amount
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+	return fmt.Sprintf("%s%d.%02d %s", sign, amount/100, amount%100, m.Currency)
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface. Currency codes are
+// accepted in any case and stored in upper case.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	type money Money
+
+	var v money
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	v.Currency = strings.ToUpper(strings.TrimSpace(v.Currency))
+
+	*m = Money(v)
+	return nil
+}
@@ -0,0 +1,68 @@
+package money_test
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/andrewyang17/service/business/sys/money"
+)
+
+// Success and failure markers.
+const (
+	success = "\u2713"
+	failed  = "\u2717"
+)
+
+func TestMoney(t *testing.T) {
+	t.Log("Given the need to work with amounts of money.")
+	{
+		testID := 0
+		t.Logf("\tTest %d:\tWhen combining and encoding amounts.", testID)
+		{
+			price := money.New(1250, money.USD)
+
+			total, err := price.Mul(3).Add(money.New(50, money.USD))
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to add amounts in the same currency : %s.", failed, testID, err)
+			}
+			if total.Amount != 3800 || total.Currency != money.USD {
+				t.Fatalf("\t%s\tTest %d:\tShould get back the right total : got %v.", failed, testID, total)
+			}
+			t.Logf("\t%s\tTest %d:\tShould be able to add amounts in the same currency.", success, testID)
+
+			if _, err := price.Add(money.New(1, money.EUR)); !errors.Is(err, money.ErrCurrencyMismatch) {
+				t.Fatalf("\t%s\tTest %d:\tShould NOT be able to add amounts in different currencies : %v.", failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould NOT be able to add amounts in different currencies.", success, testID)
+
+			if s := money.New(-1205, money.GBP).String(); s != "-12.05 GBP" {
+				t.Fatalf("\t%s\tTest %d:\tShould format in major units : got %q.", failed, testID, s)
+			}
+			t.Logf("\t%s\tTest %d:\tShould format in major units.", success, testID)
+
+			var m money.Money
+			if err := json.Unmarshal([]byte(`{"amount":999,"currency":" eur "}`), &m); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to decode an amount : %s.", failed, testID, err)
+			}
+			if m != money.New(999, money.EUR) {
+				t.Fatalf("\t%s\tTest %d:\tShould normalize the currency code : got %+v.", failed, testID, m)
+			}
+			t.Logf("\t%s\tTest %d:\tShould normalize the currency code.", success, testID)
+
+			data, err := json.Marshal(m)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to encode an amount : %s.", failed, testID, err)
+			}
+			if string(data) != `{"amount":999,"currency":"EUR"}` {
+				t.Fatalf("\t%s\tTest %d:\tShould encode amount and currency : got %s.", failed, testID, data)
+			}
+			t.Logf("\t%s\tTest %d:\tShould encode amount and currency.", success, testID)
+
+			if !money.IsSupported(money.GBP) || money.IsSupported("JPY") {
+				t.Fatalf("\t%s\tTest %d:\tShould only support the configured currencies.", failed, testID)
+			}
+			t.Logf("\t%s\tTest %d:\tShould only support the configured currencies.", success, testID)
+		}
+	}
+}
@@ -0,0 +1,232 @@
+// Package password provides support for hashing and comparing passwords.
+// Every hash carries the algorithm and parameters it was made with, so hashes
+// made with older settings can still be compared and then replaced.
+package password
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	ErrMismatch         = errors.New("password does not match hash")
+	ErrUnknownAlgorithm = errors.New("hash uses an unknown algorithm")
+)
+
+// Hasher is the behavior required to hash passwords with one algorithm.
+type Hasher interface {
+	Hash(password string) ([]byte, error)
+	NeedsRehash(hash []byte) bool
+}
+
+// Compare checks a password against a hash made by any of the supported
+// algorithms. It returns ErrMismatch when the password is wrong.
+func Compare(hash []byte, password string) error {
+	switch {
+	case isBcrypt(hash):
+		if err := bcrypt.CompareHashAndPassword(hash, []byte(password)); err != nil {
+			if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+				return ErrMismatch
+			}
+			return fmt.Errorf("comparing bcrypt hash: %w", err)
+		}
+		return nil
+
+	case isArgon2id(hash):
+		params, salt, key, err := decodeArgon2id(hash)
+		if err != nil {
+			return err
+		}
+
+		other := argon2.IDKey([]byte(password), salt, params.Time, params.Memory, params.Threads, uint32(len(key)))
+		if subtle.ConstantTimeCompare(key, other) != 1 {
+			return ErrMismatch
+		}
+		return nil
+	}
+
+	return ErrUnknownAlgorithm
+}
+
+// =============================================================================
+
+// Bcrypt hashes passwords with bcrypt at a fixed cost.
+type Bcrypt struct {
+	cost int
+}
+
+// NewBcrypt constructs a hasher that uses bcrypt with the specified cost.
+func NewBcrypt(cost int) (Bcrypt, error) {
+	if cost < bcrypt.MinCost || cost > bcrypt.MaxCost {
+		return Bcrypt{}, fmt.Errorf("bcrypt cost %d is outside allowed range (%d,%d)", cost, bcrypt.MinCost, bcrypt.MaxCost)
+	}
+
+	return Bcrypt{
+		cost: cost,
+	}, nil
+}
+
+// Hash returns the bcrypt hash of the password.
+func (b Bcrypt) Hash(password string) ([]byte, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), b.cost)
+	if err != nil {
+		return nil, fmt.Errorf("generating bcrypt hash: %w", err)
+	}
+
+	return hash, nil
+}
+
+// NeedsRehash reports whether the hash was made with another algorithm or
+// another cost.
+func (b Bcrypt) NeedsRehash(hash []byte) bool {
+	if !isBcrypt(hash) {
+		return true
+	}
+
+	cost, err := bcrypt.Cost(hash)
+	if err != nil {
+		return true
+	}
+
+	return cost != b.cost
+}
+
+// =============================================================================
+
+// Argon2Params holds the parameters used to hash passwords with argon2id.
+// Memory is in KiB.
+type Argon2Params struct {
+	Time    uint32
+	Memory  uint32
+	Threads uint8
+	KeyLen  uint32
+	SaltLen uint32
+}
+
+// DefaultArgon2Params are the parameters recommended for argon2id when
+// hashing passwords.
+var DefaultArgon2Params = Argon2Params{
+	Time:    1,
+	Memory:  64 * 1024,
+	Threads: 4,
+	KeyLen:  32,
+	SaltLen: 16,
+}
+
+// Argon2id hashes passwords with argon2id. Hashes are kept in the PHC string
+// format, for example $argon2id$v=19$m=65536,t=1,p=4$<salt>$<key>.
+type Argon2id struct {
+	params Argon2Params
+}
+
+// NewArgon2id constructs a hasher that uses argon2id with the specified
+// parameters.
+func NewArgon2id(params Argon2Params) (Argon2id, error) {
+	if params.Time < 1 || params.Memory < 8*uint32(params.Threads) || params.Threads < 1 || params.KeyLen < 16 || params.SaltLen < 8 {
+		return Argon2id{}, fmt.Errorf("argon2id parameters %+v are too weak", params)
+	}
+
+	return Argon2id{
+		params: params,
+	}, nil
+}
+
+// Hash returns the argon2id hash of the password with a random salt.
+func (a Argon2id) Hash(password string) ([]byte, error) {
+	salt := make([]byte, a.params.SaltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, fmt.Errorf("generating salt: %w", err)
+	}
+
+	key := argon2.IDKey([]byte(password), salt, a.params.Time, a.params.Memory, a.params.Threads, a.params.KeyLen)
+
+	hash := fmt.Sprintf("%sv=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2idPrefix,
+		argon2.Version,
+		a.params.Memory,
+		a.params.Time,
+		a.params.Threads,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	)
+
+	return []byte(hash), nil
+}
+
+// NeedsRehash reports whether the hash was made with another algorithm or
+// other parameters.
+func (a Argon2id) NeedsRehash(hash []byte) bool {
+	if !isArgon2id(hash) {
+		return true
+	}
+
+	params, salt, key, err := decodeArgon2id(hash)
+	if err != nil {
+		return true
+	}
+
+	return params.Time != a.params.Time ||
+		params.Memory != a.params.Memory ||
+		params.Threads != a.params.Threads ||
+		uint32(len(salt)) != a.params.SaltLen ||
+		uint32(len(key)) != a.params.KeyLen
+}
+
+// =============================================================================
+
+const argon2idPrefix = "$argon2id$"
+
+func isBcrypt(hash []byte) bool {
+	return bytes.HasPrefix(hash, []byte("$2a$")) ||
+		bytes.HasPrefix(hash, []byte("$2b$")) ||
+		bytes.HasPrefix(hash, []byte("$2y$"))
+}
+
+func isArgon2id(hash []byte) bool {
+	return bytes.HasPrefix(hash, []byte(argon2idPrefix))
+}
+
+// decodeArgon2id reads the parameters, salt and key out of an argon2id hash
+// in the PHC string format.
+func decodeArgon2id(hash []byte) (Argon2Params, []byte, []byte, error) {
+	parts := strings.Split(string(hash), "$")
+	if len(parts) != 6 {
+		return Argon2Params{}, nil, nil, errors.New("malformed argon2id hash")
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil {
+		return Argon2Params{}, nil, nil, fmt.Errorf("reading argon2id version: %w", err)
+	}
+	if version != argon2.Version {
+		return Argon2Params{}, nil, nil, fmt.Errorf("unsupported argon2id version %d", version)
+	}
+
+	var params Argon2Params
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Time, &params.Threads); err != nil {
+		return Argon2Params{}, nil, nil, fmt.Errorf("reading argon2id parameters: %w", err)
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return Argon2Params{}, nil, nil, fmt.Errorf("decoding argon2id salt: %w", err)
+	}
+
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return Argon2Params{}, nil, nil, fmt.Errorf("decoding argon2id key: %w", err)
+	}
+
+	params.SaltLen = uint32(len(salt))
+	params.KeyLen = uint32(len(key))
+
+	return params, salt, key, nil
+}
@@ -0,0 +1,117 @@
+package password_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/andrewyang17/service/business/sys/password"
+)
+
+// Success and failure markers.
+const (
+	success = "\u2713"
+	failed  = "\u2717"
+)
+
+// weakArgon2Params keeps the tests fast.
+var weakArgon2Params = password.Argon2Params{
+	Time:    1,
+	Memory:  1024,
+	Threads: 1,
+	KeyLen:  32,
+	SaltLen: 16,
+}
+
+func TestHashers(t *testing.T) {
+	bc, err := password.NewBcrypt(4)
+	if err != nil {
+		t.Fatalf("\t%s\tShould be able to construct a bcrypt hasher : %v.", failed, err)
+	}
+
+	a2, err := password.NewArgon2id(weakArgon2Params)
+	if err != nil {
+		t.Fatalf("\t%s\tShould be able to construct an argon2id hasher : %v.", failed, err)
+	}
+
+	hashers := map[string]password.Hasher{
+		"bcrypt":   bc,
+		"argon2id": a2,
+	}
+
+	t.Log("Given the need to hash and compare passwords.")
+	{
+		testID := 0
+		for name, hasher := range hashers {
+			t.Logf("\tTest %d:\tWhen hashing with %s.", testID, name)
+			{
+				hash, err := hasher.Hash("gophers")
+				if err != nil {
+					t.Fatalf("\t%s\tTest %d:\tShould be able to hash a password : %v.", failed, testID, err)
+				}
+
+				if err := password.Compare(hash, "gophers"); err != nil {
+					t.Fatalf("\t%s\tTest %d:\tShould match the right password : %v.", failed, testID, err)
+				}
+				t.Logf("\t%s\tTest %d:\tShould match the right password.", success, testID)
+
+				if err := password.Compare(hash, "gopher"); !errors.Is(err, password.ErrMismatch) {
+					t.Fatalf("\t%s\tTest %d:\tShould NOT match the wrong password : %v.", failed, testID, err)
+				}
+				t.Logf("\t%s\tTest %d:\tShould NOT match the wrong password.", success, testID)
+
+				if hasher.NeedsRehash(hash) {
+					t.Fatalf("\t%s\tTest %d:\tShould NOT need to rehash its own hash.", failed, testID)
+				}
+				t.Logf("\t%s\tTest %d:\tShould NOT need to rehash its own hash.", success, testID)
+			}
+			testID++
+		}
+	}
+}
+
+func TestNeedsRehash(t *testing.T) {
+	t.Log("Given the need to find hashes made with outdated settings.")
+	{
+		testID := 0
+		t.Logf("\tTest %d:\tWhen the algorithm or its parameters change.", testID)
+		{
+			bc4, _ := password.NewBcrypt(4)
+			bc5, _ := password.NewBcrypt(5)
+			a2, _ := password.NewArgon2id(weakArgon2Params)
+
+			stronger := weakArgon2Params
+			stronger.Time = 2
+			a2Stronger, _ := password.NewArgon2id(stronger)
+
+			bcHash, err := bc4.Hash("gophers")
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to hash a password : %v.", failed, testID, err)
+			}
+
+			a2Hash, err := a2.Hash("gophers")
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to hash a password : %v.", failed, testID, err)
+			}
+
+			if !bc5.NeedsRehash(bcHash) {
+				t.Fatalf("\t%s\tTest %d:\tShould rehash when the bcrypt cost changes.", failed, testID)
+			}
+			t.Logf("\t%s\tTest %d:\tShould rehash when the bcrypt cost changes.", success, testID)
+
+			if !a2Stronger.NeedsRehash(a2Hash) {
+				t.Fatalf("\t%s\tTest %d:\tShould rehash when the argon2id parameters change.", failed, testID)
+			}
+			t.Logf("\t%s\tTest %d:\tShould rehash when the argon2id parameters change.", success, testID)
+
+			if !a2.NeedsRehash(bcHash) || !bc4.NeedsRehash(a2Hash) {
+				t.Fatalf("\t%s\tTest %d:\tShould rehash when the algorithm changes.", failed, testID)
+			}
+			t.Logf("\t%s\tTest %d:\tShould rehash when the algorithm changes.", success, testID)
+
+			if err := password.Compare([]byte("plain"), "plain"); !errors.Is(err, password.ErrUnknownAlgorithm) {
+				t.Fatalf("\t%s\tTest %d:\tShould reject an unknown hash : %v.", failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould reject an unknown hash.", success, testID)
+		}
+	}
+}
@@ -0,0 +1,29 @@
+// Package tenant carries the organization a request acts for so every core
+// can keep the data of one organization apart from another.
+package tenant
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrMissing is returned when no organization has been set for a request.
+var ErrMissing = errors.New("organization missing from context")
+
+type ctxKey int
+
+const key ctxKey = 1
+
+// Set returns a new context that acts for the specified organization.
+func Set(ctx context.Context, orgID string) context.Context {
+	return context.WithValue(ctx, key, orgID)
+}
+
+// Get returns the organization the context acts for.
+func Get(ctx context.Context) (string, error) {
+	orgID, ok := ctx.Value(key).(string)
+	if !ok || orgID == "" {
+		return "", ErrMissing
+	}
+	return orgID, nil
+}
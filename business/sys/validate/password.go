@@ -0,0 +1,132 @@
+package validate
+
+import (
+	"bufio"
+	_ "embed"
+	"fmt"
+	"strings"
+	"sync"
+	"unicode"
+
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+)
+
+// PasswordPolicy holds the rules a password has to follow to pass the
+// password tag.
+type PasswordPolicy struct {
+	MinLength     int
+	RequireUpper  bool
+	RequireLower  bool
+	RequireDigit  bool
+	RequireSymbol bool
+	RejectCommon  bool
+}
+
+// DefaultPasswordPolicy is the policy used until another one is set.
+var DefaultPasswordPolicy = PasswordPolicy{
+	MinLength:    8,
+	RequireUpper: true,
+	RequireLower: true,
+	RequireDigit: true,
+	RejectCommon: true,
+}
+
+//go:embed common_passwords.txt
+var commonPasswordsFile string
+
+// commonPasswords is the set of passwords rejected for being too common.
+var commonPasswords = parseCommonPasswords(commonPasswordsFile)
+
+// policy is the password policy in use.
+var policy = struct {
+	mu sync.RWMutex
+	PasswordPolicy
+}{
+	PasswordPolicy: DefaultPasswordPolicy,
+}
+
+// SetPasswordPolicy replaces the rules used by the password tag.
+func SetPasswordPolicy(p PasswordPolicy) {
+	policy.mu.Lock()
+	defer policy.mu.Unlock()
+
+	policy.PasswordPolicy = p
+}
+
+// CheckPassword returns the reason a password breaks the password policy, or
+// an empty string when it follows it.
+func CheckPassword(password string) string {
+	policy.mu.RLock()
+	p := policy.PasswordPolicy
+	policy.mu.RUnlock()
+
+	if len([]rune(password)) < p.MinLength {
+		return fmt.Sprintf("must be at least %d characters long", p.MinLength)
+	}
+
+	var upper, lower, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsDigit(r):
+			digit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.IsSpace(r):
+			symbol = true
+		}
+	}
+
+	switch {
+	case p.RequireUpper && !upper:
+		return "must contain an uppercase letter"
+	case p.RequireLower && !lower:
+		return "must contain a lowercase letter"
+	case p.RequireDigit && !digit:
+		return "must contain a digit"
+	case p.RequireSymbol && !symbol:
+		return "must contain a symbol"
+	}
+
+	if p.RejectCommon {
+		if _, exists := commonPasswords[strings.ToLower(password)]; exists {
+			return "is too common"
+		}
+	}
+
+	return ""
+}
+
+// =============================================================================
+
+// registerPassword registers the password tag and its translation.
+func registerPassword(validate *validator.Validate, translator ut.Translator) {
+	validate.RegisterValidation("password", func(fl validator.FieldLevel) bool {
+		return CheckPassword(fl.Field().String()) == ""
+	})
+	validate.RegisterTranslation("password", translator, func(ut ut.Translator) error {
+		return ut.Add("password", "{0} {1}", true)
+	}, func(ut ut.Translator, fe validator.FieldError) string {
+		reason := CheckPassword(fmt.Sprint(fe.Value()))
+		t, _ := ut.T("password", fe.Field(), reason)
+		return t
+	})
+}
+
+// parseCommonPasswords reads the embedded list of common passwords.
+func parseCommonPasswords(file string) map[string]struct{} {
+	passwords := make(map[string]struct{})
+
+	scanner := bufio.NewScanner(strings.NewReader(file))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		passwords[strings.ToLower(line)] = struct{}{}
+	}
+
+	return passwords
+}
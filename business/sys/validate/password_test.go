@@ -0,0 +1,84 @@
+package validate_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/andrewyang17/service/business/sys/validate"
+)
+
+// Success and failure markers.
+const (
+	success = "\u2713"
+	failed  = "\u2717"
+)
+
+func TestPassword(t *testing.T) {
+	type account struct {
+		Password string `json:"password" validate:"required,password"`
+	}
+
+	tt := []struct {
+		name     string
+		password string
+		reason   string
+	}{
+		{"short", "Go2", "password must be at least 8 characters long"},
+		{"upper", "gophers2022", "password must contain an uppercase letter"},
+		{"lower", "GOPHERS2022", "password must contain a lowercase letter"},
+		{"digit", "GophersGophers", "password must contain a digit"},
+		{"common", "Password123", "password is too common"},
+		{"strong", "Gophers2022", ""},
+	}
+
+	t.Log("Given the need to reject weak passwords.")
+	{
+		for testID, tst := range tt {
+			t.Logf("\tTest %d:\tWhen checking a %s password.", testID, tst.name)
+			{
+				err := validate.Check(account{Password: tst.password})
+
+				if tst.reason == "" {
+					if err != nil {
+						t.Fatalf("\t%s\tTest %d:\tShould accept the password : %v.", failed, testID, err)
+					}
+					t.Logf("\t%s\tTest %d:\tShould accept the password.", success, testID)
+					continue
+				}
+
+				var fields validate.FieldErrors
+				if !errors.As(err, &fields) {
+					t.Fatalf("\t%s\tTest %d:\tShould get field errors : %v.", failed, testID, err)
+				}
+
+				if got := fields.Fields()["password"]; got != tst.reason {
+					t.Fatalf("\t%s\tTest %d:\tShould explain why the password was rejected : got %q, exp %q.", failed, testID, got, tst.reason)
+				}
+				t.Logf("\t%s\tTest %d:\tShould explain why the password was rejected.", success, testID)
+			}
+		}
+	}
+}
+
+func TestSetPasswordPolicy(t *testing.T) {
+	t.Cleanup(func() { validate.SetPasswordPolicy(validate.DefaultPasswordPolicy) })
+
+	t.Log("Given the need to configure the password policy.")
+	{
+		testID := 0
+		t.Logf("\tTest %d:\tWhen symbols are required.", testID)
+		{
+			p := validate.DefaultPasswordPolicy
+			p.RequireSymbol = true
+			validate.SetPasswordPolicy(p)
+
+			if reason := validate.CheckPassword("Gophers2022"); reason != "must contain a symbol" {
+				t.Fatalf("\t%s\tTest %d:\tShould require a symbol : got %q.", failed, testID, reason)
+			}
+			if reason := validate.CheckPassword("Gophers 2022!"); reason != "" {
+				t.Fatalf("\t%s\tTest %d:\tShould accept a password with a symbol : got %q.", failed, testID, reason)
+			}
+			t.Logf("\t%s\tTest %d:\tShould require a symbol.", success, testID)
+		}
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"reflect"
 	"strings"
 
+	"github.com/andrewyang17/service/business/sys/money"
 	"github.com/go-playground/locales/en"
 	ut "github.com/go-playground/universal-translator"
 	"github.com/go-playground/validator/v10"
@@ -35,6 +36,20 @@ func init() {
 		}
 		return name
 	})
+
+	// Register the currency tag used by money values.
+	validate.RegisterValidation("currency", func(fl validator.FieldLevel) bool {
+		return money.IsSupported(fl.Field().String())
+	})
+	validate.RegisterTranslation("currency", translator, func(ut ut.Translator) error {
+		return ut.Add("currency", "{0} must be one of "+strings.Join(money.Supported(), ", "), true)
+	}, func(ut ut.Translator, fe validator.FieldError) string {
+		t, _ := ut.T("currency", fe.Field())
+		return t
+	})
+
+	// Register the password tag that applies the password policy.
+	registerPassword(validate, translator)
 }
 
 // Check validates the provided model against it's declared tags.
@@ -6,33 +6,72 @@ import (
 	"fmt"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/andrewyang17/service/business/sys/auth"
+	"github.com/andrewyang17/service/business/sys/tenant"
 	"github.com/andrewyang17/service/business/web/v1"
 	"github.com/andrewyang17/service/foundation/web"
 )
 
-// Authenticate validates a JWT from the `Authorization` header.
-func Authenticate(a *auth.Auth) web.Middleware {
+// KeyAuthenticator returns the claims an API key acts with. An error wrapping
+// auth.ErrInvalidKey is returned when the key can't be used.
+type KeyAuthenticator interface {
+	Authenticate(ctx context.Context, now time.Time, key string) (auth.Claims, error)
+}
+
+// Authenticate validates a JWT or an API key from the `Authorization`
+// header. Machine clients send their API key with the apikey scheme.
+func Authenticate(a *auth.Auth, keys KeyAuthenticator) web.Middleware {
 
 	m := func(handler web.Handler) web.Handler {
 
 		h := func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+			v, err := web.GetValues(ctx)
+			if err != nil {
+				return web.NewShutdownError("web value missing from context")
+			}
 
 			authStr := r.Header.Get("authorization")
 
 			parts := strings.Split(authStr, " ")
-			if len(parts) != 2 || strings.ToLower(parts[0]) != "bearer" {
-				err := errors.New("expected authorization header format: bearer <token>")
+			if len(parts) != 2 {
+				err := errors.New("expected authorization header format: bearer <token> or apikey <key>")
 				return v1.NewRequestError(err, http.StatusUnauthorized)
 			}
 
-			claims, err := a.ValidateToken(parts[1])
-			if err != nil {
+			var claims auth.Claims
+			switch strings.ToLower(parts[0]) {
+			case "bearer":
+				claims, err = a.ValidateToken(ctx, parts[1])
+				if err != nil {
+					if errors.Is(err, auth.ErrInvalidToken) || errors.Is(err, auth.ErrRevoked) {
+						return v1.NewRequestError(err, http.StatusUnauthorized)
+					}
+					return fmt.Errorf("validating token: %w", err)
+				}
+
+			case "apikey":
+				claims, err = keys.Authenticate(ctx, v.Now, parts[1])
+				if err != nil {
+					if errors.Is(err, auth.ErrInvalidKey) {
+						return v1.NewRequestError(err, http.StatusUnauthorized)
+					}
+					return fmt.Errorf("authenticating api key: %w", err)
+				}
+
+			default:
+				err := errors.New("expected authorization header format: bearer <token> or apikey <key>")
+				return v1.NewRequestError(err, http.StatusUnauthorized)
+			}
+
+			if claims.OrgID == "" {
+				err := errors.New("token has no organization, request a new token")
 				return v1.NewRequestError(err, http.StatusUnauthorized)
 			}
 
 			ctx = auth.SetClaims(ctx, claims)
+			ctx = tenant.Set(ctx, claims.OrgID)
 
 			return handler(ctx, w, r)
 		}
@@ -73,3 +112,96 @@ func Authorize(roles ...string) web.Middleware {
 
 	return m
 }
+
+// RequireVerified validates that an authenticated user has verified their
+// email. It must run after Authenticate.
+func RequireVerified() web.Middleware {
+
+	m := func(handler web.Handler) web.Handler {
+
+		h := func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+			claims, err := auth.GetClaims(ctx)
+			if err != nil {
+				return v1.NewRequestError(
+					fmt.Errorf("you are not authorized for that action, no claims"),
+					http.StatusForbidden,
+				)
+			}
+
+			if !claims.Verified {
+				return v1.NewRequestError(
+					fmt.Errorf("you must verify your email before taking that action"),
+					http.StatusForbidden,
+				)
+			}
+
+			return handler(ctx, w, r)
+		}
+
+		return h
+	}
+
+	return m
+}
+
+// RequireToken validates that a request was authenticated with a token
+// rather than an API key. It must run after Authenticate.
+func RequireToken() web.Middleware {
+
+	m := func(handler web.Handler) web.Handler {
+
+		h := func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+			claims, err := auth.GetClaims(ctx)
+			if err != nil {
+				return v1.NewRequestError(
+					fmt.Errorf("you are not authorized for that action, no claims"),
+					http.StatusForbidden,
+				)
+			}
+
+			if claims.FromAPIKey() {
+				return v1.NewRequestError(
+					fmt.Errorf("you must use a token for that action, not an api key"),
+					http.StatusForbidden,
+				)
+			}
+
+			return handler(ctx, w, r)
+		}
+
+		return h
+	}
+
+	return m
+}
+
+// RequirePermission validates that the roles of an authenticated user grant
+// the specified permission. It must run after Authenticate.
+func RequirePermission(permission string) web.Middleware {
+
+	m := func(handler web.Handler) web.Handler {
+
+		h := func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+			claims, err := auth.GetClaims(ctx)
+			if err != nil {
+				return v1.NewRequestError(
+					fmt.Errorf("you are not authorized for that action, no claims"),
+					http.StatusForbidden,
+				)
+			}
+
+			if !claims.HasPermission(permission) {
+				return v1.NewRequestError(
+					fmt.Errorf("you are not authorized for that action, roles[%v] permission[%s]", claims.Roles, permission),
+					http.StatusForbidden,
+				)
+			}
+
+			return handler(ctx, w, r)
+		}
+
+		return h
+	}
+
+	return m
+}
@@ -0,0 +1,46 @@
+package mid
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+
+	"github.com/andrewyang17/service/business/web/v1"
+	"github.com/andrewyang17/service/foundation/limiter"
+	"github.com/andrewyang17/service/foundation/web"
+)
+
+// RateLimit limits how often each client address can call the handler. Calls
+// over the limit are rejected with a Retry-After header saying when to try
+// again.
+func RateLimit(l *limiter.Limiter) web.Middleware {
+
+	m := func(handler web.Handler) web.Handler {
+
+		h := func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+			v, err := web.GetValues(ctx)
+			if err != nil {
+				return web.NewShutdownError("web value missing from context")
+			}
+
+			host, _, err := net.SplitHostPort(r.RemoteAddr)
+			if err != nil {
+				host = r.RemoteAddr
+			}
+
+			if ok, wait := l.Allow(host, v.Now); !ok {
+				w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+				return v1.NewRequestError(errors.New("too many requests, try again later"), http.StatusTooManyRequests)
+			}
+
+			return handler(ctx, w, r)
+		}
+
+		return h
+	}
+
+	return m
+}
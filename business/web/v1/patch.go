@@ -0,0 +1,60 @@
+package v1
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+
+	"github.com/andrewyang17/service/foundation/jsonpatch"
+)
+
+// DecodePatch applies the patch in the body of a PATCH request to the current
+// representation of a resource and decodes the patched document into val.
+// The Content-Type header picks between JSON Merge Patch and JSON Patch.
+// Errors are returned as request errors carrying the status to respond with.
+func DecodePatch(r *http.Request, current interface{}, val interface{}) error {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+
+	var apply func(doc []byte, patch []byte) ([]byte, error)
+	switch mediaType {
+	case jsonpatch.MergePatchType:
+		apply = jsonpatch.MergePatch
+	case jsonpatch.JSONPatchType:
+		apply = jsonpatch.Apply
+	default:
+		err := fmt.Errorf("content type must be %s or %s", jsonpatch.MergePatchType, jsonpatch.JSONPatchType)
+		return NewRequestError(err, http.StatusUnsupportedMediaType)
+	}
+
+	patch, err := io.ReadAll(r.Body)
+	if err != nil {
+		return fmt.Errorf("reading patch: %w", err)
+	}
+
+	doc, err := json.Marshal(current)
+	if err != nil {
+		return fmt.Errorf("encoding current document: %w", err)
+	}
+
+	patched, err := apply(doc, patch)
+	if err != nil {
+		switch {
+		case errors.Is(err, jsonpatch.ErrPathNotFound), errors.Is(err, jsonpatch.ErrTestFailed):
+			return NewRequestError(err, http.StatusConflict)
+		default:
+			return NewRequestError(err, http.StatusBadRequest)
+		}
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(patched))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(val); err != nil {
+		return NewRequestError(fmt.Errorf("decoding patched document: %w", err), http.StatusBadRequest)
+	}
+
+	return nil
+}
@@ -76,7 +76,7 @@ func StopContainer(id string) error {
 func DumpContainerLogs(t *testing.T, id string) {
 	out, err := exec.Command("docker", "logs", id).CombinedOutput()
 	if err != nil {
-		t.Fatalf("could not log container: %w", err)
+		t.Fatalf("could not log container: %v", err)
 	}
 	t.Logf("Logs for %s\n%s:", id, out)
 }
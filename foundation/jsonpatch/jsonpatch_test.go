@@ -0,0 +1,147 @@
+package jsonpatch_test
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/andrewyang17/service/foundation/jsonpatch"
+)
+
+// Success and failure markers.
+const (
+	success = "\u2713"
+	failed  = "\u2717"
+)
+
+func TestMergePatch(t *testing.T) {
+	// These are the examples from appendix A of RFC 7396.
+	tests := []struct {
+		doc    string
+		patch  string
+		result string
+	}{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`["a","b"]`, `["c","d"]`, `["c","d"]`},
+		{`{"a":"b"}`, `["c"]`, `["c"]`},
+		{`{"a":"foo"}`, `null`, `null`},
+		{`{"a":"foo"}`, `"bar"`, `"bar"`},
+		{`{"e":null}`, `{"a":1}`, `{"a":1,"e":null}`},
+		{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+	}
+
+	t.Log("Given the need to apply JSON Merge Patch documents.")
+	{
+		for testID, tt := range tests {
+			t.Logf("\tTest %d:\tWhen applying %s to %s.", testID, tt.patch, tt.doc)
+			{
+				got, err := jsonpatch.MergePatch([]byte(tt.doc), []byte(tt.patch))
+				if err != nil {
+					t.Fatalf("\t%s\tTest %d:\tShould be able to apply the patch : %s.", failed, testID, err)
+				}
+
+				if !sameJSON(t, got, tt.result) {
+					t.Fatalf("\t%s\tTest %d:\tShould get %s : got %s.", failed, testID, tt.result, got)
+				}
+				t.Logf("\t%s\tTest %d:\tShould get %s.", success, testID, tt.result)
+			}
+		}
+
+		testID := len(tests)
+		t.Logf("\tTest %d:\tWhen applying a malformed patch.", testID)
+		{
+			_, err := jsonpatch.MergePatch([]byte(`{}`), []byte(`{"a":`))
+			if !errors.Is(err, jsonpatch.ErrInvalidPatch) {
+				t.Fatalf("\t%s\tTest %d:\tShould get ErrInvalidPatch : got %v.", failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould get ErrInvalidPatch.", success, testID)
+		}
+	}
+}
+
+func TestApply(t *testing.T) {
+	// Most of these are the examples from appendix A of RFC 6902.
+	tests := []struct {
+		name   string
+		doc    string
+		patch  string
+		result string
+		err    error
+	}{
+		{"add object member", `{"foo":"bar"}`, `[{"op":"add","path":"/baz","value":"qux"}]`, `{"baz":"qux","foo":"bar"}`, nil},
+		{"add array element", `{"foo":["bar","baz"]}`, `[{"op":"add","path":"/foo/1","value":"qux"}]`, `{"foo":["bar","qux","baz"]}`, nil},
+		{"add to array end", `{"foo":["bar"]}`, `[{"op":"add","path":"/foo/-","value":["abc"]}]`, `{"foo":["bar",["abc"]]}`, nil},
+		{"add null value", `{"foo":"bar"}`, `[{"op":"add","path":"/baz","value":null}]`, `{"baz":null,"foo":"bar"}`, nil},
+		{"add escaped member", `{}`, `[{"op":"add","path":"/a~1b~0c","value":1}]`, `{"a/b~c":1}`, nil},
+		{"remove object member", `{"baz":"qux","foo":"bar"}`, `[{"op":"remove","path":"/baz"}]`, `{"foo":"bar"}`, nil},
+		{"remove array element", `{"foo":["bar","qux","baz"]}`, `[{"op":"remove","path":"/foo/1"}]`, `{"foo":["bar","baz"]}`, nil},
+		{"replace value", `{"baz":"qux","foo":"bar"}`, `[{"op":"replace","path":"/baz","value":"boo"}]`, `{"baz":"boo","foo":"bar"}`, nil},
+		{"replace array element", `{"foo":[1,2,3]}`, `[{"op":"replace","path":"/foo/1","value":5}]`, `{"foo":[1,5,3]}`, nil},
+		{"replace whole document", `{"foo":"bar"}`, `[{"op":"replace","path":"","value":[1]}]`, `[1]`, nil},
+		{"move value", `{"foo":{"bar":"baz","waldo":"fred"},"qux":{"corge":"grault"}}`, `[{"op":"move","from":"/foo/waldo","path":"/qux/thud"}]`, `{"foo":{"bar":"baz"},"qux":{"corge":"grault","thud":"fred"}}`, nil},
+		{"move array element", `{"foo":["all","grass","cows","eat"]}`, `[{"op":"move","from":"/foo/1","path":"/foo/3"}]`, `{"foo":["all","cows","eat","grass"]}`, nil},
+		{"copy value", `{"foo":{"a":1}}`, `[{"op":"copy","from":"/foo","path":"/bar"},{"op":"add","path":"/bar/b","value":2}]`, `{"foo":{"a":1},"bar":{"a":1,"b":2}}`, nil},
+		{"test success", `{"baz":"qux","foo":["a",2,"c"]}`, `[{"op":"test","path":"/baz","value":"qux"},{"op":"test","path":"/foo/1","value":2.0}]`, `{"baz":"qux","foo":["a",2,"c"]}`, nil},
+		{"test failure", `{"baz":"qux"}`, `[{"op":"test","path":"/baz","value":"bar"}]`, "", jsonpatch.ErrTestFailed},
+		{"test string and number", `{"/":9}`, `[{"op":"test","path":"/~1","value":"9"}]`, "", jsonpatch.ErrTestFailed},
+		{"add to missing parent", `{"foo":"bar"}`, `[{"op":"add","path":"/baz/bat","value":"qux"}]`, "", jsonpatch.ErrPathNotFound},
+		{"remove missing member", `{"foo":"bar"}`, `[{"op":"remove","path":"/baz"}]`, "", jsonpatch.ErrPathNotFound},
+		{"replace missing member", `{"foo":"bar"}`, `[{"op":"replace","path":"/baz","value":1}]`, "", jsonpatch.ErrPathNotFound},
+		{"index out of range", `{"foo":[1]}`, `[{"op":"add","path":"/foo/2","value":1}]`, "", jsonpatch.ErrPathNotFound},
+		{"index with leading zero", `{"foo":[1,2]}`, `[{"op":"remove","path":"/foo/01"}]`, "", jsonpatch.ErrPathNotFound},
+		{"move into child", `{"foo":{"bar":1}}`, `[{"op":"move","from":"/foo","path":"/foo/bar/baz"}]`, "", jsonpatch.ErrInvalidPatch},
+		{"unknown operation", `{}`, `[{"op":"merge","path":"/foo","value":1}]`, "", jsonpatch.ErrInvalidPatch},
+		{"missing value", `{}`, `[{"op":"add","path":"/foo"}]`, "", jsonpatch.ErrInvalidPatch},
+		{"invalid pointer", `{}`, `[{"op":"add","path":"foo","value":1}]`, "", jsonpatch.ErrInvalidPatch},
+		{"not an array", `{}`, `{"op":"add","path":"/foo","value":1}`, "", jsonpatch.ErrInvalidPatch},
+	}
+
+	t.Log("Given the need to apply JSON Patch documents.")
+	{
+		for testID, tt := range tests {
+			t.Logf("\tTest %d:\tWhen applying a patch that does %s.", testID, tt.name)
+			{
+				got, err := jsonpatch.Apply([]byte(tt.doc), []byte(tt.patch))
+				if tt.err != nil {
+					if !errors.Is(err, tt.err) {
+						t.Fatalf("\t%s\tTest %d:\tShould get error %q : got %v.", failed, testID, tt.err, err)
+					}
+					t.Logf("\t%s\tTest %d:\tShould get error %q.", success, testID, tt.err)
+					continue
+				}
+
+				if err != nil {
+					t.Fatalf("\t%s\tTest %d:\tShould be able to apply the patch : %s.", failed, testID, err)
+				}
+
+				if !sameJSON(t, got, tt.result) {
+					t.Fatalf("\t%s\tTest %d:\tShould get %s : got %s.", failed, testID, tt.result, got)
+				}
+				t.Logf("\t%s\tTest %d:\tShould get %s.", success, testID, tt.result)
+			}
+		}
+	}
+}
+
+// sameJSON reports whether the document holds the same JSON value as want.
+func sameJSON(t *testing.T, doc []byte, want string) bool {
+	var a, b interface{}
+	if err := json.Unmarshal(doc, &a); err != nil {
+		t.Fatalf("\t%s\tShould get valid JSON : %s.", failed, err)
+	}
+	if err := json.Unmarshal([]byte(want), &b); err != nil {
+		t.Fatalf("\t%s\tShould have valid expected JSON : %s.", failed, err)
+	}
+
+	x, _ := json.Marshal(a)
+	y, _ := json.Marshal(b)
+	return string(x) == string(y)
+}
@@ -0,0 +1,95 @@
+// Package limiter provides support for limiting how often something can
+// happen per key, such as requests per client address.
+package limiter
+
+import (
+	"sync"
+	"time"
+)
+
+// Limiter allows a number of events per period for each key. Each key has a
+// bucket that holds up to limit tokens and refills evenly over the period, so
+// a key can use its whole limit at once and then has to wait for tokens to
+// come back.
+type Limiter struct {
+	limit     float64
+	period    time.Duration
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// New constructs a limiter that allows limit events per period for each key.
+func New(limit int, period time.Duration) *Limiter {
+	return &Limiter{
+		limit:   float64(limit),
+		period:  period,
+		buckets: make(map[string]*bucket),
+	}
+}
+
+// Allow reports whether an event for the key can happen at the specified
+// time and takes a token for it when it can. When it can't, Allow returns
+// how long to wait until it can.
+func (l *Limiter) Allow(key string, now time.Time) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.sweep(now)
+
+	b, exists := l.buckets[key]
+	if !exists {
+		b = &bucket{
+			tokens: l.limit,
+			last:   now,
+		}
+		l.buckets[key] = b
+	}
+
+	b.tokens = l.refill(b, now)
+	b.last = now
+
+	if b.tokens < 1 {
+		wait := time.Duration((1 - b.tokens) / l.limit * float64(l.period))
+		return false, wait
+	}
+
+	b.tokens--
+
+	return true, 0
+}
+
+// refill returns the tokens in the bucket at the specified time.
+func (l *Limiter) refill(b *bucket, now time.Time) float64 {
+	elapsed := now.Sub(b.last)
+	if elapsed <= 0 {
+		return b.tokens
+	}
+
+	tokens := b.tokens + l.limit*float64(elapsed)/float64(l.period)
+	if tokens > l.limit {
+		return l.limit
+	}
+
+	return tokens
+}
+
+// sweep drops the buckets that have refilled completely, since they behave
+// the same as a new bucket. It runs at most once a period.
+func (l *Limiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < l.period {
+		return
+	}
+	l.lastSweep = now
+
+	for key, b := range l.buckets {
+		if l.refill(b, now) >= l.limit {
+			delete(l.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package limiter_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/andrewyang17/service/foundation/limiter"
+)
+
+// Success and failure markers.
+const (
+	success = "\u2713"
+	failed  = "\u2717"
+)
+
+func TestAllow(t *testing.T) {
+	t.Log("Given the need to limit events per key.")
+	{
+		testID := 0
+		t.Logf("\tTest %d:\tWhen allowing 3 events an hour.", testID)
+		{
+			l := limiter.New(3, time.Hour)
+			now := time.Date(2022, time.January, 8, 17, 56, 0, 0, time.UTC)
+
+			for i := 0; i < 3; i++ {
+				if ok, _ := l.Allow("a", now); !ok {
+					t.Fatalf("\t%s\tTest %d:\tShould allow event %d.", failed, testID, i)
+				}
+			}
+			t.Logf("\t%s\tTest %d:\tShould allow the first 3 events.", success, testID)
+
+			ok, wait := l.Allow("a", now)
+			if ok {
+				t.Fatalf("\t%s\tTest %d:\tShould NOT allow a 4th event.", failed, testID)
+			}
+			if wait != 20*time.Minute {
+				t.Fatalf("\t%s\tTest %d:\tShould wait 20m for the next token : got %v.", failed, testID, wait)
+			}
+			t.Logf("\t%s\tTest %d:\tShould NOT allow a 4th event.", success, testID)
+
+			if ok, _ := l.Allow("b", now); !ok {
+				t.Fatalf("\t%s\tTest %d:\tShould limit each key on its own.", failed, testID)
+			}
+			t.Logf("\t%s\tTest %d:\tShould limit each key on its own.", success, testID)
+
+			if ok, _ := l.Allow("a", now.Add(20*time.Minute)); !ok {
+				t.Fatalf("\t%s\tTest %d:\tShould allow an event once a token comes back.", failed, testID)
+			}
+			if ok, _ := l.Allow("a", now.Add(20*time.Minute)); ok {
+				t.Fatalf("\t%s\tTest %d:\tShould only get back one token in 20m.", failed, testID)
+			}
+			t.Logf("\t%s\tTest %d:\tShould allow an event once a token comes back.", success, testID)
+		}
+	}
+}